@@ -0,0 +1,70 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+)
+
+// RowScanner is current row of a result set streamed by ScanAggregate, valid only during call of fold.
+type RowScanner interface {
+	// Columns returns names of columns.
+	Columns() ([]string, error)
+
+	// Scan copies columns of current row into dest, like sql.Rows.Scan.
+	Scan(dest ...interface{}) error
+}
+
+// rowScanner hides iteration of rows from fold
+type rowScanner struct {
+	rows *sql.Rows
+}
+
+func (r rowScanner) Columns() ([]string, error) {
+	return r.rows.Columns()
+}
+
+func (r rowScanner) Scan(dest ...interface{}) error {
+	return r.rows.Scan(dest...)
+}
+
+// ScanAggregate streams result set of query on slaves, calling fold for each row without materializing
+// structs, i.e for high-volume aggregation jobs which scan into reused variables:
+//
+//	var total, amount int64
+//	err := db.ScanAggregate(ctx, "SELECT amount FROM orders", nil, func(r mssqlx.RowScanner) error {
+//		if err := r.Scan(&amount); err != nil {
+//			return err
+//		}
+//		total += amount
+//		return nil
+//	})
+//
+// Streaming stops at first error of fold, which is returned then.
+func (dbs *DBs) ScanAggregate(ctx context.Context, query string, args []interface{}, fold func(RowScanner) error) error {
+	return _scanAggregate(ctx, dbs.slaves, query, args, fold)
+}
+
+// ScanAggregateOnMaster streams result set of query on masters, calling fold for each row.
+func (dbs *DBs) ScanAggregateOnMaster(ctx context.Context, query string, args []interface{}, fold func(RowScanner) error) error {
+	return _scanAggregate(ctx, dbs.masters, query, args, fold)
+}
+
+func _scanAggregate(ctx context.Context, target *balancer, query string, args []interface{}, fold func(RowScanner) error) (err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	_, rows, err := _query(ctx, target, query, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	r := rowScanner{rows: rows}
+	for rows.Next() {
+		if err = fold(r); err != nil {
+			return
+		}
+	}
+	return rows.Err()
+}
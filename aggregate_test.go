@@ -0,0 +1,45 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestScanAggregate(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	query := "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < ?) SELECT x, x % 2 FROM c"
+
+	var total, odd, x, parity int64
+	err := dbs.ScanAggregate(context.Background(), query, []interface{}{1000}, func(r RowScanner) error {
+		if err := r.Scan(&x, &parity); err != nil {
+			return err
+		}
+		total, odd = total+x, odd+parity
+		return nil
+	})
+	if err != nil || total != 500500 || odd != 500 {
+		t.Fatal("ScanAggregate: unexpected aggregate", total, odd, err)
+	}
+
+	// fold error stops streaming
+	errStop, rows := errors.New("stop"), 0
+	err = dbs.ScanAggregateOnMaster(context.Background(), query, []interface{}{1000}, func(r RowScanner) error {
+		if columns, _ := r.Columns(); len(columns) != 2 {
+			t.Fatal("ScanAggregate: unexpected columns", columns)
+		}
+		if rows++; rows == 10 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || rows != 10 {
+		t.Fatal("ScanAggregate: fold error should stop streaming", rows, err)
+	}
+
+	if err = dbs.ScanAggregate(context.Background(), "SELECT * FROM not_existed_table", nil, nil); err == nil {
+		t.Fatal("ScanAggregate: query error should be returned")
+	}
+}
@@ -0,0 +1,65 @@
+package mssqlx
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	// ErrApplicationNameNotSupported application name tagging is not supported by driver
+	ErrApplicationNameNotSupported = errors.New("Application name is only supported by postgres drivers and mysql drivers with connection attributes")
+)
+
+// ApplicationName tags connections of every node with a service name plus node role, i.e billing/master,
+// so that DBAs could see which traffic came through mssqlx and for what role in server-side views:
+// application_name of pg_stat_activity on postgres, program_name and mssqlx_role connection attributes
+// of performance_schema.session_connect_attrs on mysql 8. Tags set by DSN are respected.
+//
+// Role is tagged at connecting time, it is not updated when node is promoted or demoted.
+// Tagging mysql connections requires a driver supporting connectionAttributes DSN param, otherwise
+// a warning is logged and connections are not tagged.
+//
+// Pass it as an arg of ConnectMasterSlaves.
+type ApplicationName string
+
+var warnMySQLAttributes sync.Once
+
+// mysqlConnectionAttributes tells whether mysql driver supports connectionAttributes DSN param
+var mysqlConnectionAttributes = func() bool {
+	_, ok := reflect.TypeOf(mysql.Config{}).FieldByName("ConnectionAttributes")
+	return ok
+}()
+
+// tagDSN tags dsn of node with application name and role
+func tagDSN(driverName, dsn string, name ApplicationName, role Role) (string, error) {
+	tag := string(name) + "/" + string(role)
+
+	switch driverName {
+	case "postgres", "pgx":
+		if strings.Contains(dsn, "://") {
+			return addDSNParam(dsn, "application_name", url.QueryEscape(tag)), nil
+		}
+		if strings.Contains(dsn, "application_name=") {
+			return dsn, nil
+		}
+		return dsn + " application_name='" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(tag) + "'", nil
+
+	case "mysql":
+		if mysqlConnectionAttributes {
+			attrs := "program_name:" + string(name) + ",mssqlx_role:" + string(role)
+			return addDSNParam(dsn, "connectionAttributes", url.QueryEscape(attrs)), nil
+		}
+
+		warnMySQLAttributes.Do(func() {
+			logEntry(LogLevelWarn, "mysql driver does not support connection attributes, application name is not tagged")
+		})
+		return dsn, nil
+	}
+
+	return "", ErrApplicationNameNotSupported
+}
@@ -0,0 +1,48 @@
+package mssqlx
+
+import "testing"
+
+func TestApplicationName(t *testing.T) {
+	cases := []struct {
+		driverName, dsn, expected string
+	}{
+		{"postgres", "host=db1 dbname=app", "host=db1 dbname=app application_name='billing/master'"},
+		{"pgx", "postgres://u:p@db1/app", "postgres://u:p@db1/app?application_name=billing%2Fmaster"},
+		{"postgres", "postgres://u:p@db1/app?sslmode=disable", "postgres://u:p@db1/app?sslmode=disable&application_name=billing%2Fmaster"},
+		{"postgres", "host=db1 application_name=custom", "host=db1 application_name=custom"},
+	}
+	for _, c := range cases {
+		if dsn, err := tagDSN(c.driverName, c.dsn, "billing", RoleMaster); err != nil || dsn != c.expected {
+			t.Fatal("ApplicationName: tagging fail", c.dsn, dsn, err)
+		}
+	}
+
+	if dsn, _ := tagDSN("postgres", "host=db1", "it's", RoleSlave); dsn != `host=db1 application_name='it\'s/slave'` {
+		t.Fatal("ApplicationName: quoting fail", dsn)
+	}
+
+	dsn, err := tagDSN("mysql", "u:p@tcp(db1:3306)/app", "billing", RoleSlave)
+	if mysqlConnectionAttributes {
+		if err != nil || dsn != "u:p@tcp(db1:3306)/app?connectionAttributes=program_name%3Abilling%2Cmssqlx_role%3Aslave" {
+			t.Fatal("ApplicationName: mysql tagging fail", dsn, err)
+		}
+	} else if err != nil || dsn != "u:p@tcp(db1:3306)/app" {
+		t.Fatal("ApplicationName: mysql driver without connection attributes should connect untagged", dsn, err)
+	}
+
+	if _, errs := ConnectMasterSlaves("mysql", []string{"u:p@tcp(db1:3306)/app"}, nil, ApplicationName("billing")); errs[0] != nil {
+		t.Fatal("ApplicationName: mysql should connect", errs[0])
+	}
+
+	if _, errs := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil, ApplicationName("billing")); errs[0] != ErrApplicationNameNotSupported {
+		t.Fatal("ApplicationName: unsupported driver should fail", errs[0])
+	}
+
+	dbs, errs := ConnectMasterSlaves("postgres", []string{"host=db1 dbname=app"}, []string{"host=db2 dbname=app"}, ApplicationName("billing"))
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal("ApplicationName: connect fail", err)
+		}
+	}
+	dbs.Destroy()
+}
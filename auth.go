@@ -0,0 +1,38 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// AuthProvider supplies credentials of a node at connecting time, instead of static DSN credentials.
+// It is invoked before each new connection with configured DSN of node and returns DSN to connect with,
+// i.e with password replaced by a freshly issued token (Azure AD, IAM) or ticket (Kerberos/GSSAPI).
+//
+// Pass it as an arg of ConnectMasterSlaves.
+type AuthProvider func(ctx context.Context, dsn string) (string, error)
+
+// authConnector opens connections with DSN supplied by AuthProvider
+type authConnector struct {
+	dsn      string
+	driver   driver.Driver
+	provider AuthProvider
+	open     connectorOpener
+}
+
+func (c *authConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dsn, err := c.provider(ctx, c.dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	connector, err := c.open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+func (c *authConnector) Driver() driver.Driver {
+	return c.driver
+}
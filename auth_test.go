@@ -0,0 +1,40 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAuthProvider(t *testing.T) {
+	var calls int32
+
+	dbs, errs := ConnectMasterSlaves("sqlite3", []string{"master"}, []string{"slave"},
+		AuthProvider(func(ctx context.Context, dsn string) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			if dsn == "slave" {
+				return "", errors.New("token expired")
+			}
+			return ":memory:", nil // credentials are resolved per connection
+		}),
+	)
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer dbs.Destroy()
+
+	if atomic.LoadInt32(&calls) != 0 {
+		t.Fatal("AuthProvider: should not be invoked before connecting")
+	}
+
+	if _, err := dbs.Exec("SELECT 1"); err != nil || atomic.LoadInt32(&calls) == 0 {
+		t.Fatal("AuthProvider: master connection fail", err)
+	}
+
+	if err := dbs.slaveNodes()[0].db.Ping(); err == nil || err.Error() != "token expired" {
+		t.Fatal("AuthProvider: provider error should be returned", err)
+	}
+}
@@ -2,8 +2,8 @@ package mssqlx
 
 import (
 	"context"
+	"sync"
 	"sync/atomic"
-	"time"
 )
 
 // database balancer and health checker.
@@ -12,17 +12,39 @@ type balancer struct {
 	cancel                context.CancelFunc
 	driverName            string
 	dbs                   *dbList
-	fail                  chan *wrapper
 	isWsrep               bool
-	isMulti               bool
 	numberOfHealthChecker int
+	health                *healthScheduler
+	events                *eventLog
+	preferred             atomic.Value // *preferredPrimary, set by setPreferred
+	quorum                *quorumChecker
+	members               atomic.Value // map[*wrapper]struct{}, set by ApplyTopology
+	shadow                atomic.Value // *shadowing, set by SetShadow
+	master                *balancer    // where queries go on ForceMaster directive
+	routeChains           *sync.Map    // query => []RouteStep, registered by SetRouteChain
+	coalesced             *sync.Map    // query => struct{}, registered by SetReadCoalescing
+	flights               flightGroup  // in-flight coalesced reads
+	misses                *missCache   // shared by balancers of DBs
+	routing               *routingCounters
+	leaks                 *leakTracker
+	readRetries           int32
+	maxRows               int32
+	failureThreshold      int32
+	timeoutMinQueries     int32
+	evictionPolicy        int32
+	healthCheckers        int32     // max number of nodes checked at once, 0 means no limit besides health scheduler's
+	single                int32     // single-node mode
+	weighted              int32     // some node has non-default traffic weight
 	_p1                   [8]uint64 // prevent false sharing
 	healthCheckPeriod     uint64
+	healthCheckTimeout    int64
+	failureWindow         int64
+	timeoutRatio          uint64 // math.Float64bits of timeout ejection ratio
 	_p2                   [8]uint64
 }
 
-// new balancer and start health checkers
-func newBalancer(ctx context.Context, numHealthChecker int, numDbInstance int, isWsrep bool) *balancer {
+// new balancer with its own health scheduler
+func newBalancer(ctx context.Context, numHealthChecker int, isWsrep bool) *balancer {
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -34,20 +56,14 @@ func newBalancer(ctx context.Context, numHealthChecker int, numDbInstance int, i
 	c := &balancer{
 		numberOfHealthChecker: numHealthChecker,
 		dbs:                   &dbList{},
-		fail:                  make(chan *wrapper, numDbInstance),
+		health:                newHealthScheduler(numHealthChecker),
 		isWsrep:               isWsrep,
-		isMulti:               numDbInstance > 1,
 		healthCheckPeriod:     DefaultHealthCheckPeriodInMilli,
 	}
 
 	// setup context
 	c.ctx, c.cancel = context.WithCancel(ctx)
 
-	// run health checker
-	for i := 0; i < numHealthChecker; i++ {
-		go c.healthChecker()
-	}
-
 	return c
 }
 
@@ -66,69 +82,108 @@ func (c *balancer) setHealthCheckPeriod(period uint64) {
 	atomic.StoreUint64(&c.healthCheckPeriod, period)
 }
 
+// isMulti reports whether balancer has several healthy nodes to balance between
+func (c *balancer) isMulti() bool {
+	return c.dbs.size() > 1
+}
+
 // add a db connection to handle in balancer
 func (c *balancer) add(w *wrapper) {
 	c.dbs.add(w)
 }
 
+// isMember reports whether w belongs to balancer in current topology
+func (c *balancer) isMember(w *wrapper) bool {
+	members, stored := c.members.Load().(map[*wrapper]struct{})
+	if !stored {
+		return true
+	}
+	_, ok := members[w]
+	return ok
+}
+
 // get a db to handle our query
 func (c *balancer) get(shouldBalancing bool) *wrapper {
 	if shouldBalancing {
+		if c.isWeighted() {
+			if w := c.dbs.weighted(); w != nil {
+				return w
+			}
+		}
 		return c.dbs.next()
 	}
 	return c.dbs.current()
 }
 
-// failure make a db node become failure and auto health tracking
-func (c *balancer) failure(w *wrapper) {
-	if c.dbs.remove(w) { // remove this node
-		c.sendFailure(w)
+// pick a db to handle query made with ctx, respecting pinned node and routing key
+func (c *balancer) pick(ctx context.Context) *wrapper {
+	if c.isSingle() {
+		return c.dbs.current()
 	}
-}
 
-func (c *balancer) sendFailure(w *wrapper) {
-	select {
-	case <-c.ctx.Done():
-		return
+	p := pinnedNodeFromContext(ctx, c)
+	if p != nil {
+		if w := p.load(); w != nil && c.dbs.contains(w) && !isTried(ctx, w) {
+			if isRoutingDebug() {
+				debugPicked(ctx, w, RoutingPinned)
+			}
+			return w
+		}
+	}
 
-	case c.fail <- w: // give to health checker
+	var (
+		w      *wrapper
+		reason RoutingReason
+		match  func(*wrapper) bool
+	)
+	if d, ok := maxStalenessFromContext(ctx); ok && c.master != nil {
+		match = freshWithin(d)
+		w, reason = c.dbs.nextMatching(match), RoutingFresh
+	} else if nw, ok := c.pickByChain(ctx); ok {
+		w, reason = nw, RoutingNearest
+	} else if key, ok := routingKeyFromContext(ctx); ok {
+		w, reason = c.dbs.hashed(key), RoutingKeyed
+	} else {
+		w, reason = c.getPreferred(), RoutingBalanced
 	}
-}
 
-// healthChecker daemon to check health of db connection
-func (c *balancer) healthChecker() {
-	doneCh := c.ctx.Done()
+	if w != nil && isTried(ctx, w) {
+		w = c.untried(ctx, match)
+	}
 
-	var db *wrapper
-	for {
-		select {
-		case <-doneCh:
-			return
+	if p != nil && w != nil {
+		p.store(w) // re-pin
+	}
 
-		case db = <-c.fail:
-			if ping(db) == nil && (!c.isWsrep || db.checkWsrepReady()) {
-				c.dbs.add(db)
-				continue
-			}
+	if w != nil && isRoutingDebug() {
+		debugPicked(ctx, w, reason)
+	}
 
-			select {
-			case <-doneCh:
-				return
+	return w
+}
 
-			case <-time.After(time.Duration(c.getHealthCheckPeriod()) * time.Millisecond):
-			}
+// failure make a db node become failure and auto health tracking
+func (c *balancer) failure(w *wrapper) {
+	c.failureWithCause(w, nil)
+}
 
-			select {
-			case <-doneCh:
-				return
+func (c *balancer) failureWithCause(w *wrapper, cause error) {
+	if c.dbs.remove(w) { // remove this node
+		logEntry(LogLevelWarn, "node is down", nodeFields(w)...)
+		c.events.record(w, NodeStateUp, NodeStateDown, cause)
+		c.demotePreferred(w)
+		c.sendFailure(w)
+	}
+}
 
-			case c.fail <- db:
-			}
-		}
+func (c *balancer) sendFailure(w *wrapper) {
+	if c.ctx.Err() == nil {
+		c.health.schedule(c, w, 0) // give to health checker
 	}
 }
 
 func (c *balancer) destroy() {
 	c.dbs.clear()
 	c.cancel()
+	c.health.cancel(c)
 }
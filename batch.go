@@ -0,0 +1,126 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Batch is a queue of independent statements executed over a single master connection by Run,
+// cutting connection checkouts and round trips of write bursts. It is not safe for concurrent use.
+//
+// Statements are not executed in a transaction: a failing statement stops the batch, statements
+// executed before it are kept.
+type Batch struct {
+	dbs   *DBs
+	items []batchItem
+}
+
+type batchItem struct {
+	query string
+	args  []interface{}
+}
+
+// Batch returns an empty batch of statements on masters.
+func (dbs *DBs) Batch() *Batch {
+	return &Batch{dbs: dbs}
+}
+
+// Queue appends statement to batch.
+func (b *Batch) Queue(query string, args ...interface{}) {
+	b.items = append(b.items, batchItem{query: query, args: args})
+}
+
+// Len returns number of queued statements.
+func (b *Batch) Len() int {
+	return len(b.items)
+}
+
+// Run executes queued statements in order over a single connection of a healthy master. Queries queued
+// more than once are prepared once. database/sql sends one statement at a time, thus statements are not
+// pipelined on wire; round trips of connection checkout and repeated parsing are saved.
+//
+// Run is deadline-aware: once remaining time of ctx is less than average time of executed statements,
+// the rest is not started and context.DeadlineExceeded is returned, rather than having a statement
+// cancelled midway. On error, results of statements executed before the failing one are returned.
+func (b *Batch) Run(ctx context.Context) (results []sql.Result, err error) {
+	if len(b.items) == 0 {
+		return nil, nil
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	target := b.dbs.masters
+	markWrite(ctx)
+
+	w, conn, err := _conn(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	defer w.limiter.release()
+	defer conn.Close()
+
+	// prepare repeated queries
+	counts := make(map[string]int, len(b.items))
+	for _, item := range b.items {
+		counts[item.query]++
+	}
+	stmts := make(map[string]*sql.Stmt)
+	defer func() {
+		for _, stmt := range stmts {
+			_ = stmt.Close()
+		}
+	}()
+
+	results = make([]sql.Result, 0, len(b.items))
+	startedAt := time.Now()
+
+	for i, item := range b.items {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		if deadline, ok := ctx.Deadline(); ok && i > 0 && time.Until(deadline) < time.Since(startedAt)/time.Duration(i) {
+			return results, context.DeadlineExceeded
+		}
+
+		var res sql.Result
+		res, err = b.exec(ctx, w, conn, stmts, item, counts[item.query] > 1)
+		target.misses.invalidate(item.query)
+		if err != nil {
+			if target.shouldFailure(w, err) {
+				target.countFailure(w, err)
+			}
+			reportNodeError(w, item.query, err)
+			return
+		}
+		results = append(results, res)
+	}
+
+	return
+}
+
+func (b *Batch) exec(ctx context.Context, w *wrapper, conn *sql.Conn, stmts map[string]*sql.Stmt, item batchItem, prepare bool) (res sql.Result, err error) {
+	startedAt := time.Now()
+	defer func() {
+		w.stats.done(item.query, err)
+		logSlowStatement(w, item.query, time.Since(startedAt))
+	}()
+
+	nargs, buf := w.acquireArgs(item.args)
+	defer putValues(buf)
+
+	if !prepare {
+		return conn.ExecContext(ctx, withTraceComment(ctx, w.rebind(item.query)), nargs...)
+	}
+
+	stmt := stmts[item.query]
+	if stmt == nil {
+		if stmt, err = conn.PrepareContext(ctx, w.rebind(item.query)); err != nil {
+			return
+		}
+		stmts[item.query] = stmt
+	}
+	return stmt.ExecContext(ctx, nargs...)
+}
@@ -0,0 +1,53 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBatch(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+
+	if results, err := dbs.Batch().Run(context.Background()); err != nil || results != nil {
+		t.Fatal("Batch: empty batch fail", results, err)
+	}
+
+	b := dbs.Batch()
+	b.Queue("CREATE TABLE batch_test (id INTEGER PRIMARY KEY, name TEXT)")
+	for i := 1; i <= 3; i++ {
+		b.Queue("INSERT INTO batch_test (id, name) VALUES (?, ?)", i, "name")
+	}
+	b.Queue("DELETE FROM batch_test WHERE id > ?", 1)
+	if b.Len() != 5 {
+		t.Fatal("Batch: queue fail", b.Len())
+	}
+
+	// statements of batch share connection, thus the same in-memory database
+	results, err := b.Run(context.Background())
+	if err != nil || len(results) != 5 {
+		t.Fatal("Batch: run fail", len(results), err)
+	}
+	if id, _ := results[3].LastInsertId(); id != 3 {
+		t.Fatal("Batch: result of prepared statement fail", id)
+	}
+	if n, _ := results[4].RowsAffected(); n != 2 {
+		t.Fatal("Batch: result fail", n)
+	}
+
+	b = dbs.Batch()
+	b.Queue("CREATE TABLE batch_fail (id INTEGER)")
+	b.Queue("INSERT INTO batch_missing VALUES (1)")
+	b.Queue("INSERT INTO batch_fail VALUES (1)")
+	if results, err = b.Run(context.Background()); err == nil || len(results) != 1 {
+		t.Fatal("Batch: failing statement should stop batch", len(results), err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+	if _, err = b.Run(ctx); err != context.DeadlineExceeded {
+		t.Fatal("Batch: expired deadline should fail", err)
+	}
+}
@@ -0,0 +1,405 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/lib/pq"
+)
+
+const (
+	// jsonTagOption marks struct fields bound and scanned as JSON, i.e `db:"data,json"`
+	jsonTagOption = "json"
+
+	// zeronullTagOption marks struct fields of plain types scanning NULL as zero value, i.e `db:"note,zeronull"`
+	zeronullTagOption = "zeronull"
+)
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+	pqArrayTypes = map[reflect.Type]bool{
+		reflect.TypeOf([]bool{}):    true,
+		reflect.TypeOf([]float32{}): true,
+		reflect.TypeOf([]float64{}): true,
+		reflect.TypeOf([]int32{}):   true,
+		reflect.TypeOf([]int64{}):   true,
+		reflect.TypeOf([]string{}):  true,
+		reflect.TypeOf([][]byte{}):  true,
+	}
+)
+
+type bindingKind int
+
+const (
+	bindingNone bindingKind = iota
+	bindingJSON
+	bindingArray
+	bindingUTC
+	bindingZeroNull
+	bindingEncrypted
+)
+
+func fieldBinding(fi *reflectx.FieldInfo, postgres, utc bool) bindingKind {
+	if fi == nil {
+		return bindingNone
+	}
+
+	if _, ok := fi.Options[encryptedTagOption]; ok {
+		return bindingEncrypted
+	}
+
+	if _, ok := fi.Options[jsonTagOption]; ok {
+		return bindingJSON
+	}
+
+	t := fi.Field.Type
+	if postgres && pqArrayTypes[t] && !t.Implements(valuerType) && !reflect.PtrTo(t).Implements(scannerType) {
+		return bindingArray
+	}
+
+	if utc && (t == timeType || t == timePtrType) {
+		return bindingUTC
+	}
+
+	if _, ok := fi.Options[zeronullTagOption]; ok && t.Kind() != reflect.Ptr {
+		return bindingZeroNull
+	}
+
+	return bindingNone
+}
+
+type bindingCacheKey struct {
+	mapper   *reflectx.Mapper
+	t        reflect.Type
+	postgres bool
+	utc      bool
+}
+
+var bindingCache sync.Map // bindingCacheKey -> bool
+
+// needsBinding reports whether struct type t has fields requiring JSON/array binding or encryption
+func needsBinding(m *reflectx.Mapper, t reflect.Type, postgres, utc bool) bool {
+	if t.Kind() != reflect.Struct || t.Implements(valuerType) || reflect.PtrTo(t).Implements(scannerType) {
+		return false
+	}
+
+	key := bindingCacheKey{mapper: m, t: t, postgres: postgres, utc: utc}
+	if v, ok := bindingCache.Load(key); ok {
+		return v.(bool)
+	}
+
+	needed := false
+	for _, fi := range m.TypeMap(t).Index {
+		if fieldBinding(fi, postgres, utc) != bindingNone {
+			needed = true
+			break
+		}
+	}
+
+	bindingCache.Store(key, needed)
+	return needed
+}
+
+func bindValue(kind bindingKind, v reflect.Value) (interface{}, error) {
+	switch kind {
+	case bindingJSON:
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+
+	case bindingArray:
+		return pq.Array(v.Interface()), nil
+
+	case bindingUTC:
+		return toUTC(v.Interface()), nil
+
+	case bindingEncrypted:
+		return encryptValue(v)
+	}
+	return v.Interface(), nil
+}
+
+// bindArg converts struct arg of named queries which needs JSON/array binding
+// (or UTC normalization if utc is true) into a map.
+func bindArg(db *sqlx.DB, arg interface{}, utc bool) (interface{}, error) {
+	if m, ok := arg.(map[string]interface{}); ok && utc {
+		bound := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			bound[k] = toUTC(v)
+		}
+		return bound, nil
+	}
+
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	postgres := isPostgres(db.DriverName())
+	if !v.IsValid() || !needsBinding(db.Mapper, v.Type(), postgres, utc) {
+		return arg, nil
+	}
+
+	fields := getBindPlan(db.Mapper, v.Type(), postgres, utc)
+	m := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		f := reflectx.FieldByIndexesReadOnly(v, field.index)
+		if !f.IsValid() {
+			continue
+		}
+
+		value, err := bindValue(field.binding, f)
+		if err != nil {
+			return nil, err
+		}
+		m[field.name] = value
+	}
+
+	return m, nil
+}
+
+// bindNamed binds named query with arg like sqlx, respecting JSON/array binding, UTC normalization and encryption
+// of struct arg, or of each element of a batch arg (slice of structs).
+func bindNamed(db *sqlx.DB, query string, arg interface{}, utc bool) (string, []interface{}, error) {
+	v := reflect.ValueOf(arg)
+	if k := v.Kind(); (k == reflect.Slice || k == reflect.Array) && v.Len() > 0 &&
+		needsBinding(db.Mapper, reflectx.Deref(v.Type().Elem()), isPostgres(db.DriverName()), utc) {
+		// sqlx expands query for the whole batch, args are bound element by element
+		bound, _, err := db.BindNamed(query, arg)
+		if err != nil {
+			return "", nil, err
+		}
+
+		var args []interface{}
+		for i := 0; i < v.Len(); i++ {
+			elem, err := bindArg(db, v.Index(i).Interface(), utc)
+			if err != nil {
+				return "", nil, err
+			}
+
+			_, elemArgs, err := db.BindNamed(query, elem)
+			if err != nil {
+				return "", nil, err
+			}
+			args = append(args, elemArgs...)
+		}
+		return bound, args, nil
+	}
+
+	bound, err := bindArg(db, arg, utc)
+	if err != nil {
+		return "", nil, err
+	}
+	return db.BindNamed(query, bound)
+}
+
+// jsonScanner scans JSON column into a struct field
+type jsonScanner struct {
+	dst reflect.Value
+}
+
+func (s *jsonScanner) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		s.dst.Set(reflect.Zero(s.dst.Type()))
+		return nil
+
+	case []byte:
+		return json.Unmarshal(v, s.dst.Addr().Interface())
+
+	case string:
+		return json.Unmarshal([]byte(v), s.dst.Addr().Interface())
+	}
+	return fmt.Errorf("mssqlx: unsupported type %T for JSON field", src)
+}
+
+// scan current row into struct value v
+func scanBound(rows *sqlx.Rows, plan *scanPlan, columns []string, v reflect.Value, dest interface{}) error {
+	type nullable struct {
+		field, ptr reflect.Value
+	}
+	var nullables []nullable
+
+	buf := getValues(len(columns))
+	defer putValues(buf)
+
+	targets := *buf
+	for i, traversal := range plan.traversals {
+		if len(traversal) == 0 {
+			return fmt.Errorf("missing destination name %s in %T", columns[i], dest)
+		}
+
+		f := reflectx.FieldByIndexes(v, traversal)
+		switch plan.bindings[i] {
+		case bindingJSON:
+			targets[i] = &jsonScanner{dst: f}
+
+		case bindingEncrypted:
+			targets[i] = &encryptedScanner{dst: f}
+
+		case bindingArray:
+			targets[i] = pq.Array(f.Addr().Interface())
+
+		case bindingZeroNull:
+			// scan into **T, which is nil on NULL
+			p := reflect.New(reflect.PtrTo(f.Type()))
+			targets[i] = p.Interface()
+			nullables = append(nullables, nullable{field: f, ptr: p})
+
+		default:
+			targets[i] = f.Addr().Interface()
+		}
+	}
+
+	if err := rows.Scan(targets...); err != nil {
+		return err
+	}
+
+	for _, n := range nullables {
+		if p := n.ptr.Elem(); p.IsNil() {
+			n.field.Set(reflect.Zero(n.field.Type()))
+		} else {
+			n.field.Set(p.Elem())
+		}
+	}
+
+	return nil
+}
+
+// isScannableType is the same as sqlx: scannable types are scanned directly instead of by columns
+func isScannableType(m *reflectx.Mapper, t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(scannerType) || t.Kind() != reflect.Struct {
+		return true
+	}
+	return len(m.TypeMap(t).Index) == 0
+}
+
+// selectContext is sqlx SelectContext respecting JSON/array binding of struct destination, querying by q
+// (node of w or a transaction on it). Only destinations with bindings are scanned by scanBound. If limit > 0, ErrTooManyRows is returned once more than limit rows are read.
+func selectContext(ctx context.Context, w *wrapper, q sqlx.QueryerContext, limit int, dest interface{}, query string, args ...interface{}) error {
+	if ok, err := selectPrimitives(ctx, q, limit, dest, query, args...); ok {
+		return err
+	}
+
+	db := w.db
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return sqlx.SelectContext(ctx, q, dest, query, args...)
+	}
+
+	sliceType := v.Elem().Type()
+	elemType := sliceType.Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	baseType := reflectx.Deref(elemType)
+
+	scannable := isScannableType(db.Mapper, baseType)
+	if scannable && limit <= 0 {
+		return sqlx.SelectContext(ctx, q, dest, query, args...)
+	}
+
+	rows, err := q.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if scannable {
+		return scanLimited(rows, dest, limit)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	postgres := isPostgres(db.DriverName())
+	if !needsBinding(db.Mapper, baseType, postgres, false) {
+		// sqlx scans destination without bindings, columns are kept to diagnose scan errors
+		if limit > 0 {
+			err = scanLimited(rows, dest, limit)
+		} else {
+			err = sqlx.StructScan(rows, dest)
+		}
+		if err == ErrTooManyRows {
+			return err
+		}
+		return w.checkScan(err, query, dest, baseType, columns)
+	}
+
+	plan := getScanPlan(db.Mapper, baseType, postgres, columns)
+
+	result := v.Elem()
+	for n := 0; rows.Next(); n++ {
+		if limit > 0 && n >= limit {
+			return ErrTooManyRows
+		}
+
+		elem := reflect.New(baseType)
+		if err = scanBound(rows, plan, columns, elem.Elem(), dest); err != nil {
+			return w.checkScan(err, query, dest, baseType, columns)
+		}
+
+		if isPtr {
+			result = reflect.Append(result, elem)
+		} else {
+			result = reflect.Append(result, elem.Elem())
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	v.Elem().Set(result)
+	return w.checkScan(nil, query, dest, baseType, columns)
+}
+
+// getContext is sqlx GetContext respecting JSON/array binding of struct destination, querying by q.
+// Only destinations with bindings are scanned by scanBound.
+func getContext(ctx context.Context, w *wrapper, q sqlx.QueryerContext, dest interface{}, query string, args ...interface{}) error {
+	db := w.db
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || isScannableType(db.Mapper, v.Elem().Type()) {
+		return sqlx.GetContext(ctx, q, dest, query, args...)
+	}
+	baseType := v.Elem().Type()
+
+	rows, err := q.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	if postgres := isPostgres(db.DriverName()); needsBinding(db.Mapper, baseType, postgres, false) {
+		err = scanBound(rows, getScanPlan(db.Mapper, baseType, postgres, columns), columns, v.Elem(), dest)
+	} else {
+		err = rows.StructScan(dest)
+	}
+
+	return w.checkScan(err, query, dest, baseType, columns)
+}
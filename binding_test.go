@@ -0,0 +1,109 @@
+package mssqlx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type bindingItem struct {
+	ID    int64             `db:"id"`
+	Tags  []string          `db:"tags"`
+	Attrs map[string]string `db:"attrs,json"`
+}
+
+func TestBindArg(t *testing.T) {
+	pg, _ := sqlx.Open("postgres", "user=test1 dbname=test1 sslmode=disable")
+	defer pg.Close()
+
+	arg, err := bindArg(pg, &bindingItem{ID: 1, Tags: []string{"a"}, Attrs: map[string]string{"k": "v"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, ok := arg.(map[string]interface{})
+	if !ok || m["id"] != int64(1) || m["attrs"] != `{"k":"v"}` {
+		t.Fatal("BindArg: unexpected bound arg", arg)
+	}
+	if _, ok = m["tags"].(*pq.StringArray); !ok {
+		t.Fatal("BindArg: array should be wrapped", reflect.TypeOf(m["tags"]))
+	}
+
+	type plain struct {
+		ID int64 `db:"id"`
+	}
+	if arg, _ = bindArg(pg, plain{ID: 1}, false); reflect.TypeOf(arg) != reflect.TypeOf(plain{}) {
+		t.Fatal("BindArg: plain struct should be untouched")
+	}
+}
+
+func TestJSONBinding(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+
+	type item struct {
+		ID    int64             `db:"id"`
+		Attrs map[string]string `db:"attrs,json"`
+	}
+
+	if _, err := dbs.Exec("CREATE TABLE item (id INTEGER, attrs TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.NamedExec("INSERT INTO item (id, attrs) VALUES (:id, :attrs)", &item{ID: 1, Attrs: map[string]string{"k": "v"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.Exec("INSERT INTO item (id, attrs) VALUES (2, NULL)"); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []*item
+	if err := dbs.SelectOnMaster(&items, "SELECT * FROM item ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Attrs["k"] != "v" || items[1].Attrs != nil {
+		t.Fatal("JSONBinding: unexpected select result", items)
+	}
+
+	var it item
+	if err := dbs.GetContextOnMaster(context.Background(), &it, "SELECT * FROM item WHERE id = 1"); err != nil || it.Attrs["k"] != "v" {
+		t.Fatal("JSONBinding: unexpected get result", it, err)
+	}
+
+	if err := dbs.GetOnMaster(&it, "SELECT * FROM item WHERE id = 3"); err == nil {
+		t.Fatal("JSONBinding: no rows should be reported")
+	}
+}
+
+func TestZeroNullScanning(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+
+	type item struct {
+		ID    int64   `db:"id"`
+		Name  string  `db:"name,zeronull"`
+		Score float64 `db:"score,zeronull"`
+	}
+
+	if _, err := dbs.Exec("CREATE TABLE item (id INTEGER, name TEXT, score REAL)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.Exec("INSERT INTO item VALUES (1, 'a', 1.5), (2, NULL, NULL)"); err != nil {
+		t.Fatal(err)
+	}
+
+	items := []item{{Name: "stale"}}
+	if err := dbs.SelectOnMaster(&items, "SELECT * FROM item ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 || items[1].Name != "a" || items[1].Score != 1.5 || items[2].Name != "" || items[2].Score != 0 {
+		t.Fatal("ZeroNull: unexpected result", items)
+	}
+
+	it := item{Name: "stale"}
+	if err := dbs.GetOnMaster(&it, "SELECT * FROM item WHERE id = 2"); err != nil || it.Name != "" {
+		t.Fatal("ZeroNull: NULL should be scanned as zero value", it, err)
+	}
+}
@@ -0,0 +1,57 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+var (
+	// ErrQueryBudgetExceeded number of queries executed within context exceeds its budget
+	ErrQueryBudgetExceeded = errors.New("Query budget exceeded")
+)
+
+type queryBudgetKey struct{}
+
+type queryBudget struct {
+	limit int64
+	used  int64
+}
+
+// WithQueryBudget returns a context which allows at most n queries executed through DBs with it.
+// Queries past the limit fail with ErrQueryBudgetExceeded.
+//
+// It is a guardrail against accidental N+1 queries in request handlers. Queries executed
+// inside transactions, prepared statements or dedicated connections are not counted.
+func WithQueryBudget(ctx context.Context, n int) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, queryBudgetKey{}, &queryBudget{limit: int64(n)})
+}
+
+// QueryBudgetUsed returns number of queries counted against budget of ctx.
+func QueryBudgetUsed(ctx context.Context) int {
+	if b := queryBudgetFromContext(ctx); b != nil {
+		if used := atomic.LoadInt64(&b.used); used < b.limit {
+			return int(used)
+		}
+		return int(b.limit)
+	}
+	return 0
+}
+
+func queryBudgetFromContext(ctx context.Context) *queryBudget {
+	if ctx == nil {
+		return nil
+	}
+	b, _ := ctx.Value(queryBudgetKey{}).(*queryBudget)
+	return b
+}
+
+func consumeQueryBudget(ctx context.Context) error {
+	if b := queryBudgetFromContext(ctx); b != nil && atomic.AddInt64(&b.used, 1) > b.limit {
+		return ErrQueryBudgetExceeded
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQueryBudget(t *testing.T) {
+	if QueryBudgetUsed(context.Background()) != 0 || consumeQueryBudget(nil) != nil {
+		t.Fatal("QueryBudget: context without budget should not be limited")
+	}
+
+	ctx := WithQueryBudget(context.Background(), 2)
+	if consumeQueryBudget(ctx) != nil || consumeQueryBudget(ctx) != nil || QueryBudgetUsed(ctx) != 2 {
+		t.Fatal("QueryBudget: consume fail")
+	}
+	if consumeQueryBudget(ctx) != ErrQueryBudgetExceeded || QueryBudgetUsed(ctx) != 2 {
+		t.Fatal("QueryBudget: exceeded check fail")
+	}
+
+	_RunWithSchema(defaultSchema, t, func(db *DBs, t *testing.T) {
+		_loadDefaultFixture(db, t)
+
+		ctx := WithQueryBudget(context.Background(), 1)
+
+		var people []Person
+		if err := db.SelectContext(ctx, &people, "SELECT * FROM person"); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.GetContext(ctx, &people, "SELECT * FROM person"); err != ErrQueryBudgetExceeded {
+			t.Fatal("QueryBudget: exceeded check fail", err)
+		}
+	})
+}
@@ -0,0 +1,292 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrBufferLimitExceeded result set of BufferedQueryx is larger than buffer limit
+	ErrBufferLimitExceeded = errors.New("Result set exceeds buffer limit")
+
+	// ErrBufferedRowsNotFound buffered rows are already consumed
+	ErrBufferedRowsNotFound = errors.New("Buffered rows not found")
+)
+
+const (
+	// DefaultBufferLimit default maximum size (in bytes) of a result set materialized by BufferedQueryx
+	DefaultBufferLimit = 32 << 20
+)
+
+// approximate memory footprint of an empty value
+const bufferedValueOverhead = 16
+
+func (dbs *DBs) getBufferLimit() int64 {
+	if v := atomic.LoadInt64(&dbs.bufferLimit); v > 0 {
+		return v
+	}
+	return DefaultBufferLimit
+}
+
+// SetBufferLimit sets the maximum size (in bytes, approximately) of a result set BufferedQueryx materializes.
+//
+// If n <= 0, DefaultBufferLimit is used.
+func (dbs *DBs) SetBufferLimit(n int64) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt64(&dbs.bufferLimit, n)
+}
+
+// materialized result set
+type bufferedResult struct {
+	columns []string
+	values  [][]driver.Value
+	size    int64
+}
+
+func valueSize(v interface{}) int64 {
+	switch v := v.(type) {
+	case []byte:
+		return bufferedValueOverhead + int64(len(v))
+	case string:
+		return bufferedValueOverhead + int64(len(v))
+	}
+	return bufferedValueOverhead
+}
+
+// materialize reads whole result set of query into memory.
+// ErrBufferLimitExceeded is returned once result set is larger than limit bytes, ErrTooManyRows once more than maxRows rows are read.
+func materialize(ctx context.Context, w *wrapper, limit int64, maxRows int, query string, args ...interface{}) (res *bufferedResult, err error) {
+	rows, err := w.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return
+	}
+
+	res = &bufferedResult{columns: columns}
+
+	buf := getValues(2 * len(columns))
+	defer putValues(buf)
+
+	values, ptrs := (*buf)[:len(columns)], (*buf)[len(columns):]
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	var size int64
+	for rows.Next() {
+		if maxRows > 0 && len(res.values) >= maxRows {
+			return nil, ErrTooManyRows
+		}
+
+		if err = rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := make([]driver.Value, len(values))
+		for i, v := range values {
+			row[i], size = v, size+valueSize(v)
+		}
+		if size > limit {
+			return nil, ErrBufferLimitExceeded
+		}
+
+		res.values = append(res.values, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		res = nil
+	} else {
+		res.size = size
+	}
+	return
+}
+
+// Buffered results are served by an in-memory driver, so that callers get regular
+// *sqlx.Rows while original node connection is already released.
+var (
+	bufferedResults sync.Map
+	bufferedSeq     uint64
+
+	bufferedDBOnce sync.Once
+	bufferedDB     *sql.DB
+)
+
+type bufferedConnector struct{}
+
+func (c bufferedConnector) Connect(context.Context) (driver.Conn, error) {
+	return bufferedConn{}, nil
+}
+
+func (c bufferedConnector) Driver() driver.Driver {
+	return bufferedDriver{}
+}
+
+type bufferedDriver struct{}
+
+func (d bufferedDriver) Open(string) (driver.Conn, error) {
+	return bufferedConn{}, nil
+}
+
+type bufferedConn struct{}
+
+func (c bufferedConn) Prepare(string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c bufferedConn) Close() error {
+	return nil
+}
+
+func (c bufferedConn) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c bufferedConn) QueryContext(_ context.Context, key string, _ []driver.NamedValue) (driver.Rows, error) {
+	v, ok := bufferedResults.Load(key)
+	if !ok {
+		return nil, ErrBufferedRowsNotFound
+	}
+	bufferedResults.Delete(key)
+
+	return &bufferedRows{bufferedResult: v.(*bufferedResult)}, nil
+}
+
+type bufferedRows struct {
+	*bufferedResult
+	pos int
+}
+
+func (r *bufferedRows) Columns() []string {
+	return r.columns
+}
+
+func (r *bufferedRows) Close() error {
+	r.values = nil
+	return nil
+}
+
+func (r *bufferedRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.pos])
+	r.pos++
+	return nil
+}
+
+// open serves materialized result set as *sqlx.Rows.
+func (res *bufferedResult) open(ctx context.Context, db *sqlx.DB) (*sqlx.Rows, error) {
+	bufferedDBOnce.Do(func() {
+		bufferedDB = sql.OpenDB(bufferedConnector{})
+	})
+
+	key := strconv.FormatUint(atomic.AddUint64(&bufferedSeq, 1), 10)
+	bufferedResults.Store(key, res)
+
+	rows, err := bufferedDB.QueryContext(ctx, key)
+	if err != nil {
+		bufferedResults.Delete(key)
+		return nil, err
+	}
+	return &sqlx.Rows{Rows: rows, Mapper: guardMapper(db.Mapper)}, nil
+}
+
+func _bufferedQueryx(ctx context.Context, target *balancer, limit int64, query string, args ...interface{}) (dbr *wrapper, res *sqlx.Rows, err error) {
+	var (
+		w *wrapper
+		r interface{}
+	)
+
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	// read-after-write consistency
+	ctx, target = target.route(ctx, query)
+
+	retries, maxRows := 0, target.maxRowsFor(ctx)
+	queryInfoFromContext(ctx).start()
+	for {
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
+			reportError(query, err)
+			return
+		}
+
+		// executing
+		r, err = retryBackoff(ctx, w, query, func() (interface{}, error) {
+			q, stop := w.withCancel(ctx, w.withServerTimeout(ctx, withTraceComment(ctx, w.rebind(query))))
+			defer stop()
+
+			nargs, buf := w.acquireArgs(args)
+			defer putValues(buf)
+
+			return materialize(ctx, w, limit, maxRows, q, nargs...)
+		})
+
+		// check networking/wsrep error
+		if target.shouldFailure(w, err) && target.countFailure(w, err) {
+			continue
+		}
+
+		// retry on another node
+		if isNetworkError(err) && retries < target.getReadRetries() {
+			if rctx, ok := target.retryRead(ctx, w); ok {
+				retries, ctx = retries+1, rctx
+				reportNodeError(w, query, err)
+				continue
+			}
+		}
+
+		if err == nil {
+			result := r.(*bufferedResult)
+			logBigResult(w, query, len(result.values), result.size)
+			res, err = result.open(ctx, w.db)
+		}
+
+		dbr = w
+		return
+	}
+}
+
+// BufferedQueryx executes a query on slaves like Queryx, but whole result set is read into memory
+// before returning, so that iterating slowly is not exposed to node failures mid-stream.
+// ErrBufferLimitExceeded is returned if result set is larger than buffer limit (see SetBufferLimit).
+//
+// Returned rows do not support ColumnTypes.
+func (dbs *DBs) BufferedQueryx(query string, args ...interface{}) (r *sqlx.Rows, err error) {
+	_, r, err = _bufferedQueryx(context.Background(), dbs.slaves, dbs.getBufferLimit(), query, args...)
+	return
+}
+
+// BufferedQueryxOnMaster executes a query on masters like QueryxOnMaster, but whole result set is read into memory.
+func (dbs *DBs) BufferedQueryxOnMaster(query string, args ...interface{}) (r *sqlx.Rows, err error) {
+	_, r, err = _bufferedQueryx(context.Background(), dbs.masters, dbs.getBufferLimit(), query, args...)
+	return
+}
+
+// BufferedQueryxContext executes a query on slaves like QueryxContext, but whole result set is read into memory.
+func (dbs *DBs) BufferedQueryxContext(ctx context.Context, query string, args ...interface{}) (r *sqlx.Rows, err error) {
+	_, r, err = _bufferedQueryx(ctx, dbs.slaves, dbs.getBufferLimit(), query, args...)
+	return
+}
+
+// BufferedQueryxContextOnMaster executes a query on masters like QueryxContextOnMaster, but whole result set is read into memory.
+func (dbs *DBs) BufferedQueryxContextOnMaster(ctx context.Context, query string, args ...interface{}) (r *sqlx.Rows, err error) {
+	_, r, err = _bufferedQueryx(ctx, dbs.masters, dbs.getBufferLimit(), query, args...)
+	return
+}
@@ -0,0 +1,47 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBufferedQueryx(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	if dbs.getBufferLimit() != DefaultBufferLimit {
+		t.Fatal("BufferedQueryx: default limit fail")
+	}
+
+	rows, err := dbs.BufferedQueryx("SELECT 1 AS id, 'a' AS name UNION ALL SELECT 2, 'b'")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type row struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	var res []row
+	for rows.Next() {
+		var r row
+		if err = rows.StructScan(&r); err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, r)
+	}
+	if err = rows.Close(); err != nil || len(res) != 2 || res[0].Name != "a" || res[1].ID != 2 {
+		t.Fatal("BufferedQueryx: scan fail", err, res)
+	}
+
+	if rows, err = dbs.BufferedQueryxContextOnMaster(context.Background(), "SELECT 1 WHERE 1 = 0"); err != nil || rows.Next() {
+		t.Fatal("BufferedQueryx: empty result fail", err)
+	}
+	_ = rows.Close()
+
+	dbs.SetBufferLimit(bufferedValueOverhead)
+	if _, err = dbs.BufferedQueryx("SELECT 1 UNION ALL SELECT 2"); err != ErrBufferLimitExceeded {
+		t.Fatal("BufferedQueryx: limit fail", err)
+	}
+}
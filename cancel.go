@@ -0,0 +1,106 @@
+package mssqlx
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultCancelTimeout timeout of killing a cancelled statement on server
+	DefaultCancelTimeout = 5 * time.Second
+)
+
+var (
+	// unique per process, so that statements of other clients are never killed
+	cancelTagPrefix = "mssqlx-cancel-" + strconv.FormatInt(time.Now().UnixNano(), 36) + "-"
+	cancelSeq       uint64
+)
+
+func _setAggressiveCancel(target []*wrapper, enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+
+	for _, db := range target {
+		if db != nil {
+			atomic.StoreInt32(&db.aggressiveCancel, v)
+		}
+	}
+}
+
+// SetAggressiveCancel enables killing statements on server when their context is done, for drivers
+// lacking server-side cancellation or nodes behind poolers.
+//
+// Statements of Exec, NamedExec, Select, Get and BufferedQueryx are tagged with a comment. Once
+// context is done, tagged statement is looked up and cancelled from a side connection with
+// KILL QUERY (mysql) or pg_cancel_backend (postgres). It costs a goroutine per statement.
+func (dbs *DBs) SetAggressiveCancel(enabled bool) {
+	_setAggressiveCancel(dbs.allNodes(), enabled)
+}
+
+// killTaggedQuery returns statement cancelling server-side queries tagged with tag.
+func killTaggedQuery(driverName string) string {
+	switch driverName {
+	case "mysql":
+		return "SELECT ID FROM information_schema.PROCESSLIST WHERE INFO LIKE ? AND ID <> CONNECTION_ID()"
+
+	case "postgres", "pgx":
+		return "SELECT pg_cancel_backend(pid) FROM pg_stat_activity WHERE query LIKE $1 AND pid <> pg_backend_pid()"
+	}
+	return ""
+}
+
+// withCancel tags query and watches ctx, killing tagged statement on server once ctx is done.
+// Returned stop func must be called after statement is finished.
+func (w *wrapper) withCancel(ctx context.Context, query string) (string, func()) {
+	if ctx == nil || ctx.Done() == nil || atomic.LoadInt32(&w.aggressiveCancel) == 0 {
+		return query, func() {}
+	}
+
+	lookup := killTaggedQuery(w.db.DriverName())
+	if lookup == "" {
+		return query, func() {}
+	}
+
+	tag := "/* " + cancelTagPrefix + strconv.FormatUint(atomic.AddUint64(&cancelSeq, 1), 10) + " */"
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			w.killTagged(lookup, tag)
+		}
+	}()
+
+	return tag + " " + query, func() { close(done) }
+}
+
+func (w *wrapper) killTagged(lookup, tag string) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCancelTimeout)
+	defer cancel()
+
+	pattern := "%" + tag + "%"
+	if w.db.DriverName() != "mysql" {
+		if _, err := w.db.ExecContext(ctx, lookup, pattern); err != nil {
+			reportNodeError(w, lookup, err)
+		}
+		return
+	}
+
+	var ids []int64
+	if err := w.db.SelectContext(ctx, &ids, lookup, pattern); err != nil {
+		reportNodeError(w, lookup, err)
+		return
+	}
+
+	for _, id := range ids {
+		query := "KILL QUERY " + strconv.FormatInt(id, 10)
+		if _, err := w.db.ExecContext(ctx, query); err != nil {
+			reportNodeError(w, query, err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package mssqlx
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestAggressiveCancel(t *testing.T) {
+	if killTaggedQuery("sqlite3") != "" || killTaggedQuery("mysql") == "" || killTaggedQuery("pgx") == "" {
+		t.Fatal("AggressiveCancel: lookup query fail")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := newWrapper(sqlx.NewDb(nil, "mysql"), "", RoleMaster, 0)
+	if q, stop := w.withCancel(ctx, "SELECT 1"); q != "SELECT 1" {
+		t.Fatal("AggressiveCancel: disabled fail", q)
+	} else {
+		stop()
+	}
+
+	_setAggressiveCancel([]*wrapper{w}, true)
+	if q, stop := w.withCancel(context.Background(), "SELECT 1"); q != "SELECT 1" {
+		t.Fatal("AggressiveCancel: non-cancellable context fail", q)
+	} else {
+		stop()
+	}
+
+	q1, stop1 := w.withCancel(ctx, "SELECT 1")
+	q2, stop2 := w.withCancel(ctx, "SELECT 1")
+	stop1()
+	stop2()
+	if q1 == q2 || !strings.HasPrefix(q1, "/* "+cancelTagPrefix) || !strings.HasSuffix(q1, " */ SELECT 1") {
+		t.Fatal("AggressiveCancel: tag fail", q1, q2)
+	}
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	dbs.SetAggressiveCancel(true)
+	if _, err := dbs.ExecContext(ctx, "CREATE TABLE t (a INT)"); err != nil {
+		t.Fatal("AggressiveCancel: sqlite exec fail", err)
+	}
+	if _, err := dbs.NamedExecContext(ctx, "INSERT INTO t (a) VALUES (:a)", map[string]interface{}{"a": 1}); err != nil {
+		t.Fatal("AggressiveCancel: sqlite named exec fail", err)
+	}
+
+	rows, err := dbs.NamedQueryContextOnMaster(ctx, "SELECT a FROM t WHERE a = :a", map[string]interface{}{"a": 1})
+	if err != nil {
+		t.Fatal("AggressiveCancel: sqlite named query fail", err)
+	}
+	if !rows.Next() {
+		t.Fatal("AggressiveCancel: sqlite named query should return row")
+	}
+	_ = rows.Close()
+}
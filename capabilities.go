@@ -0,0 +1,182 @@
+package mssqlx
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Capabilities describes what current topology supports, derived from driver and the lowest server version of nodes.
+type Capabilities struct {
+	// Version lowest server version of nodes, empty if none of nodes could be probed.
+	// Version dependent capabilities are false then.
+	Version string `json:"version"`
+
+	// Returning INSERT/UPDATE/DELETE ... RETURNING
+	Returning bool `json:"returning"`
+
+	// Savepoints SAVEPOINT, thus nested transactions
+	Savepoints bool `json:"savepoints"`
+
+	// Copy bulk loading with COPY FROM STDIN (pq.CopyIn)
+	Copy bool `json:"copy"`
+
+	// JSON native JSON type
+	JSON bool `json:"json"`
+
+	// AdvisoryLocks application defined locks, i.e pg_advisory_lock or GET_LOCK
+	AdvisoryLocks bool `json:"advisory_locks"`
+}
+
+type capabilitiesHolder struct {
+	caps *Capabilities
+}
+
+// versionQuery returns query of server version
+func versionQuery(driverName string) string {
+	switch driverName {
+	case "mysql":
+		return "SELECT VERSION()"
+
+	case "postgres", "pgx":
+		return "SELECT current_setting('server_version')"
+
+	case "sqlite3":
+		return "SELECT sqlite_version()"
+	}
+	return ""
+}
+
+// Capabilities returns what current topology supports, so that higher layers could branch reliably
+// instead of sniffing error strings. See CapabilitiesContext.
+func (dbs *DBs) Capabilities() Capabilities {
+	return dbs.CapabilitiesContext(context.Background())
+}
+
+// CapabilitiesContext returns what current topology supports. Server versions of nodes are probed on first call,
+// the result is cached until topology is switched. If none of nodes could be probed, capabilities known from driver
+// are returned without caching.
+func (dbs *DBs) CapabilitiesContext(ctx context.Context) Capabilities {
+	if h, _ := dbs.capabilities.Load().(capabilitiesHolder); h.caps != nil {
+		return *h.caps
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	caps := driverCapabilities(dbs.driverName, dbs.lowestVersion(ctx))
+	if caps.Version != "" {
+		dbs.capabilities.Store(capabilitiesHolder{caps: &caps})
+	}
+	return caps
+}
+
+// resetCapabilities drops cached capabilities, i.e when nodes are replaced
+func (dbs *DBs) resetCapabilities() {
+	dbs.capabilities.Store(capabilitiesHolder{})
+}
+
+// lowestVersion probes server versions of nodes, returns the lowest one
+func (dbs *DBs) lowestVersion(ctx context.Context) (lowest string) {
+	query := versionQuery(dbs.driverName)
+	if query == "" {
+		return
+	}
+
+	nodes := dbs.allNodes()
+
+	versions := make([]string, len(nodes))
+
+	var wg sync.WaitGroup
+	for i, w := range nodes {
+		if w == nil || w.db == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, w *wrapper) {
+			defer wg.Done()
+			_ = w.db.GetContext(ctx, &versions[i], query)
+		}(i, w)
+	}
+	wg.Wait()
+
+	for _, v := range versions {
+		if v != "" && (lowest == "" || compareVersions(v, lowest) < 0) {
+			lowest = v
+		}
+	}
+	return
+}
+
+// driverCapabilities returns capabilities of driver talking to server of version
+func driverCapabilities(driverName, version string) (caps Capabilities) {
+	caps.Version = version
+	known := version != ""
+
+	switch driverName {
+	case "postgres", "pgx":
+		caps.Returning, caps.Savepoints, caps.AdvisoryLocks = true, true, true
+		caps.Copy = driverName == "postgres" // pgx does not expose COPY through database/sql
+		caps.JSON = known && compareVersions(version, "9.2") >= 0
+
+	case "mysql":
+		caps.Savepoints, caps.AdvisoryLocks = true, true
+		if strings.Contains(version, "MariaDB") {
+			caps.Returning = compareVersions(version, "10.5") >= 0 // INSERT/DELETE only
+		} else {
+			caps.JSON = known && compareVersions(version, "5.7.8") >= 0
+		}
+
+	case "sqlite3":
+		caps.Savepoints = true
+		caps.Returning = known && compareVersions(version, "3.35") >= 0
+	}
+
+	return
+}
+
+// compareVersions compares leading numeric components of versions, i.e 8.0.35 > 8.0.4
+func compareVersions(a, b string) int {
+	pa, pb := versionNumbers(a), versionNumbers(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionNumbers parses leading numeric components of version, i.e [10 11 2] of 10.11.2-MariaDB
+func versionNumbers(version string) (numbers []int) {
+	for _, part := range strings.Split(version, ".") {
+		end := 0
+		for end < len(part) && part[end] >= '0' && part[end] <= '9' {
+			end++
+		}
+
+		n, err := strconv.Atoi(part[:end])
+		if err != nil {
+			return
+		}
+		numbers = append(numbers, n)
+
+		if end < len(part) {
+			return
+		}
+	}
+	return
+}
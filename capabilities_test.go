@@ -0,0 +1,46 @@
+package mssqlx
+
+import "testing"
+
+func TestCapabilities(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	caps := dbs.Capabilities()
+	if caps.Version == "" || !caps.Savepoints || caps.Copy || caps.AdvisoryLocks || caps.Returning != (compareVersions(caps.Version, "3.35") >= 0) {
+		t.Fatal("Capabilities: sqlite3 fail", caps)
+	}
+
+	// cached
+	dbs.masterNodes()[0].db.Close()
+	dbs.slaveNodes()[0].db.Close()
+	if dbs.Capabilities() != caps {
+		t.Fatal("Capabilities: should be cached")
+	}
+
+	// none of nodes could be probed
+	dbs.resetCapabilities()
+	if caps = dbs.Capabilities(); caps.Version != "" || caps.Returning || !caps.Savepoints {
+		t.Fatal("Capabilities: unknown version fail", caps)
+	}
+
+	cases := []struct {
+		driverName, version string
+		expected            Capabilities
+	}{
+		{"postgres", "15.4", Capabilities{Version: "15.4", Returning: true, Savepoints: true, Copy: true, JSON: true, AdvisoryLocks: true}},
+		{"pgx", "9.1.24", Capabilities{Version: "9.1.24", Returning: true, Savepoints: true, AdvisoryLocks: true}},
+		{"mysql", "8.0.35", Capabilities{Version: "8.0.35", Savepoints: true, JSON: true, AdvisoryLocks: true}},
+		{"mysql", "5.7.7-log", Capabilities{Version: "5.7.7-log", Savepoints: true, AdvisoryLocks: true}},
+		{"mysql", "10.11.2-MariaDB-1:10.11.2+maria~ubu2204", Capabilities{Version: "10.11.2-MariaDB-1:10.11.2+maria~ubu2204", Returning: true, Savepoints: true, AdvisoryLocks: true}},
+	}
+	for _, c := range cases {
+		if caps := driverCapabilities(c.driverName, c.version); caps != c.expected {
+			t.Fatal("Capabilities: fail", c.driverName, c.version, caps)
+		}
+	}
+
+	if compareVersions("8.0.35", "8.0.4") <= 0 || compareVersions("10.5", "10.5.0") != 0 || compareVersions("9.6", "10") >= 0 {
+		t.Fatal("Capabilities: comparing versions fail")
+	}
+}
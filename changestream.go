@@ -0,0 +1,324 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrChangeReaderRequired change stream needs a reader
+	ErrChangeReaderRequired = errors.New("Change reader is required")
+)
+
+const (
+	// DefaultChangePollInterval default interval of polling changes when there is none
+	DefaultChangePollInterval = time.Second
+
+	// DefaultChangeBatchSize default max number of changes read at once
+	DefaultChangeBatchSize = 1000
+)
+
+// ChangeOp is operation of a change event.
+type ChangeOp string
+
+const (
+	// ChangeInsert row is inserted
+	ChangeInsert ChangeOp = "INSERT"
+
+	// ChangeUpdate row is updated
+	ChangeUpdate ChangeOp = "UPDATE"
+
+	// ChangeDelete row is deleted
+	ChangeDelete ChangeOp = "DELETE"
+
+	// ChangeTruncate table is truncated
+	ChangeTruncate ChangeOp = "TRUNCATE"
+
+	// ChangeOther change which could not be decoded, see Raw
+	ChangeOther ChangeOp = "OTHER"
+)
+
+// ChangeEvent is a row change read from a change stream.
+type ChangeEvent struct {
+	// Node which change is read from
+	Node string
+
+	// Position of change in log of node, i.e LSN on postgres or binlog file:position on mysql
+	Position string
+
+	// TxID id of transaction making change
+	TxID int64
+
+	// Table qualified name of changed table
+	Table string
+
+	Op      ChangeOp
+	Columns []ChangeColumn
+
+	// Raw change as read from log
+	Raw string
+}
+
+// ChangeColumn is a column of changed row.
+type ChangeColumn struct {
+	Name  string
+	Type  string
+	Value string
+	Null  bool
+}
+
+// ChangeSource is node which changes are read from.
+type ChangeSource struct {
+	Name string
+	DSN  string
+	DB   *sqlx.DB
+}
+
+// ChangeReader reads changes from node, i.e from a postgres logical replication slot or mysql binlog.
+type ChangeReader interface {
+	// ReadChanges returns up to limit changes of source following acknowledged ones, without consuming them.
+	ReadChanges(ctx context.Context, source ChangeSource, limit int) ([]ChangeEvent, error)
+
+	// Ack consumes changes of source up to and including position, once they are delivered.
+	Ack(ctx context.Context, source ChangeSource, position string) error
+}
+
+// ChangeStreamOptions configures StreamChanges.
+type ChangeStreamOptions struct {
+	// Reader of changes, i.e PostgresSlotReader or a binlog reader
+	Reader ChangeReader
+
+	// Role of nodes which changes are read from. Default is RoleMaster.
+	Role Role
+
+	// PollInterval between reads when there is no change. Default is DefaultChangePollInterval.
+	PollInterval time.Duration
+
+	// BatchSize max number of changes read at once. Default is DefaultChangeBatchSize.
+	BatchSize int
+}
+
+// StreamChanges reads changes from a healthy node of opts.Role and delivers them to fn in order, until ctx is done
+// or fn returns error, which is returned then. Changes of a batch are acknowledged once all of them are delivered,
+// thus delivery is at-least-once. If node fails, reading continues from another healthy node.
+func (dbs *DBs) StreamChanges(ctx context.Context, opts ChangeStreamOptions, fn func(ChangeEvent) error) error {
+	if opts.Reader == nil {
+		return ErrChangeReaderRequired
+	}
+
+	if opts.Role == "" {
+		opts.Role = RoleMaster
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultChangePollInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultChangeBatchSize
+	}
+
+	target, err := dbs.getBalancer(opts.Role)
+	if err != nil {
+		return err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for {
+		w, err := getDBFromBalancer(ctx, target)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			reportError("StreamChanges", err)
+			continue
+		}
+
+		source := ChangeSource{Name: w.getName(), DSN: w.dsn, DB: w.db}
+
+		n, err := deliverChanges(ctx, opts, source, fn)
+		switch {
+		case err == nil:
+
+		case ctx.Err() != nil:
+			return ctx.Err()
+
+		case isDeliveryError(err):
+			return err.(*deliveryError).err
+
+		case target.shouldFailure(w, err):
+			reportNodeError(w, "StreamChanges", err)
+			target.countFailure(w, err)
+			continue // fail over
+
+		default:
+			return err
+		}
+
+		if n < opts.BatchSize {
+			if err = sleepContext(ctx, opts.PollInterval); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// deliveryError is error returned by callback of StreamChanges
+type deliveryError struct {
+	err error
+}
+
+func (e *deliveryError) Error() string {
+	return e.err.Error()
+}
+
+func isDeliveryError(err error) bool {
+	_, ok := err.(*deliveryError)
+	return ok
+}
+
+// deliverChanges reads one batch of changes from source and delivers them, returns number of changes
+func deliverChanges(ctx context.Context, opts ChangeStreamOptions, source ChangeSource, fn func(ChangeEvent) error) (int, error) {
+	changes, err := opts.Reader.ReadChanges(ctx, source, opts.BatchSize)
+	if err != nil || len(changes) == 0 {
+		return 0, err
+	}
+
+	for _, change := range changes {
+		change.Node = source.Name
+		if err = fn(change); err != nil {
+			return 0, &deliveryError{err: err}
+		}
+	}
+
+	return len(changes), opts.Reader.Ack(ctx, source, changes[len(changes)-1].Position)
+}
+
+// PostgresSlotReader reads changes from a postgres logical replication slot using test_decoding output plugin,
+// i.e created by SELECT pg_create_logical_replication_slot('slot', 'test_decoding'). Requires postgres 11 or newer.
+//
+// Slots are not replicated to standbys (before postgres 17 failover slots), slot should be re-created on
+// new primary after failover.
+type PostgresSlotReader struct {
+	Slot string
+}
+
+// ReadChanges peeks changes of slot.
+func (r PostgresSlotReader) ReadChanges(ctx context.Context, source ChangeSource, limit int) (changes []ChangeEvent, err error) {
+	rows, err := source.DB.QueryContext(ctx, "SELECT lsn::text, xid::text::bigint, data FROM pg_logical_slot_peek_changes($1, NULL, $2)", r.Slot, limit)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	var last string // position of last change, including transaction boundaries
+	for rows.Next() {
+		var change ChangeEvent
+		if err = rows.Scan(&change.Position, &change.TxID, &change.Raw); err != nil {
+			return nil, err
+		}
+
+		if last = change.Position; !strings.HasPrefix(change.Raw, "BEGIN") && !strings.HasPrefix(change.Raw, "COMMIT") {
+			decodeTestDecoding(&change)
+			changes = append(changes, change)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// transaction boundaries are not delivered, but acknowledged with changes
+	if len(changes) > 0 {
+		changes[len(changes)-1].Position = last
+	} else if last != "" {
+		err = r.Ack(ctx, source, last)
+	}
+	return
+}
+
+// Ack advances slot to position.
+func (r PostgresSlotReader) Ack(ctx context.Context, source ChangeSource, position string) error {
+	_, err := source.DB.ExecContext(ctx, "SELECT pg_replication_slot_advance($1, $2::pg_lsn)", r.Slot, position)
+	return err
+}
+
+// decodeTestDecoding parses change formatted by test_decoding, i.e
+// table public.users: UPDATE: id[integer]:1 name[text]:'john'
+func decodeTestDecoding(change *ChangeEvent) {
+	change.Op = ChangeOther
+
+	rest := strings.TrimPrefix(change.Raw, "table ")
+	if len(rest) == len(change.Raw) {
+		return
+	}
+
+	parts := strings.SplitN(rest, ": ", 3)
+	if len(parts) < 2 {
+		return
+	}
+
+	op := ChangeOp(strings.TrimSuffix(parts[1], ":"))
+	switch op {
+	case ChangeInsert, ChangeUpdate, ChangeDelete, ChangeTruncate:
+	default:
+		return
+	}
+
+	change.Table, change.Op = parts[0], op
+	if len(parts) == 3 && op != ChangeTruncate {
+		change.Columns = decodeTestDecodingColumns(parts[2])
+	}
+}
+
+// decodeTestDecodingColumns parses columns formatted as name[type]:value, values of string types are quoted
+func decodeTestDecodingColumns(s string) (columns []ChangeColumn) {
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		open := strings.IndexByte(s, '[')
+		end := strings.Index(s, "]:")
+		if open < 0 || end < open {
+			return
+		}
+
+		column := ChangeColumn{Name: s[:open], Type: s[open+1 : end]}
+		s = s[end+2:]
+
+		if strings.HasPrefix(s, "'") { // quoted, quotes are doubled
+			var b strings.Builder
+			i := 1
+			for ; i < len(s); i++ {
+				if s[i] == '\'' {
+					if i+1 < len(s) && s[i+1] == '\'' {
+						b.WriteByte('\'')
+						i++
+						continue
+					}
+					break
+				}
+				b.WriteByte(s[i])
+			}
+			if i < len(s) {
+				i++ // closing quote
+			}
+			column.Value, s = b.String(), s[i:]
+		} else {
+			next := strings.IndexByte(s, ' ')
+			if next < 0 {
+				next = len(s)
+			}
+			column.Value, s = s[:next], s[next:]
+			column.Null = column.Value == "null"
+			if column.Null {
+				column.Value = ""
+			}
+		}
+
+		columns = append(columns, column)
+	}
+	return
+}
@@ -0,0 +1,105 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeChangeReader struct {
+	mu      sync.Mutex
+	changes []ChangeEvent
+	acked   int // number of acknowledged changes
+}
+
+func (r *fakeChangeReader) ReadChanges(_ context.Context, _ ChangeSource, limit int) ([]ChangeEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changes := r.changes[r.acked:]
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return append([]ChangeEvent(nil), changes...), nil
+}
+
+func (r *fakeChangeReader) Ack(_ context.Context, _ ChangeSource, position string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.acked, _ = strconv.Atoi(position)
+	return nil
+}
+
+func TestStreamChanges(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+
+	if err := dbs.StreamChanges(context.Background(), ChangeStreamOptions{}, nil); err != ErrChangeReaderRequired {
+		t.Fatal("StreamChanges: reader should be required", err)
+	}
+
+	reader := &fakeChangeReader{}
+	for i := 1; i <= 5; i++ {
+		reader.changes = append(reader.changes, ChangeEvent{Position: strconv.Itoa(i), Op: ChangeInsert})
+	}
+
+	// callback error stops stream, batch is not acknowledged
+	errStop := errors.New("stop")
+	var delivered []string
+	err := dbs.StreamChanges(context.Background(), ChangeStreamOptions{Reader: reader, BatchSize: 2}, func(c ChangeEvent) error {
+		if c.Position == "4" {
+			return errStop
+		}
+		if c.Node != "master-0" {
+			t.Fatal("StreamChanges: node should be set", c.Node)
+		}
+		delivered = append(delivered, c.Position)
+		return nil
+	})
+	if err != errStop || !reflect.DeepEqual(delivered, []string{"1", "2", "3"}) || reader.acked != 2 {
+		t.Fatal("StreamChanges: delivery fail", err, delivered, reader.acked)
+	}
+
+	// resumed from acknowledged position, at-least-once
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	delivered = delivered[:0]
+	err = dbs.StreamChanges(ctx, ChangeStreamOptions{Reader: reader, PollInterval: time.Millisecond}, func(c ChangeEvent) error {
+		delivered = append(delivered, c.Position)
+		return nil
+	})
+	if err != context.DeadlineExceeded || !reflect.DeepEqual(delivered, []string{"3", "4", "5"}) || reader.acked != 5 {
+		t.Fatal("StreamChanges: resuming fail", err, delivered, reader.acked)
+	}
+}
+
+func TestDecodeTestDecoding(t *testing.T) {
+	change := ChangeEvent{Raw: "table public.users: UPDATE: id[integer]:1 name[text]:'it''s me' note[text]:null active[boolean]:true"}
+	decodeTestDecoding(&change)
+
+	expected := []ChangeColumn{
+		{Name: "id", Type: "integer", Value: "1"},
+		{Name: "name", Type: "text", Value: "it's me"},
+		{Name: "note", Type: "text", Null: true},
+		{Name: "active", Type: "boolean", Value: "true"},
+	}
+	if change.Table != "public.users" || change.Op != ChangeUpdate || !reflect.DeepEqual(change.Columns, expected) {
+		t.Fatal("DecodeTestDecoding: fail", change)
+	}
+
+	change = ChangeEvent{Raw: "table public.users: TRUNCATE: (no-flags)"}
+	if decodeTestDecoding(&change); change.Op != ChangeTruncate || change.Columns != nil {
+		t.Fatal("DecodeTestDecoding: truncate fail", change)
+	}
+
+	change = ChangeEvent{Raw: "message: transactional: 1 prefix: x"}
+	if decodeTestDecoding(&change); change.Op != ChangeOther {
+		t.Fatal("DecodeTestDecoding: unknown change fail", change)
+	}
+}
@@ -0,0 +1,185 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ChecksumReport is result of checksum verification across nodes.
+type ChecksumReport struct {
+	// Reference node which others are compared with: the first master
+	Reference string
+
+	// Checksums of nodes
+	Checksums map[string]string
+
+	// Divergent nodes whose checksum differs from reference, sorted by name
+	Divergent []string
+
+	// Errors of nodes on which checksum query failed
+	Errors map[string]error
+}
+
+// checksum runs query on node and joins all columns of the first row
+func checksum(ctx context.Context, w *wrapper, query string, args []interface{}) (string, error) {
+	rows, err := w.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return "", err
+		}
+		return "", sql.ErrNoRows
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err = rows.Scan(dest...); err != nil {
+		return "", err
+	}
+
+	parts := make([]string, len(values))
+	for i := range values {
+		parts[i] = string(values[i])
+	}
+	return strings.Join(parts, "|"), nil
+}
+
+// VerifyChecksum runs a user-supplied checksum query (i.e CHECKSUM TABLE, or a hash aggregate over a pk range)
+// on every node concurrently, including failing ones, and reports nodes whose result differs from the first master.
+//
+// All columns of the first row of result are compared.
+func (dbs *DBs) VerifyChecksum(ctx context.Context, query string, args ...interface{}) (*ChecksumReport, error) {
+	if len(dbs.masterNodes()) == 0 || dbs.masterNodes()[0] == nil {
+		return nil, ErrNoConnection
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	nodes := make([]*wrapper, 0, len(dbs.allNodes()))
+	for _, w := range dbs.allNodes() {
+		if w != nil {
+			nodes = append(nodes, w)
+		}
+	}
+
+	sums, errs := make([]string, len(nodes)), make([]error, len(nodes))
+
+	var wg sync.WaitGroup
+	for i := range nodes {
+		wg.Add(1)
+		go func(ind int) {
+			sums[ind], errs[ind] = checksum(ctx, nodes[ind], query, args)
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+
+	reference := dbs.masterNodes()[0]
+	report := &ChecksumReport{
+		Reference: reference.getName(),
+		Checksums: make(map[string]string, len(nodes)),
+		Errors:    make(map[string]error),
+	}
+
+	var expected string
+	for i, w := range nodes {
+		if errs[i] != nil {
+			report.Errors[w.getName()] = errs[i]
+			continue
+		}
+
+		report.Checksums[w.getName()] = sums[i]
+		if w == reference {
+			expected = sums[i]
+		}
+	}
+
+	if err := report.Errors[reference.getName()]; err != nil {
+		return nil, err
+	}
+
+	for name, sum := range report.Checksums {
+		if sum != expected {
+			report.Divergent = append(report.Divergent, name)
+		}
+	}
+	sort.Strings(report.Divergent)
+
+	return report, nil
+}
+
+// tableChecksumQuery builds checksum query of rows of table whose pk is in [from, to).
+func tableChecksumQuery(driverName, table, pk string, columns []string) string {
+	switch driverName {
+	case "mysql":
+		nulls := make([]string, len(columns))
+		for i, c := range columns {
+			nulls[i] = "ISNULL(" + c + ")"
+		}
+		return "SELECT COUNT(*), COALESCE(BIT_XOR(CRC32(CONCAT_WS('#', " + strings.Join(columns, ", ") + ", " + strings.Join(nulls, ", ") + "))), 0) FROM " +
+			table + " WHERE " + pk + " >= ? AND " + pk + " < ?"
+
+	case "postgres", "pgx":
+		return "SELECT COUNT(*), COALESCE(md5(string_agg(t::text, ',' ORDER BY t." + pk + ")), '') FROM " +
+			table + " t WHERE t." + pk + " >= $1 AND t." + pk + " < $2"
+
+	case "sqlite3":
+		quoted := make([]string, len(columns))
+		for i, c := range columns {
+			quoted[i] = "quote(" + c + ")"
+		}
+		return "SELECT COUNT(*), COALESCE(group_concat(r, ';'), '') FROM (SELECT " + strings.Join(quoted, " || ',' || ") + " AS r FROM " +
+			table + " WHERE " + pk + " >= ? AND " + pk + " < ? ORDER BY " + pk + ")"
+	}
+	return ""
+}
+
+// VerifyTableChecksum is VerifyChecksum with a built-in checksum of rows of table whose pk is in [from, to).
+// Columns are taken from the first master. Checking a big table range by range keeps load on nodes bounded.
+func (dbs *DBs) VerifyTableChecksum(ctx context.Context, table, pk string, from, to interface{}) (*ChecksumReport, error) {
+	query := columnsQuery(dbs.driverName)
+	if query == "" {
+		return nil, ErrSchemaCheckNotSupported
+	}
+
+	if len(dbs.masterNodes()) == 0 || dbs.masterNodes()[0] == nil {
+		return nil, ErrNoConnection
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	defs, err := fetchColumns(ctx, dbs.masterNodes()[0], query, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(defs) == 0 {
+		return nil, ErrNoColumns
+	}
+
+	columns := make([]string, 0, len(defs))
+	for name := range defs {
+		columns = append(columns, name)
+	}
+	sort.Strings(columns)
+
+	return dbs.VerifyChecksum(ctx, tableChecksumQuery(dbs.driverName, table, pk, columns), from, to)
+}
@@ -0,0 +1,57 @@
+package mssqlx
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyTableChecksum(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mssqlx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{filepath.Join(dir, "m.db")}, []string{filepath.Join(dir, "s0.db"), filepath.Join(dir, "s1.db")})
+	defer dbs.Destroy()
+
+	for _, w := range dbs.allNodes() {
+		if _, err = w.db.Exec("CREATE TABLE person (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.db.Exec("INSERT INTO person VALUES (1, 'a'), (2, NULL), (3, 'c')"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// slave-1 drifts
+	if _, err = dbs.slaveNodes()[1].db.Exec("UPDATE person SET name = 'b' WHERE id = 2"); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := dbs.VerifyTableChecksum(context.Background(), "person", "id", 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Reference != "master-0" || len(report.Checksums) != 3 || len(report.Divergent) != 1 || report.Divergent[0] != "slave-1" {
+		t.Fatal("VerifyTableChecksum: unexpected report", report)
+	}
+
+	// range without drift
+	if report, err = dbs.VerifyTableChecksum(context.Background(), "person", "id", 3, 10); err != nil || len(report.Divergent) != 0 {
+		t.Fatal("VerifyTableChecksum: range should be consistent", report, err)
+	}
+
+	// user-supplied query
+	report, err = dbs.VerifyChecksum(context.Background(), "SELECT COUNT(*) FROM person WHERE name IS NULL")
+	if err != nil || len(report.Divergent) != 1 || report.Checksums["slave-0"] != "1" {
+		t.Fatal("VerifyChecksum: unexpected report", report, err)
+	}
+
+	if _, err = dbs.VerifyChecksum(context.Background(), "SELECT * FROM not_existed_table"); err == nil {
+		t.Fatal("VerifyChecksum: reference error should be returned")
+	}
+}
@@ -0,0 +1,116 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrInvalidChunkedIn query of SelectChunkedIn must have exactly one slice arg
+	ErrInvalidChunkedIn = errors.New("Query must have exactly one slice arg to be chunked")
+)
+
+var bytesType = reflect.TypeOf([]byte{})
+
+// maxPlaceholders returns maximum number of placeholders per statement driver supports.
+func maxPlaceholders(driverName string) int {
+	switch driverName {
+	case "mysql", "postgres", "pgx":
+		return 65535
+
+	case "sqlserver", "mssql":
+		return 2100
+
+	case "sqlite3", "sqlite":
+		return 999
+	}
+	return 999
+}
+
+// index of the only slice arg which is expanded by sqlx.In
+func chunkedInArg(args []interface{}) (ind int, err error) {
+	ind = -1
+	for i, arg := range args {
+		if _, ok := arg.(driver.Valuer); ok || arg == nil {
+			continue
+		}
+
+		if t := reflect.TypeOf(arg); t.Kind() == reflect.Slice && t != bytesType {
+			if ind >= 0 {
+				return -1, ErrInvalidChunkedIn
+			}
+			ind = i
+		}
+	}
+
+	if ind < 0 {
+		err = ErrInvalidChunkedIn
+	}
+	return
+}
+
+// chunkedInQueries splits slice arg of an `IN (?)` query into chunks fitting placeholder limit of driver.
+func chunkedInQueries(driverName, query string, args []interface{}) ([]QuerySpec, error) {
+	ind, err := chunkedInArg(args)
+	if err != nil {
+		return nil, err
+	}
+
+	slice := reflect.ValueOf(args[ind])
+	n := slice.Len()
+
+	size := maxPlaceholders(driverName) - (len(args) - 1)
+	if size <= 0 {
+		return nil, ErrInvalidChunkedIn
+	}
+
+	bindType := sqlx.BindType(driverName)
+
+	queries := make([]QuerySpec, 0, (n+size-1)/size)
+	for i := 0; i < n; i += size {
+		j := i + size
+		if j > n {
+			j = n
+		}
+
+		chunkArgs := make([]interface{}, len(args))
+		copy(chunkArgs, args)
+		chunkArgs[ind] = slice.Slice(i, j).Interface()
+
+		q, a, err := sqlx.In(query, chunkArgs...)
+		if err != nil {
+			return nil, err
+		}
+
+		queries = append(queries, QuerySpec{Query: sqlx.Rebind(bindType, q), Args: a})
+	}
+
+	return queries, nil
+}
+
+// SelectChunkedIn runs an `IN (?)` select, whose slice arg might exceed placeholder limit of driver,
+// on slaves. Slice arg is split into driver-appropriate chunks which are queried concurrently,
+// bounded by SetSelectParallelLimit. Results are appended into dest preserving order of chunks.
+//
+// Query must use the `?` bindvar and have exactly one slice arg, as sqlx.In.
+// An empty slice arg leaves dest untouched.
+func (dbs *DBs) SelectChunkedIn(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return dbs.selectChunkedIn(ctx, dbs.slaves, dbs.getSelectParallelLimit(), dest, query, args)
+}
+
+// SelectChunkedInOnMaster is SelectChunkedIn on masters. Chunks are queried sequentially.
+func (dbs *DBs) SelectChunkedInOnMaster(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return dbs.selectChunkedIn(ctx, dbs.masters, 1, dest, query, args)
+}
+
+func (dbs *DBs) selectChunkedIn(ctx context.Context, target *balancer, limit int, dest interface{}, query string, args []interface{}) error {
+	queries, err := chunkedInQueries(dbs.driverName, query, args)
+	if err != nil {
+		return err
+	}
+	return _selectParallel(ctx, target, limit, dest, queries)
+}
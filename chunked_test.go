@@ -0,0 +1,62 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestChunkedInQueries(t *testing.T) {
+	if _, err := chunkedInQueries("sqlite3", "SELECT 1", []interface{}{1}); err != ErrInvalidChunkedIn {
+		t.Fatal("ChunkedIn: query without slice arg should be refused")
+	}
+	if _, err := chunkedInQueries("sqlite3", "SELECT 1", []interface{}{[]int{1}, []int{2}}); err != ErrInvalidChunkedIn {
+		t.Fatal("ChunkedIn: query with many slice args should be refused")
+	}
+
+	ids := make([]int, 2000)
+	queries, err := chunkedInQueries("postgres", "SELECT * FROM t WHERE a = ? AND id IN (?) AND b = ?", []interface{}{"a", ids, []byte("b")})
+	if err != nil || len(queries) != 1 || len(queries[0].Args) != 2002 {
+		t.Fatal("ChunkedIn: unexpected queries", len(queries), err)
+	}
+	if q := queries[0].Query; q[:33] != "SELECT * FROM t WHERE a = $1 AND " {
+		t.Fatal("ChunkedIn: query should be rebound", q[:40])
+	}
+
+	if queries, err = chunkedInQueries("sqlite3", "SELECT * FROM t WHERE a = ? AND id IN (?)", []interface{}{"a", ids}); err != nil || len(queries) != 3 {
+		t.Fatal("ChunkedIn: unexpected chunks", len(queries), err)
+	}
+	if len(queries[0].Args) != 999 || len(queries[2].Args) != 1+2000-2*998 {
+		t.Fatal("ChunkedIn: unexpected chunk sizes")
+	}
+
+	if queries, err = chunkedInQueries("sqlite3", "SELECT * FROM t WHERE id IN (?)", []interface{}{[]int{}}); err != nil || len(queries) != 0 {
+		t.Fatal("ChunkedIn: empty slice should have no query", err)
+	}
+}
+
+func TestSelectChunkedIn(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	ids := make([]int, 2500)
+	for i := range ids {
+		ids[i] = len(ids) - i
+	}
+
+	var got []int
+	if err := dbs.SelectChunkedIn(context.Background(), &got, "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM c WHERE x < 3000) SELECT x FROM c WHERE x IN (?) ORDER BY x", ids); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(ids) || got[0] != 1502 || got[len(got)-1] != 502 {
+		t.Fatal("SelectChunkedIn: results should be merged in order of chunks", len(got))
+	}
+
+	got = got[:0]
+	if err := dbs.SelectChunkedInOnMaster(context.Background(), &got, "SELECT ? WHERE 1 IN (?)", 5, []int{1}); err != nil || len(got) != 1 || got[0] != 5 {
+		t.Fatal("SelectChunkedInOnMaster: unexpected result", got, err)
+	}
+
+	if err := dbs.SelectChunkedIn(context.Background(), got, "SELECT 1 WHERE 1 IN (?)", []int{1}); err != ErrInvalidDestination {
+		t.Fatal("SelectChunkedIn: destination check fail", err)
+	}
+}
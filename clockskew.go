@@ -0,0 +1,159 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultClockSkewCheckPeriod default period of measuring clock skew of nodes
+	DefaultClockSkewCheckPeriod = 10 * time.Second
+
+	// DefaultClockSkewThreshold default clock skew above which node is alerted
+	DefaultClockSkewThreshold = time.Second
+)
+
+var (
+	// ErrClockSkewNotSupported measuring clock skew is not supported by driver
+	ErrClockSkewNotSupported = errors.New("Measuring clock skew is only supported by mysql, postgres and sqlite3 drivers")
+)
+
+// clockQuery returns query selecting current time of node in unix seconds
+func clockQuery(driverName string) string {
+	switch driverName {
+	case "postgres", "pgx":
+		return "SELECT EXTRACT(EPOCH FROM clock_timestamp())"
+
+	case "mysql":
+		return "SELECT UNIX_TIMESTAMP(NOW(6))"
+
+	case "sqlite3":
+		return "SELECT (julianday('now') - 2440587.5) * 86400.0"
+	}
+	return ""
+}
+
+// measureClockSkew returns node clock minus client clock, at the midpoint of round trip
+func measureClockSkew(ctx context.Context, w *wrapper) (time.Duration, error) {
+	query := clockQuery(w.db.DriverName())
+	if query == "" {
+		return 0, ErrClockSkewNotSupported
+	}
+
+	var seconds float64
+	sentAt := time.Now()
+	if err := w.db.GetContext(ctx, &seconds, query); err != nil {
+		return 0, err
+	}
+	rtt := time.Since(sentAt)
+
+	nodeTime := time.Unix(0, int64(seconds*float64(time.Second)))
+	return nodeTime.Sub(sentAt.Add(rtt / 2)), nil
+}
+
+func (w *wrapper) getClockSkew() (time.Duration, bool) {
+	return time.Duration(atomic.LoadInt64(&w.clockSkew)), atomic.LoadInt32(&w.clockSkewKnown) == 1
+}
+
+func (w *wrapper) isClockSkewed(threshold time.Duration) bool {
+	skew, ok := w.getClockSkew()
+	if skew < 0 {
+		skew = -skew
+	}
+	return ok && skew > threshold
+}
+
+// SetClockSkewThreshold sets clock skew above which node is alerted: logged as warning and flagged in Status.
+// If d <= 0, DefaultClockSkewThreshold is used.
+func (dbs *DBs) SetClockSkewThreshold(d time.Duration) {
+	if d <= 0 {
+		d = DefaultClockSkewThreshold
+	}
+	atomic.StoreInt64(&dbs.clockSkewThreshold, int64(d))
+}
+
+func (dbs *DBs) getClockSkewThreshold() time.Duration {
+	if d := atomic.LoadInt64(&dbs.clockSkewThreshold); d > 0 {
+		return time.Duration(d)
+	}
+	return DefaultClockSkewThreshold
+}
+
+func (dbs *DBs) refreshClockSkew(ctx context.Context) {
+	threshold := dbs.getClockSkewThreshold()
+
+	for _, w := range dbs.allNodes() {
+		if w == nil || w.db == nil {
+			continue
+		}
+
+		skew, err := measureClockSkew(ctx, w)
+		if err != nil {
+			reportNodeError(w, "measure clock skew", err)
+			continue
+		}
+
+		atomic.StoreInt64(&w.clockSkew, int64(skew))
+		atomic.StoreInt32(&w.clockSkewKnown, 1)
+
+		if w.isClockSkewed(threshold) {
+			if atomic.CompareAndSwapInt32(&w.clockSkewAlert, 0, 1) {
+				logEntry(LogLevelWarn, "node clock is skewed by "+skew.String(), nodeFields(w)...)
+			}
+		} else if atomic.CompareAndSwapInt32(&w.clockSkewAlert, 1, 0) {
+			logEntry(LogLevelInfo, "node clock is in sync", nodeFields(w)...)
+		}
+	}
+}
+
+// MonitorClockSkew compares clock of nodes with client clock every period until ctx is done.
+// Skewed clocks make replication lag math wrong and break ordering by timestamps written by different nodes.
+// Measured skews are reported by ClockSkews and Status. If period <= 0, DefaultClockSkewCheckPeriod is used.
+func (dbs *DBs) MonitorClockSkew(ctx context.Context, period time.Duration) error {
+	if clockQuery(dbs.driverName) == "" {
+		return ErrClockSkewNotSupported
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if period <= 0 {
+		period = DefaultClockSkewCheckPeriod
+	}
+
+	dbs.refreshClockSkew(ctx)
+
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-ticker.C:
+				dbs.refreshClockSkew(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// ClockSkews returns measured clock skew (node clock minus client clock) of nodes by name.
+// Nodes with unknown skew are omitted.
+func (dbs *DBs) ClockSkews() map[string]time.Duration {
+	skews := make(map[string]time.Duration)
+	for _, w := range dbs.allNodes() {
+		if w != nil {
+			if skew, ok := w.getClockSkew(); ok {
+				skews[w.getName()] = skew
+			}
+		}
+	}
+	return skews
+}
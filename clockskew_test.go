@@ -0,0 +1,58 @@
+package mssqlx
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClockSkew(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	if len(dbs.ClockSkews()) != 0 {
+		t.Fatal("ClockSkew: should be unknown before monitoring")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := dbs.MonitorClockSkew(ctx, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	skews := dbs.ClockSkews()
+	if len(skews) != 2 {
+		t.Fatal("ClockSkew: all nodes should be measured", skews)
+	}
+	for node, skew := range skews {
+		if skew < -100*time.Millisecond || skew > 100*time.Millisecond {
+			t.Fatal("ClockSkew: local node should be in sync", node, skew)
+		}
+	}
+
+	// slave clock runs ahead
+	w := dbs.findNode("slave-0")
+	atomic.StoreInt64(&w.clockSkew, int64(2*time.Second))
+
+	alerts := func() (nodes []string) {
+		for _, st := range dbs.Status().Nodes {
+			if st.ClockSkewAlert {
+				nodes = append(nodes, st.Name)
+			}
+		}
+		return
+	}
+	if nodes := alerts(); len(nodes) != 1 || nodes[0] != "slave-0" {
+		t.Fatal("ClockSkew: skewed node should be alerted", nodes)
+	}
+
+	dbs.SetClockSkewThreshold(3 * time.Second)
+	if nodes := alerts(); len(nodes) != 0 {
+		t.Fatal("ClockSkew: threshold should be configurable", nodes)
+	}
+
+	if clockQuery("mssql") != "" {
+		t.Fatal("ClockSkew: unsupported driver check fail")
+	}
+}
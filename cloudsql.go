@@ -0,0 +1,37 @@
+package mssqlx
+
+import (
+	"context"
+	"net"
+)
+
+// CloudSQLDialer connects to Google Cloud SQL instance by its instance connection name (project:region:instance),
+// i.e Dial method of cloud.google.com/go/cloudsqlconn Dialer, which handles authorization and TLS.
+//
+// With it, host of node DSN is instance connection name instead of network address:
+//
+//	mysql:    user:password@cloudsql(project:region:instance)/dbname
+//	postgres: host=project:region:instance user=postgres dbname=postgres sslmode=disable
+//
+// Pass it as an arg of ConnectMasterSlaves. Nodes are balanced and health checked as usual.
+type CloudSQLDialer func(ctx context.Context, instance string) (net.Conn, error)
+
+func (d CloudSQLDialer) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := d(ctx, cloudSQLInstance(addr))
+	if err != nil {
+		// reported as network error, so that node is failed over
+		if _, ok := err.(net.Error); !ok {
+			err = &net.OpError{Op: "dial", Net: "cloudsql", Err: err}
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+// cloudSQLInstance strips port which drivers append to host
+func cloudSQLInstance(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,51 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+)
+
+func TestCloudSQLDialer(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		instances []string
+	)
+	dialer := CloudSQLDialer(func(ctx context.Context, instance string) (net.Conn, error) {
+		mu.Lock()
+		instances = append(instances, instance)
+		mu.Unlock()
+		return nil, errors.New("instance not found")
+	})
+
+	for _, tc := range []struct {
+		driverName string
+		dsn        string
+	}{
+		{"mysql", "user:password@cloudsql(project:region:mysql)/db"},
+		{"postgres", "host=project:region:postgres user=postgres dbname=db sslmode=disable"},
+	} {
+		dbs, errs := ConnectMasterSlaves(tc.driverName, []string{tc.dsn}, nil, dialer)
+		if errs[0] != nil {
+			t.Fatal("CloudSQLDialer: connect fail", tc.driverName, errs[0])
+		}
+
+		err := dbs.masterNodes()[0].db.Ping()
+		if err == nil || !isConnectionError(err) {
+			t.Fatal("CloudSQLDialer: dial error should be connection error", tc.driverName, err)
+		}
+		dbs.Destroy()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(instances) < 2 || instances[0] != "project:region:mysql" || instances[len(instances)-1] != "project:region:postgres" {
+		t.Fatal("CloudSQLDialer: instance connection names fail", instances)
+	}
+
+	if _, errs := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil, dialer); errs[0] != ErrDialerNotSupported {
+		t.Fatal("CloudSQLDialer: unsupported driver should fail", errs[0])
+	}
+}
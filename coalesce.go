@@ -0,0 +1,155 @@
+package mssqlx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// flight is an in-flight read shared by identical concurrent calls
+type flight struct {
+	done   chan struct{}
+	w      *wrapper
+	err    error
+	result reflect.Value // snapshot of rows scanned by leader
+}
+
+// flightGroup coalesces identical concurrent reads
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// SetReadCoalescing enables or disables coalescing of identical concurrent Get/Select of query, i.e
+// a hot lookup behind a cache. Calls with the same query text, args and routing directives of context made
+// while one of them is running wait for it and get a copy of its result, instead of running the query again.
+//
+// Copies are shallow: pointers, slices and maps inside scanned rows are shared by coalesced callers.
+// Query must be exactly the same text. Coalescing is disabled by default.
+func (dbs *DBs) SetReadCoalescing(query string, enabled bool) {
+	if enabled {
+		dbs.coalesced.Store(query, struct{}{})
+	} else {
+		dbs.coalesced.Delete(query)
+	}
+}
+
+func (c *balancer) coalesces(query string) bool {
+	if c.coalesced == nil {
+		return false
+	}
+	_, ok := c.coalesced.Load(query)
+	return ok
+}
+
+// coalesceKey identifies identical reads: same kind, query, args and routing directives
+func coalesceKey(ctx context.Context, kind, query string, args []interface{}) string {
+	var b strings.Builder
+	b.WriteString(kind)
+	b.WriteByte(0)
+	b.WriteString(query)
+
+	for _, arg := range args {
+		_, _ = fmt.Fprintf(&b, "\x00%T=%v", arg, arg)
+	}
+
+	if IsForceMaster(ctx) {
+		b.WriteString("\x00master")
+	}
+	if d, ok := maxStalenessFromContext(ctx); ok {
+		_, _ = fmt.Fprintf(&b, "\x00staleness=%d", d)
+	}
+	if chain, ok := routeChainFromContext(ctx); ok {
+		_, _ = fmt.Fprintf(&b, "\x00chain=%v", chain)
+	}
+	return b.String()
+}
+
+// coalesce runs read by run, unless an identical one is running, whose result is copied to dest then
+func (c *balancer) coalesce(ctx context.Context, kind string, dest interface{}, query string, args []interface{},
+	run func(ctx context.Context, dest interface{}) (*wrapper, error)) (*wrapper, error) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return run(ctx, dest)
+	}
+
+	key := coalesceKey(ctx, kind, query, args)
+
+	c.flights.mu.Lock()
+	if f, ok := c.flights.flights[key]; ok {
+		c.flights.mu.Unlock()
+		return f.wait(ctx, v, func() (*wrapper, error) { return run(ctx, dest) })
+	}
+
+	f := &flight{done: make(chan struct{})}
+	if c.flights.flights == nil {
+		c.flights.flights = make(map[string]*flight)
+	}
+	c.flights.flights[key] = f
+	c.flights.mu.Unlock()
+
+	defer func() {
+		c.flights.mu.Lock()
+		delete(c.flights.flights, key)
+		c.flights.mu.Unlock()
+		close(f.done)
+	}()
+
+	n := destLen(dest)
+	f.w, f.err = run(ctx, dest)
+	if f.err == nil {
+		f.result = snapshot(v.Elem(), n)
+	}
+	return f.w, f.err
+}
+
+// wait waits for leader of flight, copying its result to dest. Read is run by itself if leader gave up
+// or result could not be copied.
+func (f *flight) wait(ctx context.Context, dest reflect.Value, run func() (*wrapper, error)) (*wrapper, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case <-f.done:
+	}
+
+	if f.err != nil {
+		if f.err == context.Canceled || f.err == context.DeadlineExceeded {
+			return run()
+		}
+		return f.w, f.err
+	}
+
+	if !f.result.IsValid() || f.result.Type() != dest.Elem().Type() {
+		return run()
+	}
+
+	if elem := dest.Elem(); elem.Kind() == reflect.Slice {
+		elem.Set(reflect.AppendSlice(elem, f.result))
+	} else {
+		elem.Set(f.result)
+	}
+	return f.w, nil
+}
+
+// snapshot copies scanned value, for slices only elements appended after n
+func snapshot(v reflect.Value, n int) reflect.Value {
+	if v.Kind() == reflect.Slice {
+		if n < 0 || n > v.Len() {
+			n = 0
+		}
+		s := reflect.MakeSlice(v.Type(), v.Len()-n, v.Len()-n)
+		reflect.Copy(s, v.Slice(n, v.Len()))
+		return s
+	}
+
+	s := reflect.New(v.Type()).Elem()
+	s.Set(v)
+	return s
+}
@@ -0,0 +1,102 @@
+package mssqlx
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestReadCoalescing(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+
+	query := "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < ?) SELECT COUNT(*) FROM c"
+	dbs.SetReadCoalescing(query, true)
+
+	var wg sync.WaitGroup
+	counts := make([]int, 20)
+	for i := range counts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := dbs.GetOnMaster(&counts[i], query, 300000); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, n := range counts {
+		if n != 300000 {
+			t.Fatal("ReadCoalescing: unexpected result", counts)
+		}
+	}
+	if queries, _ := dbs.masterNodes()[0].stats.load(); queries >= uint64(len(counts)) {
+		t.Fatal("ReadCoalescing: identical reads should be coalesced", queries)
+	}
+
+	dbs.SetReadCoalescing(query, false)
+	if dbs.masters.coalesces(query) {
+		t.Fatal("ReadCoalescing: should be disabled")
+	}
+}
+
+func TestCoalesceKey(t *testing.T) {
+	ctx := context.Background()
+	key := coalesceKey(ctx, "get", "SELECT ?", []interface{}{1})
+
+	for _, other := range []string{
+		coalesceKey(ctx, "select", "SELECT ?", []interface{}{1}),
+		coalesceKey(ctx, "get", "SELECT ?", []interface{}{"1"}),
+		coalesceKey(ctx, "get", "SELECT ?", []interface{}{2}),
+		coalesceKey(WithForceMaster(ctx), "get", "SELECT ?", []interface{}{1}),
+	} {
+		if other == key {
+			t.Fatal("CoalesceKey: different reads should not be coalesced", other)
+		}
+	}
+
+	if coalesceKey(ctx, "get", "SELECT ?", []interface{}{1}) != key {
+		t.Fatal("CoalesceKey: identical reads should be coalesced")
+	}
+}
+
+func TestFlightWait(t *testing.T) {
+	f := &flight{done: make(chan struct{})}
+	close(f.done)
+
+	// appended rows of leader are appended to follower
+	leader := []int{0, 1, 2}
+	f.result = snapshot(reflect.ValueOf(leader), 1)
+
+	follower := []int{9}
+	if _, err := f.wait(context.Background(), reflect.ValueOf(&follower), nil); err != nil || !reflect.DeepEqual(follower, []int{9, 1, 2}) {
+		t.Fatal("FlightWait: unexpected rows", follower, err)
+	}
+	follower[1] = 5
+	if f.result.Index(0).Int() != 1 {
+		t.Fatal("FlightWait: result should be copied")
+	}
+
+	// other type runs itself
+	var s []string
+	ran := false
+	_, _ = f.wait(context.Background(), reflect.ValueOf(&s), func() (*wrapper, error) {
+		ran = true
+		return nil, nil
+	})
+	if !ran {
+		t.Fatal("FlightWait: mismatched destination should run itself")
+	}
+
+	// leader gave up
+	f.err, ran = context.Canceled, false
+	_, _ = f.wait(context.Background(), reflect.ValueOf(&follower), func() (*wrapper, error) {
+		ran = true
+		return nil, nil
+	})
+	if !ran {
+		t.Fatal("FlightWait: should run itself when leader gave up")
+	}
+}
@@ -0,0 +1,110 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+type statsCollectorKey struct{}
+
+// RequestStats is statistics of queries made with a context, see WithStatsCollector.
+type RequestStats struct {
+	// Queries number of queries executed
+	Queries int
+
+	// QueriesByRole number of queries executed per role of node
+	QueriesByRole map[Role]int
+
+	// QueriesByType number of queries executed per statement type
+	QueriesByType map[StatementType]int
+
+	// Errors number of failed queries
+	Errors int
+
+	// TotalTime total time spent on executing queries, excluding waiting for concurrency limit
+	TotalTime time.Duration
+
+	// SlowestQuery the slowest statement
+	SlowestQuery string
+
+	// SlowestTime execution time of the slowest statement
+	SlowestTime time.Duration
+}
+
+// StatsCollector collects statistics of queries made with a context. It is safe for concurrent use.
+type StatsCollector struct {
+	mu    sync.Mutex
+	stats RequestStats
+}
+
+// WithStatsCollector returns a context which collects statistics of queries made with it
+// (and its derived contexts), i.e for per-endpoint performance budgets:
+//
+//	ctx, collector := mssqlx.WithStatsCollector(r.Context())
+//	... // handle request with ctx
+//	stats := collector.Stats()
+func WithStatsCollector(ctx context.Context) (context.Context, *StatsCollector) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	c := &StatsCollector{}
+	return context.WithValue(ctx, statsCollectorKey{}, c), c
+}
+
+func statsCollectorFromContext(ctx context.Context) *StatsCollector {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(statsCollectorKey{}).(*StatsCollector)
+	return c
+}
+
+func (c *StatsCollector) record(w *wrapper, query string, elapsed time.Duration, err error) {
+	if c == nil || w == nil {
+		return
+	}
+
+	c.mu.Lock()
+	s := &c.stats
+
+	s.Queries++
+	if s.QueriesByRole == nil {
+		s.QueriesByRole = make(map[Role]int, 2)
+	}
+	s.QueriesByRole[w.getRole()]++
+
+	if s.QueriesByType == nil {
+		s.QueriesByType = make(map[StatementType]int, 2)
+	}
+	s.QueriesByType[ClassifyStatement(query)]++
+
+	if err != nil && err != sql.ErrNoRows {
+		s.Errors++
+	}
+
+	s.TotalTime += elapsed
+	if elapsed > s.SlowestTime || s.SlowestQuery == "" {
+		s.SlowestQuery, s.SlowestTime = query, elapsed
+	}
+
+	c.mu.Unlock()
+}
+
+// Stats returns snapshot of collected statistics.
+func (c *StatsCollector) Stats() (s RequestStats) {
+	c.mu.Lock()
+	s = c.stats
+	s.QueriesByRole = make(map[Role]int, len(c.stats.QueriesByRole))
+	for role, n := range c.stats.QueriesByRole {
+		s.QueriesByRole[role] = n
+	}
+	s.QueriesByType = make(map[StatementType]int, len(c.stats.QueriesByType))
+	for kind, n := range c.stats.QueriesByType {
+		s.QueriesByType[kind] = n
+	}
+	c.mu.Unlock()
+	return
+}
@@ -0,0 +1,37 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStatsCollector(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	ctx, collector := WithStatsCollector(context.Background())
+	if s := collector.Stats(); s.Queries != 0 || len(s.QueriesByRole) != 0 {
+		t.Fatal("StatsCollector: should be empty", s)
+	}
+
+	var v int
+	if err := dbs.GetContext(ctx, &v, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.QueryRowContext(ctx, "SELECT 2"); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = dbs.ExecContext(ctx, "SELECT * FROM not_existed_table")
+	_, _ = dbs.Exec("SELECT 3") // not collected
+
+	s := collector.Stats()
+	if s.Queries != 3 || s.QueriesByRole[RoleSlave] != 2 || s.QueriesByRole[RoleMaster] != 1 || s.Errors != 1 {
+		t.Fatal("StatsCollector: unexpected stats", s)
+	}
+	if s.QueriesByType[StatementSelect] != 3 || len(s.QueriesByType) != 1 {
+		t.Fatal("StatsCollector: unexpected statement types", s.QueriesByType)
+	}
+	if s.TotalTime <= 0 || s.SlowestQuery == "" || s.SlowestTime > s.TotalTime {
+		t.Fatal("StatsCollector: unexpected timing", s)
+	}
+}
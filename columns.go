@@ -0,0 +1,84 @@
+package mssqlx
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+var (
+	columnsMapper     *reflectx.Mapper
+	columnsMapperOnce sync.Once
+	columnLists       sync.Map // reflect.Type => *columnList
+)
+
+// columnList is cached column lists of a struct type
+type columnList struct {
+	all          string
+	insert       string
+	placeholders string
+}
+
+func getColumnList(arg interface{}) *columnList {
+	t := reflect.TypeOf(arg)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return &columnList{}
+	}
+
+	if l, ok := columnLists.Load(t); ok {
+		return l.(*columnList)
+	}
+
+	columnsMapperOnce.Do(func() {
+		columnsMapper = reflectx.NewMapperFunc("db", sqlx.NameMapper)
+	})
+
+	columns := typeColumns(columnsMapper, t)
+
+	all, insert := make([]string, 0, len(columns)), make([]string, 0, len(columns))
+	for _, c := range columns {
+		all = append(all, c.name)
+		if !hasTagOption(c.field, readonlyTagOption) && !hasTagOption(c.field, defaultTagOption) {
+			insert = append(insert, c.name)
+		}
+	}
+
+	l := &columnList{all: strings.Join(all, ", "), insert: strings.Join(insert, ", ")}
+	if len(insert) > 0 {
+		l.placeholders = strings.Repeat("?, ", len(insert)-1) + "?"
+	}
+
+	v, _ := columnLists.LoadOrStore(t, l)
+	return v.(*columnList)
+}
+
+// Columns returns comma-separated columns of struct arg (or pointer to struct, which might be nil) derived from db tags,
+// i.e for SELECT statements staying in sync with struct definition:
+//
+//	"SELECT " + mssqlx.Columns(Person{}) + " FROM person"
+//
+// Column lists are cached per type. Empty string is returned if arg is not a struct.
+func Columns(arg interface{}) string {
+	return getColumnList(arg).all
+}
+
+// InsertColumns returns comma-separated columns of struct arg written on insert: readonly and default ones are skipped,
+// as by InsertStruct. Use with Placeholders:
+//
+//	db.Rebind("INSERT INTO person (" + mssqlx.InsertColumns(Person{}) + ") VALUES (" + mssqlx.Placeholders(Person{}) + ")")
+func InsertColumns(arg interface{}) string {
+	return getColumnList(arg).insert
+}
+
+// Placeholders returns comma-separated `?` bindvars, one per column of InsertColumns.
+// Bindvars are not of driver's type: the query must be rebound by DBs.Rebind
+// unless DBs is connected with RebindAlways.
+func Placeholders(arg interface{}) string {
+	return getColumnList(arg).placeholders
+}
@@ -0,0 +1,48 @@
+package mssqlx
+
+import (
+	"testing"
+	"time"
+)
+
+type columnsBase struct {
+	CreatedAt time.Time `db:"created_at,readonly"`
+}
+
+type columnsPerson struct {
+	columnsBase
+	ID        int64  `db:"id,default"`
+	FirstName string `db:"first_name"`
+	Email     string `db:"email,omitempty"`
+	Ignored   string `db:"-"`
+}
+
+func TestColumns(t *testing.T) {
+	if c := Columns(columnsPerson{}); c != "id, first_name, email, created_at" {
+		t.Fatal("Columns: all columns fail", c)
+	}
+	if c := InsertColumns((*columnsPerson)(nil)); c != "first_name, email" {
+		t.Fatal("Columns: insert columns fail", c)
+	}
+	if p := Placeholders(&columnsPerson{}); p != "?, ?" {
+		t.Fatal("Columns: placeholders fail", p)
+	}
+	if Columns(1) != "" || Placeholders(nil) != "" {
+		t.Fatal("Columns: non-struct should have no column")
+	}
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{"file:columns?mode=memory&cache=shared"}, nil)
+	defer dbs.Destroy()
+
+	if _, err := dbs.Exec("CREATE TABLE person (id INTEGER PRIMARY KEY, first_name TEXT, email TEXT, created_at DATETIME DEFAULT CURRENT_TIMESTAMP)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.Exec(dbs.Rebind("INSERT INTO person ("+InsertColumns(columnsPerson{})+") VALUES ("+Placeholders(columnsPerson{})+")"), "a", "a@b"); err != nil {
+		t.Fatal(err)
+	}
+
+	var p columnsPerson
+	if err := dbs.GetOnMaster(&p, "SELECT "+Columns(p)+" FROM person"); err != nil || p.ID != 1 || p.FirstName != "a" || p.CreatedAt.IsZero() {
+		t.Fatal("Columns: select fail", p, err)
+	}
+}
@@ -0,0 +1,167 @@
+package mssqlx
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Cond is a composable condition of a select, building WHERE, ORDER BY and LIMIT clauses with args,
+// so that dynamic filters do not need a third-party builder:
+//
+//	cond := mssqlx.Where("age >= ?", 18)
+//	if name != "" {
+//		cond.And("name = ?", name)
+//	}
+//	cond.In("status", statuses).OrderBy("id DESC").Limit(20)
+//	err := db.SelectWhere(ctx, &people, "SELECT * FROM person", cond)
+//
+// Expressions use the `?` bindvar, which is rebound to bindvar type of driver. Zero value is an empty condition.
+// Cond is not safe for concurrent use.
+type Cond struct {
+	where   []byte
+	args    []interface{}
+	orderBy []string
+	limit   int
+}
+
+// Where returns condition of expr with args. Empty expr is ignored.
+func Where(expr string, args ...interface{}) *Cond {
+	c := &Cond{}
+	return c.And(expr, args...)
+}
+
+// And appends expr with args, joined by AND. Empty expr is ignored.
+func (c *Cond) And(expr string, args ...interface{}) *Cond {
+	if expr == "" {
+		return c
+	}
+
+	if len(c.where) > 0 {
+		c.where = append(c.where, " AND "...)
+	}
+	c.where = append(c.where, '(')
+	c.where = append(c.where, expr...)
+	c.where = append(c.where, ')')
+	c.args = append(c.args, args...)
+	return c
+}
+
+// In appends `column IN (...)` of values, which must be a slice, joined by AND.
+// An empty slice matches no row.
+func (c *Cond) In(column string, values interface{}) *Cond {
+	if len(c.where) > 0 {
+		c.where = append(c.where, " AND "...)
+	}
+
+	n := 0
+	if list, ok := values.([]interface{}); ok {
+		n = len(list)
+		c.args = append(c.args, list...)
+	} else if v := reflect.ValueOf(values); v.Kind() == reflect.Slice && v.Type() != bytesType {
+		n = v.Len()
+		for i := 0; i < n; i++ {
+			c.args = append(c.args, v.Index(i).Interface())
+		}
+	} else { // single value
+		n = 1
+		c.args = append(c.args, values)
+	}
+
+	if n == 0 {
+		c.where = append(c.where, "1 = 0"...)
+		return c
+	}
+
+	c.where = append(c.where, column...)
+	c.where = append(c.where, " IN (?"...)
+	for i := 1; i < n; i++ {
+		c.where = append(c.where, ", ?"...)
+	}
+	c.where = append(c.where, ')')
+	return c
+}
+
+// OrderBy appends ordering, i.e OrderBy("created_at DESC", "id").
+func (c *Cond) OrderBy(orders ...string) *Cond {
+	c.orderBy = append(c.orderBy, orders...)
+	return c
+}
+
+// Limit sets max number of rows. Zero or negative means no limit.
+func (c *Cond) Limit(n int) *Cond {
+	c.limit = n
+	return c
+}
+
+// Build appends clauses of condition to query, returns it rebound to bindType (as sqlx.BindType) with args.
+func (c *Cond) Build(query string, bindType int) (string, []interface{}) {
+	if c == nil {
+		return query, nil
+	}
+
+	size := len(query) + len(c.where) + 32 // keywords and limit
+	for _, o := range c.orderBy {
+		size += len(o) + 2
+	}
+
+	var b strings.Builder
+	b.Grow(size)
+
+	b.WriteString(query)
+	if len(c.where) > 0 {
+		b.WriteString(" WHERE ")
+		b.Write(c.where)
+	}
+
+	if len(c.orderBy) > 0 {
+		b.WriteString(" ORDER BY ")
+		for i, o := range c.orderBy {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(o)
+		}
+	}
+
+	if c.limit > 0 {
+		b.WriteString(" LIMIT ")
+		b.WriteString(strconv.Itoa(c.limit))
+	}
+
+	if bindType == sqlx.QUESTION || bindType == sqlx.UNKNOWN {
+		return b.String(), c.args
+	}
+	return sqlx.Rebind(bindType, b.String()), c.args
+}
+
+// SelectWhere does select of query with cond appended on slaves.
+func (dbs *DBs) SelectWhere(ctx context.Context, dest interface{}, query string, cond *Cond) (err error) {
+	q, args := cond.Build(query, sqlx.BindType(dbs.driverName))
+	_, err = _select(ctx, dbs.slaves, dest, q, args...)
+	return
+}
+
+// SelectWhereOnMaster does select of query with cond appended on masters only.
+func (dbs *DBs) SelectWhereOnMaster(ctx context.Context, dest interface{}, query string, cond *Cond) (err error) {
+	q, args := cond.Build(query, sqlx.BindType(dbs.driverName))
+	_, err = _select(ctx, dbs.masters, dest, q, args...)
+	return
+}
+
+// GetWhere does get of query with cond appended on slaves.
+func (dbs *DBs) GetWhere(ctx context.Context, dest interface{}, query string, cond *Cond) (err error) {
+	q, args := cond.Build(query, sqlx.BindType(dbs.driverName))
+	_, err = _get(ctx, dbs.slaves, dest, q, args...)
+	return
+}
+
+// GetWhereOnMaster does get of query with cond appended on masters only.
+func (dbs *DBs) GetWhereOnMaster(ctx context.Context, dest interface{}, query string, cond *Cond) (err error) {
+	q, args := cond.Build(query, sqlx.BindType(dbs.driverName))
+	_, err = _get(ctx, dbs.masters, dest, q, args...)
+	return
+}
@@ -0,0 +1,51 @@
+package mssqlx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestCond(t *testing.T) {
+	cond := Where("age >= ?", 18).And("").And("name = ? OR nick = ?", "a", "b").In("id", []int{1, 2, 3}).OrderBy("id DESC", "name").Limit(10)
+
+	q, args := cond.Build("SELECT * FROM person", sqlx.DOLLAR)
+	if q != "SELECT * FROM person WHERE (age >= $1) AND (name = $2 OR nick = $3) AND id IN ($4, $5, $6) ORDER BY id DESC, name LIMIT 10" {
+		t.Fatal("Cond: build fail", q)
+	}
+	if !reflect.DeepEqual(args, []interface{}{18, "a", "b", 1, 2, 3}) {
+		t.Fatal("Cond: args fail", args)
+	}
+
+	if q, args = Where("").In("id", []interface{}{}).Build("SELECT 1", sqlx.QUESTION); q != "SELECT 1 WHERE 1 = 0" || len(args) != 0 {
+		t.Fatal("Cond: empty IN fail", q, args)
+	}
+	if q, _ = (&Cond{}).Build("SELECT 1", sqlx.QUESTION); q != "SELECT 1" {
+		t.Fatal("Cond: empty cond fail", q)
+	}
+	if q, _ = (*Cond)(nil).Build("SELECT 1", sqlx.QUESTION); q != "SELECT 1" {
+		t.Fatal("Cond: nil cond fail", q)
+	}
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{"file:cond?mode=memory&cache=shared"}, nil)
+	defer dbs.Destroy()
+
+	if _, err := dbs.Exec("CREATE TABLE cond_test (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.Exec("INSERT INTO cond_test (id, name) VALUES (1, 'a'), (2, 'b'), (3, 'a')"); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int
+	if err := dbs.SelectWhereOnMaster(context.Background(), &ids, "SELECT id FROM cond_test", Where("name = ?", "a").OrderBy("id DESC")); err != nil || !reflect.DeepEqual(ids, []int{3, 1}) {
+		t.Fatal("Cond: select fail", ids, err)
+	}
+
+	var name string
+	if err := dbs.GetWhereOnMaster(context.Background(), &name, "SELECT name FROM cond_test", Where("").In("id", []int64{2}).Limit(1)); err != nil || name != "b" {
+		t.Fatal("Cond: get fail", name, err)
+	}
+}
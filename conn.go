@@ -0,0 +1,135 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrInvalidRole role is neither master nor slave
+	ErrInvalidRole = errors.New("Invalid role, must be master or slave")
+)
+
+// Conn is a dedicated connection checked out from a healthy node, useful for workflows
+// needing session state (temp tables, SET variables, etc).
+//
+// When a call on Conn fails because of networking/wsrep error, its node is marked as failed
+// for auto health tracking. The caller must call Close to return the connection to pool.
+type Conn struct {
+	*sql.Conn
+	w      *wrapper
+	target *balancer
+	closed int32
+}
+
+func (dbs *DBs) getBalancer(role Role) (*balancer, error) {
+	switch role {
+	case RoleMaster:
+		return dbs.masters, nil
+
+	case RoleSlave:
+		return dbs.slaves, nil
+	}
+	return nil, ErrInvalidRole
+}
+
+// Conn checks out a dedicated connection from a healthy node of role.
+func (dbs *DBs) Conn(ctx context.Context, role Role) (*Conn, error) {
+	target, err := dbs.getBalancer(role)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	w, conn, err := _conn(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Conn{Conn: conn, w: w, target: target}
+	target.leaks.track("conn", w, "", func() bool { return atomic.LoadInt32(&c.closed) == 1 })
+
+	return c, nil
+}
+
+// Close returns the connection to the connection pool.
+func (c *Conn) Close() error {
+	if atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
+		defer c.w.limiter.release()
+	}
+	return c.Conn.Close()
+}
+
+// discard closes the underlying connection instead of returning it to pool, i.e when its session state could not be reset.
+func (c *Conn) discard() {
+	_ = c.Conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+}
+
+func (c *Conn) check(err error) error {
+	if c.target.shouldFailure(c.w, err) {
+		c.target.countFailure(c.w, err)
+	}
+	return err
+}
+
+// Node returns name of node which connection belongs to.
+func (c *Conn) Node() string {
+	return c.w.getName()
+}
+
+// Rebind transforms a query from QUESTION to the DB driver's bindvar type.
+func (c *Conn) Rebind(query string) string {
+	return c.w.db.Rebind(query)
+}
+
+// PingContext verifies the connection to the database is still alive.
+func (c *Conn) PingContext(ctx context.Context) error {
+	return c.check(c.Conn.PingContext(ctx))
+}
+
+// ExecContext executes a query without returning any rows.
+func (c *Conn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	r, err := c.Conn.ExecContext(ctx, c.w.rebind(query), args...)
+	return r, c.check(err)
+}
+
+// QueryContext executes a query that returns rows, typically a SELECT.
+func (c *Conn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	r, err := c.Conn.QueryContext(ctx, c.w.rebind(query), args...)
+	return r, c.check(err)
+}
+
+// QueryxContext executes a query that returns rows, typically a SELECT.
+// But return sqlx.Rows instead of sql.Rows.
+func (c *Conn) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	r, err := c.Conn.QueryContext(ctx, c.w.rebind(query), args...)
+	if err != nil {
+		return nil, c.check(err)
+	}
+	return &sqlx.Rows{Rows: r, Mapper: guardMapper(c.w.db.Mapper)}, nil
+}
+
+// QueryRowContext executes a query that is expected to return at most one row.
+func (c *Conn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.Conn.QueryRowContext(ctx, c.w.rebind(query), args...)
+}
+
+// PrepareContext creates a prepared statement on the connection.
+func (c *Conn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	r, err := c.Conn.PrepareContext(ctx, c.w.rebind(query))
+	return r, c.check(err)
+}
+
+// BeginTx starts a transaction on the connection.
+func (c *Conn) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	r, err := c.Conn.BeginTx(ctx, opts)
+	return r, c.check(err)
+}
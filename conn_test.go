@@ -0,0 +1,53 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConn(t *testing.T) {
+	dbs := &DBs{}
+	if _, err := dbs.Conn(context.Background(), Role("unknown")); err != ErrInvalidRole {
+		t.Fatal("Conn: invalid role check fail")
+	}
+
+	_RunWithSchema(defaultSchema, t, func(db *DBs, t *testing.T) {
+		_loadDefaultFixture(db, t)
+
+		conn, err := db.Conn(context.Background(), RoleSlave)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		if conn.Node() == "" {
+			t.Fatal("Conn: node name should not be empty")
+		}
+		if err = conn.PingContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		rows, err := conn.QueryxContext(context.Background(), conn.Rebind("SELECT * FROM person WHERE first_name = ?"), "Jason")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var people []Person
+		for rows.Next() {
+			var p Person
+			if err = rows.StructScan(&p); err != nil {
+				t.Fatal(err)
+			}
+			people = append(people, p)
+		}
+		rows.Close()
+
+		if len(people) != 1 || people[0].FirstName != "Jason" {
+			t.Fatal("Conn: unexpected result", people)
+		}
+
+		if _, err = conn.ExecContext(context.Background(), "DELETE FROM not_existed_table"); err == nil {
+			t.Fatal("Conn: error should be returned")
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package mssqlx
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+type forceMasterKey struct{}
+
+type forceMaster struct {
+	forced int32
+	parent *forceMaster // read-your-writes tracker of parent context, marked by writes too
+}
+
+// WithForceMaster returns a context whose queries, even ones made with slave-balanced
+// functions (i.e Select, Get, Query), are routed to masters.
+func WithForceMaster(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, forceMasterKey{}, &forceMaster{forced: 1})
+}
+
+// WithReadYourWrites returns a context which tracks writes (Exec, NamedExec, BeginTx, BeginTxx)
+// made through DBs. After the first write, ForceMaster directive is set for the remainder of
+// ctx and its derived contexts, so that following reads see the write without replication lag.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, forceMasterKey{}, &forceMaster{})
+}
+
+// IsForceMaster reports whether queries made with ctx are routed to masters.
+func IsForceMaster(ctx context.Context) bool {
+	f := forceMasterFromContext(ctx)
+	return f != nil && atomic.LoadInt32(&f.forced) == 1
+}
+
+func forceMasterFromContext(ctx context.Context) *forceMaster {
+	if ctx != nil {
+		if f, ok := ctx.Value(forceMasterKey{}).(*forceMaster); ok {
+			return f
+		}
+	}
+	return nil
+}
+
+// markWrite sets ForceMaster directive on ctx tracking writes
+func markWrite(ctx context.Context) {
+	for f := forceMasterFromContext(ctx); f != nil; f = f.parent {
+		atomic.StoreInt32(&f.forced, 1)
+	}
+}
+
+// withoutForceMaster clears ForceMaster directive of ctx, keeping its read-your-writes tracker if any
+func withoutForceMaster(ctx context.Context) context.Context {
+	f := forceMasterFromContext(ctx)
+	if f == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, forceMasterKey{}, &forceMaster{parent: f})
+}
+
+// route query made with ctx to masters if ForceMaster directive is set,
+// or no slave satisfies max staleness of ctx. Otherwise routing chain of query applies.
+func (c *balancer) route(ctx context.Context, query string) (context.Context, *balancer) {
+	ctx = withRoutingTrace(ctx, query)
+
+	if c.master == nil {
+		return ctx, c
+	}
+
+	if IsForceMaster(ctx) {
+		if isRoutingDebug() {
+			debugMasters(ctx, RoutingForceMaster)
+		}
+		return ctx, c.master
+	}
+
+	if d, ok := maxStalenessFromContext(ctx); ok {
+		if c.dbs.fresh(d) == nil {
+			if isRoutingDebug() {
+				c.dbs.debugSkippedLagging(ctx, int64(d))
+				debugMasters(ctx, RoutingLagging)
+			}
+			return ctx, c.fallback()
+		}
+		return ctx, c
+	}
+
+	return c.routeChain(ctx, query)
+}
+
+// ConsistencyLevel is read consistency a session expects.
+type ConsistencyLevel int
+
+const (
+	// ConsistencyEventual reads are served by any slave
+	ConsistencyEventual ConsistencyLevel = iota
+
+	// ConsistencyBounded reads are served by slaves lagging at most MaxLag, or by masters
+	ConsistencyBounded
+
+	// ConsistencyStrong reads are served by masters
+	ConsistencyStrong
+)
+
+// Consistency is read consistency of a session, see WithConsistency.
+type Consistency struct {
+	Level ConsistencyLevel
+
+	// MaxLag max replication lag of slaves serving reads, for ConsistencyBounded
+	MaxLag time.Duration
+}
+
+// Strong reads see every committed write: they are served by masters.
+func Strong() Consistency {
+	return Consistency{Level: ConsistencyStrong}
+}
+
+// Bounded reads are at most lag behind masters: they are served by slaves whose replication lag,
+// measured by MonitorLag, does not exceed lag, or by masters if there is no such slave.
+func Bounded(lag time.Duration) Consistency {
+	if lag < 0 {
+		lag = 0
+	}
+	return Consistency{Level: ConsistencyBounded, MaxLag: lag}
+}
+
+// Eventual reads are served by any slave.
+func Eventual() Consistency {
+	return Consistency{Level: ConsistencyEventual}
+}
+
+func (c Consistency) String() string {
+	switch c.Level {
+	case ConsistencyStrong:
+		return "strong"
+
+	case ConsistencyBounded:
+		return "bounded(" + c.MaxLag.String() + ")"
+	}
+	return "eventual"
+}
+
+// WithConsistency returns a context whose reads, made with slave-balanced functions (i.e Select, Get, Query),
+// follow consistency c, so that application code expresses intent instead of hard-coding node choices:
+//
+//	ctx = mssqlx.WithConsistency(ctx, mssqlx.Bounded(2*time.Second))
+//
+// Consistency overrides ForceMaster directive and max staleness of parent context, the innermost one applies.
+// Writes made with returned context are still tracked by WithReadYourWrites of parent context.
+func WithConsistency(ctx context.Context, c Consistency) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	switch c.Level {
+	case ConsistencyStrong:
+		return WithForceMaster(ctx)
+
+	case ConsistencyBounded:
+		return WithMaxStaleness(withoutForceMaster(ctx), c.MaxLag)
+	}
+
+	return context.WithValue(withoutForceMaster(ctx), maxStalenessKey{}, nil)
+}
+
+// ConsistencyFromContext returns read consistency of ctx, derived from ForceMaster directive and max staleness
+// of ctx as set by WithConsistency, WithForceMaster, WithReadYourWrites or WithMaxStaleness.
+func ConsistencyFromContext(ctx context.Context) Consistency {
+	if IsForceMaster(ctx) {
+		return Strong()
+	}
+	if d, ok := maxStalenessFromContext(ctx); ok {
+		return Bounded(d)
+	}
+	return Eventual()
+}
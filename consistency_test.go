@@ -0,0 +1,96 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestForceMaster(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	if IsForceMaster(context.Background()) || !IsForceMaster(WithForceMaster(context.Background())) {
+		t.Fatal("ForceMaster: directive check fail")
+	}
+
+	get := func(ctx context.Context) Role {
+		var info QueryInfo
+		var v int
+		if err := dbs.GetContext(WithQueryInfo(ctx, &info), &v, "SELECT 1"); err != nil {
+			t.Fatal(err)
+		}
+		return info.Role
+	}
+
+	if get(context.Background()) != RoleSlave || get(WithForceMaster(context.Background())) != RoleMaster {
+		t.Fatal("ForceMaster: reads should go to masters")
+	}
+
+	ctx := WithReadYourWrites(context.Background())
+	if IsForceMaster(ctx) || get(ctx) != RoleSlave {
+		t.Fatal("ReadYourWrites: reads before writes should go to slaves")
+	}
+
+	if _, err := dbs.ExecContext(ctx, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsForceMaster(ctx) || get(ctx) != RoleMaster {
+		t.Fatal("ReadYourWrites: reads after writes should go to masters")
+	}
+}
+
+func TestConsistency(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	get := func(ctx context.Context) Role {
+		var info QueryInfo
+		var v int
+		if err := dbs.GetContext(WithQueryInfo(ctx, &info), &v, "SELECT 1"); err != nil {
+			t.Fatal(err)
+		}
+		return info.Role
+	}
+
+	ctx := WithConsistency(context.Background(), Strong())
+	if get(ctx) != RoleMaster || ConsistencyFromContext(ctx).String() != "strong" {
+		t.Fatal("Consistency: strong reads should go to masters")
+	}
+
+	// innermost consistency applies
+	ctx = WithConsistency(ctx, Eventual())
+	if get(ctx) != RoleSlave || ConsistencyFromContext(ctx) != Eventual() {
+		t.Fatal("Consistency: eventual reads should go to slaves")
+	}
+
+	ctx = WithConsistency(WithForceMaster(context.Background()), Bounded(time.Second))
+	if c := ConsistencyFromContext(ctx); c.Level != ConsistencyBounded || c.MaxLag != time.Second || c.String() != "bounded(1s)" {
+		t.Fatal("Consistency: bounded fail", c)
+	}
+	if get(ctx) != RoleMaster {
+		t.Fatal("Consistency: bounded reads should go to masters when lag is unknown")
+	}
+
+	dbs.slaveNodes()[0].setLag(100*time.Millisecond, true)
+	if get(ctx) != RoleSlave {
+		t.Fatal("Consistency: bounded reads should go to fresh slaves")
+	}
+	if get(WithConsistency(ctx, Bounded(10*time.Millisecond))) != RoleMaster {
+		t.Fatal("Consistency: bounded reads should not go to lagging slaves")
+	}
+
+	// read-your-writes tracker of parent context is kept
+	rw := WithReadYourWrites(context.Background())
+	ctx = WithConsistency(rw, Eventual())
+	if _, err := dbs.ExecContext(ctx, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if !IsForceMaster(rw) || !IsForceMaster(ctx) {
+		t.Fatal("Consistency: writes should still be tracked by parent context")
+	}
+	if get(WithConsistency(rw, Eventual())) != RoleSlave {
+		t.Fatal("Consistency: eventual reads should go to slaves after writes")
+	}
+}
@@ -0,0 +1,209 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxDrainBackoff caps interval of checking connections held by transactions of a draining node
+const maxDrainBackoff = 100 * time.Millisecond
+
+var (
+	// ErrDrainTimeout old node still had work in progress when drain timeout elapsed
+	ErrDrainTimeout = errors.New("Drain timeout elapsed")
+
+	// ErrNoMasterToSwitch switching cluster would leave no master
+	ErrNoMasterToSwitch = errors.New("No master to switch to")
+)
+
+// SwitchCluster atomically swaps current nodes for nodes of given DSNs, i.e for zero-downtime migration to a new cluster.
+//
+// New nodes are connected with args of ConnectMasterSlaves and pinged first. If no master is given, ErrNoMasterToSwitch
+// is returned. If none of new masters is healthy, they are closed and current nodes are kept. Otherwise, queries go to new nodes right away while old nodes are drained:
+// each is closed once its in-flight queries, open rows and transactions are finished, or drainTimeout elapses.
+// SwitchCluster returns when draining is done.
+//
+// Progress is recorded as node events: old nodes go draining then removed, healthy new nodes go up.
+// With PreferredPrimary, master of new cluster at the same index becomes preferred primary.
+// Pool settings other than max open connections should be applied again after switching.
+func (dbs *DBs) SwitchCluster(newMasters, newSlaves []string, drainTimeout time.Duration) error {
+	nMaster := len(newMasters)
+	if nMaster == 0 {
+		return ErrNoMasterToSwitch
+	}
+
+	dsns := append(append(make([]string, 0, nMaster+len(newSlaves)), newMasters...), newSlaves...)
+
+	nodes := make([]*wrapper, len(dsns))
+	openErrs, pingErrs := make([]error, len(dsns)), make([]error, len(dsns))
+
+	var wg sync.WaitGroup
+	for i := range dsns {
+		role, ind := RoleMaster, i
+		if i >= nMaster {
+			role, ind = RoleSlave, i-nMaster
+		}
+
+		wg.Add(1)
+		go func(i int, role Role, ind int) {
+			defer wg.Done()
+			if nodes[i], openErrs[i] = dbs.connectNode(dsns[i], role, ind); openErrs[i] == nil {
+				pingErrs[i] = ping(nodes[i])
+			}
+		}(i, role, ind)
+	}
+	wg.Wait()
+
+	if err := switchableNodes(nMaster, openErrs, pingErrs); err != nil {
+		_close(nodes)
+		return err
+	}
+
+	old := dbs.swapNodes(nodes, nMaster, pingErrs)
+	dbs.drain(old, drainTimeout)
+
+	return nil
+}
+
+// switchableNodes returns error if any node could not be opened, or none of masters is healthy
+func switchableNodes(nMaster int, openErrs, pingErrs []error) error {
+	for _, err := range openErrs {
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < nMaster; i++ {
+		if pingErrs[i] == nil {
+			return nil
+		}
+	}
+
+	if nMaster > 0 {
+		return pingErrs[0]
+	}
+	return ErrNoMasterToSwitch
+}
+
+// swapNodes re-points balancers to new nodes and returns old ones
+func (dbs *DBs) swapNodes(nodes []*wrapper, nMaster int, pingErrs []error) (old []*wrapper) {
+	dbs.topologyLock.Lock()
+	defer dbs.topologyLock.Unlock()
+
+	old = dbs.allNodes()
+
+	var prev *wrapper
+	for _, w := range old {
+		if w != nil && w.db != nil {
+			prev = w
+			break
+		}
+	}
+
+	healthy := make(map[*wrapper]bool, len(nodes))
+	for i, w := range nodes {
+		w.inherit(prev)
+		healthy[w] = pingErrs[i] == nil
+	}
+
+	for _, w := range old {
+		if w == nil {
+			continue
+		}
+
+		from := NodeStateDown
+		if dbs.masters.dbs.contains(w) || dbs.slaves.dbs.contains(w) {
+			from = NodeStateUp
+		}
+		dbs.events.record(w, from, NodeStateDraining, nil)
+		dbs.masters.demotePreferred(w)
+	}
+
+	masters := append([]*wrapper(nil), nodes[:nMaster]...)
+	slaves := append([]*wrapper(nil), nodes[nMaster:]...)
+	dbs.setNodes(masters, slaves, nodes)
+
+	dbs.masters.repoint(masters, healthy)
+	dbs.slaves.repoint(slaves, healthy)
+	dbs.all.repoint(nodes, healthy)
+	dbs.resetCapabilities()
+
+	// preferred primary of old cluster is no longer watched, new one is designated by the same index
+	if p := dbs.opts.preferredPrimary; p != nil && p.Index >= 0 && p.Index < nMaster {
+		dbs.masters.setPreferred(masters[p.Index], p)
+	} else {
+		dbs.masters.clearPreferred()
+	}
+
+	for _, w := range nodes {
+		if healthy[w] {
+			dbs.events.record(w, NodeStateDown, NodeStateUp, nil)
+		}
+	}
+
+	logEntry(LogLevelInfo, "cluster is switched")
+	return
+}
+
+// drain waits for work in progress on old nodes until timeout, then closes them
+func (dbs *DBs) drain(old []*wrapper, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, w := range old {
+		if w == nil {
+			continue
+		}
+
+		var cause error
+		if !w.waitDrained(ctx.Done()) {
+			cause = ErrDrainTimeout
+		}
+		dbs.events.record(w, NodeStateDraining, NodeStateRemoved, cause)
+	}
+
+	_close(old)
+}
+
+// waitDrained waits until node has no in-flight query, open rows or transaction, returns false if done is closed first.
+// In-flight queries notify once finished. Connections held by rows, transactions and Conns are not notified
+// by database/sql, so they are checked with backoff.
+func (w *wrapper) waitDrained(done <-chan struct{}) bool {
+	if w.db == nil {
+		return true
+	}
+
+	select {
+	case <-w.limiter.idle():
+	case <-done:
+		return false
+	}
+
+	for backoff := time.Millisecond; w.db.Stats().InUse > 0; {
+		select {
+		case <-time.After(backoff):
+		case <-done:
+			return w.db.Stats().InUse == 0
+		}
+
+		if backoff < maxDrainBackoff {
+			backoff <<= 1
+		}
+	}
+	return true
+}
+
+// inherit copies per-node settings of prev, which is replaced by w
+func (w *wrapper) inherit(prev *wrapper) {
+	if prev == nil || w.db == nil {
+		return
+	}
+
+	atomic.StoreInt32(&w.strictScan, atomic.LoadInt32(&prev.strictScan))
+	atomic.StoreInt32(&w.propagateDeadline, atomic.LoadInt32(&prev.propagateDeadline))
+	atomic.StoreInt32(&w.aggressiveCancel, atomic.LoadInt32(&prev.aggressiveCancel))
+	w.db.SetMaxOpenConns(prev.db.Stats().MaxOpenConnections)
+}
@@ -0,0 +1,118 @@
+package mssqlx
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSwitchCluster(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mssqlx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldDSN, newDSN := filepath.Join(dir, "old.db"), filepath.Join(dir, "new.db")
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{oldDSN}, []string{oldDSN})
+	defer dbs.Destroy()
+
+	if _, err = dbs.Exec("CREATE TABLE cluster (name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dbs.Exec("INSERT INTO cluster VALUES ('old')"); err != nil {
+		t.Fatal(err)
+	}
+
+	// unhealthy new master, current nodes are kept
+	if err = dbs.SwitchCluster([]string{filepath.Join(dir, "missing", "x.db")}, nil, time.Second); err == nil {
+		t.Fatal("SwitchCluster: unhealthy master should fail")
+	}
+
+	if err = dbs.SwitchCluster(nil, []string{newDSN}, time.Second); err != ErrNoMasterToSwitch {
+		t.Fatal("SwitchCluster: switch without master should fail", err)
+	}
+
+	var name string
+	if err = dbs.Get(&name, "SELECT name FROM cluster"); err != nil || name != "old" {
+		t.Fatal("SwitchCluster: current nodes should be kept", err, name)
+	}
+
+	// transaction in progress on old master is drained
+	tx, err := dbs.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = tx.Commit()
+	}()
+
+	startedAt := time.Now()
+	if err = dbs.SwitchCluster([]string{newDSN}, []string{newDSN}, time.Second); err != nil {
+		t.Fatal("SwitchCluster: switch fail", err)
+	}
+	if time.Since(startedAt) < 20*time.Millisecond {
+		t.Fatal("SwitchCluster: should wait for old transaction")
+	}
+
+	if _, err = dbs.Exec("CREATE TABLE cluster (name TEXT)"); err != nil {
+		t.Fatal("SwitchCluster: new master fail", err)
+	}
+	if err = dbs.Get(&name, "SELECT COUNT(*) FROM cluster"); err != nil || name != "0" {
+		t.Fatal("SwitchCluster: new slave fail", err, name)
+	}
+
+	if topo := dbs.Topology(); len(topo.Masters) != 1 || len(topo.Slaves) != 1 {
+		t.Fatal("SwitchCluster: topology fail", topo)
+	}
+
+	states := make(map[NodeState]int)
+	for _, e := range dbs.Events() {
+		if e.Cause != "" {
+			t.Fatal("SwitchCluster: old nodes should be drained in time", e)
+		}
+		states[e.To]++
+	}
+	if states[NodeStateDraining] != 2 || states[NodeStateRemoved] != 2 || states[NodeStateUp] != 2 {
+		t.Fatal("SwitchCluster: events fail", states)
+	}
+}
+
+func TestSwitchClusterMultiMaster(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mssqlx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{filepath.Join(dir, "old.db")}, nil, PreferredPrimary{Index: 0})
+	defer dbs.Destroy()
+
+	old := dbs.masters.loadPreferred()
+
+	if err = dbs.SwitchCluster([]string{filepath.Join(dir, "a.db"), filepath.Join(dir, "b.db")}, nil, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if old.ctx.Err() == nil {
+		t.Fatal("SwitchCluster: failback watcher of old preferred primary should be stopped")
+	}
+	if p := dbs.masters.loadPreferred(); p == nil || p.w != dbs.masterNodes()[0] {
+		t.Fatal("SwitchCluster: preferred primary of new cluster should be designated")
+	}
+
+	// without preferred primary, writes are balanced between new masters
+	dbs.masters.clearPreferred()
+
+	seen := make(map[*wrapper]bool)
+	for i := 0; i < 4; i++ {
+		seen[dbs.masters.getPreferred()] = true
+	}
+	if len(seen) != 2 {
+		t.Fatal("SwitchCluster: writes should be balanced between new masters", len(seen))
+	}
+}
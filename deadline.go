@@ -0,0 +1,64 @@
+package mssqlx
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+func _setDeadlinePropagation(target []*wrapper, enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+
+	for _, db := range target {
+		if db != nil {
+			atomic.StoreInt32(&db.propagateDeadline, v)
+		}
+	}
+}
+
+// SetDeadlinePropagation enables propagating context deadline of reads into server-side timeout,
+// so that statements whose context is done stop consuming resources on database nodes.
+//
+// On mysql, remaining time is set by MAX_EXECUTION_TIME optimizer hint of SELECT statements;
+// go-sql-driver/mysql just closes connection on cancellation, leaving statement running on server.
+// Postgres drivers (lib/pq, pgx) already cancel statements server-side, nothing is injected for them.
+func (dbs *DBs) SetDeadlinePropagation(enabled bool) {
+	_setDeadlinePropagation(dbs.allNodes(), enabled)
+}
+
+// withServerTimeout returns query carrying server-side timeout derived from ctx deadline.
+func (w *wrapper) withServerTimeout(ctx context.Context, query string) string {
+	if ctx == nil || atomic.LoadInt32(&w.propagateDeadline) == 0 || w.db.DriverName() != "mysql" {
+		return query
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return query
+	}
+
+	ms := time.Until(deadline) / time.Millisecond
+	if ms < 1 {
+		ms = 1
+	}
+	return maxExecutionTimeHint(query, int64(ms))
+}
+
+// maxExecutionTimeHint injects mysql MAX_EXECUTION_TIME hint into a SELECT statement.
+func maxExecutionTimeHint(query string, ms int64) string {
+	trimmed := strings.TrimLeft(query, " \t\r\n")
+	if len(trimmed) <= 6 || !strings.EqualFold(trimmed[:6], "SELECT") {
+		return query
+	}
+
+	if strings.Contains(strings.ToUpper(query), "MAX_EXECUTION_TIME") {
+		return query
+	}
+
+	return injectOptimizerHint(query, "MAX_EXECUTION_TIME("+strconv.FormatInt(ms, 10)+")")
+}
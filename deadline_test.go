@@ -0,0 +1,50 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestDeadlinePropagation(t *testing.T) {
+	if q := maxExecutionTimeHint("  select * FROM t", 150); q != "select /*+ MAX_EXECUTION_TIME(150) */ * FROM t" {
+		t.Fatal("DeadlinePropagation: hint fail", q)
+	}
+	if q := maxExecutionTimeHint("UPDATE t SET a = 1", 150); q != "UPDATE t SET a = 1" {
+		t.Fatal("DeadlinePropagation: non-select fail", q)
+	}
+	if q := maxExecutionTimeHint("SELECT /*+ MAX_EXECUTION_TIME(10) */ 1", 150); q != "SELECT /*+ MAX_EXECUTION_TIME(10) */ 1" {
+		t.Fatal("DeadlinePropagation: existing hint fail", q)
+	}
+	if q := maxExecutionTimeHint("SELECTED", 150); q != "SELECTED" {
+		t.Fatal("DeadlinePropagation: keyword boundary fail", q)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	w := newWrapper(sqlx.NewDb(nil, "mysql"), "", RoleMaster, 0)
+	if q := w.withServerTimeout(ctx, "SELECT 1"); q != "SELECT 1" {
+		t.Fatal("DeadlinePropagation: disabled fail", q)
+	}
+
+	_setDeadlinePropagation([]*wrapper{w}, true)
+	if q := w.withServerTimeout(context.Background(), "SELECT 1"); q != "SELECT 1" {
+		t.Fatal("DeadlinePropagation: no deadline fail", q)
+	}
+	if q := w.withServerTimeout(ctx, "SELECT 1"); q == "SELECT 1" {
+		t.Fatal("DeadlinePropagation: enabled fail", q)
+	}
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	dbs.SetDeadlinePropagation(true)
+
+	var v int
+	if err := dbs.GetContext(ctx, &v, "SELECT 1"); err != nil || v != 1 {
+		t.Fatal("DeadlinePropagation: sqlite query fail", err)
+	}
+}
@@ -0,0 +1,147 @@
+package mssqlx
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const missingDestinationPrefix = "missing destination name "
+
+var fromTableRegexp = regexp.MustCompile("(?i)\\bfrom\\s+([A-Za-z0-9_.\"`]+)")
+
+// ColumnMismatchError is returned by Select/Get when result set has columns which
+// are not mapped to any field of destination struct.
+type ColumnMismatchError struct {
+	// Node name which served the query
+	Node string
+
+	// Table of the query, best effort
+	Table string
+
+	// Query executed
+	Query string
+
+	// Dest type of destination
+	Dest string
+
+	// Columns not mapped to any field
+	Columns []string
+
+	// Suggestions closest field name of unmapped columns
+	Suggestions map[string]string
+}
+
+func (e *ColumnMismatchError) Error() string {
+	var b strings.Builder
+	b.WriteString(missingDestinationPrefix)
+	b.WriteString(strings.Join(e.Columns, ", "))
+	b.WriteString(" in ")
+	b.WriteString(e.Dest)
+
+	b.WriteString(" (node: ")
+	b.WriteString(e.Node)
+	if e.Table != "" {
+		b.WriteString(", table: ")
+		b.WriteString(e.Table)
+	}
+	b.WriteString(")")
+
+	sep := "; did you mean "
+	for _, column := range e.Columns {
+		if field, ok := e.Suggestions[column]; ok {
+			b.WriteString(sep)
+			b.WriteString(field + " for " + column)
+			sep = ", "
+		}
+	}
+
+	return b.String()
+}
+
+func queryTable(query string) string {
+	if m := fromTableRegexp.FindStringSubmatch(query); len(m) == 2 {
+		return strings.Trim(m[1], "\"`")
+	}
+	return ""
+}
+
+// levenshtein distance of a and b
+func levenshtein(a, b string) int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = prev[j-1] + cost
+			if v := prev[j] + 1; v < cur[j] {
+				cur[j] = v
+			}
+			if v := cur[j-1] + 1; v < cur[j] {
+				cur[j] = v
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+// closestField returns field name closest to column, if it is close enough
+func closestField(column string, fields []string) (closest string, ok bool) {
+	best := len(column)/2 + 1
+	for _, field := range fields {
+		if d := levenshtein(strings.ToLower(column), strings.ToLower(field)); d < best {
+			best, closest, ok = d, field, true
+		}
+	}
+	return
+}
+
+// diagnoseScan replaces missing destination error of sqlx with ColumnMismatchError
+func (w *wrapper) diagnoseScan(err error, query string, dest interface{}, t reflect.Type, columns []string) error {
+	if err == nil || !strings.HasPrefix(err.Error(), missingDestinationPrefix) {
+		return err
+	}
+
+	m := w.db.Mapper
+	tm := m.TypeMap(t)
+
+	fields := make([]string, 0, len(tm.Names))
+	for name := range tm.Names {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+
+	e := &ColumnMismatchError{
+		Node:        w.getName(),
+		Table:       queryTable(query),
+		Query:       query,
+		Dest:        fmt.Sprintf("%T", dest),
+		Suggestions: make(map[string]string),
+	}
+
+	for i, traversal := range getScanPlan(m, t, isPostgres(w.db.DriverName()), columns).traversals {
+		if len(traversal) == 0 {
+			e.Columns = append(e.Columns, columns[i])
+			if field, ok := closestField(columns[i], fields); ok {
+				e.Suggestions[columns[i]] = field
+			}
+		}
+	}
+
+	if len(e.Columns) == 0 {
+		return err
+	}
+	return e
+}
@@ -0,0 +1,48 @@
+package mssqlx
+
+import (
+	"testing"
+)
+
+func TestColumnMismatchError(t *testing.T) {
+	if d := levenshtein("kitten", "sitting"); d != 3 {
+		t.Fatal("Levenshtein: unexpected distance", d)
+	}
+	if tb := queryTable("select * FROM `person` WHERE id = 1"); tb != "person" {
+		t.Fatal("QueryTable: unexpected table", tb)
+	}
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+
+	type person struct {
+		FirstName string `db:"first_name"`
+		Email     string `db:"email"`
+	}
+
+	if _, err := dbs.Exec("CREATE TABLE person (first_nam TEXT, emial TEXT, xyz INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.Exec("INSERT INTO person VALUES ('a', 'b', 1)"); err != nil {
+		t.Fatal(err)
+	}
+
+	var people []person
+	err := dbs.SelectOnMaster(&people, "SELECT * FROM person")
+
+	e, ok := err.(*ColumnMismatchError)
+	if !ok {
+		t.Fatal("ColumnMismatchError: unexpected error", err)
+	}
+	if e.Node != "master-0" || e.Table != "person" || len(e.Columns) != 3 || e.Suggestions["first_nam"] != "first_name" || e.Suggestions["emial"] != "email" {
+		t.Fatal("ColumnMismatchError: unexpected diagnostics", e)
+	}
+	if _, ok = e.Suggestions["xyz"]; ok {
+		t.Fatal("ColumnMismatchError: unrelated column should not be suggested")
+	}
+
+	var p person
+	if err = dbs.GetOnMaster(&p, "SELECT first_nam AS first_name, emial FROM person"); err == nil || err.Error() != "missing destination name emial in *mssqlx.person (node: master-0, table: person); did you mean email for emial" {
+		t.Fatal("ColumnMismatchError: unexpected error", err)
+	}
+}
@@ -0,0 +1,147 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrDialerNotSupported custom dialers are only supported by mysql and postgres drivers
+	ErrDialerNotSupported = errors.New("Custom dialer is only supported by mysql and postgres drivers")
+)
+
+// dialFunc establishes connection to address of node
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Dialer establishes connections to nodes, i.e through SSH tunnel or SOCKS proxy.
+// network is tcp or unix, addr is address of node taken from its DSN.
+type Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// DialerOption overrides how connections of nodes are established. Created by SetDialer.
+//
+// Pass it as an arg of ConnectMasterSlaves.
+type DialerOption struct {
+	dial Dialer
+	dsns []string
+}
+
+// SetDialer returns option making nodes of given DSNs connect through dial, or all nodes if dsns is empty.
+// Dialer of node takes precedence over dialer of all nodes. Only mysql and postgres drivers are supported.
+func SetDialer(dial Dialer, dsns ...string) DialerOption {
+	return DialerOption{dial: dial, dsns: dsns}
+}
+
+// dialerOf returns dial func of node
+func (opts *connectOptions) dialerOf(dsn string) dialFunc {
+	if dial, ok := opts.nodeDials[dsn]; ok {
+		return dial
+	}
+	return opts.dial
+}
+
+// sequence of mysql networks registered for dial funcs
+var dialNetworkSeq uint64
+
+// connectorOpener opens connector of DSN
+type connectorOpener func(dsn string) (driver.Connector, error)
+
+// newConnector opens connector of DSN with driver
+func newConnector(d driver.Driver, dsn string) (driver.Connector, error) {
+	if dc, ok := d.(driver.DriverContext); ok {
+		return dc.OpenConnector(dsn)
+	}
+	return &dsnConnector{dsn: dsn, driver: d}, nil
+}
+
+// dialOpener returns opener of connectors establishing connections through dial
+func dialOpener(driverName string, d driver.Driver, dial dialFunc) (connectorOpener, error) {
+	switch driverName {
+	case "mysql":
+		networks := &mysqlDialNetworks{dial: dial, names: make(map[string]string)}
+		return func(dsn string) (driver.Connector, error) {
+			cfg, err := mysql.ParseDSN(dsn)
+			if err != nil {
+				return nil, err
+			}
+
+			cfg.Net = networks.get(cfg.Net)
+			return newConnector(d, cfg.FormatDSN())
+		}, nil
+
+	case "postgres":
+		return func(dsn string) (driver.Connector, error) {
+			if _, err := pq.NewConnector(dsn); err != nil { // validate DSN
+				return nil, err
+			}
+			return &pqDialConnector{dsn: dsn, dialer: pqDialer(dial), driver: d}, nil
+		}, nil
+	}
+
+	return nil, ErrDialerNotSupported
+}
+
+// mysqlDialNetworks registers networks of mysql driver dialing through dial func, one per network of DSNs
+type mysqlDialNetworks struct {
+	dial  dialFunc
+	mu    sync.Mutex
+	names map[string]string // network of DSN => registered network
+}
+
+func (n *mysqlDialNetworks) get(network string) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	name, ok := n.names[network]
+	if !ok {
+		name = "mssqlx-" + network + "-" + strconv.FormatUint(atomic.AddUint64(&dialNetworkSeq, 1), 10)
+		n.names[network] = name
+
+		dial := n.dial
+		mysql.RegisterDialContext(name, func(ctx context.Context, addr string) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		})
+	}
+	return name
+}
+
+// pqDialConnector opens postgres connections with dialer.
+// DriverWrapper is not applied to these connections, ConnectorWrapper is.
+type pqDialConnector struct {
+	dsn    string
+	dialer pqDialer
+	driver driver.Driver
+}
+
+func (c *pqDialConnector) Connect(context.Context) (driver.Conn, error) {
+	return pq.DialOpen(c.dialer, c.dsn)
+}
+
+func (c *pqDialConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// pqDialer adapts dial func to pq.Dialer and pq.DialerContext
+type pqDialer dialFunc
+
+func (d pqDialer) Dial(network, address string) (net.Conn, error) {
+	return d(context.Background(), network, address)
+}
+
+func (d pqDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return d(ctx, network, address)
+}
+
+func (d pqDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return d(ctx, network, address)
+}
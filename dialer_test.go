@@ -0,0 +1,60 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+)
+
+func TestSetDialer(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		dials = make(map[string]string) // addr => dialer
+	)
+	dialer := func(name string) Dialer {
+		return func(ctx context.Context, network, addr string) (net.Conn, error) {
+			mu.Lock()
+			dials[network+" "+addr] = name
+			mu.Unlock()
+			return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("tunnel is down")}
+		}
+	}
+
+	master := "user:password@tcp(10.0.0.1:3306)/db"
+	slave := "user:password@unix(/var/run/mysqld.sock)/db"
+
+	dbs, errs := ConnectMasterSlaves("mysql", []string{master}, []string{slave},
+		SetDialer(dialer("all")),
+		SetDialer(dialer("node"), slave),
+	)
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal("SetDialer: connect fail", err)
+		}
+	}
+	defer dbs.Destroy()
+
+	for _, w := range dbs.allNodes() {
+		if err := w.db.Ping(); err == nil || !isConnectionError(err) {
+			t.Fatal("SetDialer: dial error should be connection error", err)
+		}
+	}
+
+	pg, _ := ConnectMasterSlaves("postgres", []string{"host=10.0.0.2 port=5433 user=u dbname=db sslmode=disable"}, nil,
+		SetDialer(dialer("all")),
+	)
+	defer pg.Destroy()
+	_ = pg.masterNodes()[0].db.Ping()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if dials["tcp 10.0.0.1:3306"] != "all" || dials["unix /var/run/mysqld.sock"] != "node" || dials["tcp 10.0.0.2:5433"] != "all" {
+		t.Fatal("SetDialer: dialers fail", dials)
+	}
+
+	if _, errs := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil, SetDialer(dialer("all"))); errs[0] != ErrDialerNotSupported {
+		t.Fatal("SetDialer: unsupported driver should fail", errs[0])
+	}
+}
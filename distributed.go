@@ -0,0 +1,268 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	// ErrDistributedTxNotSupported driver does not support two-phase commit
+	ErrDistributedTxNotSupported = errors.New("Two-phase commit is not supported by driver")
+
+	// ErrDistributedTxNotPrepared distributed transaction must be prepared before committing
+	ErrDistributedTxNotPrepared = errors.New("Distributed transaction is not prepared")
+
+	// ErrInvalidXID xid of distributed transaction contains invalid characters
+	ErrInvalidXID = errors.New("Invalid distributed transaction xid")
+)
+
+const (
+	distributedTxActive int32 = iota
+	distributedTxPrepared
+	distributedTxDone
+)
+
+// two-phase commit statements of a dialect
+type xaDialect struct {
+	begin           func(xid string) []string
+	prepare         func(xid string) []string
+	commitPrepared  func(xid string) string
+	rollbackActive  func(xid string) []string
+	rollbackPrepare func(xid string) string
+}
+
+var (
+	mysqlXA = &xaDialect{
+		begin:           func(xid string) []string { return []string{"XA START " + xid} },
+		prepare:         func(xid string) []string { return []string{"XA END " + xid, "XA PREPARE " + xid} },
+		commitPrepared:  func(xid string) string { return "XA COMMIT " + xid },
+		rollbackActive:  func(xid string) []string { return []string{"XA END " + xid, "XA ROLLBACK " + xid} },
+		rollbackPrepare: func(xid string) string { return "XA ROLLBACK " + xid },
+	}
+
+	postgresXA = &xaDialect{
+		begin:           func(xid string) []string { return []string{"BEGIN"} },
+		prepare:         func(xid string) []string { return []string{"PREPARE TRANSACTION " + xid} },
+		commitPrepared:  func(xid string) string { return "COMMIT PREPARED " + xid },
+		rollbackActive:  func(xid string) []string { return []string{"ROLLBACK"} },
+		rollbackPrepare: func(xid string) string { return "ROLLBACK PREPARED " + xid },
+	}
+)
+
+func getXADialect(driverName string) *xaDialect {
+	switch driverName {
+	case "mysql":
+		return mysqlXA
+	case "postgres", "pgx":
+		return postgresXA
+	}
+	return nil
+}
+
+// isValidXID reports whether xid is safe to be quoted into statements, letters, digits and _.:- only
+func isValidXID(xid string) bool {
+	if xid == "" {
+		return false
+	}
+	for _, c := range xid {
+		if !(c == '_' || c == '.' || c == ':' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func quoteXID(xid string) string {
+	return "'" + strings.Replace(xid, "'", "''", -1) + "'"
+}
+
+// DistributedTx is a transaction participating in two-phase commit (XA on MySQL,
+// PREPARE TRANSACTION on Postgres). It is bound to a dedicated master connection.
+//
+// Typical flow: exec statements, Prepare, then Commit (or Rollback) after other resources are prepared.
+type DistributedTx struct {
+	conn    *sql.Conn
+	w       *wrapper // master which connection belongs to
+	xid     string
+	dialect *xaDialect
+	state   int32
+}
+
+// BeginDistributed starts a distributed transaction identified by xid on one of master connections.
+// xid may contain letters, digits and _.:- only, otherwise ErrInvalidXID is returned.
+func (dbs *DBs) BeginDistributed(ctx context.Context, xid string) (*DistributedTx, error) {
+	dialect := getXADialect(dbs.driverName)
+	if dialect == nil {
+		return nil, ErrDistributedTxNotSupported
+	}
+	if !isValidXID(xid) {
+		return nil, ErrInvalidXID
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	w, conn, err := _conn(ctx, dbs.masters)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &DistributedTx{conn: conn, w: w, xid: quoteXID(xid), dialect: dialect}
+	if err = tx.execAll(ctx, dialect.begin(tx.xid)); err != nil {
+		tx.finish()
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// CommitPrepared commits a prepared distributed transaction by its xid on one of master connections.
+// Useful for coordinator recovery.
+func (dbs *DBs) CommitPrepared(ctx context.Context, xid string) error {
+	dialect := getXADialect(dbs.driverName)
+	if dialect == nil {
+		return ErrDistributedTxNotSupported
+	}
+	if !isValidXID(xid) {
+		return ErrInvalidXID
+	}
+	_, err := _exec(ctx, dbs.masters, dialect.commitPrepared(quoteXID(xid)))
+	return err
+}
+
+// RollbackPrepared rollbacks a prepared distributed transaction by its xid on one of master connections.
+// Useful for coordinator recovery.
+func (dbs *DBs) RollbackPrepared(ctx context.Context, xid string) error {
+	dialect := getXADialect(dbs.driverName)
+	if dialect == nil {
+		return ErrDistributedTxNotSupported
+	}
+	if !isValidXID(xid) {
+		return ErrInvalidXID
+	}
+	_, err := _exec(ctx, dbs.masters, dialect.rollbackPrepare(quoteXID(xid)))
+	return err
+}
+
+func (tx *DistributedTx) execAll(ctx context.Context, queries []string) (err error) {
+	for _, query := range queries {
+		if _, err = tx.conn.ExecContext(ctx, query); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (tx *DistributedTx) active() error {
+	if atomic.LoadInt32(&tx.state) != distributedTxActive {
+		return sql.ErrTxDone
+	}
+	return nil
+}
+
+// ExecContext executes a query inside the transaction.
+func (tx *DistributedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if err := tx.active(); err != nil {
+		return nil, err
+	}
+	return tx.conn.ExecContext(ctx, query, args...)
+}
+
+// QueryContext executes a query that returns rows inside the transaction.
+func (tx *DistributedTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if err := tx.active(); err != nil {
+		return nil, err
+	}
+	return tx.conn.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext executes a query that is expected to return at most one row inside the transaction.
+func (tx *DistributedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if err := tx.active(); err != nil {
+		return errRow(err)
+	}
+	return tx.conn.QueryRowContext(ctx, query, args...)
+}
+
+// Prepare is the first phase of two-phase commit. After preparing, transaction
+// survives connection loss and could be finished by DBs.CommitPrepared/DBs.RollbackPrepared.
+func (tx *DistributedTx) Prepare(ctx context.Context) (err error) {
+	if err = tx.active(); err != nil {
+		return
+	}
+
+	if err = tx.execAll(ctx, tx.dialect.prepare(tx.xid)); err == nil {
+		atomic.StoreInt32(&tx.state, distributedTxPrepared)
+	}
+	return
+}
+
+// Commit is the second phase of two-phase commit. Transaction must be prepared.
+// Connection is closed even if Commit fails, use DBs.CommitPrepared to retry.
+func (tx *DistributedTx) Commit(ctx context.Context) (err error) {
+	switch atomic.LoadInt32(&tx.state) {
+	case distributedTxActive:
+		return ErrDistributedTxNotPrepared
+
+	case distributedTxDone:
+		return sql.ErrTxDone
+	}
+
+	// prepared transaction survives losing connection, could be recovered by DBs.CommitPrepared
+	defer tx.finish()
+
+	_, err = tx.conn.ExecContext(ctx, tx.dialect.commitPrepared(tx.xid))
+	return
+}
+
+// Rollback aborts the transaction, whether prepared or not.
+// Connection is closed even if Rollback fails, use DBs.RollbackPrepared to retry a prepared one.
+func (tx *DistributedTx) Rollback(ctx context.Context) (err error) {
+	switch atomic.LoadInt32(&tx.state) {
+	case distributedTxActive:
+		err = tx.execAll(ctx, tx.dialect.rollbackActive(tx.xid))
+
+	case distributedTxPrepared:
+		_, err = tx.conn.ExecContext(ctx, tx.dialect.rollbackPrepare(tx.xid))
+
+	default:
+		return sql.ErrTxDone
+	}
+
+	// closing connection aborts active transaction, prepared one could be recovered by DBs.RollbackPrepared
+	tx.finish()
+	return
+}
+
+func (tx *DistributedTx) finish() {
+	if atomic.SwapInt32(&tx.state, distributedTxDone) != distributedTxDone {
+		_ = tx.conn.Close()
+		tx.w.limiter.release()
+	}
+}
+
+// errConnector fails every connection attempt with err
+type errConnector struct {
+	err error
+}
+
+func (c errConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, c.err
+}
+
+func (c errConnector) Driver() driver.Driver {
+	return nil
+}
+
+// errRow returns a row failing with err on Scan
+func errRow(err error) *sql.Row {
+	db := sql.OpenDB(errConnector{err: err})
+	defer db.Close()
+
+	return db.QueryRowContext(context.Background(), "")
+}
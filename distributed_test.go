@@ -0,0 +1,71 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestDistributedTx(t *testing.T) {
+	if getXADialect("sqlite3") != nil || getXADialect("mysql") != mysqlXA || getXADialect("postgres") != postgresXA {
+		t.Fatal("DistributedTx: dialect detection fail")
+	}
+
+	if x := quoteXID("a'b"); x != "'a''b'" {
+		t.Fatal("DistributedTx: quote xid fail", x)
+	}
+	if !isValidXID("order-42:node.1_a") || isValidXID("") || isValidXID(`a\'; DROP TABLE b`) {
+		t.Fatal("DistributedTx: xid validation fail")
+	}
+
+	if q := mysqlXA.prepare("'x'"); len(q) != 2 || q[0] != "XA END 'x'" || q[1] != "XA PREPARE 'x'" {
+		t.Fatal("DistributedTx: mysql prepare statements fail", q)
+	}
+
+	dbs := &DBs{driverName: "sqlite3"}
+	if _, err := dbs.BeginDistributed(context.Background(), "x"); err != ErrDistributedTxNotSupported {
+		t.Fatal("DistributedTx: unsupported driver check fail")
+	}
+	if err := dbs.CommitPrepared(context.Background(), "x"); err != ErrDistributedTxNotSupported {
+		t.Fatal("DistributedTx: unsupported driver check fail")
+	}
+	if err := dbs.RollbackPrepared(context.Background(), "x"); err != ErrDistributedTxNotSupported {
+		t.Fatal("DistributedTx: unsupported driver check fail")
+	}
+
+	dbs = &DBs{driverName: "mysql"}
+	if _, err := dbs.BeginDistributed(context.Background(), "x'y"); err != ErrInvalidXID {
+		t.Fatal("DistributedTx: invalid xid check fail")
+	}
+	if err := dbs.CommitPrepared(context.Background(), "x'y"); err != ErrInvalidXID {
+		t.Fatal("DistributedTx: invalid xid check fail")
+	}
+	if err := dbs.RollbackPrepared(context.Background(), "x'y"); err != ErrInvalidXID {
+		t.Fatal("DistributedTx: invalid xid check fail")
+	}
+}
+
+func TestDistributedTxFailedCommit(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+
+	w, conn, err := _conn(context.Background(), dbs.masters)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sqlite does not support two-phase commit, so commit fails
+	tx := &DistributedTx{conn: conn, w: w, xid: "'x'", dialect: postgresXA, state: distributedTxPrepared}
+	if err = tx.Commit(context.Background()); err == nil {
+		t.Fatal("DistributedTx: commit should fail")
+	}
+
+	if err = conn.PingContext(context.Background()); err != sql.ErrConnDone {
+		t.Fatal("DistributedTx: connection should be closed after failed commit", err)
+	}
+
+	var v int
+	if err = tx.QueryRowContext(context.Background(), "SELECT 1").Scan(&v); err != sql.ErrTxDone {
+		t.Fatal("DistributedTx: finished transaction should not be queried", err)
+	}
+}
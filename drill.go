@@ -0,0 +1,168 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrFailoverDrill cause of taking node out of rotation by FailoverDrill
+	ErrFailoverDrill = errors.New("Node is quarantined by failover drill")
+)
+
+const (
+	// DefaultDrillDuration default time node is quarantined by FailoverDrill
+	DefaultDrillDuration = 10 * time.Second
+
+	// DefaultDrillInterval default interval between probes of FailoverDrill
+	DefaultDrillInterval = 100 * time.Millisecond
+
+	// DefaultDrillRestoreTimeout default time FailoverDrill waits for node back in rotation
+	DefaultDrillRestoreTimeout = 30 * time.Second
+
+	// DefaultDrillQuery default read and write probe of FailoverDrill
+	DefaultDrillQuery = "SELECT 1"
+)
+
+// DrillOptions configures probe workload of FailoverDrill.
+type DrillOptions struct {
+	// Duration node is quarantined. Default is DefaultDrillDuration.
+	Duration time.Duration
+
+	// Interval between probes. Default is DefaultDrillInterval.
+	Interval time.Duration
+
+	// RestoreTimeout max time waiting for node back in rotation after quarantine. Default is DefaultDrillRestoreTimeout.
+	RestoreTimeout time.Duration
+
+	// ReadQuery probe executed on slaves. Default is DefaultDrillQuery.
+	ReadQuery string
+
+	// WriteQuery probe executed on masters, i.e an UPDATE of a heartbeat table. Default is DefaultDrillQuery.
+	WriteQuery string
+}
+
+// DrillReport is result of FailoverDrill.
+type DrillReport struct {
+	Node      string    `json:"node"`
+	Role      Role      `json:"role"`
+	StartedAt time.Time `json:"started_at"`
+
+	Reads       int `json:"reads"`
+	ReadErrors  int `json:"read_errors"`
+	Writes      int `json:"writes"`
+	WriteErrors int `json:"write_errors"`
+
+	// LastError last error of probes, empty if none
+	LastError string `json:"last_error,omitempty"`
+
+	// Recovered tells whether both probes succeeded while node was quarantined, RecoveryTime is time
+	// from quarantine until they did
+	Recovered    bool          `json:"recovered"`
+	RecoveryTime time.Duration `json:"recovery_time"`
+
+	// Restored tells whether node is back in rotation after quarantine, RestoreTime is time it took
+	Restored    bool          `json:"restored"`
+	RestoreTime time.Duration `json:"restore_time"`
+}
+
+// FailoverDrill validates production readiness of failover: node (i.e master-0, slave-1) is quarantined
+// like on real failure while read and write probes run against DBs, then it is restored.
+// Report tells how long it took until traffic recovered, how many probes failed during the switch
+// and whether node came back to rotation.
+//
+// Drill takes at least opts.Duration. Error is returned if ctx is done before drill is over, report is valid then.
+func (dbs *DBs) FailoverDrill(ctx context.Context, name string, opts DrillOptions) (report DrillReport, err error) {
+	w := dbs.findNode(name)
+	if w == nil {
+		return report, ErrNodeNotFound
+	}
+
+	target, err := dbs.getBalancer(w.getRole())
+	if err != nil {
+		return
+	}
+
+	if opts.Duration <= 0 {
+		opts.Duration = DefaultDrillDuration
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultDrillInterval
+	}
+	if opts.RestoreTimeout <= 0 {
+		opts.RestoreTimeout = DefaultDrillRestoreTimeout
+	}
+	if opts.ReadQuery == "" {
+		opts.ReadQuery = DefaultDrillQuery
+	}
+	if opts.WriteQuery == "" {
+		opts.WriteQuery = DefaultDrillQuery
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	report.Node, report.Role, report.StartedAt = w.getName(), w.getRole(), time.Now()
+	logEntry(LogLevelInfo, "failover drill is started", nodeFields(w)...)
+
+	// health checker keeps node out of rotation until drill ends quarantine
+	atomic.StoreInt64(&w.simulatedUntil, report.StartedAt.Add(opts.Duration).UnixNano())
+	target.failureWithCause(w, ErrFailoverDrill)
+
+	for deadline := report.StartedAt.Add(opts.Duration); time.Now().Before(deadline); {
+		if dbs.drillProbe(ctx, opts, &report) && !report.Recovered {
+			report.Recovered, report.RecoveryTime = true, time.Since(report.StartedAt)
+		}
+
+		if err = sleepContext(ctx, opts.Interval); err != nil {
+			break
+		}
+	}
+
+	// restore
+	atomic.StoreInt64(&w.simulatedUntil, 0)
+	restoredAt := time.Now()
+
+	restoreCtx, cancel := context.WithTimeout(ctx, opts.RestoreTimeout)
+	defer cancel()
+
+	for !target.dbs.contains(w) && sleepContext(restoreCtx, opts.Interval) == nil {
+	}
+	if report.Restored = target.dbs.contains(w); report.Restored {
+		report.RestoreTime = time.Since(restoredAt)
+	}
+
+	logEntry(LogLevelInfo, "failover drill is over", nodeFields(w,
+		LogField{Key: "recovered", Value: report.Recovered}, LogField{Key: "recovery_time", Value: report.RecoveryTime},
+		LogField{Key: "restored", Value: report.Restored})...)
+
+	if err == nil {
+		err = ctx.Err()
+	}
+	return
+}
+
+// drillProbe runs a read and a write probe, reports whether both succeeded
+func (dbs *DBs) drillProbe(ctx context.Context, opts DrillOptions, report *DrillReport) bool {
+	report.Reads++
+	rows, readErr := dbs.QueryContext(ctx, opts.ReadQuery)
+	if readErr == nil {
+		readErr = rows.Close()
+	}
+	if readErr != nil {
+		report.ReadErrors++
+		report.LastError = readErr.Error()
+	}
+
+	report.Writes++
+	_, writeErr := dbs.ExecContext(ctx, opts.WriteQuery)
+	if writeErr != nil {
+		report.WriteErrors++
+		report.LastError = writeErr.Error()
+	}
+
+	return readErr == nil && writeErr == nil
+}
@@ -0,0 +1,44 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFailoverDrill(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:", ":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+	dbs.SetMasterHealthCheckPeriod(5)
+
+	if _, err := dbs.FailoverDrill(context.Background(), "master-9", DrillOptions{}); err != ErrNodeNotFound {
+		t.Fatal("FailoverDrill: not found check fail", err)
+	}
+
+	opts := DrillOptions{Duration: 100 * time.Millisecond, Interval: 10 * time.Millisecond}
+	report, err := dbs.FailoverDrill(context.Background(), "master-0", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Node != "master-0" || report.Role != RoleMaster || report.Writes < 2 || report.Reads != report.Writes {
+		t.Fatal("FailoverDrill: unexpected report", report)
+	}
+	if report.WriteErrors != 0 || !report.Recovered || report.RecoveryTime > opts.Duration {
+		t.Fatal("FailoverDrill: traffic should recover on other master", report)
+	}
+	if !report.Restored || !dbs.masters.dbs.contains(dbs.findNode("master-0")) {
+		t.Fatal("FailoverDrill: node should be restored", report)
+	}
+
+	if events := dbs.Events(); len(events) == 0 || events[0].Cause != ErrFailoverDrill.Error() {
+		t.Fatal("FailoverDrill: quarantine should be recorded", events)
+	}
+
+	// ctx is done before drill is over
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if report, err = dbs.FailoverDrill(ctx, "master-1", DrillOptions{Duration: time.Hour}); err != context.DeadlineExceeded || report.Restored {
+		t.Fatal("FailoverDrill: should stop when ctx is done", err, report)
+	}
+}
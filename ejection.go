@@ -0,0 +1,116 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrTooManyTimeouts cause of taking node out of rotation by timeout ejection, see SetTimeoutEjection
+	ErrTooManyTimeouts = errors.New("Node has too many timed out queries")
+)
+
+const (
+	// DefaultTimeoutEjectionMinQueries default min number of queries within ErrorRateWindow before timeout rate of node is judged
+	DefaultTimeoutEjectionMinQueries = 20
+)
+
+// isTimeoutError reports whether err is caused by deadline of context or statement timeout of server.
+//
+// ERROR 3024: Query execution was interrupted, maximum statement execution time exceeded (mysql)
+// ERROR 1969: Query execution was interrupted (max_statement_time exceeded) (mariadb)
+// SQLSTATE 57014: canceling statement due to statement timeout (postgres)
+func isTimeoutError(err error) bool {
+	if err == context.DeadlineExceeded {
+		return true
+	}
+
+	if pe, ok := err.(*pq.Error); ok {
+		return pe.Code == "57014" && strings.Contains(pe.Message, "timeout")
+	}
+
+	se := err.Error()
+	for _, code := range []string{"3024:", "1969:"} {
+		if strings.HasPrefix(se, "Error "+code) || strings.HasPrefix(se, "ERROR "+code) {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldFailure reports whether query failed on w should be retried on another node, feeding
+// timeouts of w into timeout ejection.
+func (c *balancer) shouldFailure(w *wrapper, err error) bool {
+	if err != nil && w != nil && isTimeoutError(err) {
+		c.checkTimeouts(w)
+	}
+	return shouldFailure(w, c.isWsrep, err)
+}
+
+func (c *balancer) getTimeoutEjection() (ratio float64, minQueries uint64) {
+	ratio = math.Float64frombits(atomic.LoadUint64(&c.timeoutRatio))
+	if minQueries = uint64(atomic.LoadInt32(&c.timeoutMinQueries)); minQueries == 0 {
+		minQueries = DefaultTimeoutEjectionMinQueries
+	}
+	return
+}
+
+func (c *balancer) setTimeoutEjection(ratio float64, minQueries int) {
+	if ratio < 0 {
+		ratio = 0
+	}
+	if minQueries <= 0 {
+		minQueries = DefaultTimeoutEjectionMinQueries
+	}
+	atomic.StoreUint64(&c.timeoutRatio, math.Float64bits(ratio))
+	atomic.StoreInt32(&c.timeoutMinQueries, int32(minQueries))
+}
+
+// checkTimeouts ejects w if its timeout rate reaches threshold while some other healthy node's does not.
+// If all nodes time out alike, queries or deadlines are to blame rather than node.
+func (c *balancer) checkTimeouts(w *wrapper) {
+	threshold, minQueries := c.getTimeoutEjection()
+	if threshold <= 0 || c.isSingle() {
+		return
+	}
+
+	now := time.Now()
+	if rate, queries := w.stats.timeoutRate(now); queries < minQueries || rate < threshold {
+		return
+	}
+
+	healthy, _ := c.dbs.list.Load().([]*wrapper)
+	for _, peer := range healthy {
+		if peer != w {
+			if rate, _ := peer.stats.timeoutRate(now); rate < threshold {
+				atomic.StoreInt64(&w.ejectedUntil, now.Add(ErrorRateWindow).UnixNano())
+				c.failureWithCause(w, ErrTooManyTimeouts)
+				return
+			}
+		}
+	}
+}
+
+// isEjected reports whether node is ejected for too many timeouts, health checker keeps it out of rotation
+// until its timed out queries leave ErrorRateWindow
+func (w *wrapper) isEjected() bool {
+	return time.Now().UnixNano() < atomic.LoadInt64(&w.ejectedUntil)
+}
+
+// SetTimeoutEjection demotes nodes which keep timing out while answering pings: once ratio of queries
+// of a node hitting context deadline or statement timeout within ErrorRateWindow reaches ratio (i.e 0.5),
+// over at least minQueries queries, and another healthy node of the same role stays below it, node is taken out of
+// rotation for ErrorRateWindow.
+//
+// If ratio <= 0, timeouts never take node out of rotation, which is default. If minQueries <= 0,
+// DefaultTimeoutEjectionMinQueries is used.
+func (dbs *DBs) SetTimeoutEjection(ratio float64, minQueries int) {
+	dbs.masters.setTimeoutEjection(ratio, minQueries)
+	dbs.slaves.setTimeoutEjection(ratio, minQueries)
+}
@@ -0,0 +1,84 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestIsTimeoutError(t *testing.T) {
+	for _, err := range []error{
+		context.DeadlineExceeded,
+		errors.New("Error 3024: Query execution was interrupted, maximum statement execution time exceeded"),
+		errors.New("Error 1969: Query execution was interrupted (max_statement_time exceeded)"),
+		&pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"},
+	} {
+		if !isTimeoutError(err) {
+			t.Fatal("IsTimeoutError: should be timeout", err)
+		}
+	}
+
+	for _, err := range []error{
+		context.Canceled,
+		errors.New("Error 1064: You have an error in your SQL syntax"),
+		&pq.Error{Code: "57014", Message: "canceling statement due to user request"},
+	} {
+		if isTimeoutError(err) {
+			t.Fatal("IsTimeoutError: should not be timeout", err)
+		}
+	}
+}
+
+func TestTimeoutEjection(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:", ":memory:"})
+	defer dbs.Destroy()
+	dbs.SetSlaveHealthCheckPeriod(5)
+
+	w0, w1 := dbs.findNode("slave-0"), dbs.findNode("slave-1")
+	timeouts := func(w *wrapper, n int) {
+		for i := 0; i < n; i++ {
+			w.stats.done("SELECT 1", context.DeadlineExceeded)
+		}
+	}
+
+	// disabled by default
+	timeouts(w0, 30)
+	if dbs.slaves.shouldFailure(w0, context.DeadlineExceeded) || !dbs.slaves.dbs.contains(w0) {
+		t.Fatal("TimeoutEjection: should be disabled by default")
+	}
+
+	// all nodes time out alike
+	dbs.SetTimeoutEjection(0.5, 10)
+	timeouts(w1, 10)
+	if dbs.slaves.shouldFailure(w0, context.DeadlineExceeded); !dbs.slaves.dbs.contains(w0) {
+		t.Fatal("TimeoutEjection: node should not be ejected if peers time out alike")
+	}
+
+	for i := 0; i < 20; i++ {
+		w1.stats.done("SELECT 1", nil)
+	}
+	if dbs.slaves.shouldFailure(w0, context.DeadlineExceeded); dbs.slaves.dbs.contains(w0) {
+		t.Fatal("TimeoutEjection: sick node should be ejected")
+	}
+	if events := dbs.Events(); len(events) != 1 || events[0].Cause != ErrTooManyTimeouts.Error() {
+		t.Fatal("TimeoutEjection: ejection should be recorded", events)
+	}
+	if st := dbs.Status().Nodes[1]; st.TimeoutRate != 1 {
+		t.Fatal("TimeoutEjection: unexpected timeout rate", st)
+	}
+
+	// answering pings does not bring node back
+	time.Sleep(30 * time.Millisecond)
+	if dbs.slaves.dbs.contains(w0) {
+		t.Fatal("TimeoutEjection: node should stay ejected")
+	}
+
+	// last healthy node is never ejected
+	timeouts(w1, 100)
+	if dbs.slaves.shouldFailure(w1, context.DeadlineExceeded); !dbs.slaves.dbs.contains(w1) {
+		t.Fatal("TimeoutEjection: last node should not be ejected")
+	}
+}
@@ -0,0 +1,307 @@
+package mssqlx
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+const (
+	// encryptedTagOption marks struct fields encrypted by field encryptor, i.e `db:"ssn,encrypted"`
+	encryptedTagOption = "encrypted"
+
+	keyringCiphertextVersion = 1
+)
+
+var (
+	// ErrNoFieldEncryptor struct has encrypted fields but no field encryptor is set by SetFieldEncryptor
+	ErrNoFieldEncryptor = errors.New("Field encryptor is required by encrypted fields")
+
+	// ErrKeyNotFound keyring has no key of id
+	ErrKeyNotFound = errors.New("Key not found in keyring")
+
+	// ErrInvalidCiphertext encrypted value is malformed
+	ErrInvalidCiphertext = errors.New("Invalid ciphertext")
+)
+
+// FieldEncryptor encrypts and decrypts values of struct fields tagged `db:"...,encrypted"`.
+type FieldEncryptor interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+type fieldEncryptorHolder struct {
+	e FieldEncryptor
+}
+
+var fieldEncryptor atomic.Value // fieldEncryptorHolder
+
+// SetFieldEncryptor sets encryptor of struct fields tagged `db:"...,encrypted"`, i.e NewKeyringEncryptor of a KMS-backed keyring.
+// Encrypted fields are bound by named queries (NamedExec, NamedQuery including batches, InsertStruct, UpdateStruct,
+// UpsertBatch...) and scanned by Get/Select (of DBs and transactions) through it, so that application-level encryption
+// is consistent across query paths. Positional args are bound as they are.
+//
+// StructScan of rows returned by Queryx, QueryRowx, NamedQuery and BufferedQueryx does not decrypt: encrypted fields
+// are hidden from it, so scanning their columns fails with missing destination instead of returning ciphertext.
+//
+// Values are stored as binary (i.e bytea, VARBINARY/BLOB). String and []byte fields are encrypted as they are,
+// others as JSON. Nil pointers are stored as NULL. Passing nil removes encryptor.
+func SetFieldEncryptor(e FieldEncryptor) {
+	fieldEncryptor.Store(fieldEncryptorHolder{e: e})
+}
+
+func getFieldEncryptor() FieldEncryptor {
+	h, _ := fieldEncryptor.Load().(fieldEncryptorHolder)
+	return h.e
+}
+
+// encryptValue encrypts value of field v
+func encryptValue(v reflect.Value) (interface{}, error) {
+	e := getFieldEncryptor()
+	if e == nil {
+		return nil, ErrNoFieldEncryptor
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	var plaintext []byte
+	switch {
+	case v.Kind() == reflect.String:
+		plaintext = []byte(v.String())
+
+	case v.Type() == bytesType:
+		if v.IsNil() {
+			return nil, nil
+		}
+		plaintext = v.Bytes()
+
+	default:
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		plaintext = b
+	}
+
+	return e.Encrypt(plaintext)
+}
+
+// encryptedScanner decrypts column into a struct field
+type encryptedScanner struct {
+	dst reflect.Value
+}
+
+func (s *encryptedScanner) Scan(src interface{}) error {
+	var ciphertext []byte
+	switch v := src.(type) {
+	case nil:
+		s.dst.Set(reflect.Zero(s.dst.Type()))
+		return nil
+
+	case []byte:
+		ciphertext = v
+
+	case string:
+		ciphertext = []byte(v)
+
+	default:
+		return fmt.Errorf("mssqlx: unsupported type %T for encrypted field", src)
+	}
+
+	e := getFieldEncryptor()
+	if e == nil {
+		return ErrNoFieldEncryptor
+	}
+
+	plaintext, err := e.Decrypt(ciphertext)
+	if err != nil {
+		return err
+	}
+
+	dst := s.dst
+	if dst.Kind() == reflect.Ptr {
+		dst.Set(reflect.New(dst.Type().Elem()))
+		dst = dst.Elem()
+	}
+
+	switch {
+	case dst.Kind() == reflect.String:
+		dst.SetString(string(plaintext))
+
+	case dst.Type() == bytesType:
+		dst.SetBytes(plaintext)
+
+	default:
+		return json.Unmarshal(plaintext, dst.Addr().Interface())
+	}
+	return nil
+}
+
+var (
+	mapperNames  sync.Map // *reflectx.Mapper => func(string) string, name mapper given to MapperFunc
+	guardMappers sync.Map // *reflectx.Mapper => *reflectx.Mapper hiding encrypted fields
+)
+
+// guardMapper returns mapper m hiding encrypted fields, for rows returned to callers which StructScan them
+func guardMapper(m *reflectx.Mapper) *reflectx.Mapper {
+	if m == nil {
+		return nil
+	}
+
+	if g, ok := guardMappers.Load(m); ok {
+		return g.(*reflectx.Mapper)
+	}
+
+	nameMapper := sqlx.NameMapper
+	if f, ok := mapperNames.Load(m); ok {
+		nameMapper = f.(func(string) string)
+	}
+
+	g, _ := guardMappers.LoadOrStore(m, reflectx.NewMapperTagFunc("db", nameMapper, hideEncrypted))
+	return g.(*reflectx.Mapper)
+}
+
+// hideEncrypted maps tag of encrypted field to "-", skipping it
+func hideEncrypted(tag string) string {
+	if parts := strings.Split(tag, ","); len(parts) > 1 {
+		for _, opt := range parts[1:] {
+			if opt == encryptedTagOption {
+				return "-"
+			}
+		}
+	}
+	return tag
+}
+
+func guardRows(rows *sqlx.Rows) *sqlx.Rows {
+	if rows != nil {
+		rows.Mapper = guardMapper(rows.Mapper)
+	}
+	return rows
+}
+
+func guardRow(row *sqlx.Row) *sqlx.Row {
+	if row != nil {
+		row.Mapper = guardMapper(row.Mapper)
+	}
+	return row
+}
+
+// Keyring provides data keys of field encryption, i.e unwrapped by a KMS and cached.
+// Keys are 16, 24 or 32 bytes, selecting AES-128, AES-192 or AES-256.
+type Keyring interface {
+	// CurrentKey returns id and key encrypting new values.
+	CurrentKey() (id string, key []byte, err error)
+
+	// Key returns key of id decrypting values, i.e encrypted before key rotation.
+	Key(id string) ([]byte, error)
+}
+
+// StaticKeyring is a Keyring of keys held in memory.
+type StaticKeyring struct {
+	// Current id of key encrypting new values
+	Current string
+
+	// Keys by id
+	Keys map[string][]byte
+}
+
+// CurrentKey returns current key.
+func (k StaticKeyring) CurrentKey() (string, []byte, error) {
+	key, err := k.Key(k.Current)
+	return k.Current, key, err
+}
+
+// Key returns key of id.
+func (k StaticKeyring) Key(id string) ([]byte, error) {
+	if key, ok := k.Keys[id]; ok {
+		return key, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+type keyringEncryptor struct {
+	keyring Keyring
+}
+
+// NewKeyringEncryptor returns FieldEncryptor encrypting values by AES-GCM with keys of keyring.
+// Id of key is stored along with value, so that keys could be rotated: new values are encrypted with
+// current key, old ones are still decrypted with their keys.
+func NewKeyringEncryptor(keyring Keyring) FieldEncryptor {
+	return &keyringEncryptor{keyring: keyring}
+}
+
+// Encrypt returns version | len(id) | id | nonce | sealed plaintext
+func (e *keyringEncryptor) Encrypt(plaintext []byte) ([]byte, error) {
+	id, key, err := e.keyring.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > 255 {
+		return nil, fmt.Errorf("mssqlx: key id %q is too long", id)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 2+len(id)+aead.NonceSize(), 2+len(id)+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	out[0], out[1] = keyringCiphertextVersion, byte(len(id))
+	copy(out[2:], id)
+
+	nonce := out[2+len(id):]
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(out, nonce, plaintext, out[:2+len(id)]), nil
+}
+
+func (e *keyringEncryptor) Decrypt(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 2 || ciphertext[0] != keyringCiphertextVersion || len(ciphertext) < 2+int(ciphertext[1]) {
+		return nil, ErrInvalidCiphertext
+	}
+
+	header := ciphertext[:2+int(ciphertext[1])]
+	key, err := e.keyring.Key(string(header[2:]))
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := ciphertext[len(header):]
+	if len(rest) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	return aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], header)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,132 @@
+package mssqlx
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+type encryptedItem struct {
+	ID    int64             `db:"id"`
+	SSN   string            `db:"ssn,encrypted"`
+	Note  *string           `db:"note,encrypted"`
+	Attrs map[string]string `db:"attrs,encrypted"`
+}
+
+func TestKeyringEncryptor(t *testing.T) {
+	keyring := StaticKeyring{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}}
+	e := NewKeyringEncryptor(keyring)
+
+	ciphertext, err := e.Encrypt([]byte("secret"))
+	if err != nil || bytes.Contains(ciphertext, []byte("secret")) {
+		t.Fatal("KeyringEncryptor: unexpected ciphertext", ciphertext, err)
+	}
+
+	// rotation: old values are decrypted with their keys
+	keyring.Current, keyring.Keys["k2"] = "k2", bytes.Repeat([]byte{2}, 32)
+	e = NewKeyringEncryptor(keyring)
+	if plaintext, err := e.Decrypt(ciphertext); err != nil || string(plaintext) != "secret" {
+		t.Fatal("KeyringEncryptor: unexpected plaintext", plaintext, err)
+	}
+
+	ciphertext[len(ciphertext)-1] ^= 1
+	if _, err = e.Decrypt(ciphertext); err == nil {
+		t.Fatal("KeyringEncryptor: tampered ciphertext should fail")
+	}
+	if _, err = e.Decrypt([]byte{9}); err != ErrInvalidCiphertext {
+		t.Fatal("KeyringEncryptor: expected ErrInvalidCiphertext", err)
+	}
+
+	delete(keyring.Keys, "k1")
+	if ciphertext, err = NewKeyringEncryptor(StaticKeyring{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}}).Encrypt(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = e.Decrypt(ciphertext); err != ErrKeyNotFound {
+		t.Fatal("KeyringEncryptor: expected ErrKeyNotFound", err)
+	}
+}
+
+func TestEncryptedFields(t *testing.T) {
+	defer SetFieldEncryptor(nil)
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{"file:encrypted?mode=memory&cache=shared"}, nil)
+	defer dbs.Destroy()
+
+	if _, err := dbs.Exec("CREATE TABLE item (id INTEGER PRIMARY KEY, ssn BLOB, note BLOB, attrs BLOB)"); err != nil {
+		t.Fatal(err)
+	}
+
+	insert := "INSERT INTO item (id, ssn, note, attrs) VALUES (:id, :ssn, :note, :attrs)"
+	if _, err := dbs.NamedExec(insert, &encryptedItem{ID: 1, SSN: "123"}); err != ErrNoFieldEncryptor {
+		t.Fatal("EncryptedFields: expected ErrNoFieldEncryptor", err)
+	}
+
+	SetFieldEncryptor(NewKeyringEncryptor(StaticKeyring{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}}))
+
+	note := "hello"
+	if _, err := dbs.NamedExec(insert, &encryptedItem{ID: 1, SSN: "123", Note: &note, Attrs: map[string]string{"k": "v"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.UpsertBatch(context.Background(), "item", []encryptedItem{{ID: 2, SSN: "456"}}, []string{"id"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var raw []byte
+	if err := dbs.GetOnMaster(&raw, "SELECT ssn FROM item WHERE id = 1"); err != nil || bytes.Contains(raw, []byte("123")) {
+		t.Fatal("EncryptedFields: value should be stored encrypted", raw, err)
+	}
+
+	// batch
+	if _, err := dbs.NamedExec(insert, []encryptedItem{{ID: 4, SSN: "444"}, {ID: 5, SSN: "555"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbs.GetOnMaster(&raw, "SELECT ssn FROM item WHERE id = 5"); err != nil || bytes.Contains(raw, []byte("555")) {
+		t.Fatal("EncryptedFields: batch value should be stored encrypted", raw, err)
+	}
+	var batch []encryptedItem
+	if err := dbs.SelectOnMaster(&batch, "SELECT * FROM item WHERE id >= 4 ORDER BY id"); err != nil || len(batch) != 2 || batch[1].SSN != "555" {
+		t.Fatal("EncryptedFields: unexpected batch result", batch, err)
+	}
+
+	// StructScan could not decrypt
+	rows, err := dbs.QueryxOnMaster("SELECT * FROM item WHERE id = 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scanned encryptedItem
+	for rows.Next() {
+		if err = rows.StructScan(&scanned); err == nil {
+			t.Fatal("EncryptedFields: StructScan should not scan ciphertext", scanned)
+		}
+	}
+	_ = rows.Close()
+
+	row, _ := dbs.QueryRowxOnMaster("SELECT id FROM item WHERE id = 1")
+	if err = row.StructScan(&scanned); err != nil || scanned.ID != 1 {
+		t.Fatal("EncryptedFields: StructScan without encrypted columns should work", scanned, err)
+	}
+
+	var items []encryptedItem
+	if err := dbs.SelectOnMaster(&items, "SELECT * FROM item WHERE id < 3 ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].SSN != "123" || *items[0].Note != "hello" || items[0].Attrs["k"] != "v" ||
+		items[1].SSN != "456" || items[1].Note != nil {
+		t.Fatal("EncryptedFields: unexpected select result", items)
+	}
+
+	tx, err := dbs.BeginNestedTx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.NamedExec(insert, &encryptedItem{ID: 3, SSN: "789"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var it encryptedItem
+	if err = tx.Get(&it, "SELECT * FROM item WHERE id = 3"); err != nil || it.SSN != "789" {
+		t.Fatal("EncryptedFields: unexpected transaction get result", it, err)
+	}
+}
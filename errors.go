@@ -1,11 +1,9 @@
 package mssqlx
 
 import (
+	"context"
 	"database/sql/driver"
-	"fmt"
-	"os"
 	"strings"
-	"time"
 )
 
 // ERROR 1213: Deadlock found when trying to get lock
@@ -42,7 +40,7 @@ func parseError(w *wrapper, err error) error {
 		return nil
 	}
 
-	if w != nil && ping(w) != nil {
+	if w != nil && w.probe(context.Background(), DefaultHealthCheckTimeout) != nil {
 		return ErrNetwork
 	}
 
@@ -51,6 +49,12 @@ func parseError(w *wrapper, err error) error {
 
 func reportError(v string, err error) {
 	if err != nil {
-		os.Stderr.WriteString(fmt.Sprintf("mssqlx;;%s;;%s;;%s;;%s\n", time.Now().Format("2006-01-02 15:04:05"), hostName, v, err.Error()))
+		logEntry(LogLevelError, err.Error(), LogField{Key: LogFieldQuery, Value: v})
+	}
+}
+
+func reportNodeError(w *wrapper, v string, err error) {
+	if err != nil {
+		logEntry(LogLevelError, err.Error(), nodeFields(w, LogField{Key: LogFieldQuery, Value: v})...)
 	}
 }
@@ -0,0 +1,98 @@
+package mssqlx
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultEventHistorySize default number of node events kept in memory
+	DefaultEventHistorySize = 256
+)
+
+// NodeState is health state of a node.
+type NodeState string
+
+const (
+	// NodeStateUp node is healthy and serving traffic
+	NodeStateUp NodeState = "up"
+
+	// NodeStateDown node is failed and under health tracking
+	NodeStateDown NodeState = "down"
+
+	// NodeStateQuarantined node is taken out of traffic on purpose
+	NodeStateQuarantined NodeState = "quarantined"
+
+	// NodeStateDraining node is replaced by SwitchCluster and waits for work in progress
+	NodeStateDraining NodeState = "draining"
+
+	// NodeStateRemoved node is replaced by SwitchCluster and closed
+	NodeStateRemoved NodeState = "removed"
+)
+
+// NodeEvent is a state transition of a node.
+type NodeEvent struct {
+	Time  time.Time `json:"time"`
+	Node  string    `json:"node"`
+	Role  Role      `json:"role"`
+	From  NodeState `json:"from"`
+	To    NodeState `json:"to"`
+	Cause string    `json:"cause,omitempty"`
+}
+
+// bounded history of node events
+type eventLog struct {
+	mu     sync.Mutex
+	events []NodeEvent
+	next   int
+	full   bool
+}
+
+func newEventLog(size int) *eventLog {
+	if size <= 0 {
+		size = DefaultEventHistorySize
+	}
+	return &eventLog{events: make([]NodeEvent, size)}
+}
+
+func (l *eventLog) record(w *wrapper, from, to NodeState, cause error) {
+	if l == nil || w == nil {
+		return
+	}
+
+	ev := NodeEvent{Time: time.Now(), Node: w.getName(), Role: w.getRole(), From: from, To: to}
+	if cause != nil {
+		ev.Cause = cause.Error()
+	}
+
+	l.mu.Lock()
+	l.events[l.next] = ev
+	if l.next++; l.next == len(l.events) {
+		l.next, l.full = 0, true
+	}
+	l.mu.Unlock()
+}
+
+// list events, oldest first
+func (l *eventLog) list() []NodeEvent {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.full {
+		return append([]NodeEvent(nil), l.events[:l.next]...)
+	}
+
+	result := make([]NodeEvent, 0, len(l.events))
+	result = append(result, l.events[l.next:]...)
+	return append(result, l.events[:l.next]...)
+}
+
+// Events returns recent state transitions of nodes (up/down/quarantined/draining/removed), oldest first.
+// At most DefaultEventHistorySize events are kept.
+func (dbs *DBs) Events() []NodeEvent {
+	return dbs.events.list()
+}
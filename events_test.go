@@ -0,0 +1,51 @@
+package mssqlx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestEventLog(t *testing.T) {
+	var nilLog *eventLog
+	nilLog.record(&wrapper{}, NodeStateUp, NodeStateDown, nil)
+	if nilLog.list() != nil {
+		t.Fatal("EventLog: nil log should be empty")
+	}
+
+	l := newEventLog(2)
+	if len(l.list()) != 0 {
+		t.Fatal("EventLog: should be empty")
+	}
+
+	for _, name := range []string{"a", "b", "c"} {
+		w := &wrapper{}
+		w.setName(name)
+		w.setRole(RoleSlave)
+		l.record(w, NodeStateUp, NodeStateDown, errors.New(name))
+	}
+
+	events := l.list()
+	if len(events) != 2 || events[0].Node != "b" || events[1].Node != "c" || events[1].Cause != "c" {
+		t.Fatal("EventLog: should keep latest events, oldest first", events)
+	}
+
+	dsn := "user=test1 dbname=test1 sslmode=disable"
+	db, _ := sqlx.Open("postgres", dsn)
+
+	b := newBalancer(nil, 0, false)
+	defer b.destroy()
+
+	b.events = newEventLog(0)
+	w := newWrapper(db, dsn, RoleMaster, 0)
+	b.add(w)
+
+	b.failureWithCause(w, ErrNetwork)
+	b.failureWithCause(w, ErrNetwork) // already failed
+
+	dbs := &DBs{events: b.events}
+	if events = dbs.Events(); len(events) != 1 || events[0].From != NodeStateUp || events[0].To != NodeStateDown || events[0].Cause != ErrNetwork.Error() {
+		t.Fatal("EventLog: failure should be recorded", events)
+	}
+}
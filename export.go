@@ -0,0 +1,199 @@
+package mssqlx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrInvalidExportFormat export format is neither csv nor jsonl
+	ErrInvalidExportFormat = errors.New("Invalid export format, must be csv or jsonl")
+)
+
+// ExportFormat is output format of Export.
+type ExportFormat string
+
+const (
+	// ExportCSV writes a header line of column names, then a line per row
+	ExportCSV ExportFormat = "csv"
+
+	// ExportJSONL writes a JSON object per row, keyed by column names
+	ExportJSONL ExportFormat = "jsonl"
+)
+
+// exportValue converts scanned value into its text form, ok is false for NULL.
+func exportValue(v interface{}) (s string, ok bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case []byte:
+		return string(v), true
+	case string:
+		return v, true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	}
+	return fmt.Sprint(v), true
+}
+
+type exportWriter interface {
+	header(columns []string) error
+	row(values []interface{}) error
+	flush() error
+}
+
+type csvExportWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (e *csvExportWriter) header(columns []string) error {
+	e.record = make([]string, len(columns))
+	return e.w.Write(columns)
+}
+
+func (e *csvExportWriter) row(values []interface{}) error {
+	for i, v := range values {
+		e.record[i], _ = exportValue(v)
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvExportWriter) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type jsonlExportWriter struct {
+	w    *bufio.Writer
+	keys [][]byte
+	buf  bytes.Buffer
+}
+
+func (e *jsonlExportWriter) header(columns []string) (err error) {
+	e.keys = make([][]byte, len(columns))
+	for i, column := range columns {
+		if e.keys[i], err = json.Marshal(column); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (e *jsonlExportWriter) row(values []interface{}) error {
+	e.buf.Reset()
+	e.buf.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		e.buf.Write(e.keys[i])
+		e.buf.WriteByte(':')
+
+		var (
+			b   []byte
+			err error
+		)
+		switch v := v.(type) {
+		case []byte: // text, rather than base64
+			b, err = json.Marshal(string(v))
+		default:
+			b, err = json.Marshal(v)
+		}
+		if err != nil {
+			return err
+		}
+		e.buf.Write(b)
+	}
+	e.buf.WriteString("}\n")
+
+	_, err := e.w.Write(e.buf.Bytes())
+	return err
+}
+
+func (e *jsonlExportWriter) flush() error {
+	return e.w.Flush()
+}
+
+func newExportWriter(w io.Writer, format ExportFormat) exportWriter {
+	switch format {
+	case ExportCSV:
+		return &csvExportWriter{w: csv.NewWriter(w)}
+
+	case ExportJSONL:
+		return &jsonlExportWriter{w: bufio.NewWriter(w)}
+	}
+	return nil
+}
+
+func _export(ctx context.Context, target *balancer, w io.Writer, format ExportFormat, query string, args ...interface{}) (err error) {
+	ew := newExportWriter(w, format)
+	if ew == nil {
+		return ErrInvalidExportFormat
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	_, rows, err := _query(ctx, target, query, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return
+	}
+
+	if err = ew.header(columns); err != nil {
+		return
+	}
+
+	values, ptrs := make([]interface{}, len(columns)), make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(ptrs...); err != nil {
+			return
+		}
+
+		// writing blocks on slow consumers, so that rows are read no faster than written
+		if err = ew.row(values); err != nil {
+			return
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+	return ew.flush()
+}
+
+// Export streams result set of query on slaves into w as CSV or JSON lines, useful for
+// data-export endpoints and offloading dumps to replicas. Rows are read as fast as w consumes them.
+func (dbs *DBs) Export(ctx context.Context, w io.Writer, format ExportFormat, query string, args ...interface{}) error {
+	return _export(ctx, dbs.slaves, w, format, query, args...)
+}
+
+// ExportOnMaster streams result set of query on masters into w as CSV or JSON lines.
+func (dbs *DBs) ExportOnMaster(ctx context.Context, w io.Writer, format ExportFormat, query string, args ...interface{}) error {
+	return _export(ctx, dbs.masters, w, format, query, args...)
+}
@@ -0,0 +1,34 @@
+package mssqlx
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	query := `SELECT 1 AS id, 'a,"b"' AS name, NULL AS note, 1.5 AS score UNION ALL SELECT 2, 'c', 'x', 2`
+
+	var buf bytes.Buffer
+	if err := dbs.Export(context.Background(), &buf, ExportCSV, query); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != "id,name,note,score\n1,\"a,\"\"b\"\"\",,1.5\n2,c,x,2\n" {
+		t.Fatal("Export: csv fail", s)
+	}
+
+	buf.Reset()
+	if err := dbs.ExportOnMaster(context.Background(), &buf, ExportJSONL, query); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != "{\"id\":1,\"name\":\"a,\\\"b\\\"\",\"note\":null,\"score\":1.5}\n{\"id\":2,\"name\":\"c\",\"note\":\"x\",\"score\":2}\n" {
+		t.Fatal("Export: jsonl fail", s)
+	}
+
+	if err := dbs.Export(context.Background(), &buf, "xml", query); err != ErrInvalidExportFormat {
+		t.Fatal("Export: invalid format fail", err)
+	}
+}
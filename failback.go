@@ -0,0 +1,129 @@
+package mssqlx
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultFailbackAfter default number of consecutive healthy checks required before failing back to preferred primary
+	DefaultFailbackAfter = 3
+)
+
+// PreferredPrimary designates one of masters as primary: writes go to it whenever it is eligible,
+// other masters only take over when it fails.
+//
+// After a failover, writes move back to the preferred primary only after FailbackAfter consecutive
+// healthy checks and, if provided, Approve returns true.
+//
+// Pass it as an arg of ConnectMasterSlaves.
+type PreferredPrimary struct {
+	// Index of preferred primary in masterDSNs
+	Index int
+
+	// FailbackAfter number of consecutive healthy checks required before failing back. Default is DefaultFailbackAfter.
+	FailbackAfter int
+
+	// Approve is an optional manual approval hook, called with node name before failing back.
+	Approve func(node string) bool
+}
+
+type preferredPrimary struct {
+	w        *wrapper
+	after    int
+	approve  func(node string) bool
+	eligible int32
+	streak   int
+	ctx      context.Context // done once failback watcher is stopped
+	cancel   context.CancelFunc
+}
+
+func (p *preferredPrimary) isEligible() bool {
+	return atomic.LoadInt32(&p.eligible) == 1
+}
+
+// setPreferred designates w as preferred primary and starts failback watcher, stopping watcher of previous one.
+func (c *balancer) setPreferred(w *wrapper, opt *PreferredPrimary) {
+	after := opt.FailbackAfter
+	if after <= 0 {
+		after = DefaultFailbackAfter
+	}
+
+	p := &preferredPrimary{w: w, after: after, approve: opt.Approve, eligible: 1}
+	p.ctx, p.cancel = context.WithCancel(c.ctx)
+
+	c.clearPreferred()
+	c.preferred.Store(p)
+	go c.watchFailback(p)
+}
+
+// clearPreferred drops preferred primary and stops its failback watcher.
+func (c *balancer) clearPreferred() {
+	if p := c.loadPreferred(); p != nil {
+		p.cancel()
+		c.preferred.Store((*preferredPrimary)(nil))
+	}
+}
+
+func (c *balancer) loadPreferred() *preferredPrimary {
+	p, _ := c.preferred.Load().(*preferredPrimary)
+	return p
+}
+
+// get db respecting preferred primary
+func (c *balancer) getPreferred() *wrapper {
+	p := c.loadPreferred()
+	if p == nil {
+		return c.get(c.isMulti())
+	}
+
+	if p.isEligible() && c.dbs.contains(p.w) {
+		return p.w
+	}
+
+	// skip preferred primary while failing back
+	for i, n := 0, c.size(); i < n; i++ {
+		if w := c.get(c.isMulti()); w != p.w {
+			return w
+		}
+	}
+
+	// preferred primary is the only healthy one
+	return c.get(c.isMulti())
+}
+
+func (c *balancer) demotePreferred(w *wrapper) {
+	if p := c.loadPreferred(); p != nil && p.w == w {
+		atomic.StoreInt32(&p.eligible, 0)
+	}
+}
+
+// watchFailback tracks health of preferred primary after failover and fails back when allowed
+func (c *balancer) watchFailback(p *preferredPrimary) {
+	doneCh := p.ctx.Done()
+
+	for {
+		select {
+		case <-doneCh:
+			return
+
+		case <-time.After(time.Duration(c.getHealthCheckPeriod()) * time.Millisecond):
+		}
+
+		if p.isEligible() {
+			continue
+		}
+
+		if !c.dbs.contains(p.w) || c.probe(p.w) != nil || !p.w.checkReady(c.isWsrep) {
+			p.streak = 0
+			continue
+		}
+
+		if p.streak++; p.streak >= p.after && (p.approve == nil || p.approve(p.w.getName())) {
+			p.streak = 0
+			atomic.StoreInt32(&p.eligible, 1)
+			logEntry(LogLevelInfo, "failback to preferred primary", nodeFields(p.w)...)
+		}
+	}
+}
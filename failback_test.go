@@ -0,0 +1,57 @@
+package mssqlx
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPreferredPrimary(t *testing.T) {
+	var approved int32
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:", ":memory:", ":memory:"}, nil, PreferredPrimary{
+		Index:         1,
+		FailbackAfter: 2,
+		Approve: func(node string) bool {
+			return node == "master-1" && atomic.LoadInt32(&approved) == 1
+		},
+	})
+	defer dbs.Destroy()
+	dbs.SetMasterHealthCheckPeriod(5)
+
+	servedBy := func() string {
+		var info QueryInfo
+		if _, err := dbs.ExecContext(WithQueryInfo(context.Background(), &info), "SELECT 1"); err != nil {
+			t.Fatal(err)
+		}
+		return info.Node
+	}
+
+	for i := 0; i < 5; i++ {
+		if node := servedBy(); node != "master-1" {
+			t.Fatal("PreferredPrimary: writes should go to preferred primary", node)
+		}
+	}
+
+	dbs.masters.failure(dbs.masterNodes()[1])
+	time.Sleep(50 * time.Millisecond) // recovered by health checker, but failback is not approved yet
+
+	if !dbs.masters.dbs.contains(dbs.masterNodes()[1]) {
+		t.Fatal("PreferredPrimary: preferred primary should be recovered")
+	}
+	for i := 0; i < 5; i++ {
+		if node := servedBy(); node == "master-1" {
+			t.Fatal("PreferredPrimary: should not failback without approval")
+		}
+	}
+
+	atomic.StoreInt32(&approved, 1)
+	for i := 0; i < 200 && !dbs.masters.loadPreferred().isEligible(); i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if node := servedBy(); node != "master-1" {
+		t.Fatal("PreferredPrimary: should failback after approval", node)
+	}
+}
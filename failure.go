@@ -0,0 +1,143 @@
+package mssqlx
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// DefaultFailureThreshold number of consecutive connection-level failures before a node is evicted
+	DefaultFailureThreshold = 3
+)
+
+// EvictionPolicy decides when nodes failing with connection-level errors are taken out of rotation.
+type EvictionPolicy int32
+
+const (
+	// EvictOnThreshold evicts node after failure threshold is reached. This is default policy.
+	EvictOnThreshold EvictionPolicy = iota
+
+	// EvictImmediately evicts node on its first failure.
+	EvictImmediately
+
+	// EvictNever keeps failing nodes in rotation, errors are returned to caller without
+	// retrying on another node. Useful when callers have their own circuit breaker.
+	EvictNever
+)
+
+// isConnectionError reports whether err indicates that connection to node is lost or node is shutting down,
+// as opposed to statement-level errors (syntax, constraint, statement timeout, cancellation...)
+// which never take node out of rotation.
+//
+// ERROR 1053: Server shutdown in progress
+// ERROR 2002/2003: Can't connect to MySQL server
+// ERROR 2006: MySQL server has gone away
+// ERROR 2013: Lost connection to MySQL server during query
+// SQLSTATE class 08: connection exception, 57P01-57P03: admin/crash shutdown, cannot connect now (postgres)
+func isConnectionError(err error) bool {
+	if isNetworkError(err) {
+		return true
+	}
+
+	if pe, ok := err.(*pq.Error); ok {
+		code := string(pe.Code)
+		return strings.HasPrefix(code, "08") || code == "57P01" || code == "57P02" || code == "57P03"
+	}
+
+	se := err.Error()
+	for _, code := range []string{"1053:", "2002:", "2003:", "2006:", "2013:"} {
+		if strings.HasPrefix(se, "Error "+code) || strings.HasPrefix(se, "ERROR "+code) {
+			return true
+		}
+	}
+	return strings.Contains(se, "database is closed")
+}
+
+// recordFailure counts a consecutive failure of node, returns number of consecutive failures.
+// Count restarts if previous failure is older than window (if window > 0).
+func (w *wrapper) recordFailure(window time.Duration) int32 {
+	now := time.Now().UnixNano()
+	if last := atomic.SwapInt64(&w.failedAt, now); window > 0 && now-last > int64(window) {
+		atomic.StoreInt32(&w.failures, 1)
+		return 1
+	}
+	return atomic.AddInt32(&w.failures, 1)
+}
+
+// resetFailures resets consecutive failures of node after a success
+func (w *wrapper) resetFailures() {
+	if atomic.LoadInt32(&w.failures) != 0 { // avoid writing shared cache line on hot path
+		atomic.StoreInt32(&w.failures, 0)
+	}
+}
+
+func (c *balancer) getFailureThreshold() (int32, time.Duration) {
+	n := atomic.LoadInt32(&c.failureThreshold)
+	if n <= 0 {
+		n = DefaultFailureThreshold
+	}
+	return n, time.Duration(atomic.LoadInt64(&c.failureWindow))
+}
+
+func (c *balancer) setFailureThreshold(n int, window time.Duration) {
+	if n <= 0 {
+		n = DefaultFailureThreshold
+	}
+	atomic.StoreInt32(&c.failureThreshold, int32(n))
+	atomic.StoreInt64(&c.failureWindow, int64(window))
+}
+
+func (c *balancer) getEvictionPolicy() EvictionPolicy {
+	return EvictionPolicy(atomic.LoadInt32(&c.evictionPolicy))
+}
+
+func (c *balancer) setEvictionPolicy(p EvictionPolicy) {
+	atomic.StoreInt32(&c.evictionPolicy, int32(p))
+}
+
+// countFailure records a failure of w and evicts node according to eviction policy.
+// Returns true if caller should retry on another node.
+func (c *balancer) countFailure(w *wrapper, cause error) bool {
+	if c.isSingle() { // nowhere to retry
+		return false
+	}
+
+	policy := c.getEvictionPolicy()
+	if policy == EvictNever {
+		return false
+	}
+
+	if isRoutingDebug() { // retried on another node
+		debugSkipped(context.Background(), w, RoutingUnhealthy)
+	}
+
+	if policy == EvictImmediately {
+		c.failureWithCause(w, cause)
+		return true
+	}
+
+	if threshold, window := c.getFailureThreshold(); w.recordFailure(window) >= threshold {
+		c.failureWithCause(w, cause)
+	}
+	return true
+}
+
+// SetFailureThreshold sets number of consecutive connection-level failures of a node, each within window
+// from previous one, before node is evicted under EvictOnThreshold policy. Until then, node stays in rotation
+// and failed queries are retried on another node. If window <= 0, failures are counted until a query succeeds.
+//
+// Default is DefaultFailureThreshold failures without window.
+func (dbs *DBs) SetFailureThreshold(n int, window time.Duration) {
+	dbs.masters.setFailureThreshold(n, window)
+	dbs.slaves.setFailureThreshold(n, window)
+}
+
+// SetEvictionPolicy sets how aggressively failing nodes are taken out of rotation. Default is EvictOnThreshold.
+func (dbs *DBs) SetEvictionPolicy(p EvictionPolicy) {
+	dbs.masters.setEvictionPolicy(p)
+	dbs.slaves.setEvictionPolicy(p)
+}
@@ -0,0 +1,132 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	for _, err := range []error{
+		driver.ErrBadConn,
+		errors.New("Error 2006: MySQL server has gone away"),
+		errors.New("Error 1053: Server shutdown in progress"),
+		&pq.Error{Code: "57P01"},
+		&pq.Error{Code: "08006"},
+		errors.New("sql: database is closed"),
+	} {
+		if !isConnectionError(err) {
+			t.Fatal("IsConnectionError: should be connection error", err)
+		}
+	}
+
+	for _, err := range []error{
+		context.DeadlineExceeded,
+		context.Canceled,
+		errors.New("Error 3024: Query execution was interrupted, maximum statement execution time exceeded"),
+		&pq.Error{Code: "57014"}, // statement timeout
+		&pq.Error{Code: "23505"},
+		errors.New("syntax error"),
+	} {
+		if isConnectionError(err) {
+			t.Fatal("IsConnectionError: should not be connection error", err)
+		}
+	}
+}
+
+func TestFailureThreshold(t *testing.T) {
+	dsn := "user=test1 dbname=test1 sslmode=disable host=127.0.0.1 port=1"
+	db, _ := sqlx.Open("postgres", dsn)
+	defer db.Close()
+
+	b := newBalancer(nil, 0, false)
+	defer b.destroy()
+
+	w := newWrapper(db, dsn, RoleMaster, 0)
+	b.add(w)
+
+	// statement-level error on unreachable node does not count
+	if shouldFailure(w, false, context.DeadlineExceeded) {
+		t.Fatal("FailureThreshold: statement timeout should not fail node")
+	}
+
+	err := driver.ErrBadConn
+	for i := 1; i < DefaultFailureThreshold; i++ {
+		if !shouldFailure(w, false, err) {
+			t.Fatal("FailureThreshold: unreachable node should fail")
+		}
+		b.countFailure(w, err)
+		if b.size() != 1 {
+			t.Fatal("FailureThreshold: node should stay before reaching threshold", i)
+		}
+	}
+
+	// success resets consecutive failures
+	shouldFailure(w, false, nil)
+	b.countFailure(w, err)
+	if b.size() != 1 {
+		t.Fatal("FailureThreshold: success should reset failures")
+	}
+
+	for i := 1; i < DefaultFailureThreshold; i++ {
+		b.countFailure(w, err)
+	}
+	if b.size() != 0 {
+		t.Fatal("FailureThreshold: node should be evicted after threshold")
+	}
+}
+
+func TestEvictionPolicy(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:", ":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	b, w := dbs.slaves, dbs.slaveNodes()[0]
+	err := driver.ErrBadConn
+
+	if n, window := b.getFailureThreshold(); n != DefaultFailureThreshold || window != 0 {
+		t.Fatal("EvictionPolicy: default threshold fail", n, window)
+	}
+
+	dbs.SetEvictionPolicy(EvictNever)
+	for i := 0; i < 5; i++ {
+		if b.countFailure(w, err) || b.size() != 1 {
+			t.Fatal("EvictionPolicy: never policy should keep node and not retry")
+		}
+	}
+
+	// failures apart more than window are not consecutive
+	dbs.SetEvictionPolicy(EvictOnThreshold)
+	dbs.SetFailureThreshold(2, time.Nanosecond)
+	w.resetFailures()
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+		if !b.countFailure(w, err) || b.size() != 1 {
+			t.Fatal("EvictionPolicy: failures out of window should not evict")
+		}
+	}
+
+	dbs.SetFailureThreshold(2, time.Minute)
+	if b.countFailure(w, err); downEvents(dbs) != 1 { // node might be back already
+		t.Fatal("EvictionPolicy: threshold policy should evict")
+	}
+
+	dbs.SetEvictionPolicy(EvictImmediately)
+	m := dbs.masterNodes()[0]
+	if !dbs.masters.countFailure(m, err) || downEvents(dbs) != 2 {
+		t.Fatal("EvictionPolicy: immediate policy should evict")
+	}
+}
+
+func downEvents(dbs *DBs) (n int) {
+	for _, e := range dbs.Events() {
+		if e.To == NodeStateDown {
+			n++
+		}
+	}
+	return
+}
@@ -0,0 +1,86 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+var (
+	// ErrNodeNotFound there is no node with given name
+	ErrNodeNotFound = errors.New("Node not found")
+)
+
+// findNode by name (i.e master-0, slave-1) or dsn
+func (dbs *DBs) findNode(name string) *wrapper {
+	for _, w := range dbs.allNodes() {
+		if w != nil && (w.getName() == name || w.dsn == name) {
+			return w
+		}
+	}
+	return nil
+}
+
+func (w *wrapper) isFenced() bool {
+	return atomic.LoadInt32(&w.fenced) == 1
+}
+
+// FenceNode synchronously stops all traffic to node (i.e master-0, slave-1) and waits for its
+// in-flight queries, open rows, transactions and Conns to finish until ctx is done. Fenced node is quarantined: it is not
+// recovered by health checker until UnfenceNode is called.
+//
+// Intended to be called by external failover orchestrators before they demote a primary.
+// If they are not finished in time, ctx.Err() is returned and node stays fenced.
+func (dbs *DBs) FenceNode(ctx context.Context, name string) error {
+	w := dbs.findNode(name)
+	if w == nil {
+		return ErrNodeNotFound
+	}
+
+	target, err := dbs.getBalancer(w.getRole())
+	if err != nil {
+		return err
+	}
+
+	if atomic.CompareAndSwapInt32(&w.fenced, 0, 1) {
+		from := NodeStateDown
+		if target.dbs.remove(w) {
+			from = NodeStateUp
+		}
+		target.events.record(w, from, NodeStateQuarantined, nil)
+		logEntry(LogLevelWarn, "node is fenced", nodeFields(w)...)
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// wait for in-flight queries, open rows, transactions and Conns
+	if !w.waitDrained(ctx.Done()) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// UnfenceNode puts a fenced node back to traffic.
+func (dbs *DBs) UnfenceNode(name string) error {
+	w := dbs.findNode(name)
+	if w == nil {
+		return ErrNodeNotFound
+	}
+
+	target, err := dbs.getBalancer(w.getRole())
+	if err != nil {
+		return err
+	}
+
+	if atomic.CompareAndSwapInt32(&w.fenced, 1, 0) {
+		target.events.record(w, NodeStateQuarantined, NodeStateDown, nil)
+		logEntry(LogLevelInfo, "node is unfenced", nodeFields(w)...)
+
+		// health checker will put it back when it is healthy
+		target.sendFailure(w)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFenceNode(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:", ":memory:"}, nil)
+	defer dbs.Destroy()
+	dbs.SetMasterHealthCheckPeriod(5)
+
+	if err := dbs.FenceNode(context.Background(), "master-9"); err != ErrNodeNotFound {
+		t.Fatal("FenceNode: not found check fail")
+	}
+	if err := dbs.UnfenceNode("master-9"); err != ErrNodeNotFound {
+		t.Fatal("UnfenceNode: not found check fail")
+	}
+
+	// in-flight query holds fencing until deadline
+	w := dbs.findNode("master-0")
+	_ = w.limiter.acquire(context.Background(), PriorityNormal)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := dbs.FenceNode(ctx, "master-0"); err != context.DeadlineExceeded {
+		t.Fatal("FenceNode: should wait for in-flight queries", err)
+	}
+	w.limiter.release()
+
+	if err := dbs.FenceNode(context.Background(), "master-0"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		var info QueryInfo
+		if _, err := dbs.ExecContext(WithQueryInfo(context.Background(), &info), "SELECT 1"); err != nil || info.Node != "master-1" {
+			t.Fatal("FenceNode: traffic should not go to fenced node", info.Node, err)
+		}
+	}
+
+	if events := dbs.Events(); len(events) != 1 || events[0].To != NodeStateQuarantined {
+		t.Fatal("FenceNode: should be recorded", events)
+	}
+
+	if err := dbs.UnfenceNode("master-0"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100 && !dbs.masters.dbs.contains(w); i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !dbs.masters.dbs.contains(w) {
+		t.Fatal("UnfenceNode: node should be back to traffic")
+	}
+}
+
+func TestFenceNodeOpenTx(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:", ":memory:"}, nil)
+	defer dbs.Destroy()
+
+	tx, err := dbs.BeginNestedTx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := tx.state.node.getName()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err = dbs.FenceNode(ctx, node); err != context.DeadlineExceeded {
+		t.Fatal("FenceNode: should wait for open transaction", err)
+	}
+	_ = dbs.UnfenceNode(node)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = tx.Commit()
+	}()
+
+	startedAt := time.Now()
+	if err = dbs.FenceNode(context.Background(), node); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(startedAt) < 20*time.Millisecond {
+		t.Fatal("FenceNode: should wait until transaction is committed")
+	}
+}
@@ -0,0 +1,170 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// ServerFlavor is flavor of mysql compatible server, which decides health and lag queries of node.
+//
+// Flavor is detected when node is checked first. Pass a ServerFlavor as an arg of ConnectMasterSlaves
+// to override detection for all nodes.
+type ServerFlavor string
+
+const (
+	// FlavorMySQL MySQL with asynchronous replication
+	FlavorMySQL ServerFlavor = "mysql"
+
+	// FlavorMariaDB MariaDB with asynchronous replication
+	FlavorMariaDB ServerFlavor = "mariadb"
+
+	// FlavorPercona Percona Server with asynchronous replication
+	FlavorPercona ServerFlavor = "percona"
+
+	// FlavorGalera Galera cluster, i.e MariaDB Galera or Percona XtraDB Cluster. Node is healthy once wsrep is ready
+	FlavorGalera ServerFlavor = "galera"
+
+	// FlavorGroupReplication MySQL group replication. Node is healthy once it is an online group member
+	FlavorGroupReplication ServerFlavor = "group_replication"
+)
+
+// flavorInfo detected flavor and version of node
+type flavorInfo struct {
+	flavor  ServerFlavor
+	version string
+}
+
+// getFlavor returns flavor of node, detecting it if not yet. Flavor is unknown if detection fails.
+func (w *wrapper) getFlavor(ctx context.Context) flavorInfo {
+	if info, ok := w.flavor.Load().(flavorInfo); ok {
+		return info
+	}
+
+	info, err := detectFlavor(ctx, w.db.DB)
+	if err != nil {
+		reportNodeError(w, "detect server flavor", err)
+		return flavorInfo{flavor: w.flavorOverride}
+	}
+
+	if w.flavorOverride != "" {
+		info.flavor = w.flavorOverride
+	}
+	w.flavor.Store(info)
+	return info
+}
+
+// detectedFlavor returns flavor of node if it is already detected
+func (w *wrapper) detectedFlavor() ServerFlavor {
+	info, _ := w.flavor.Load().(flavorInfo)
+	return info.flavor
+}
+
+func detectFlavor(ctx context.Context, db *sql.DB) (info flavorInfo, err error) {
+	var comment string
+	if err = db.QueryRowContext(ctx, "SELECT VERSION(), @@version_comment").Scan(&info.version, &comment); err != nil {
+		return
+	}
+
+	var name, wsrep string
+	switch err = db.QueryRowContext(ctx, "SHOW VARIABLES LIKE 'wsrep_on'").Scan(&name, &wsrep); {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return
+	case wsrep == "ON":
+		info.flavor = FlavorGalera
+		return info, nil
+	}
+
+	if strings.Contains(info.version, "MariaDB") {
+		info.flavor = FlavorMariaDB
+		return info, nil
+	}
+
+	// performance_schema might be disabled or predate group replication
+	var members int
+	if db.QueryRowContext(ctx, "SELECT COUNT(*) FROM performance_schema.replication_group_members "+
+		"WHERE CHANNEL_NAME = 'group_replication_applier' AND MEMBER_ID = @@server_uuid").Scan(&members) != nil {
+		members = 0
+	}
+
+	switch {
+	case members > 0:
+		info.flavor = FlavorGroupReplication
+
+	case strings.Contains(comment, "Percona"):
+		info.flavor = FlavorPercona
+
+	default:
+		info.flavor = FlavorMySQL
+	}
+	return info, nil
+}
+
+// checkReady checks whether node is ready for application use, by flavor of node.
+// Nodes of unknown flavor are checked for wsrep if isWsrep.
+func (w *wrapper) checkReady(isWsrep bool) bool {
+	if w.db.DriverName() == "mysql" {
+		switch w.getFlavor(context.Background()).flavor {
+		case FlavorGalera:
+			return w.checkWsrepReady()
+
+		case FlavorGroupReplication:
+			return w.checkGroupMemberOnline()
+		}
+	}
+	return !isWsrep || w.checkWsrepReady()
+}
+
+func (w *wrapper) checkGroupMemberOnline() bool {
+	var state string
+	if err := w.db.Get(&state, "SELECT MEMBER_STATE FROM performance_schema.replication_group_members "+
+		"WHERE CHANNEL_NAME = 'group_replication_applier' AND MEMBER_ID = @@server_uuid"); err != nil || state != "ONLINE" {
+		reportNodeError(w, "check group replication member", err)
+		return false
+	}
+	return true
+}
+
+// measureMySQLLag measures replication lag of mysql compatible node by its flavor
+func measureMySQLLag(ctx context.Context, w *wrapper) (time.Duration, bool, error) {
+	info := w.getFlavor(ctx)
+
+	if info.flavor == FlavorGroupReplication {
+		var seconds sql.NullFloat64
+		if err := w.db.GetContext(ctx, &seconds, "SELECT MAX(TIMESTAMPDIFF(MICROSECOND, LAST_APPLIED_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP, "+
+			"LAST_APPLIED_TRANSACTION_END_APPLY_TIMESTAMP)) / 1000000 FROM performance_schema.replication_applier_status_by_worker "+
+			"WHERE CHANNEL_NAME = 'group_replication_applier'"); err != nil {
+			return 0, false, err
+		}
+		return time.Duration(seconds.Float64 * float64(time.Second)), true, nil
+	}
+
+	// SHOW SLAVE STATUS is removed since mysql 8.4
+	query, column := "SHOW SLAVE STATUS", "Seconds_Behind_Master"
+	if (info.flavor == FlavorMySQL || info.flavor == FlavorPercona) && compareVersions(info.version, "8.0.22") >= 0 {
+		query, column = "SHOW REPLICA STATUS", "Seconds_Behind_Source"
+	}
+
+	rows, err := w.db.QueryxContext(ctx, query)
+	if err != nil {
+		return 0, false, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() { // not a replica
+		return 0, true, rows.Err()
+	}
+
+	status := make(map[string]interface{})
+	if err = rows.MapScan(status); err != nil {
+		return 0, false, err
+	}
+
+	var seconds sql.NullInt64
+	if err = seconds.Scan(status[column]); err != nil || !seconds.Valid { // replication is stopped
+		return 0, false, err
+	}
+	return time.Duration(seconds.Int64) * time.Second, true, nil
+}
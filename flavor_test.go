@@ -0,0 +1,42 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestServerFlavor(t *testing.T) {
+	if opts := parseConnectArgs([]interface{}{FlavorGroupReplication}); opts.flavor != FlavorGroupReplication {
+		t.Fatal("ServerFlavor: parsing arg fail", opts.flavor)
+	}
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil, FlavorGalera)
+	defer dbs.Destroy()
+
+	w := dbs.masterNodes()[0]
+	if w.flavorOverride != FlavorGalera || !w.checkReady(false) {
+		t.Fatal("ServerFlavor: non mysql node should be ready")
+	}
+
+	// detection fails, override is used without caching
+	if info := w.getFlavor(context.Background()); info.flavor != FlavorGalera || w.detectedFlavor() != "" {
+		t.Fatal("ServerFlavor: override fail", info)
+	}
+
+	if TestWMysql {
+		w := myDBs.masterNodes()[0]
+		switch info := w.getFlavor(context.Background()); info.flavor {
+		case FlavorMySQL, FlavorMariaDB, FlavorPercona, FlavorGalera, FlavorGroupReplication:
+			if info.version == "" || w.detectedFlavor() != info.flavor {
+				t.Fatal("ServerFlavor: detection fail", info)
+			}
+
+		default:
+			t.Fatal("ServerFlavor: detection fail", info)
+		}
+
+		if _, _, err := measureMySQLLag(context.Background(), w); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
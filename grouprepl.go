@@ -0,0 +1,194 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrGroupReplicationNotSupported tracking group replication is only supported by mysql driver
+	ErrGroupReplicationNotSupported = errors.New("Tracking group replication is only supported by mysql driver")
+
+	// ErrNoGroupPrimary group view has no online primary known as a node
+	ErrNoGroupPrimary = errors.New("Group has no online primary among nodes")
+)
+
+const (
+	// DefaultGroupCheckPeriod default period of reading group view
+	DefaultGroupCheckPeriod = 5 * time.Second
+)
+
+// Group member roles and states, as reported by performance_schema.replication_group_members.
+const (
+	GroupMemberPrimary   = "PRIMARY"
+	GroupMemberSecondary = "SECONDARY"
+	GroupMemberOnline    = "ONLINE"
+)
+
+// GroupMember is a member of MySQL group replication (i.e InnoDB Cluster).
+type GroupMember struct {
+	ID    string `db:"id" json:"id"`
+	Host  string `db:"host" json:"host"`
+	Port  int    `db:"port" json:"port"`
+	State string `db:"state" json:"state"`
+	Role  string `db:"role" json:"role"`
+
+	// Node is name of node which is the member, empty if member is not among nodes
+	Node string `db:"-" json:"node,omitempty"`
+}
+
+const groupMembersQuery = "SELECT MEMBER_ID AS id, MEMBER_HOST AS host, MEMBER_PORT AS port, MEMBER_STATE AS state, " +
+	"MEMBER_ROLE AS role FROM performance_schema.replication_group_members WHERE CHANNEL_NAME = 'group_replication_applier'"
+
+// serverUUID returns @@server_uuid of node, which identifies it among group members
+func (w *wrapper) serverUUID(ctx context.Context) string {
+	if id, _ := w.uuid.Load().(string); id != "" {
+		return id
+	}
+
+	var id string
+	if w.db != nil && w.db.GetContext(ctx, &id, "SELECT @@server_uuid") == nil {
+		w.uuid.Store(id)
+	}
+	return id
+}
+
+// GroupMembers returns current group view read from the first reachable node, masters first.
+// Requires MySQL 8.0 or newer.
+func (dbs *DBs) GroupMembers(ctx context.Context) (members []GroupMember, err error) {
+	if dbs.driverName != "mysql" {
+		return nil, ErrGroupReplicationNotSupported
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	healthy, _ := dbs.masters.dbs.list.Load().([]*wrapper)
+
+	err = ErrNoConnection
+	for _, w := range append(append([]*wrapper(nil), healthy...), dbs.allNodes()...) {
+		if w == nil || w.db == nil {
+			continue
+		}
+
+		members = members[:0]
+		if err = w.db.SelectContext(ctx, &members, groupMembersQuery); err == nil && len(members) > 0 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]string, len(dbs.allNodes()))
+	for _, w := range dbs.allNodes() {
+		if w != nil {
+			if id := w.serverUUID(ctx); id != "" {
+				nodes[id] = w.getName()
+			}
+		}
+	}
+	for i := range members {
+		members[i].Node = nodes[members[i].ID]
+	}
+
+	return
+}
+
+// SyncGroupReplication re-points master and slave balancers to current group view by ApplyTopology:
+// online primaries are masters, online secondaries are slaves, other nodes are detached from traffic.
+// In multi-primary mode every online member is a master, reads should be made on masters then.
+//
+// Topology is kept if group has no online primary among nodes.
+func (dbs *DBs) SyncGroupReplication(ctx context.Context) error {
+	members, err := dbs.GroupMembers(ctx)
+	if err != nil {
+		return err
+	}
+
+	masters, slaves := groupTopology(members)
+	if len(masters) == 0 {
+		return ErrNoGroupPrimary
+	}
+
+	if current := dbs.Topology(); sameNodes(current.Masters, masters) && sameNodes(current.Slaves, slaves) {
+		return nil
+	}
+
+	logEntry(LogLevelInfo, "group view is changed", LogField{Key: "masters", Value: masters}, LogField{Key: "slaves", Value: slaves})
+	return dbs.ApplyTopology(masters, slaves)
+}
+
+// groupTopology returns nodes of online primaries and secondaries
+func groupTopology(members []GroupMember) (masters, slaves []string) {
+	for _, m := range members {
+		if m.Node == "" || m.State != GroupMemberOnline {
+			continue
+		}
+
+		switch m.Role {
+		case GroupMemberPrimary:
+			masters = append(masters, m.Node)
+
+		case GroupMemberSecondary:
+			slaves = append(slaves, m.Node)
+		}
+	}
+	return
+}
+
+// sameNodes reports whether a and b have the same names regardless of order
+func sameNodes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	names := make(map[string]int, len(a))
+	for _, name := range a {
+		names[name]++
+	}
+	for _, name := range b {
+		if names[name]--; names[name] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TrackGroupReplication keeps master and slave balancers in sync with group view of MySQL group replication
+// (i.e InnoDB Cluster) by SyncGroupReplication every period until ctx is done. If period <= 0, DefaultGroupCheckPeriod is used.
+func (dbs *DBs) TrackGroupReplication(ctx context.Context, period time.Duration) error {
+	if dbs.driverName != "mysql" {
+		return ErrGroupReplicationNotSupported
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if period <= 0 {
+		period = DefaultGroupCheckPeriod
+	}
+
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		for {
+			if err := dbs.SyncGroupReplication(ctx); err != nil && ctx.Err() == nil {
+				reportError("sync group replication", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
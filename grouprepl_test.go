@@ -0,0 +1,40 @@
+package mssqlx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestGroupReplication(t *testing.T) {
+	members := []GroupMember{
+		{ID: "a", State: GroupMemberOnline, Role: GroupMemberSecondary, Node: "master-0"},
+		{ID: "b", State: GroupMemberOnline, Role: GroupMemberPrimary, Node: "slave-0"},
+		{ID: "c", State: "RECOVERING", Role: GroupMemberSecondary, Node: "slave-1"},
+		{ID: "d", State: GroupMemberOnline, Role: GroupMemberSecondary},
+	}
+	if masters, slaves := groupTopology(members); !reflect.DeepEqual(masters, []string{"slave-0"}) || !reflect.DeepEqual(slaves, []string{"master-0"}) {
+		t.Fatal("GroupReplication: single-primary view fail", masters, slaves)
+	}
+
+	members[0].Role = GroupMemberPrimary
+	if masters, slaves := groupTopology(members); len(masters) != 2 || len(slaves) != 0 {
+		t.Fatal("GroupReplication: multi-primary view fail", masters, slaves)
+	}
+
+	if !sameNodes([]string{"a", "b"}, []string{"b", "a"}) || sameNodes([]string{"a", "a"}, []string{"a", "b"}) || sameNodes(nil, []string{"a"}) {
+		t.Fatal("GroupReplication: comparing nodes fail")
+	}
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+	if err := dbs.TrackGroupReplication(context.Background(), 0); err != ErrGroupReplicationNotSupported {
+		t.Fatal("GroupReplication: unsupported driver should fail", err)
+	}
+
+	if TestWMysql && myDBs.masterNodes()[0].getFlavor(context.Background()).flavor != FlavorGroupReplication {
+		if err := myDBs.SyncGroupReplication(context.Background()); err == nil {
+			t.Fatal("GroupReplication: non group member should fail")
+		}
+	}
+}
@@ -0,0 +1,11 @@
+module github.com/linxGnu/mssqlx/grpcinterceptor
+
+require (
+	github.com/linxGnu/mssqlx v0.0.0
+	github.com/mattn/go-sqlite3 v1.13.0
+	google.golang.org/grpc v1.27.1
+)
+
+replace github.com/linxGnu/mssqlx => ..
+
+go 1.13
@@ -0,0 +1,124 @@
+// Package grpcinterceptor provides gRPC server interceptors which propagate routing hints
+// from incoming metadata into context used for mssqlx calls, and report node which
+// served queries in trailing metadata.
+package grpcinterceptor
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/linxGnu/mssqlx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys.
+const (
+	// MetadataRoutingKey routing key, see mssqlx.WithRoutingKey
+	MetadataRoutingKey = "x-mssqlx-routing-key"
+
+	// MetadataForceMaster routes reads to masters when "true", see mssqlx.WithForceMaster
+	MetadataForceMaster = "x-mssqlx-force-master"
+
+	// MetadataPriority query priority: low, normal or high, see mssqlx.WithPriority
+	MetadataPriority = "x-mssqlx-priority"
+
+	// MetadataTimeout timeout of queries (i.e 500ms), applied in addition to deadline of the call
+	MetadataTimeout = "x-mssqlx-timeout"
+
+	// MetadataNode trailing metadata: node served the last query
+	MetadataNode = "x-mssqlx-node"
+
+	// MetadataRole trailing metadata: role of node served the last query
+	MetadataRole = "x-mssqlx-role"
+)
+
+func first(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func parsePriority(s string) (mssqlx.Priority, bool) {
+	switch strings.ToLower(s) {
+	case "low":
+		return mssqlx.PriorityLow, true
+	case "normal":
+		return mssqlx.PriorityNormal, true
+	case "high":
+		return mssqlx.PriorityHigh, true
+	}
+	return mssqlx.PriorityNormal, false
+}
+
+// incoming builds context for mssqlx calls from incoming metadata.
+func incoming(ctx context.Context) (context.Context, context.CancelFunc, *mssqlx.QueryInfo) {
+	cancel := func() {}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if key := first(md, MetadataRoutingKey); key != "" {
+			ctx = mssqlx.WithRoutingKey(ctx, key)
+		}
+
+		if first(md, MetadataForceMaster) == "true" {
+			ctx = mssqlx.WithForceMaster(ctx)
+		}
+
+		if p, ok := parsePriority(first(md, MetadataPriority)); ok {
+			ctx = mssqlx.WithPriority(ctx, p)
+		}
+
+		if d, err := time.ParseDuration(first(md, MetadataTimeout)); err == nil && d > 0 {
+			ctx, cancel = context.WithTimeout(ctx, d)
+		}
+	}
+
+	info := &mssqlx.QueryInfo{}
+	return mssqlx.WithQueryInfo(ctx, info), cancel, info
+}
+
+func trailer(info *mssqlx.QueryInfo) metadata.MD {
+	if info.Node == "" {
+		return nil
+	}
+	return metadata.Pairs(MetadataNode, info.Node, MetadataRole, string(info.Role))
+}
+
+// UnaryServerInterceptor returns a unary server interceptor propagating routing hints to mssqlx.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel, info := incoming(ctx)
+		defer cancel()
+
+		resp, err := handler(ctx, req)
+		if md := trailer(info); md != nil {
+			_ = grpc.SetTrailer(ctx, md)
+		}
+		return resp, err
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamServerInterceptor returns a stream server interceptor propagating routing hints to mssqlx.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel, info := incoming(ss.Context())
+		defer cancel()
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		if md := trailer(info); md != nil {
+			ss.SetTrailer(md)
+		}
+		return err
+	}
+}
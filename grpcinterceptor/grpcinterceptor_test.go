@@ -0,0 +1,85 @@
+package grpcinterceptor
+
+import (
+	"context"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/linxGnu/mssqlx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type transportStream struct {
+	trailer metadata.MD
+}
+
+func (s *transportStream) Method() string                  { return "/test" }
+func (s *transportStream) SetHeader(metadata.MD) error     { return nil }
+func (s *transportStream) SendHeader(metadata.MD) error    { return nil }
+func (s *transportStream) SetTrailer(md metadata.MD) error { s.trailer = md; return nil }
+
+type serverStreamMock struct {
+	grpc.ServerStream
+	ctx     context.Context
+	trailer metadata.MD
+}
+
+func (s *serverStreamMock) Context() context.Context  { return s.ctx }
+func (s *serverStreamMock) SetTrailer(md metadata.MD) { s.trailer = md }
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	dbs, _ := mssqlx.ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	stream := &transportStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(
+		MetadataRoutingKey, "user-1",
+		MetadataForceMaster, "true",
+		MetadataPriority, "high",
+		MetadataTimeout, "1s",
+	))
+
+	_, err := UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		if !mssqlx.IsForceMaster(ctx) {
+			t.Fatal("Interceptor: force master should be propagated")
+		}
+		if _, ok := ctx.Deadline(); !ok {
+			t.Fatal("Interceptor: timeout should be propagated")
+		}
+
+		var v int
+		return nil, dbs.GetContext(ctx, &v, "SELECT 1")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := stream.trailer.Get(MetadataNode); len(v) != 1 || v[0] != "master-0" {
+		t.Fatal("Interceptor: unexpected trailer", stream.trailer)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	dbs, _ := mssqlx.ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	ss := &serverStreamMock{ctx: context.Background()}
+	err := StreamServerInterceptor()(nil, ss, &grpc.StreamServerInfo{}, func(_ interface{}, stream grpc.ServerStream) error {
+		var v int
+		return dbs.GetContext(stream.Context(), &v, "SELECT 1")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := ss.trailer.Get(MetadataRole); len(v) != 1 || v[0] != string(mssqlx.RoleSlave) {
+		t.Fatal("Interceptor: unexpected trailer", ss.trailer)
+	}
+
+	if p, ok := parsePriority("LOW"); !ok || p != mssqlx.PriorityLow {
+		t.Fatal("Interceptor: priority parsing fail")
+	}
+}
@@ -0,0 +1,112 @@
+package mssqlx
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NodeMetrics is rate-style metrics of a node since last scrape.
+type NodeMetrics struct {
+	Name            string  `json:"name"`
+	Role            Role    `json:"role"`
+	Healthy         bool    `json:"healthy"`
+	Queries         uint64  `json:"queries"`
+	Errors          uint64  `json:"errors"`
+	QueriesPerSec   float64 `json:"queries_per_sec"`
+	ErrorsPerSec    float64 `json:"errors_per_sec"`
+	ErrorRate       float64 `json:"error_rate"`
+	IntervalSeconds float64 `json:"interval_seconds"`
+
+	// Statements number of queries by statement type
+	Statements map[StatementType]uint64 `json:"statements,omitempty"`
+}
+
+// MetricsLite is a lightweight, Grafana-ready (i.e JSON datasource) metrics view of cluster.
+type MetricsLite struct {
+	Timestamp     time.Time     `json:"timestamp"`
+	QueriesPerSec float64       `json:"queries_per_sec"`
+	ErrorsPerSec  float64       `json:"errors_per_sec"`
+	Nodes         []NodeMetrics `json:"nodes"`
+	Routing       RoutingStats  `json:"routing"`
+}
+
+type statusHandler struct {
+	dbs *DBs
+
+	mu       sync.Mutex
+	lastTime time.Time
+	last     map[string]NodeStatus
+}
+
+// StatusHandler returns an http.Handler which serves cluster status as JSON.
+//
+// Requests whose path ends with /metrics-lite are served with MetricsLite view, including
+// queries/sec and errors/sec since last scrape, for teams without a metrics stack.
+// Requests whose path ends with /events are served with recent node state transitions.
+func (dbs *DBs) StatusHandler() http.Handler {
+	return &statusHandler{dbs: dbs}
+}
+
+func (h *statusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var v interface{}
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/metrics-lite"):
+		v = h.metrics(time.Now())
+
+	case strings.HasSuffix(r.URL.Path, "/events"):
+		v = h.dbs.Events()
+
+	default:
+		v = h.dbs.Status()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (h *statusHandler) metrics(now time.Time) (m MetricsLite) {
+	status := h.dbs.Status()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	elapsed := now.Sub(h.lastTime).Seconds()
+	if h.lastTime.IsZero() || elapsed <= 0 {
+		elapsed = 0
+	}
+
+	m.Timestamp = now
+	m.Routing = status.Routing
+	m.Nodes = make([]NodeMetrics, len(status.Nodes))
+
+	current := make(map[string]NodeStatus, len(status.Nodes))
+	for i, st := range status.Nodes {
+		current[st.Name] = st
+
+		nm := NodeMetrics{
+			Name:            st.Name,
+			Role:            st.Role,
+			Healthy:         st.Healthy,
+			Queries:         st.Queries,
+			Errors:          st.Errors,
+			ErrorRate:       st.ErrorRate,
+			Statements:      st.Statements,
+			IntervalSeconds: elapsed,
+		}
+
+		if prev, ok := h.last[st.Name]; ok && elapsed > 0 && st.Queries >= prev.Queries && st.Errors >= prev.Errors {
+			nm.QueriesPerSec = float64(st.Queries-prev.Queries) / elapsed
+			nm.ErrorsPerSec = float64(st.Errors-prev.Errors) / elapsed
+		}
+
+		m.QueriesPerSec += nm.QueriesPerSec
+		m.ErrorsPerSec += nm.ErrorsPerSec
+		m.Nodes[i] = nm
+	}
+
+	h.lastTime, h.last = now, current
+	return
+}
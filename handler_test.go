@@ -0,0 +1,85 @@
+package mssqlx
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStatusHandler(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	for i := 0; i < 3; i++ {
+		_, _ = dbs.Exec("SELECT 1")
+	}
+	_, _ = dbs.Exec("SELECT * FROM not_existed_table")
+
+	status := dbs.Status()
+	if status.DriverName != "sqlite3" || len(status.Nodes) != 2 {
+		t.Fatal("Status: unexpected status", status)
+	}
+	if m := status.Nodes[0]; m.Name != "master-0" || m.Role != RoleMaster || !m.Healthy || m.Queries != 4 || m.Errors != 1 || m.ErrorRate != 0.25 || m.Statements[StatementSelect] != 4 {
+		t.Fatal("Status: unexpected master status", m)
+	}
+
+	// status view
+	rec := httptest.NewRecorder()
+	dbs.StatusHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/status", nil))
+
+	var got ClusterStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got.Nodes) != 2 {
+		t.Fatal("StatusHandler: unexpected response", rec.Body.String())
+	}
+
+	// metrics-lite view
+	h := dbs.StatusHandler().(*statusHandler)
+	now := time.Now()
+	if m := h.metrics(now); m.QueriesPerSec != 0 || len(m.Nodes) != 2 {
+		t.Fatal("StatusHandler: first scrape should not have rates", m)
+	}
+
+	_, _ = dbs.Exec("SELECT 1")
+	_, _ = dbs.Exec("SELECT * FROM not_existed_table")
+
+	m := h.metrics(now.Add(2 * time.Second))
+	if m.QueriesPerSec != 1 || m.ErrorsPerSec != 0.5 || m.Nodes[0].IntervalSeconds != 2 {
+		t.Fatal("StatusHandler: unexpected rates", m)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/mssqlx/metrics-lite", nil))
+
+	var lite MetricsLite
+	if err := json.Unmarshal(rec.Body.Bytes(), &lite); err != nil || len(lite.Nodes) != 2 {
+		t.Fatal("StatusHandler: unexpected response", rec.Body.String())
+	}
+}
+
+func TestNodeErrorRate(t *testing.T) {
+	s := &nodeStats{}
+	now := time.Now()
+	if s.errorRate(now) != 0 {
+		t.Fatal("NodeErrorRate: no query should have no error rate")
+	}
+
+	s.doneAt(now.Add(-ErrorRateWindow), "SELECT 1", sql.ErrConnDone)
+	for i := 0; i < 3; i++ {
+		s.doneAt(now, "SELECT 1", nil)
+	}
+	s.doneAt(now, "SELECT 1", sql.ErrConnDone)
+
+	// errors before window are out of rate, but kept in counters
+	if rate := s.errorRate(now); rate != 0.25 {
+		t.Fatal("NodeErrorRate: unexpected error rate", rate)
+	}
+	if _, errors := s.load(); errors != 2 {
+		t.Fatal("NodeErrorRate: unexpected errors", errors)
+	}
+
+	if rate := s.errorRate(now.Add(ErrorRateWindow)); rate != 0 {
+		t.Fatal("NodeErrorRate: error rate should roll", rate)
+	}
+}
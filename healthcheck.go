@@ -0,0 +1,342 @@
+package mssqlx
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// DefaultHealthCheckTimeout default timeout of pinging nodes by health checker
+	DefaultHealthCheckTimeout = 5 * time.Second
+)
+
+// healthTask is a pending health check of failed node
+type healthTask struct {
+	c     *balancer
+	w     *wrapper
+	due   time.Time
+	index int
+}
+
+// healthQueue is min-heap of health tasks ordered by due time
+type healthQueue []*healthTask
+
+func (q healthQueue) Len() int { return len(q) }
+
+func (q healthQueue) Less(i, j int) bool { return q[i].due.Before(q[j].due) }
+
+func (q healthQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+
+func (q *healthQueue) Push(x interface{}) {
+	t := x.(*healthTask)
+	t.index = len(*q)
+	*q = append(*q, t)
+}
+
+func (q *healthQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return t
+}
+
+// healthScheduler checks failed nodes of balancers sharing it. Its scheduler routine
+// only runs while there are failed nodes and pings are done by at most maxWorkers routines,
+// which are spawned on demand. Healthy databases have no health checking routine at all.
+//
+// Nodes of a balancer are checked by at most healthCheckers of balancer routines at once, if set.
+type healthScheduler struct {
+	mu         sync.Mutex
+	queue      healthQueue
+	ready      []*healthTask
+	busy       map[*balancer]int // number of workers checking nodes of balancer
+	running    bool
+	workers    int
+	maxWorkers int
+	wake       chan struct{}
+}
+
+func newHealthScheduler(maxWorkers int) *healthScheduler {
+	if maxWorkers <= 0 {
+		maxWorkers = 2 // at least two checkers
+	}
+
+	return &healthScheduler{
+		busy:       make(map[*balancer]int),
+		maxWorkers: maxWorkers,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// schedule checking health of w after delay
+func (s *healthScheduler) schedule(c *balancer, w *wrapper, delay time.Duration) {
+	s.mu.Lock()
+	heap.Push(&s.queue, &healthTask{c: c, w: w, due: time.Now().Add(delay)})
+	if !s.running {
+		s.running = true
+		go s.run()
+	} else {
+		s.notify()
+	}
+	s.mu.Unlock()
+}
+
+// cancel drops pending health checks of balancer c
+func (s *healthScheduler) cancel(c *balancer) {
+	s.mu.Lock()
+
+	queue := s.queue[:0]
+	for _, t := range s.queue {
+		if t.c != c {
+			queue = append(queue, t)
+		}
+	}
+	for i := len(queue); i < len(s.queue); i++ {
+		s.queue[i] = nil
+	}
+	s.queue = queue
+	heap.Init(&s.queue)
+
+	ready := s.ready[:0]
+	for _, t := range s.ready {
+		if t.c != c {
+			ready = append(ready, t)
+		}
+	}
+	s.ready = ready
+
+	s.notify()
+	s.mu.Unlock()
+}
+
+// pending returns number of pending health checks
+func (s *healthScheduler) pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue) + len(s.ready)
+}
+
+func (s *healthScheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *healthScheduler) run() {
+	for {
+		s.mu.Lock()
+
+		now := time.Now()
+		for len(s.queue) > 0 && !s.queue[0].due.After(now) {
+			s.dispatch(heap.Pop(&s.queue).(*healthTask))
+		}
+
+		if len(s.queue) == 0 { // no failed node left, workers re-schedule if needed
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+
+		timer := time.NewTimer(s.queue[0].due.Sub(now))
+		s.mu.Unlock()
+
+		select {
+		case <-timer.C:
+
+		case <-s.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// dispatch due task to workers, must be called with s.mu held
+func (s *healthScheduler) dispatch(t *healthTask) {
+	if t.c.ctx.Err() != nil { // balancer destroyed
+		return
+	}
+
+	s.ready = append(s.ready, t)
+	if s.workers < s.maxWorkers {
+		s.workers++
+		go s.work()
+	}
+}
+
+func (s *healthScheduler) work() {
+	for {
+		s.mu.Lock()
+		i := s.nextReady()
+		if i < 0 { // nothing to check, or balancers of ready tasks are checked by other workers up to their limits
+			s.workers--
+			s.mu.Unlock()
+			return
+		}
+
+		t := s.ready[i]
+		copy(s.ready[i:], s.ready[i+1:])
+		s.ready[len(s.ready)-1] = nil
+		s.ready = s.ready[:len(s.ready)-1]
+		s.busy[t.c]++
+		s.mu.Unlock()
+
+		back := t.c.checkHealth(t.w)
+
+		s.mu.Lock()
+		if s.busy[t.c]--; s.busy[t.c] == 0 {
+			delete(s.busy, t.c)
+		}
+		s.mu.Unlock()
+
+		if !back {
+			s.schedule(t.c, t.w, time.Duration(t.c.getHealthCheckPeriod())*time.Millisecond)
+		}
+	}
+}
+
+// nextReady returns index of first ready task whose balancer is under its checkers limit, -1 if none.
+// Must be called with s.mu held.
+func (s *healthScheduler) nextReady() int {
+	for i, t := range s.ready {
+		if limit := t.c.getHealthCheckers(); limit <= 0 || s.busy[t.c] < limit {
+			return i
+		}
+	}
+	return -1
+}
+
+// growWorkers raises max number of workers to n, spawning workers for ready tasks
+func (s *healthScheduler) growWorkers(n int) {
+	s.mu.Lock()
+	if n > s.maxWorkers {
+		s.maxWorkers = n
+	}
+	for s.workers < s.maxWorkers && s.workers < len(s.ready) {
+		s.workers++
+		go s.work()
+	}
+	s.mu.Unlock()
+}
+
+// checkHealth checks failed node, returns true if node is back or should not be tracked anymore
+func (c *balancer) checkHealth(db *wrapper) bool {
+	if c.ctx.Err() != nil || db.isFenced() || !c.isMember(db) { // destroyed, quarantined or moved away, stop tracking
+		return true
+	}
+
+	if !db.isFailureSimulated() && !db.inMaintenance() && !db.isEjected() && c.probe(db) == nil && db.checkReady(c.isWsrep) && !db.isFenced() && c.isMember(db) {
+		logEntry(LogLevelInfo, "node is up", nodeFields(db)...)
+		c.events.record(db, NodeStateDown, NodeStateUp, nil)
+		db.resetFailures()
+		c.dbs.add(db)
+		return true
+	}
+
+	return c.ctx.Err() != nil
+}
+
+// probe pings node within health check timeout of balancer
+func (c *balancer) probe(w *wrapper) error {
+	timeout := time.Duration(atomic.LoadInt64(&c.healthCheckTimeout))
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	return w.probe(c.ctx, timeout)
+}
+
+// probe pings node over its dedicated probe connection within timeout, so that a saturated pool
+// does not make a healthy node look dead.
+func (w *wrapper) probe(ctx context.Context, timeout time.Duration) error {
+	db := w.getProbeDB()
+	if db == nil {
+		db = w.db
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, "SELECT 1")
+	return err
+}
+
+// getProbeDB returns dedicated probe pool of node, opening it if not yet. Returns nil if node has none.
+func (w *wrapper) getProbeDB() *sqlx.DB {
+	w.probeMu.Lock()
+	defer w.probeMu.Unlock()
+
+	if w.probeDB == nil && w.openProbe != nil && !w.probeClosed {
+		db, err := w.openProbe()
+		if err != nil {
+			reportNodeError(w, "open probe connection", err)
+			return nil
+		}
+
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		w.probeDB = db
+	}
+	return w.probeDB
+}
+
+func (w *wrapper) closeProbe() {
+	w.probeMu.Lock()
+	defer w.probeMu.Unlock()
+
+	w.probeClosed = true
+	if w.probeDB != nil {
+		_ = w.probeDB.Close()
+		w.probeDB = nil
+	}
+}
+
+func (c *balancer) getHealthCheckers() int {
+	return int(atomic.LoadInt32(&c.healthCheckers))
+}
+
+// SetMasterHealthCheckers sets max number of failed masters checked in parallel, i.e conservative probes of masters.
+// If n <= 0, failed nodes of all roles share health checkers, half of nodes (default).
+func (dbs *DBs) SetMasterHealthCheckers(n int) {
+	dbs.setHealthCheckers(dbs.masters, n)
+}
+
+// SetSlaveHealthCheckers sets max number of failed slaves checked in parallel, i.e many parallel probes of slaves.
+// If n <= 0, failed nodes of all roles share health checkers, half of nodes (default).
+func (dbs *DBs) SetSlaveHealthCheckers(n int) {
+	dbs.setHealthCheckers(dbs.slaves, n)
+}
+
+func (dbs *DBs) setHealthCheckers(c *balancer, n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt32(&c.healthCheckers, int32(n))
+
+	// shared scheduler must be able to run checkers of both roles
+	c.health.growWorkers(dbs.masters.getHealthCheckers() + dbs.slaves.getHealthCheckers())
+}
+
+// SetHealthCheckTimeout sets timeout of pinging failed nodes. If d <= 0, DefaultHealthCheckTimeout is used.
+func (dbs *DBs) SetHealthCheckTimeout(d time.Duration) {
+	dbs.SetMasterHealthCheckTimeout(d)
+	dbs.SetSlaveHealthCheckTimeout(d)
+}
+
+// SetMasterHealthCheckTimeout sets timeout of pinging failed masters. If d <= 0, DefaultHealthCheckTimeout is used.
+func (dbs *DBs) SetMasterHealthCheckTimeout(d time.Duration) {
+	atomic.StoreInt64(&dbs.masters.healthCheckTimeout, int64(d))
+}
+
+// SetSlaveHealthCheckTimeout sets timeout of pinging failed slaves. If d <= 0, DefaultHealthCheckTimeout is used.
+func (dbs *DBs) SetSlaveHealthCheckTimeout(d time.Duration) {
+	atomic.StoreInt64(&dbs.slaves.healthCheckTimeout, int64(d))
+}
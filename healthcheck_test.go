@@ -0,0 +1,146 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestHealthScheduler(t *testing.T) {
+	if s := newHealthScheduler(0); s.maxWorkers != 2 {
+		t.Fatal("HealthScheduler: default workers fail", s.maxWorkers)
+	}
+
+	db, _ := sqlx.Open("sqlite3", ":memory:")
+	defer db.Close()
+
+	b := newBalancer(nil, 0, false)
+	w := newWrapper(db, ":memory:", RoleMaster, 0)
+	b.add(w)
+
+	// node is back on first check
+	b.failure(w)
+	for i := 0; i < 100 && b.size() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if b.size() != 1 {
+		t.Fatal("HealthScheduler: node should be back")
+	}
+
+	// failing node is re-scheduled until balancer is destroyed
+	dsn := "user=test1 dbname=test1 sslmode=disable host=127.0.0.1 port=1 connect_timeout=1"
+	pg, _ := sqlx.Open("postgres", dsn)
+	defer pg.Close()
+
+	f := newWrapper(pg, dsn, RoleMaster, 1)
+	b.add(f)
+	b.setHealthCheckPeriod(20)
+	b.failure(f)
+
+	time.Sleep(100 * time.Millisecond)
+	if b.size() != 1 || healthSchedulerIdle(b.health) {
+		t.Fatal("HealthScheduler: failing node should be tracked")
+	}
+
+	b.destroy()
+	for i := 0; i < 200 && !healthSchedulerIdle(b.health); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !healthSchedulerIdle(b.health) || b.health.pending() != 0 {
+		t.Fatal("HealthScheduler: should stop after balancer is destroyed")
+	}
+}
+
+func healthSchedulerIdle(s *healthScheduler) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue) == 0 && len(s.ready) == 0 && !s.running && s.workers == 0
+}
+
+func TestHealthSchedulerShared(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:", ":memory:"})
+	defer dbs.Destroy()
+
+	if dbs.masters.health != dbs.slaves.health || dbs.masters.health != dbs.all.health {
+		t.Fatal("HealthScheduler: should be shared by balancers")
+	}
+
+	if !healthSchedulerIdle(dbs.masters.health) {
+		t.Fatal("HealthScheduler: should not run without failed node")
+	}
+}
+
+func TestHealthCheckersPerRole(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:", ":memory:"})
+	defer dbs.Destroy()
+
+	s := dbs.masters.health
+	dbs.SetSlaveHealthCheckers(8)
+	dbs.SetMasterHealthCheckers(1)
+	if dbs.slaves.getHealthCheckers() != 8 || dbs.masters.getHealthCheckers() != 1 || s.maxWorkers != 9 {
+		t.Fatal("HealthCheckersPerRole: setting checkers fail", s.maxWorkers)
+	}
+
+	// master being checked, next master waits while slaves are checked
+	s.mu.Lock()
+	s.ready = []*healthTask{{c: dbs.masters}, {c: dbs.slaves}}
+	s.busy[dbs.masters] = 1
+	if i := s.nextReady(); i != 1 {
+		t.Fatal("HealthCheckersPerRole: masters should be limited", i)
+	}
+	s.busy[dbs.slaves] = 8
+	if i := s.nextReady(); i != -1 {
+		t.Fatal("HealthCheckersPerRole: slaves should be limited", i)
+	}
+	s.ready, s.busy = nil, make(map[*balancer]int)
+	s.mu.Unlock()
+
+	dbs.SetMasterHealthCheckers(0)
+	if dbs.masters.getHealthCheckers() != 0 {
+		t.Fatal("HealthCheckersPerRole: resetting checkers fail")
+	}
+
+	dbs.SetHealthCheckTimeout(time.Second)
+	dbs.SetMasterHealthCheckTimeout(50 * time.Millisecond)
+	if err := dbs.slaves.probe(dbs.slaveNodes()[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// probe of unreachable node gives up in time
+	dsn := "user=test1 dbname=test1 sslmode=disable host=10.255.255.1 port=5432 connect_timeout=10"
+	pg, _ := sqlx.Open("postgres", dsn)
+	defer pg.Close()
+
+	startedAt := time.Now()
+	if err := dbs.masters.probe(newWrapper(pg, dsn, RoleMaster, 1)); err == nil || time.Since(startedAt) > time.Second {
+		t.Fatal("HealthCheckersPerRole: probe should time out", err, time.Since(startedAt))
+	}
+}
+
+func TestHealthProbeConnection(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+
+	// saturated pool does not make node look dead
+	w := dbs.masterNodes()[0]
+	w.db.SetMaxOpenConns(1)
+	conn, err := w.db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err = dbs.masters.probe(w); err != nil {
+		t.Fatal("HealthProbeConnection: probe should use dedicated connection", err)
+	}
+	if w.probeDB == nil || w.probeDB == w.db || w.probeDB.Stats().MaxOpenConnections != 1 {
+		t.Fatal("HealthProbeConnection: dedicated probe connection fail")
+	}
+
+	conn.Close()
+	dbs.Destroy()
+	if w.probeDB != nil || w.getProbeDB() != nil {
+		t.Fatal("HealthProbeConnection: probe connection should be closed with node")
+	}
+}
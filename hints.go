@@ -0,0 +1,99 @@
+package mssqlx
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type optimizerHintsKey struct{}
+
+// WithOptimizerHints returns a context whose Select, Get and Exec statements carry optimizer hints,
+// i.e to work around a bad plan on one replica without rewriting SQL at call sites:
+//
+// On mysql, hints are injected as an optimizer hint comment following statement keyword,
+// i.e WithOptimizerHints(ctx, "INDEX(t idx_created_at)") makes SELECT /*+ INDEX(t idx_created_at) */ ...
+//
+// On postgres, hints are planner settings applied by SET LOCAL within a transaction wrapping statement,
+// i.e WithOptimizerHints(ctx, "enable_nestloop = off").
+//
+// Hints are not applied to Query/Queryx, whose rows outlive statement, nor within transactions.
+// Hints are trusted SQL, never pass user input.
+func WithOptimizerHints(ctx context.Context, hints ...string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, optimizerHintsKey{}, hints)
+}
+
+func optimizerHintsFromContext(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	hints, _ := ctx.Value(optimizerHintsKey{}).([]string)
+	return hints
+}
+
+// withOptimizerHints returns query carrying optimizer hints of ctx on mysql
+func (w *wrapper) withOptimizerHints(ctx context.Context, query string) string {
+	if w.db.DriverName() != "mysql" {
+		return query
+	}
+
+	if hints := optimizerHintsFromContext(ctx); len(hints) > 0 {
+		return injectOptimizerHint(query, strings.Join(hints, " "))
+	}
+	return query
+}
+
+// injectOptimizerHint injects mysql optimizer hint following keyword of SELECT/INSERT/REPLACE/UPDATE/DELETE statement.
+// Hint is merged into an existing hint comment, since only the first one is honored.
+func injectOptimizerHint(query, hint string) string {
+	trimmed := strings.TrimLeft(query, " \t\r\n")
+
+	end := 0
+	for end < len(trimmed) && (trimmed[end]|0x20 >= 'a' && trimmed[end]|0x20 <= 'z') {
+		end++
+	}
+	if end == len(trimmed) || !strings.ContainsAny(trimmed[end:end+1], " \t\r\n") {
+		return query
+	}
+
+	switch strings.ToUpper(trimmed[:end]) {
+	case "SELECT", "INSERT", "REPLACE", "UPDATE", "DELETE":
+	default:
+		return query
+	}
+
+	if rest := strings.TrimLeft(trimmed[end:], " \t\r\n"); strings.HasPrefix(rest, "/*+") {
+		return trimmed[:end] + " /*+ " + hint + " " + strings.TrimLeft(rest[3:], " ")
+	}
+	return trimmed[:end] + " /*+ " + hint + " */" + trimmed[end:]
+}
+
+// hinted runs fn against node, or against a transaction applying optimizer hints of ctx on postgres
+func (w *wrapper) hinted(ctx context.Context, fn func(sqlx.ExtContext) error) error {
+	hints := optimizerHintsFromContext(ctx)
+	if len(hints) == 0 || !isPostgres(w.db.DriverName()) {
+		return fn(w.db)
+	}
+
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, hint := range hints {
+		if _, err = tx.ExecContext(ctx, "SET LOCAL "+hint); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,56 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestOptimizerHints(t *testing.T) {
+	if q := injectOptimizerHint(" SELECT * FROM t", "INDEX(t idx)"); q != "SELECT /*+ INDEX(t idx) */ * FROM t" {
+		t.Fatal("OptimizerHints: select fail", q)
+	}
+	if q := injectOptimizerHint("delete FROM t", "BKA(t)"); q != "delete /*+ BKA(t) */ FROM t" {
+		t.Fatal("OptimizerHints: delete fail", q)
+	}
+	if q := injectOptimizerHint("SELECT /*+ MAX_EXECUTION_TIME(10) */ 1", "NO_ICP(t)"); q != "SELECT /*+ NO_ICP(t) MAX_EXECUTION_TIME(10) */ 1" {
+		t.Fatal("OptimizerHints: merging fail", q)
+	}
+	if q := injectOptimizerHint("SHOW TABLES", "NO_ICP(t)"); q != "SHOW TABLES" {
+		t.Fatal("OptimizerHints: unsupported statement fail", q)
+	}
+
+	ctx := WithOptimizerHints(context.Background(), "INDEX(t idx)", "NO_ICP(t)")
+
+	w := newWrapper(sqlx.NewDb(nil, "mysql"), "", RoleMaster, 0)
+	if q := w.withOptimizerHints(ctx, "SELECT 1"); q != "SELECT /*+ INDEX(t idx) NO_ICP(t) */ 1" {
+		t.Fatal("OptimizerHints: mysql fail", q)
+	}
+	if q := w.withOptimizerHints(context.Background(), "SELECT 1"); q != "SELECT 1" {
+		t.Fatal("OptimizerHints: no hint fail", q)
+	}
+
+	w = newWrapper(sqlx.NewDb(nil, "postgres"), "", RoleMaster, 0)
+	if q := w.withOptimizerHints(ctx, "SELECT 1"); q != "SELECT 1" {
+		t.Fatal("OptimizerHints: postgres query should be kept", q)
+	}
+
+	// hints of other drivers are ignored
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+	var n int
+	if err := dbs.GetContextOnMaster(ctx, &n, "SELECT 1"); err != nil || n != 1 {
+		t.Fatal("OptimizerHints: sqlite3 fail", n, err)
+	}
+
+	if TestWPostgres {
+		var setting string
+		if err := pgDBs.GetContextOnMaster(WithOptimizerHints(context.Background(), "enable_nestloop = off"), &setting, "SELECT current_setting('enable_nestloop')"); err != nil || setting != "off" {
+			t.Fatal("OptimizerHints: postgres fail", setting, err)
+		}
+		if err := pgDBs.GetContextOnMaster(context.Background(), &setting, "SELECT current_setting('enable_nestloop')"); err != nil || setting != "on" {
+			t.Fatal("OptimizerHints: postgres setting should be local", setting, err)
+		}
+	}
+}
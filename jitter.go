@@ -0,0 +1,62 @@
+package mssqlx
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultConnMaxLifetimeJitter default jitter (in percent) applied to connection max lifetime of each node
+	DefaultConnMaxLifetimeJitter = 10
+)
+
+var (
+	jitterRandLock sync.Mutex
+	jitterRand     = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// jitterLifetime shortens d by a random amount up to jitter percent. Lifetime is never extended,
+// since it is usually set below server-side idle timeout.
+func jitterLifetime(d time.Duration, jitter int) time.Duration {
+	if d <= 0 || jitter <= 0 {
+		return d
+	}
+
+	max := int64(d) * int64(jitter) / 100
+	if max <= 0 {
+		return d
+	}
+
+	jitterRandLock.Lock()
+	v := jitterRand.Int63n(max + 1)
+	jitterRandLock.Unlock()
+
+	return d - time.Duration(v)
+}
+
+func (dbs *DBs) getLifetimeJitter() int {
+	if v := atomic.LoadInt32(&dbs.lifetimeJitter); v >= 0 {
+		if v == 0 {
+			return DefaultConnMaxLifetimeJitter
+		}
+		return int(v)
+	}
+	return 0
+}
+
+// SetConnMaxLifetimeJitter sets jitter (in percent, 0-100) applied by SetConnMaxLifetime family: each node gets
+// its own lifetime, shortened by a random amount up to percent, so that connections of all nodes do not expire
+// simultaneously causing periodic latency spikes. It takes effect on next SetConnMaxLifetime call.
+//
+// If percent is 0, DefaultConnMaxLifetimeJitter is used. If percent < 0, jitter is disabled.
+func (dbs *DBs) SetConnMaxLifetimeJitter(percent int) {
+	if percent > 100 {
+		percent = 100
+	}
+	if percent < 0 {
+		percent = -1
+	}
+	atomic.StoreInt32(&dbs.lifetimeJitter, int32(percent))
+}
@@ -0,0 +1,37 @@
+package mssqlx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConnMaxLifetimeJitter(t *testing.T) {
+	if d := jitterLifetime(time.Minute, 0); d != time.Minute {
+		t.Fatal("ConnMaxLifetimeJitter: no jitter fail", d)
+	}
+	if d := jitterLifetime(0, 10); d != 0 {
+		t.Fatal("ConnMaxLifetimeJitter: unlimited lifetime fail", d)
+	}
+	for i := 0; i < 100; i++ {
+		if d := jitterLifetime(time.Minute, 10); d > time.Minute || d < 54*time.Second {
+			t.Fatal("ConnMaxLifetimeJitter: jitter out of range", d)
+		}
+	}
+
+	dbs := &DBs{}
+	if dbs.getLifetimeJitter() != DefaultConnMaxLifetimeJitter {
+		t.Fatal("ConnMaxLifetimeJitter: default fail")
+	}
+	if dbs.SetConnMaxLifetimeJitter(200); dbs.getLifetimeJitter() != 100 {
+		t.Fatal("ConnMaxLifetimeJitter: cap fail")
+	}
+	if dbs.SetConnMaxLifetimeJitter(-5); dbs.getLifetimeJitter() != 0 {
+		t.Fatal("ConnMaxLifetimeJitter: disable fail")
+	}
+
+	dbs, _ = ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:", ":memory:"})
+	defer dbs.Destroy()
+
+	dbs.SetConnMaxLifetimeJitter(50)
+	dbs.SetConnMaxLifetime(time.Hour)
+}
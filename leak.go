@@ -0,0 +1,190 @@
+package mssqlx
+
+import (
+	"database/sql"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LeakDetectionOptions configures leak detection of checked-out Conns and open Rows.
+type LeakDetectionOptions struct {
+	// Threshold resources not closed within it are reported as leaks. Zero disables leak detection.
+	Threshold time.Duration
+
+	// CaptureStack captures stack where resource is checked out, at a cost for every Conn/Query.
+	CaptureStack bool
+}
+
+// Leak is a Conn or Rows not closed within leak detection threshold.
+type Leak struct {
+	// Kind of resource: conn or rows
+	Kind string `json:"kind"`
+
+	// Node which resource belongs to
+	Node string `json:"node"`
+
+	// Query which opened rows
+	Query string `json:"query,omitempty"`
+
+	// Since when resource is checked out
+	Since time.Time `json:"since"`
+
+	// Stack where resource is checked out, if captured
+	Stack string `json:"stack,omitempty"`
+}
+
+type trackedResource struct {
+	Leak
+	closed   func() bool
+	reported bool
+}
+
+type leakTracker struct {
+	threshold    int64 // nanoseconds, first field, 64-bit aligned for atomic access
+	captureStack int32
+
+	mu        sync.Mutex
+	resources map[*trackedResource]struct{}
+	watchOnce sync.Once
+}
+
+func newLeakTracker() *leakTracker {
+	return &leakTracker{resources: make(map[*trackedResource]struct{})}
+}
+
+func (t *leakTracker) enabled() bool {
+	return t != nil && atomic.LoadInt64(&t.threshold) > 0
+}
+
+func (t *leakTracker) track(kind string, w *wrapper, query string, closed func() bool) {
+	if !t.enabled() {
+		return
+	}
+
+	r := &trackedResource{Leak: Leak{Kind: kind, Node: w.getName(), Query: query, Since: time.Now()}, closed: closed}
+	if atomic.LoadInt32(&t.captureStack) == 1 {
+		buf := make([]byte, 4096)
+		r.Stack = string(buf[:runtime.Stack(buf, false)])
+	}
+
+	t.mu.Lock()
+	t.resources[r] = struct{}{}
+	t.mu.Unlock()
+}
+
+func (t *leakTracker) trackRows(w *wrapper, query string, rows *sql.Rows) {
+	if rows != nil {
+		t.track("rows", w, query, func() bool {
+			_, err := rows.Columns() // fails once rows are closed
+			return err != nil
+		})
+	}
+}
+
+// purge untracks all resources, i.e when leak detection is disabled
+func (t *leakTracker) purge() {
+	t.mu.Lock()
+	t.resources = make(map[*trackedResource]struct{})
+	t.mu.Unlock()
+}
+
+// check untracks closed resources and returns leaks, newly found ones are logged
+func (t *leakTracker) check(now time.Time) (leaks []Leak) {
+	if t == nil {
+		return nil
+	}
+
+	threshold := time.Duration(atomic.LoadInt64(&t.threshold))
+
+	// snapshot resources, closed() could block on rows in use so it is not called under lock
+	t.mu.Lock()
+	resources := make([]*trackedResource, 0, len(t.resources))
+	for r := range t.resources {
+		resources = append(resources, r)
+	}
+	t.mu.Unlock()
+
+	var closed []*trackedResource
+	for _, r := range resources {
+		if r.closed() {
+			closed = append(closed, r)
+			continue
+		}
+
+		if threshold > 0 && now.Sub(r.Since) > threshold {
+			t.mu.Lock()
+			report := !r.reported
+			r.reported = true
+			t.mu.Unlock()
+
+			if report {
+				logEntry(LogLevelWarn, r.Kind+" is not closed within "+threshold.String(),
+					LogField{Key: LogFieldNode, Value: r.Node}, LogField{Key: LogFieldQuery, Value: r.Query}, LogField{Key: "stack", Value: r.Stack})
+			}
+			leaks = append(leaks, r.Leak)
+		}
+	}
+
+	if len(closed) > 0 {
+		t.mu.Lock()
+		for _, r := range closed {
+			delete(t.resources, r)
+		}
+		t.mu.Unlock()
+	}
+
+	sort.Slice(leaks, func(i, j int) bool { return leaks[i].Since.Before(leaks[j].Since) })
+	return
+}
+
+// SetLeakDetection enables tracking of Conns checked out by Conn and Rows opened by Query, Queryx and NamedQuery.
+// Ones not closed within opts.Threshold are reported through logger and LeakReport.
+// Disabling it (zero threshold) untracks all resources.
+func (dbs *DBs) SetLeakDetection(opts LeakDetectionOptions) {
+	t := dbs.leaks
+	if t == nil {
+		return
+	}
+
+	var capture int32
+	if opts.CaptureStack {
+		capture = 1
+	}
+	atomic.StoreInt32(&t.captureStack, capture)
+	atomic.StoreInt64(&t.threshold, int64(opts.Threshold))
+
+	if opts.Threshold <= 0 {
+		t.purge()
+		return
+	}
+
+	t.watchOnce.Do(func() {
+		go dbs.watchLeaks()
+	})
+}
+
+func (dbs *DBs) watchLeaks() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	doneCh := dbs.masters.ctx.Done()
+	for {
+		select {
+		case <-doneCh:
+			return
+
+		case now := <-ticker.C:
+			if dbs.leaks.enabled() {
+				dbs.leaks.check(now)
+			}
+		}
+	}
+}
+
+// LeakReport returns Conns and Rows not closed within leak detection threshold, oldest first.
+func (dbs *DBs) LeakReport() []Leak {
+	return dbs.leaks.check(time.Now())
+}
@@ -0,0 +1,66 @@
+package mssqlx
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeakDetection(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	// disabled by default
+	rows, err := dbs.Queryx("SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rows.Close()
+	if leaks := dbs.LeakReport(); len(leaks) != 0 {
+		t.Fatal("LeakDetection: should be disabled", leaks)
+	}
+
+	dbs.SetLeakDetection(LeakDetectionOptions{Threshold: time.Minute, CaptureStack: true})
+
+	leaked, err := dbs.Queryx("SELECT 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer leaked.Close()
+
+	closed, err := dbs.Query("SELECT 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := dbs.Conn(context.Background(), RoleMaster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if leaks := dbs.LeakReport(); len(leaks) != 0 {
+		t.Fatal("LeakDetection: reported before threshold", leaks)
+	}
+
+	_ = closed.Close()
+	_ = conn.Close()
+
+	leaks := dbs.leaks.check(time.Now().Add(2 * time.Minute))
+	if len(leaks) != 1 || leaks[0].Kind != "rows" || leaks[0].Query != "SELECT 2" || leaks[0].Node != "slave-0" || !strings.Contains(leaks[0].Stack, "TestLeakDetection") {
+		t.Fatal("LeakDetection: leak report fail", leaks)
+	}
+
+	_ = leaked.Close()
+	if leaks = dbs.leaks.check(time.Now().Add(2 * time.Minute)); len(leaks) != 0 {
+		t.Fatal("LeakDetection: closed rows reported", leaks)
+	}
+
+	// disabling purges tracked resources
+	open, _ := dbs.Query("SELECT 2")
+	dbs.SetLeakDetection(LeakDetectionOptions{})
+	if n := len(dbs.leaks.resources); n != 0 {
+		t.Fatal("LeakDetection: resources should be purged", n)
+	}
+	_ = open.Close()
+}
@@ -0,0 +1,181 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrListenNotSupported LISTEN/NOTIFY is only supported by postgres driver
+	ErrListenNotSupported = errors.New("LISTEN/NOTIFY is only supported by postgres driver")
+)
+
+const (
+	// DefaultListenerMaxReconnectInterval maximum interval listener waits before reconnecting to current node
+	DefaultListenerMaxReconnectInterval = time.Second
+)
+
+// Notification is a notification received from LISTEN channel.
+type Notification struct {
+	// Channel where notification was sent to
+	Channel string
+
+	// Payload of notification
+	Payload string
+
+	// BePid is process id of the notifying backend
+	BePid int
+}
+
+// Listen subscribes to a postgres channel on one of healthy masters and returns notification stream.
+// When current node fails, listener is automatically re-subscribed on another master.
+//
+// A nil notification is sent after each re-subscription to indicate that notifications
+// might be lost in between; consumers (i.e cache invalidators) should resync their state.
+//
+// Stream is closed when ctx is done.
+func (dbs *DBs) Listen(ctx context.Context, channel string) (<-chan *Notification, error) {
+	if dbs.driverName != "postgres" {
+		return nil, ErrListenNotSupported
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ch := make(chan *Notification, 64)
+	go _listen(ctx, dbs.masters, &dbs.opts, channel, ch)
+
+	return ch, nil
+}
+
+func _listen(ctx context.Context, target *balancer, opts *connectOptions, channel string, ch chan<- *Notification) {
+	defer close(ch)
+
+	doneCh := ctx.Done()
+	for subscribed := false; ; subscribed = true {
+		w, err := getDBFromBalancer(ctx, target)
+		if err != nil {
+			reportError("LISTEN "+channel, err)
+
+			select {
+			case <-doneCh:
+				return
+
+			case <-time.After(time.Duration(target.getHealthCheckPeriod()) * time.Millisecond):
+				continue
+			}
+		}
+
+		if subscribed {
+			select {
+			case <-doneCh:
+				return
+
+			case ch <- nil:
+			}
+		}
+
+		if !_listenOn(ctx, target, opts, w, channel, ch) {
+			return
+		}
+	}
+}
+
+// newListener creates listener of node, connecting like node's pool does: with its dialer and
+// credentials supplied by AuthProvider
+func newListener(ctx context.Context, opts *connectOptions, w *wrapper, minReconnectInterval time.Duration, eventCallback pq.EventCallbackType) (*pq.Listener, error) {
+	dsn, err := opts.nodeDSN("postgres", w.dsn, w.getRole())
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.authProvider != nil {
+		if dsn, err = opts.authProvider(ctx, dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	if dial := opts.dialerOf(w.dsn); dial != nil {
+		return pq.NewDialListener(pqDialer(dial), dsn, minReconnectInterval, DefaultListenerMaxReconnectInterval, eventCallback), nil
+	}
+	return pq.NewListener(dsn, minReconnectInterval, DefaultListenerMaxReconnectInterval, eventCallback), nil
+}
+
+// listen on a specific node, returns true if node failed and we should re-subscribe on another one.
+func _listenOn(ctx context.Context, target *balancer, opts *connectOptions, w *wrapper, channel string, ch chan<- *Notification) bool {
+	failed := make(chan error, 1)
+
+	period := time.Duration(target.getHealthCheckPeriod()) * time.Millisecond
+	listener, err := newListener(ctx, opts, w, period, func(ev pq.ListenerEventType, err error) {
+		if ev == pq.ListenerEventConnectionAttemptFailed || ev == pq.ListenerEventDisconnected {
+			select {
+			case failed <- err:
+			default:
+			}
+		}
+	})
+	if err != nil {
+		reportNodeError(w, "LISTEN "+channel, err)
+		return waitResubscribe(ctx, period)
+	}
+	defer listener.Close() // also unblocks Listen waiting for connection
+
+	// Listen blocks until listener is connected, which never happens while node is unreachable
+	listened := make(chan error, 1)
+	go func() {
+		listened <- listener.Listen(channel)
+	}()
+
+	doneCh, notifyCh := ctx.Done(), listener.NotificationChannel()
+	for {
+		select {
+		case <-doneCh:
+			return false
+
+		case err := <-failed:
+			// listener keeps reconnecting to the same node unless node is counted as failed
+			if target.shouldFailure(w, err) && target.countFailure(w, err) {
+				return true
+			}
+
+		case err := <-listened:
+			if err != nil {
+				reportNodeError(w, "LISTEN "+channel, err)
+				return waitResubscribe(ctx, period)
+			}
+
+		case n := <-notifyCh:
+			if n == nil { // reconnected to same node, notifications might be lost
+				select {
+				case <-doneCh:
+					return false
+
+				case ch <- nil:
+				}
+				continue
+			}
+
+			select {
+			case <-doneCh:
+				return false
+
+			case ch <- &Notification{Channel: n.Channel, Payload: n.Extra, BePid: n.BePid}:
+			}
+		}
+	}
+}
+
+// waitResubscribe waits a health check period before re-subscribing, returns false if ctx is done
+func waitResubscribe(ctx context.Context, period time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+
+	case <-time.After(period):
+		return true
+	}
+}
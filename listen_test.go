@@ -0,0 +1,90 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestListen(t *testing.T) {
+	dbs := &DBs{driverName: "mysql"}
+	if _, err := dbs.Listen(context.Background(), "cache"); err != ErrListenNotSupported {
+		t.Fatal("Listen: unsupported driver check fail")
+	}
+
+	if TestWPostgres {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		ch, err := pgDBs.Listen(ctx, "mssqlx_test")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// wait for subscription
+		for received := false; !received; {
+			pgDBs.Exec("NOTIFY mssqlx_test, 'hello'")
+
+			select {
+			case n := <-ch:
+				if n != nil && (n.Channel != "mssqlx_test" || n.Payload != "hello") {
+					t.Fatal("Listen: unexpected notification", n)
+				}
+				received = n != nil
+
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+
+		cancel()
+		for range ch {
+		}
+	}
+}
+
+func TestListenUnreachableNode(t *testing.T) {
+	dsn := "user=test1 dbname=test1 sslmode=disable host=127.0.0.1 port=1"
+	db, _ := sqlx.Open("postgres", dsn)
+	defer db.Close()
+
+	b := newBalancer(nil, 0, false)
+	defer b.destroy()
+
+	w := newWrapper(db, dsn, RoleMaster, 0)
+	b.add(w)
+	b.add(newWrapper(db, dsn, RoleMaster, 1))
+
+	// connection attempts keep failing, node is failed once threshold is reached
+	done := make(chan bool, 1)
+	go func() {
+		done <- _listenOn(context.Background(), b, &connectOptions{}, w, "mssqlx_test", make(chan *Notification))
+	}()
+
+	select {
+	case resubscribe := <-done:
+		if !resubscribe {
+			t.Fatal("Listen: should re-subscribe on another node")
+		}
+
+	case <-time.After(10 * time.Second):
+		t.Fatal("Listen: stuck connecting to unreachable node")
+	}
+
+	// cancelled while connecting
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		done <- _listenOn(ctx, b, &connectOptions{}, w, "mssqlx_test", make(chan *Notification))
+	}()
+	cancel()
+
+	select {
+	case resubscribe := <-done:
+		if resubscribe {
+			t.Fatal("Listen: should stop when ctx is done")
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen: stuck connecting after ctx is done")
+	}
+}
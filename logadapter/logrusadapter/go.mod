@@ -0,0 +1,10 @@
+module github.com/linxGnu/mssqlx/logadapter/logrusadapter
+
+require (
+	github.com/linxGnu/mssqlx v0.0.0
+	github.com/sirupsen/logrus v1.8.1
+)
+
+replace github.com/linxGnu/mssqlx => ../..
+
+go 1.13
@@ -0,0 +1,37 @@
+// Package logrusadapter bridges mssqlx logs to logrus.
+package logrusadapter
+
+import (
+	"github.com/linxGnu/mssqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type logger struct {
+	l logrus.FieldLogger
+}
+
+// New returns mssqlx.Logger writing to l.
+//
+//	mssqlx.SetLogger(logrusadapter.New(logrus.StandardLogger()))
+func New(l logrus.FieldLogger) mssqlx.Logger {
+	return &logger{l: l}
+}
+
+func (lg *logger) Log(lv mssqlx.LogLevel, msg string, fields ...mssqlx.LogField) {
+	lf := make(logrus.Fields, len(fields))
+	for _, f := range fields {
+		lf[f.Key] = f.Value
+	}
+
+	entry := lg.l.WithFields(lf)
+	switch lv {
+	case mssqlx.LogLevelDebug:
+		entry.Debug(msg)
+	case mssqlx.LogLevelInfo:
+		entry.Info(msg)
+	case mssqlx.LogLevelWarn:
+		entry.Warn(msg)
+	default:
+		entry.Error(msg)
+	}
+}
@@ -0,0 +1,24 @@
+package logrusadapter
+
+import (
+	"testing"
+
+	"github.com/linxGnu/mssqlx"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestLogger(t *testing.T) {
+	lr, hook := test.NewNullLogger()
+	l := New(lr)
+
+	l.Log(mssqlx.LogLevelDebug, "ignored")
+	l.Log(mssqlx.LogLevelError, "bad connection", mssqlx.LogField{Key: mssqlx.LogFieldRole, Value: "master"})
+
+	if len(hook.Entries) != 1 {
+		t.Fatal("unexpected number of entries", len(hook.Entries))
+	}
+	if e := hook.LastEntry(); e.Level != logrus.ErrorLevel || e.Message != "bad connection" || e.Data[mssqlx.LogFieldRole] != "master" {
+		t.Fatal("unexpected entry", e)
+	}
+}
@@ -0,0 +1,13 @@
+module github.com/linxGnu/mssqlx/logadapter/slogadapter
+
+require github.com/linxGnu/mssqlx v0.0.0
+
+require (
+	github.com/go-sql-driver/mysql v1.4.1-0.20191114115753-b4242bab7dc5 // indirect
+	github.com/jmoiron/sqlx v1.2.1-0.20190826204134-d7d95172beb5 // indirect
+	github.com/lib/pq v1.2.1-0.20191011153232-f91d3411e481 // indirect
+)
+
+replace github.com/linxGnu/mssqlx => ../..
+
+go 1.21
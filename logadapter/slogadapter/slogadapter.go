@@ -0,0 +1,46 @@
+//go:build go1.21
+// +build go1.21
+
+// Package slogadapter bridges mssqlx logs to log/slog.
+package slogadapter
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/linxGnu/mssqlx"
+)
+
+type logger struct {
+	l *slog.Logger
+}
+
+// New returns mssqlx.Logger writing to l. If l is nil, slog.Default() is used.
+//
+//	mssqlx.SetLogger(slogadapter.New(slog.Default()))
+func New(l *slog.Logger) mssqlx.Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &logger{l: l}
+}
+
+func level(lv mssqlx.LogLevel) slog.Level {
+	switch lv {
+	case mssqlx.LogLevelDebug:
+		return slog.LevelDebug
+	case mssqlx.LogLevelInfo:
+		return slog.LevelInfo
+	case mssqlx.LogLevelWarn:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
+
+func (s *logger) Log(lv mssqlx.LogLevel, msg string, fields ...mssqlx.LogField) {
+	attrs := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		attrs[i] = slog.Any(f.Key, f.Value)
+	}
+	s.l.LogAttrs(context.Background(), level(lv), msg, attrs...)
+}
@@ -0,0 +1,26 @@
+//go:build go1.21
+// +build go1.21
+
+package slogadapter
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/linxGnu/mssqlx"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	l.Log(mssqlx.LogLevelDebug, "ignored")
+	l.Log(mssqlx.LogLevelWarn, "node is down", mssqlx.LogField{Key: mssqlx.LogFieldNode, Value: "slave-1"})
+
+	out := buf.String()
+	if strings.Contains(out, "ignored") || !strings.Contains(out, "level=WARN") || !strings.Contains(out, `msg="node is down" node=slave-1`) {
+		t.Fatal("unexpected output", out)
+	}
+}
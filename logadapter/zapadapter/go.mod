@@ -0,0 +1,10 @@
+module github.com/linxGnu/mssqlx/logadapter/zapadapter
+
+require (
+	github.com/linxGnu/mssqlx v0.0.0
+	go.uber.org/zap v1.16.0
+)
+
+replace github.com/linxGnu/mssqlx => ../..
+
+go 1.13
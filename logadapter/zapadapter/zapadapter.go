@@ -0,0 +1,41 @@
+// Package zapadapter bridges mssqlx logs to zap.
+package zapadapter
+
+import (
+	"github.com/linxGnu/mssqlx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type logger struct {
+	l *zap.Logger
+}
+
+// New returns mssqlx.Logger writing to l.
+//
+//	mssqlx.SetLogger(zapadapter.New(zapLogger))
+func New(l *zap.Logger) mssqlx.Logger {
+	return &logger{l: l}
+}
+
+func level(lv mssqlx.LogLevel) zapcore.Level {
+	switch lv {
+	case mssqlx.LogLevelDebug:
+		return zapcore.DebugLevel
+	case mssqlx.LogLevelInfo:
+		return zapcore.InfoLevel
+	case mssqlx.LogLevelWarn:
+		return zapcore.WarnLevel
+	}
+	return zapcore.ErrorLevel
+}
+
+func (z *logger) Log(lv mssqlx.LogLevel, msg string, fields ...mssqlx.LogField) {
+	if ce := z.l.Check(level(lv), msg); ce != nil {
+		zf := make([]zap.Field, len(fields))
+		for i, f := range fields {
+			zf[i] = zap.Any(f.Key, f.Value)
+		}
+		ce.Write(zf...)
+	}
+}
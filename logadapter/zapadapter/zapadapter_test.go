@@ -0,0 +1,26 @@
+package zapadapter
+
+import (
+	"testing"
+
+	"github.com/linxGnu/mssqlx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := New(zap.New(core))
+
+	l.Log(mssqlx.LogLevelDebug, "ignored")
+	l.Log(mssqlx.LogLevelWarn, "node is down", mssqlx.LogField{Key: mssqlx.LogFieldNode, Value: "slave-1"})
+
+	entries := logs.AllUntimed()
+	if len(entries) != 1 {
+		t.Fatal("unexpected number of entries", len(entries))
+	}
+	if e := entries[0]; e.Level != zapcore.WarnLevel || e.Message != "node is down" || e.ContextMap()[mssqlx.LogFieldNode] != "slave-1" {
+		t.Fatal("unexpected entry", e)
+	}
+}
@@ -0,0 +1,121 @@
+package mssqlx
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// LogLevel is severity of a log entry.
+type LogLevel int
+
+const (
+	// LogLevelDebug debug level
+	LogLevelDebug LogLevel = iota
+
+	// LogLevelInfo info level
+	LogLevelInfo
+
+	// LogLevelWarn warning level
+	LogLevelWarn
+
+	// LogLevelError error level
+	LogLevelError
+)
+
+// String returns name of level.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	}
+	return "error"
+}
+
+// Keys of structured fields in log entries.
+const (
+	// LogFieldQuery query or operation related to entry
+	LogFieldQuery = "query"
+
+	// LogFieldNode name of node related to entry
+	LogFieldNode = "node"
+
+	// LogFieldRole role of node related to entry
+	LogFieldRole = "role"
+)
+
+// LogField is a structured field of log entry.
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger logs events of mssqlx, i.e query errors, node failures and recoveries.
+type Logger interface {
+	Log(level LogLevel, msg string, fields ...LogField)
+}
+
+// default logger writes errors to stderr: mssqlx;;time;;hostname;;query;;message
+type stderrLogger struct{}
+
+func (stderrLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	if level < LogLevelError {
+		return
+	}
+
+	var query string
+
+	others := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.Key == LogFieldQuery {
+			query = fmt.Sprint(f.Value)
+		} else {
+			others = append(others, fmt.Sprintf("%s=%v", f.Key, f.Value))
+		}
+	}
+
+	if len(others) > 0 {
+		msg += " " + strings.Join(others, " ")
+	}
+
+	os.Stderr.WriteString(fmt.Sprintf("mssqlx;;%s;;%s;;%s;;%s\n", time.Now().Format("2006-01-02 15:04:05"), hostName, query, msg))
+}
+
+type loggerHolder struct {
+	Logger
+}
+
+var logger atomic.Value // loggerHolder
+
+func init() {
+	logger.Store(loggerHolder{Logger: stderrLogger{}})
+}
+
+// SetLogger sets logger of mssqlx. Default logger writes errors to stderr.
+// Passing nil logger discards all logs.
+func SetLogger(l Logger) {
+	logger.Store(loggerHolder{Logger: l})
+}
+
+func getLogger() Logger {
+	return logger.Load().(loggerHolder).Logger
+}
+
+func logEntry(level LogLevel, msg string, fields ...LogField) {
+	if l := getLogger(); l != nil {
+		l.Log(level, msg, fields...)
+	}
+}
+
+func nodeFields(w *wrapper, fields ...LogField) []LogField {
+	if w != nil {
+		fields = append(fields, LogField{Key: LogFieldNode, Value: w.getName()}, LogField{Key: LogFieldRole, Value: string(w.getRole())})
+	}
+	return fields
+}
@@ -0,0 +1,41 @@
+package mssqlx
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (c *captureLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	c.mu.Lock()
+	c.entries = append(c.entries, fmt.Sprintf("%s %s %v", level, msg, fields))
+	c.mu.Unlock()
+}
+
+func TestLogger(t *testing.T) {
+	defer SetLogger(stderrLogger{})
+
+	if LogLevelDebug.String() != "debug" || LogLevelWarn.String() != "warn" || LogLevelError.String() != "error" {
+		t.Fatal("Logger: level name fail")
+	}
+
+	c := &captureLogger{}
+	SetLogger(c)
+
+	reportError("SELECT 1", nil)
+	reportError("SELECT 1", fmt.Errorf("abc"))
+	reportNodeError(newWrapper(nil, "", RoleSlave, 0), "SELECT 2", fmt.Errorf("def"))
+
+	if len(c.entries) != 2 || c.entries[0] != "error abc [{query SELECT 1}]" || c.entries[1] != "error def [{query SELECT 2} {node slave-0} {role slave}]" {
+		t.Fatal("Logger: unexpected entries", c.entries)
+	}
+
+	// discard
+	SetLogger(nil)
+	reportError("SELECT 1", fmt.Errorf("abc"))
+}
@@ -0,0 +1,65 @@
+package mssqlx
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrInvalidMaintenanceWindow maintenance window must end after it starts
+	ErrInvalidMaintenanceWindow = errors.New("Maintenance window must end after it starts")
+
+	// ErrNodeMaintenance cause of taking node out of rotation by MarkNodeMaintenance
+	ErrNodeMaintenance = errors.New("Node is in maintenance window")
+)
+
+// MarkNodeMaintenance schedules maintenance window [from, to) of slave (i.e slave-1), such as nightly backup or ANALYZE.
+// Slave is taken out of read rotation when window starts and put back by health checker once window is over
+// and node is healthy again.
+//
+// Calling it again replaces window, a window which is already over (i.e to before now) cancels maintenance.
+func (dbs *DBs) MarkNodeMaintenance(name string, from, to time.Time) error {
+	if !to.After(from) {
+		return ErrInvalidMaintenanceWindow
+	}
+
+	w := dbs.findNode(name)
+	if w == nil {
+		return ErrNodeNotFound
+	}
+	if w.getRole() != RoleSlave {
+		return ErrNotSlave
+	}
+
+	target, err := dbs.getBalancer(RoleSlave)
+	if err != nil {
+		return err
+	}
+
+	atomic.StoreInt64(&w.maintenanceFrom, from.UnixNano())
+	atomic.StoreInt64(&w.maintenanceTo, to.UnixNano())
+
+	if !to.After(time.Now()) {
+		return nil
+	}
+
+	start := func() {
+		if w.inMaintenance() {
+			target.failureWithCause(w, ErrNodeMaintenance)
+		}
+	}
+
+	if d := time.Until(from); d > 0 {
+		time.AfterFunc(d, start)
+	} else {
+		start()
+	}
+
+	return nil
+}
+
+func (w *wrapper) inMaintenance() bool {
+	now := time.Now().UnixNano()
+	return atomic.LoadInt64(&w.maintenanceFrom) <= now && now < atomic.LoadInt64(&w.maintenanceTo)
+}
@@ -0,0 +1,65 @@
+package mssqlx
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMarkNodeMaintenance(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:", ":memory:"})
+	defer dbs.Destroy()
+	dbs.SetSlaveHealthCheckPeriod(5)
+
+	now := time.Now()
+	if err := dbs.MarkNodeMaintenance("slave-0", now, now); err != ErrInvalidMaintenanceWindow {
+		t.Fatal("MarkNodeMaintenance: empty window should fail", err)
+	}
+	if err := dbs.MarkNodeMaintenance("slave-9", now, now.Add(time.Second)); err != ErrNodeNotFound {
+		t.Fatal("MarkNodeMaintenance: not found check fail", err)
+	}
+	if err := dbs.MarkNodeMaintenance("master-0", now, now.Add(time.Second)); err != ErrNotSlave {
+		t.Fatal("MarkNodeMaintenance: master should fail", err)
+	}
+
+	w := dbs.findNode("slave-0")
+	if err := dbs.MarkNodeMaintenance("slave-0", now.Add(30*time.Millisecond), now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if !dbs.slaves.dbs.contains(w) {
+		t.Fatal("MarkNodeMaintenance: node should serve reads before window")
+	}
+
+	for i := 0; i < 400 && dbs.slaves.dbs.contains(w); i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if dbs.slaves.dbs.contains(w) || !dbs.Status().Nodes[1].Maintenance {
+		t.Fatal("MarkNodeMaintenance: node should be out of rotation during window")
+	}
+	if events := dbs.Events(); len(events) != 1 || events[0].Cause != ErrNodeMaintenance.Error() {
+		t.Fatal("MarkNodeMaintenance: should be recorded", events)
+	}
+
+	// window is over
+	atomic.StoreInt64(&w.maintenanceTo, time.Now().UnixNano())
+	for i := 0; i < 400 && !dbs.slaves.dbs.contains(w); i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !dbs.slaves.dbs.contains(w) {
+		t.Fatal("MarkNodeMaintenance: node should be back after window")
+	}
+
+	// cancelled
+	w = dbs.findNode("slave-1")
+	_ = dbs.MarkNodeMaintenance("slave-1", now, now.Add(time.Hour))
+	if dbs.slaves.dbs.contains(w) {
+		t.Fatal("MarkNodeMaintenance: node should be out of rotation when window has started")
+	}
+	_ = dbs.MarkNodeMaintenance("slave-1", now.Add(-time.Hour), now)
+	for i := 0; i < 400 && !dbs.slaves.dbs.contains(w); i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !dbs.slaves.dbs.contains(w) {
+		t.Fatal("MarkNodeMaintenance: node should be back when maintenance is cancelled")
+	}
+}
@@ -0,0 +1,95 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+var (
+	// ErrTooManyRows result set has more rows than allowed maximum
+	ErrTooManyRows = errors.New("Result set has too many rows")
+)
+
+type maxRowsKey struct{}
+
+// WithMaxRows returns a context whose Select/BufferedQueryx queries fail with ErrTooManyRows
+// once more than n rows are read, overriding limit set by SetMaxRows. If n <= 0, queries made
+// with returned context are not limited.
+func WithMaxRows(ctx context.Context, n int) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if n < 0 {
+		n = 0
+	}
+	return context.WithValue(ctx, maxRowsKey{}, n)
+}
+
+func (c *balancer) setMaxRows(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt32(&c.maxRows, int32(n))
+}
+
+// maxRowsFor returns maximum number of rows a query made with ctx could read, 0 means unlimited.
+func (c *balancer) maxRowsFor(ctx context.Context) int {
+	if ctx != nil {
+		if n, ok := ctx.Value(maxRowsKey{}).(int); ok {
+			return n
+		}
+	}
+	return int(atomic.LoadInt32(&c.maxRows))
+}
+
+// SetMaxRows sets maximum number of rows Select/BufferedQueryx could read. Queries reading
+// more rows are aborted with ErrTooManyRows, protecting from unbounded result sets.
+//
+// If n <= 0, there is no limit. The default is 0.
+func (dbs *DBs) SetMaxRows(n int) {
+	dbs.masters.setMaxRows(n)
+	dbs.slaves.setMaxRows(n)
+}
+
+// scanLimited is sqlx.StructScan which stops with ErrTooManyRows once more than limit rows are read.
+func scanLimited(rows *sqlx.Rows, dest interface{}, limit int) error {
+	v := reflect.ValueOf(dest).Elem()
+	elemType := v.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	baseType := reflectx.Deref(elemType)
+	scannable := isScannableType(rows.Mapper, baseType)
+
+	result := v
+	for n := 0; rows.Next(); n++ {
+		if n >= limit {
+			return ErrTooManyRows
+		}
+
+		elem := reflect.New(baseType)
+		if scannable {
+			if err := rows.Scan(elem.Interface()); err != nil {
+				return err
+			}
+		} else if err := rows.StructScan(elem.Interface()); err != nil {
+			return err
+		}
+
+		if isPtr {
+			result = reflect.Append(result, elem)
+		} else {
+			result = reflect.Append(result, elem.Elem())
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	v.Set(result)
+	return nil
+}
@@ -0,0 +1,58 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMaxRows(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	query := "SELECT 1 AS id UNION ALL SELECT 2 UNION ALL SELECT 3"
+
+	var ids []int
+	if err := dbs.Select(&ids, query); err != nil || len(ids) != 3 {
+		t.Fatal("MaxRows: unlimited select fail", err, ids)
+	}
+
+	dbs.SetMaxRows(2)
+
+	ids = nil
+	if err := dbs.Select(&ids, query); err != ErrTooManyRows || len(ids) != 0 {
+		t.Fatal("MaxRows: limited select fail", err, ids)
+	}
+
+	type row struct {
+		ID int `db:"id"`
+	}
+	var rows []*row
+	if err := dbs.SelectOnMaster(&rows, query); err != ErrTooManyRows {
+		t.Fatal("MaxRows: limited struct select fail", err)
+	}
+	if err := dbs.SelectContext(WithMaxRows(context.Background(), 3), &rows, query); err != nil || len(rows) != 3 || rows[2].ID != 3 {
+		t.Fatal("MaxRows: per-call limit fail", err)
+	}
+	if err := dbs.SelectContext(context.Background(), &rows, query+" LIMIT 2"); err != nil || len(rows) != 5 {
+		t.Fatal("MaxRows: select under limit fail", err)
+	}
+
+	if _, err := dbs.BufferedQueryx(query); err != ErrTooManyRows {
+		t.Fatal("MaxRows: buffered query fail", err)
+	}
+	if r, err := dbs.BufferedQueryxContext(WithMaxRows(context.Background(), 0), query); err != nil {
+		t.Fatal("MaxRows: disabled per-call limit fail", err)
+	} else {
+		_ = r.Close()
+	}
+
+	var sum int
+	if err := dbs.ScanAggregate(context.Background(), query, nil, func(row RowScanner) error {
+		var id int
+		err := row.Scan(&id)
+		sum += id
+		return err
+	}); err != nil || sum != 6 {
+		t.Fatal("MaxRows: streaming aggregate should not be limited", err, sum)
+	}
+}
@@ -0,0 +1,68 @@
+package mssqlx
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// RoutingStats quantifies effectiveness of read/write splitting.
+type RoutingStats struct {
+	// ReadOnlyWrites number of writes rejected by read-only nodes, i.e attempted on slaves
+	ReadOnlyWrites uint64 `json:"read_only_writes"`
+
+	// FallbackReads number of slave-balanced reads served by masters because of fallback
+	// (max staleness or routing chain), excluding ForceMaster directive
+	FallbackReads uint64 `json:"fallback_reads"`
+}
+
+type routingCounters struct {
+	readOnlyWrites uint64
+	fallbackReads  uint64
+}
+
+func (r *routingCounters) load() (s RoutingStats) {
+	if r != nil {
+		s.ReadOnlyWrites, s.FallbackReads = atomic.LoadUint64(&r.readOnlyWrites), atomic.LoadUint64(&r.fallbackReads)
+	}
+	return
+}
+
+// isReadOnlyError reports whether err is rejection of a write by read-only node.
+//
+// ERROR 1290: The MySQL server is running with the --read-only option
+// ERROR 1792: Cannot execute statement in a READ ONLY transaction
+// SQLSTATE 25006: cannot execute ... in a read-only transaction (postgres)
+func isReadOnlyError(err error) (v bool) {
+	if err != nil {
+		se := err.Error()
+		v = strings.HasPrefix(se, "Error 1290:") || strings.HasPrefix(se, "ERROR 1290:") ||
+			strings.HasPrefix(se, "Error 1792:") || strings.HasPrefix(se, "ERROR 1792:") ||
+			strings.Contains(se, "25006") || strings.Contains(se, "read-only transaction") ||
+			strings.Contains(se, "readonly database")
+	}
+	return
+}
+
+// checkMisroute counts and reports writes rejected by read-only nodes.
+func (c *balancer) checkMisroute(w *wrapper, query string, err error) {
+	if c.routing != nil && isReadOnlyError(err) {
+		atomic.AddUint64(&c.routing.readOnlyWrites, 1)
+		logEntry(LogLevelWarn, "write is rejected by read-only node", nodeFields(w, LogField{Key: LogFieldQuery, Value: query})...)
+	}
+}
+
+// fallback counts reads served by masters because of fallback.
+func (c *balancer) fallback() *balancer {
+	if c.routing != nil {
+		atomic.AddUint64(&c.routing.fallbackReads, 1)
+	}
+	return c.master
+}
+
+// RoutingStats returns read/write splitting statistics, useful to catch code paths misusing the API.
+func (dbs *DBs) RoutingStats() (s RoutingStats) {
+	if dbs.slaves != nil {
+		s = dbs.slaves.routing.load()
+	}
+	return
+}
@@ -0,0 +1,47 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMisroute(t *testing.T) {
+	if !isReadOnlyError(errors.New("Error 1290: The MySQL server is running with the --read-only option")) ||
+		!isReadOnlyError(errors.New("pq: cannot execute INSERT in a read-only transaction")) ||
+		isReadOnlyError(errors.New("Error 1213: Deadlock")) || isReadOnlyError(nil) {
+		t.Fatal("Misroute: read-only error detection fail")
+	}
+
+	dir, err := ioutil.TempDir("", "mssqlx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "misroute.db")
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{path}, []string{"file:" + path + "?mode=ro"})
+	defer dbs.Destroy()
+
+	if _, err = dbs.Exec("CREATE TABLE t (a INT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = dbs.ExecOnSlave("INSERT INTO t VALUES (1)"); err == nil {
+		t.Fatal("Misroute: write on read-only slave should fail")
+	}
+	if s := dbs.RoutingStats(); s.ReadOnlyWrites != 1 || s.FallbackReads != 0 {
+		t.Fatal("Misroute: read-only writes fail", s)
+	}
+
+	dbs.slaves.dbs.remove(dbs.slaveNodes()[0])
+	if _, target := dbs.slaves.route(WithRouteChain(context.Background(), AnySlave, Master), "SELECT 1"); target != dbs.masters {
+		t.Fatal("Misroute: fallback route fail")
+	}
+	if st := dbs.Status(); st.Routing.FallbackReads != 1 {
+		t.Fatal("Misroute: fallback reads fail", st.Routing)
+	}
+}
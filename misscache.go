@@ -0,0 +1,249 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+)
+
+// missPolicy is miss caching of a query, registered by SetMissCache
+type missPolicy struct {
+	table string
+	ttl   time.Duration
+}
+
+type missEntry struct {
+	table   string
+	gen     uint64 // generation of table when read started
+	global  uint64
+	expires int64
+}
+
+// missCache caches misses (sql.ErrNoRows) of Get, until they expire or a write to their table is made
+type missCache struct {
+	policies sync.Map // query => missPolicy
+
+	mu        sync.Mutex
+	entries   map[string]missEntry
+	gens      map[string]uint64 // table => generation, bumped by writes
+	global    uint64            // bumped by writes of unknown tables
+	writtenAt map[string]int64  // table => unix nano of last write
+	globalAt  int64             // unix nano of last write of unknown table
+	sweepAt   int
+}
+
+// SetMissCache caches misses (sql.ErrNoRows) of Get of query for ttl, i.e a hot lookup which usually misses.
+// Misses are cached per args and routing directives of context, and invalidated by writes to table made through
+// DBs (Exec, NamedExec, Batch and statements of transactions started by BeginNestedTx).
+// Writes which table could not be told (i.e DDL, multi-table UPDATE, WITH) invalidate all misses.
+// Since slaves might lag behind a write, misses of table served by slaves are not cached for ttl after it is written.
+//
+// Cache is local to process: writes made by others are seen once misses expire. Query must be exactly
+// the same text. If ttl <= 0, caching of query is disabled, which is default.
+func (dbs *DBs) SetMissCache(query, table string, ttl time.Duration) {
+	if ttl <= 0 {
+		dbs.misses.policies.Delete(query)
+	} else {
+		dbs.misses.policies.Store(query, missPolicy{table: normalizeTable(table), ttl: ttl})
+	}
+}
+
+func (m *missCache) policy(query string) (p missPolicy, ok bool) {
+	if m != nil {
+		var v interface{}
+		if v, ok = m.policies.Load(query); ok {
+			p = v.(missPolicy)
+		}
+	}
+	return
+}
+
+// lookup reports whether miss of key is cached, otherwise returns entry to store if read misses
+func (m *missCache) lookup(key string, p missPolicy, now time.Time) (hit bool, e missEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := missEntry{table: p.table, gen: m.gens[p.table], global: m.global}
+	if e, ok := m.entries[key]; ok {
+		if e.gen == current.gen && e.global == current.global && now.UnixNano() < e.expires {
+			return true, e
+		}
+		delete(m.entries, key)
+	}
+
+	current.expires = now.Add(p.ttl).UnixNano()
+	return false, current
+}
+
+// store caches a miss, unless its table is written since read started. Misses served by slaves are not cached
+// within grace after table is written, since they might not have replicated the write yet.
+func (m *missCache) store(key string, e missEntry, grace time.Duration, master bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e.gen != m.gens[e.table] || e.global != m.global {
+		return
+	}
+
+	if !master {
+		since := time.Now().UnixNano() - int64(grace)
+		if m.writtenAt[e.table] > since || m.globalAt > since {
+			return
+		}
+	}
+
+	if m.entries == nil {
+		m.entries = make(map[string]missEntry)
+	}
+	m.entries[key] = e
+
+	if len(m.entries) >= m.sweepAt {
+		now := time.Now().UnixNano()
+		for k, e := range m.entries {
+			if e.gen != m.gens[e.table] || e.global != m.global || now >= e.expires {
+				delete(m.entries, k)
+			}
+		}
+		m.sweepAt = 2*len(m.entries) + 1024
+	}
+}
+
+// invalidate drops misses of table written by query
+func (m *missCache) invalidate(query string) {
+	if m == nil {
+		return
+	}
+
+	kind := statementKind(query)
+	if kind == kindSelect || kind == kindOther && !mayWrite(query) {
+		return
+	}
+
+	table := ""
+	if kind == kindInsert || kind == kindUpdate || kind == kindDelete {
+		table = writeTable(query)
+	}
+
+	now := time.Now().UnixNano()
+
+	m.mu.Lock()
+	if table == "" {
+		m.global++
+		m.globalAt = now
+	} else {
+		if m.gens == nil {
+			m.gens = make(map[string]uint64)
+			m.writtenAt = make(map[string]int64)
+		}
+		m.gens[table]++
+		m.writtenAt[table] = now
+	}
+	m.mu.Unlock()
+}
+
+// mayWrite reports whether statement of other type might write, i.e CALL
+func mayWrite(query string) bool {
+	switch keyword, _ := nextKeyword(query); keyword {
+	case "SET", "SHOW", "EXPLAIN", "BEGIN", "START", "COMMIT", "ROLLBACK", "SAVEPOINT", "RELEASE":
+		return false
+	}
+	return true
+}
+
+// written invalidates misses of table written by statement of transaction, again once transaction is finished
+func (s *txState) written(query string) {
+	if s.misses == nil {
+		return
+	}
+
+	if kind := statementKind(query); kind != kindSelect && (kind != kindOther || mayWrite(query)) {
+		s.misses.invalidate(query)
+
+		s.mu.Lock()
+		s.writes = append(s.writes, query)
+		s.mu.Unlock()
+	}
+}
+
+// invalidateWrites invalidates misses of tables written by transaction
+func (s *txState) invalidateWrites() {
+	if s.misses == nil {
+		return
+	}
+
+	s.mu.Lock()
+	writes := s.writes
+	s.writes = nil
+	s.mu.Unlock()
+
+	for _, query := range writes {
+		s.misses.invalidate(query)
+	}
+}
+
+// getMissCached runs Get by run, unless its miss is cached
+func (c *balancer) getMissCached(ctx context.Context, query string, args []interface{}, run func() (*wrapper, error)) (*wrapper, error) {
+	p, ok := c.misses.policy(query)
+	if !ok {
+		return run()
+	}
+
+	key := coalesceKey(ctx, "get", query, args)
+	hit, e := c.misses.lookup(key, p, time.Now())
+	if hit {
+		return nil, sql.ErrNoRows
+	}
+
+	w, err := run()
+	if err == sql.ErrNoRows {
+		c.misses.store(key, e, p.ttl, w != nil && w.getRole() == RoleMaster)
+	}
+	return w, err
+}
+
+// writeTable returns normalized table written by INSERT, UPDATE or DELETE query, empty if it could not be told
+func writeTable(query string) string {
+	keyword, rest := nextKeyword(query)
+	if keyword == "WITH" {
+		return ""
+	}
+
+	fields := strings.Fields(rest)
+	for len(fields) > 0 {
+		switch strings.ToUpper(fields[0]) {
+		case "INTO", "FROM", "IGNORE", "LOW_PRIORITY", "HIGH_PRIORITY", "DELAYED", "QUICK", "ONLY":
+			fields = fields[1:]
+			continue
+		}
+		break
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// multi-table statements
+	if keyword == "UPDATE" || keyword == "DELETE" {
+		if strings.HasSuffix(fields[0], ",") {
+			return ""
+		}
+		for _, field := range fields {
+			if field = strings.ToUpper(field); field == "JOIN" || field == "USING" {
+				return ""
+			}
+		}
+	}
+
+	table := fields[0]
+	if i := strings.IndexAny(table, "(,;"); i >= 0 {
+		table = table[:i]
+	}
+	return normalizeTable(table)
+}
+
+// normalizeTable lower-cases unqualified, unquoted name of table
+func normalizeTable(table string) string {
+	_, name := splitTable(table)
+	return strings.ToLower(strings.Trim(name, "`\"[]"))
+}
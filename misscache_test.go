@@ -0,0 +1,156 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestMissCache(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{"file:misscache?mode=memory&cache=shared"}, nil)
+	defer dbs.Destroy()
+
+	if _, err := dbs.Exec("CREATE TABLE miss_users (id INTEGER, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	query := "SELECT name FROM miss_users WHERE id = ?"
+	dbs.SetMissCache(query, "Miss_Users", time.Minute)
+
+	w := dbs.masterNodes()[0]
+	get := func(id int) (name string, queries uint64, err error) {
+		before, _ := w.stats.load()
+		err = dbs.GetOnMaster(&name, query, id)
+		after, _ := w.stats.load()
+		return name, after - before, err
+	}
+
+	if _, n, err := get(1); err != sql.ErrNoRows || n != 1 {
+		t.Fatal("MissCache: first miss should be queried", n, err)
+	}
+	if _, n, err := get(1); err != sql.ErrNoRows || n != 0 {
+		t.Fatal("MissCache: miss should be cached", n, err)
+	}
+	if _, n, _ := get(2); n != 1 {
+		t.Fatal("MissCache: misses should be cached per args", n)
+	}
+
+	// writes to other tables keep misses
+	_, _ = dbs.Exec("CREATE TABLE miss_other (id INTEGER)")
+	if _, n, _ := get(1); n != 1 {
+		t.Fatal("MissCache: DDL should invalidate misses", n)
+	}
+	_, _ = dbs.Exec("INSERT INTO miss_other VALUES (1)")
+	if _, n, _ := get(1); n != 0 {
+		t.Fatal("MissCache: write to other table should keep misses", n)
+	}
+
+	if _, err := dbs.Exec("INSERT INTO miss_users VALUES (1, 'jon')"); err != nil {
+		t.Fatal(err)
+	}
+	if name, _, err := get(1); err != nil || name != "jon" {
+		t.Fatal("MissCache: write should invalidate misses", name, err)
+	}
+
+	_, _, _ = get(4)
+	dbs.MustExec("INSERT INTO miss_users VALUES (4, 'arya')")
+	if name, _, err := get(4); err != nil || name != "arya" {
+		t.Fatal("MissCache: MustExec should invalidate misses", name, err)
+	}
+
+	// transaction
+	_, _, _ = get(2)
+	tx, err := dbs.BeginNestedTx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Exec("INSERT INTO miss_users VALUES (2, 'snow')"); err != nil {
+		t.Fatal(err)
+	}
+	if _, n, _ := get(2); n != 1 {
+		t.Fatal("MissCache: write of transaction should invalidate misses", n)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if name, _, err := get(2); err != nil || name != "snow" {
+		t.Fatal("MissCache: commit should invalidate misses", name, err)
+	}
+
+	// expired, disabled
+	dbs.SetMissCache(query, "miss_users", time.Millisecond)
+	_, _, _ = get(3)
+	time.Sleep(5 * time.Millisecond)
+	if _, n, _ := get(3); n != 1 {
+		t.Fatal("MissCache: miss should expire", n)
+	}
+
+	dbs.SetMissCache(query, "", 0)
+	_, _, _ = get(4)
+	if _, n, _ := get(4); n != 1 {
+		t.Fatal("MissCache: should be disabled", n)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	for query, expected := range map[string]string{
+		"INSERT INTO users (id) VALUES (1)":                          "users",
+		"insert ignore into `app`.`Users`(id) VALUES(1)":             "users",
+		"REPLACE INTO users VALUES (1)":                              "users",
+		`UPDATE "public"."users" SET name = 'a'`:                     "users",
+		"UPDATE LOW_PRIORITY users SET name = 'a'":                   "users",
+		"DELETE FROM users WHERE id = 1":                             "users",
+		"DELETE FROM ONLY users WHERE id = 1":                        "users",
+		"UPDATE users u JOIN orders o ON o.uid = u.id SET u.n = 1":   "",
+		"DELETE FROM users USING orders WHERE orders.uid = users.id": "",
+		"DELETE users, orders FROM users JOIN orders":                "",
+		"INSERT": "",
+		"WITH u AS (SELECT 1) UPDATE users SET n = 1": "",
+	} {
+		if actual := writeTable(query); actual != expected {
+			t.Fatal("WriteTable: unexpected table", query, actual)
+		}
+	}
+}
+
+func TestMissCacheAfterWrite(t *testing.T) {
+	dsn := "file:misscachelag?mode=memory&cache=shared"
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{dsn}, []string{dsn})
+	defer dbs.Destroy()
+
+	if _, err := dbs.Exec("CREATE TABLE lag_users (id INTEGER, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	query := "SELECT name FROM lag_users WHERE id = ?"
+	dbs.SetMissCache(query, "lag_users", time.Minute)
+
+	w := dbs.slaveNodes()[0]
+	get := func(id int) uint64 {
+		var name string
+		before, _ := w.stats.load()
+		_ = dbs.Get(&name, query, id)
+		after, _ := w.stats.load()
+		return after - before
+	}
+
+	// misses served by slaves right after a write to table might be stale
+	if _, err := dbs.Exec("INSERT INTO lag_users VALUES (1, 'jon')"); err != nil {
+		t.Fatal(err)
+	}
+	_ = get(2)
+	if n := get(2); n != 1 {
+		t.Fatal("MissCache: miss of slave after write should not be cached", n)
+	}
+
+	dbs.misses.mu.Lock()
+	dbs.misses.writtenAt["lag_users"] -= int64(time.Minute)
+	dbs.misses.globalAt -= int64(time.Minute)
+	dbs.misses.mu.Unlock()
+
+	_ = get(2)
+	if n := get(2); n != 0 {
+		t.Fatal("MissCache: miss of slave should be cached after grace", n)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -40,17 +41,66 @@ func ping(w *wrapper) (err error) {
 
 // DBs sqlx wrapper supports querying master-slave database connections for HA and scalability, auto-balancer integrated.
 type DBs struct {
+	bufferLimit        int64 // first field, 64-bit aligned for atomic access
+	clockSkewThreshold int64
+	maxTxDuration      int64
+
 	driverName string
+	opts       connectOptions // parsed args of ConnectMasterSlaves
 
 	masters *balancer
 	slaves  *balancer
 	all     *balancer
 
-	_masters []*wrapper
-	_slaves  []*wrapper
-	_all     []*wrapper
+	nodes atomic.Value // *nodeSets
+
+	selectParallelLimit int32
+	lifetimeJitter      int32
+
+	events *eventLog
+
+	topologyLock sync.Mutex
+
+	routeChains sync.Map
+
+	coalesced sync.Map // query => struct{}, registered by SetReadCoalescing
+
+	misses missCache // misses of queries registered by SetMissCache
+
+	txs sync.Map // *Tx => struct{}, transactions started by BeginNestedTx
+
+	leaks *leakTracker
+
+	virtual virtualDBs // handles returned by WriterDB/ReaderDB
+
+	capabilities atomic.Value // capabilitiesHolder
+}
+
+// nodeSets is a snapshot of nodes by role, replaced as a whole on topology changes
+type nodeSets struct {
+	masters []*wrapper
+	slaves  []*wrapper
+	all     []*wrapper
 }
 
+func (dbs *DBs) nodeSets() *nodeSets {
+	if s, ok := dbs.nodes.Load().(*nodeSets); ok {
+		return s
+	}
+	return &nodeSets{}
+}
+
+// setNodes publishes node sets, which must not be modified afterward
+func (dbs *DBs) setNodes(masters, slaves, all []*wrapper) {
+	dbs.nodes.Store(&nodeSets{masters: masters, slaves: slaves, all: all})
+}
+
+func (dbs *DBs) masterNodes() []*wrapper { return dbs.nodeSets().masters }
+
+func (dbs *DBs) slaveNodes() []*wrapper { return dbs.nodeSets().slaves }
+
+func (dbs *DBs) allNodes() []*wrapper { return dbs.nodeSets().all }
+
 // DriverName returns the driverName passed to the Open function for this DB.
 func (dbs *DBs) DriverName() string {
 	return dbs.driverName
@@ -67,12 +117,12 @@ func (dbs *DBs) getDBs(s []*wrapper) ([]*sqlx.DB, int) {
 
 // GetAllMasters get all master database connections, included failing one.
 func (dbs *DBs) GetAllMasters() ([]*sqlx.DB, int) {
-	return dbs.getDBs(dbs._masters)
+	return dbs.getDBs(dbs.masterNodes())
 }
 
 // GetAllSlaves get all slave database connections, included failing one.
 func (dbs *DBs) GetAllSlaves() ([]*sqlx.DB, int) {
-	return dbs.getDBs(dbs._slaves)
+	return dbs.getDBs(dbs.slaveNodes())
 }
 
 func _ping(target []*wrapper) []error {
@@ -104,17 +154,17 @@ func _ping(target []*wrapper) []error {
 
 // Ping all master-slave database connections
 func (dbs *DBs) Ping() []error {
-	return _ping(dbs._all)
+	return _ping(dbs.allNodes())
 }
 
 // PingMaster all master database connections
 func (dbs *DBs) PingMaster() []error {
-	return _ping(dbs._masters)
+	return _ping(dbs.masterNodes())
 }
 
 // PingSlave all slave database connections
 func (dbs *DBs) PingSlave() []error {
-	return _ping(dbs._slaves)
+	return _ping(dbs.slaveNodes())
 }
 
 func _close(target []*wrapper) []error {
@@ -135,6 +185,7 @@ func _close(target []*wrapper) []error {
 			wg.Add(1)
 			go func(db *wrapper, ind int, wg *sync.WaitGroup) {
 				errResult[ind] = db.db.Close()
+				db.closeProbe()
 				wg.Done()
 			}(db, i, &wg)
 		}
@@ -149,7 +200,9 @@ func _close(target []*wrapper) []error {
 // It is rare to Close a DB, as the DB handle is meant to be
 // long-lived and shared between many goroutines.
 func (dbs *DBs) Destroy() []error {
-	res := _close(dbs._all)
+	dbs.virtual.close()
+
+	res := _close(dbs.allNodes())
 
 	if dbs.masters != nil {
 		dbs.masters.destroy()
@@ -172,7 +225,7 @@ func (dbs *DBs) DestroyMaster() []error {
 		dbs.masters.destroy()
 	}
 
-	return _close(dbs._masters)
+	return _close(dbs.masterNodes())
 }
 
 // DestroySlave closes all master database connections, releasing any open resources.
@@ -184,7 +237,7 @@ func (dbs *DBs) DestroySlave() []error {
 		dbs.slaves.destroy()
 	}
 
-	return _close(dbs._slaves)
+	return _close(dbs.slaveNodes())
 }
 
 func _setMaxIdleConns(target []*wrapper, n int) {
@@ -202,7 +255,7 @@ func _setMaxIdleConns(target []*wrapper, n int) {
 		if db != nil && db.db != nil {
 			wg.Add(1)
 			go func(db *wrapper, wg *sync.WaitGroup) {
-				db.db.SetMaxIdleConns(n)
+				db.setMaxIdleConns(n)
 				wg.Done()
 			}(db, &wg)
 		}
@@ -243,7 +296,7 @@ func (dbs *DBs) SetSlaveHealthCheckPeriod(period uint64) {
 //
 // If n <= 0, no idle connections are retained.
 func (dbs *DBs) SetMaxIdleConns(n int) {
-	_setMaxIdleConns(dbs._all, n)
+	_setMaxIdleConns(dbs.allNodes(), n)
 }
 
 // SetMasterMaxIdleConns sets the maximum number of connections in the idle
@@ -254,7 +307,7 @@ func (dbs *DBs) SetMaxIdleConns(n int) {
 //
 // If n <= 0, no idle connections are retained.
 func (dbs *DBs) SetMasterMaxIdleConns(n int) {
-	_setMaxIdleConns(dbs._masters, n)
+	_setMaxIdleConns(dbs.masterNodes(), n)
 }
 
 // SetSlaveMaxIdleConns sets the maximum number of connections in the idle
@@ -265,7 +318,7 @@ func (dbs *DBs) SetMasterMaxIdleConns(n int) {
 //
 // If n <= 0, no idle connections are retained.
 func (dbs *DBs) SetSlaveMaxIdleConns(n int) {
-	_setMaxIdleConns(dbs._slaves, n)
+	_setMaxIdleConns(dbs.slaveNodes(), n)
 }
 
 func _setMaxOpenConns(target []*wrapper, n int) {
@@ -300,7 +353,7 @@ func _setMaxOpenConns(target []*wrapper, n int) {
 // If n <= 0, then there is no limit on the number of open connections.
 // The default is 0 (unlimited).
 func (dbs *DBs) SetMaxOpenConns(n int) {
-	_setMaxOpenConns(dbs._all, n)
+	_setMaxOpenConns(dbs.allNodes(), n)
 }
 
 // SetMasterMaxOpenConns sets the maximum number of open connections to the master databases.
@@ -312,7 +365,7 @@ func (dbs *DBs) SetMaxOpenConns(n int) {
 // If n <= 0, then there is no limit on the number of open connections.
 // The default is 0 (unlimited).
 func (dbs *DBs) SetMasterMaxOpenConns(n int) {
-	_setMaxOpenConns(dbs._masters, n)
+	_setMaxOpenConns(dbs.masterNodes(), n)
 }
 
 // SetSlaveMaxOpenConns sets the maximum number of open connections to the slave databases.
@@ -324,10 +377,15 @@ func (dbs *DBs) SetMasterMaxOpenConns(n int) {
 // If n <= 0, then there is no limit on the number of open connections.
 // The default is 0 (unlimited).
 func (dbs *DBs) SetSlaveMaxOpenConns(n int) {
-	_setMaxOpenConns(dbs._slaves, n)
+	_setMaxOpenConns(dbs.slaveNodes(), n)
 }
 
 func _setConnMaxLifetime(target []*wrapper, d time.Duration) {
+	_setConnMaxLifetimeJitter(target, d, 0)
+}
+
+// _setConnMaxLifetimeJitter sets lifetime of each node shortened by random jitter, in percent
+func _setConnMaxLifetimeJitter(target []*wrapper, d time.Duration, jitter int) {
 	if target == nil {
 		return
 	}
@@ -341,10 +399,10 @@ func _setConnMaxLifetime(target []*wrapper, d time.Duration) {
 	for _, db := range target {
 		if db != nil && db.db != nil {
 			wg.Add(1)
-			go func(db *wrapper, wg *sync.WaitGroup) {
+			go func(db *wrapper, d time.Duration, wg *sync.WaitGroup) {
 				db.db.SetConnMaxLifetime(d)
 				wg.Done()
-			}(db, &wg)
+			}(db, jitterLifetime(d, jitter), &wg)
 		}
 	}
 	wg.Wait()
@@ -354,9 +412,9 @@ func _setConnMaxLifetime(target []*wrapper, d time.Duration) {
 //
 // Expired connections may be closed lazily before reuse.
 //
-// If d <= 0, connections are reused forever.
+// If d <= 0, connections are reused forever. Lifetime of each node is jittered, see SetConnMaxLifetimeJitter.
 func (dbs *DBs) SetConnMaxLifetime(d time.Duration) {
-	_setConnMaxLifetime(dbs._all, d)
+	_setConnMaxLifetimeJitter(dbs.allNodes(), d, dbs.getLifetimeJitter())
 }
 
 // SetMasterConnMaxLifetime sets the maximum amount of time a master connection may be reused.
@@ -365,7 +423,7 @@ func (dbs *DBs) SetConnMaxLifetime(d time.Duration) {
 //
 // If d <= 0, connections are reused forever.
 func (dbs *DBs) SetMasterConnMaxLifetime(d time.Duration) {
-	_setConnMaxLifetime(dbs._masters, d)
+	_setConnMaxLifetimeJitter(dbs.masterNodes(), d, dbs.getLifetimeJitter())
 }
 
 // SetSlaveConnMaxLifetime sets the maximum amount of time a slave connection may be reused.
@@ -374,7 +432,40 @@ func (dbs *DBs) SetMasterConnMaxLifetime(d time.Duration) {
 //
 // If d <= 0, connections are reused forever.
 func (dbs *DBs) SetSlaveConnMaxLifetime(d time.Duration) {
-	_setConnMaxLifetime(dbs._slaves, d)
+	_setConnMaxLifetimeJitter(dbs.slaveNodes(), d, dbs.getLifetimeJitter())
+}
+
+func _setMaxConcurrentQueries(target []*wrapper, n int) {
+	for _, db := range target {
+		if db != nil {
+			db.limiter.setLimit(n)
+		}
+	}
+}
+
+// SetMaxConcurrentQueries sets the maximum number of concurrent queries per node for all master-slave databases.
+//
+// When limit of a node is saturated, queries are queued by their priority, see WithPriority.
+// Streaming rows, transactions started by BeginNestedTx and Conns hold their slot until they are closed,
+// rows of QueryRow until scanned. Other transactions and QueryRowx hold it only while beginning or querying.
+//
+// If n <= 0, then there is no limit. The default is 0 (unlimited).
+func (dbs *DBs) SetMaxConcurrentQueries(n int) {
+	_setMaxConcurrentQueries(dbs.allNodes(), n)
+}
+
+// SetMasterMaxConcurrentQueries sets the maximum number of concurrent queries per master node.
+//
+// If n <= 0, then there is no limit. The default is 0 (unlimited).
+func (dbs *DBs) SetMasterMaxConcurrentQueries(n int) {
+	_setMaxConcurrentQueries(dbs.masterNodes(), n)
+}
+
+// SetSlaveMaxConcurrentQueries sets the maximum number of concurrent queries per slave node.
+//
+// If n <= 0, then there is no limit. The default is 0 (unlimited).
+func (dbs *DBs) SetSlaveMaxConcurrentQueries(n int) {
+	_setMaxConcurrentQueries(dbs.slaveNodes(), n)
 }
 
 func _stats(target []*wrapper) []sql.DBStats {
@@ -406,19 +497,19 @@ func _stats(target []*wrapper) []sql.DBStats {
 
 // Stats returns database statistics.
 func (dbs *DBs) Stats() (stats []sql.DBStats) {
-	stats = _stats(dbs._all)
+	stats = _stats(dbs.allNodes())
 	return
 }
 
 // StatsMaster returns master database statistics.
 func (dbs *DBs) StatsMaster() (stats []sql.DBStats) {
-	stats = _stats(dbs._masters)
+	stats = _stats(dbs.masterNodes())
 	return
 }
 
 // StatsSlave returns slave database statistics.
 func (dbs *DBs) StatsSlave() (stats []sql.DBStats) {
-	stats = _stats(dbs._slaves)
+	stats = _stats(dbs.slaveNodes())
 	return
 }
 
@@ -438,6 +529,7 @@ func _mapperFunc(target []*wrapper, mf func(string) string) {
 			wg.Add(1)
 			go func(db *wrapper, ind int) {
 				db.db.MapperFunc(mf)
+				mapperNames.Store(db.db.Mapper, mf)
 				wg.Done()
 			}(db, ind)
 		}
@@ -448,28 +540,24 @@ func _mapperFunc(target []*wrapper, mf func(string) string) {
 // MapperFunc sets a new mapper for this db using the default sqlx struct tag
 // and the provided mapper function.
 func (dbs *DBs) MapperFunc(mf func(string) string) {
-	_mapperFunc(dbs._all, mf)
+	_mapperFunc(dbs.allNodes(), mf)
 }
 
 // MapperFuncMaster sets a new mapper for this db using the default sqlx struct tag
 // and the provided mapper function.
 func (dbs *DBs) MapperFuncMaster(mf func(string) string) {
-	_mapperFunc(dbs._masters, mf)
+	_mapperFunc(dbs.masterNodes(), mf)
 }
 
 // MapperFuncSlave sets a new mapper for this db using the default sqlx struct tag
 // and the provided mapper function.
 func (dbs *DBs) MapperFuncSlave(mf func(string) string) {
-	_mapperFunc(dbs._slaves, mf)
+	_mapperFunc(dbs.slaveNodes(), mf)
 }
 
 // Rebind transforms a query from QUESTION to the DB driver's bindvar type.
 func (dbs *DBs) Rebind(query string) string {
-	if dbs._all == nil || len(dbs._all) == 0 {
-		return ""
-	}
-
-	for _, db := range dbs._all {
+	for _, db := range dbs.allNodes() {
 		if db != nil && db.db != nil {
 			return db.db.Rebind(query)
 		}
@@ -480,11 +568,7 @@ func (dbs *DBs) Rebind(query string) string {
 
 // BindNamed binds a query using the DB driver's bindvar type.
 func (dbs *DBs) BindNamed(query string, arg interface{}) (string, []interface{}, error) {
-	if dbs._all == nil || len(dbs._all) == 0 {
-		return "", nil, ErrNoConnection
-	}
-
-	for _, db := range dbs._all {
+	for _, db := range dbs.allNodes() {
 		if db != nil {
 			return db.db.BindNamed(query, arg)
 		}
@@ -493,19 +577,29 @@ func (dbs *DBs) BindNamed(query string, arg interface{}) (string, []interface{},
 	return "", nil, ErrNoConnection
 }
 
-func getDBFromBalancer(target *balancer) (db *wrapper, err error) {
-	if db = target.get(target.isMulti); db != nil {
+func getDBFromBalancer(ctx context.Context, target *balancer) (db *wrapper, err error) {
+	if err = ctx.Err(); err != nil { // caller gave up, stop trying other nodes
+		return
+	}
+
+	if db = target.pick(ctx); db != nil {
 		return
 	}
 
 	// retry if there is no connection available. This event could happen when database closes all non-interactive connection.
 	for i := 0; i < 3; i++ {
-		time.Sleep(time.Duration(target.getHealthCheckPeriod()) * time.Millisecond)
-		if db = target.get(target.isMulti); db != nil {
+		if err = sleepContext(ctx, time.Duration(target.getHealthCheckPeriod())*time.Millisecond); err != nil {
+			return
+		}
+		if db = target.pick(ctx); db != nil {
 			return
 		}
 	}
 
+	if isRoutingDebug() {
+		logEntry(LogLevelDebug, "no healthy node is available", routingFields(ctx, RoutingUnhealthy, 0)...)
+	}
+
 	// need to return error
 	if target.isWsrep {
 		err = ErrNoConnectionOrWsrep
@@ -516,12 +610,53 @@ func getDBFromBalancer(target *balancer) (db *wrapper, err error) {
 	return
 }
 
-func retryBackoff(query string, exec func() (interface{}, error)) (v interface{}, err error) {
+func retryBackoff(ctx context.Context, w *wrapper, query string, exec func() (interface{}, error)) (v interface{}, err error) {
+	if v, err = leaseBackoff(ctx, w, query, exec); err == nil {
+		w.limiter.release()
+	}
+	return
+}
+
+// leaseBackoff is retryBackoff for calls leasing a connection of node, i.e streaming rows, transaction or Conn.
+// On success, concurrency slot of node is held until caller releases it once leased resource is closed,
+// see leaseQuery.
+func leaseBackoff(ctx context.Context, w *wrapper, query string, exec func() (interface{}, error)) (v interface{}, err error) {
+	info := queryInfoFromContext(ctx)
+	defer info.served(w)
+
+	if isRoutingDebug() && w.limiter.saturated() {
+		logEntry(LogLevelDebug, "node is saturated, query waits", nodeFields(w, routingFields(ctx, RoutingSaturated, 0)...)...)
+	}
+
+	if err = w.limiter.acquire(ctx, priorityFromContext(ctx)); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			w.limiter.release()
+		}
+	}()
+
+	collector, startedAt := statsCollectorFromContext(ctx), time.Now()
+	defer func() {
+		elapsed := time.Since(startedAt)
+		w.stats.done(query, err)
+		collector.record(w, query, elapsed, err)
+		logSlowStatement(w, query, elapsed)
+	}()
+
 	for retry := 0; retry < 200; retry++ {
+		info.attempt()
 		if v, err = exec(); err == nil {
 			return
 		}
 
+		if ctxErr := ctx.Err(); ctxErr != nil { // caller gave up, return context error as is
+			err = ctxErr
+			return
+		}
+
+		var backoff time.Duration
 		switch err {
 		case sql.ErrConnDone:
 
@@ -530,32 +665,60 @@ func retryBackoff(query string, exec func() (interface{}, error)) (v interface{}
 
 		default:
 			if isErrBadConn(err) {
-				time.Sleep(5 * time.Millisecond)
+				backoff = 5 * time.Millisecond
 			} else if !isDeadlock(err) {
 				return
 			} else {
-				time.Sleep(10 * time.Millisecond)
+				backoff = 10 * time.Millisecond
 			}
 		}
+
+		if ctxErr := sleepContext(ctx, backoff); ctxErr != nil {
+			err = ctxErr
+			return
+		}
 	}
 
 	if err == sql.ErrConnDone || isErrBadConn(err) {
-		reportError(query, err)
+		reportNodeError(w, query, err)
 	}
 
 	return
 }
 
+// sleepContext waits for d unless ctx is done first, in which case ctx error is returned
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case <-t.C:
+		return nil
+	}
+}
+
+// shouldFailure reports whether err is a connection-level failure of node w (or wsrep not ready),
+// confirmed by ping. Statement-level errors never fail node. Success resets consecutive failures of w.
 func shouldFailure(w *wrapper, isWsrep bool, err error) bool {
-	if err = parseError(w, err); err == nil {
+	if err == nil {
+		if w != nil {
+			w.resetFailures()
+		}
 		return false
 	}
 
-	if err == ErrNetwork || (isWsrep && isWsrepNotReady(err)) {
+	if isWsrep && isWsrepNotReady(err) {
 		return true
 	}
 
-	return false
+	return isConnectionError(err) && parseError(w, err) == ErrNetwork
 }
 
 func _namedQuery(ctx context.Context, target *balancer, query string, arg interface{}) (res *sqlx.Rows, err error) {
@@ -564,25 +727,49 @@ func _namedQuery(ctx context.Context, target *balancer, query string, arg interf
 		r interface{}
 	)
 
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	// read-after-write consistency
+	ctx, target = target.route(ctx, query)
+
+	queryInfoFromContext(ctx).start()
 	for {
-		if w, err = getDBFromBalancer(target); err != nil {
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
 			reportError(query, err)
 			return
 		}
 
-		r, err = retryBackoff(query, func() (interface{}, error) {
-			return w.db.NamedQueryContext(ctx, query, arg)
+		r, err = leaseBackoff(ctx, w, query, func() (interface{}, error) {
+			// comments are added after binding, their content must not be taken as named params
+			q, args, err := bindNamed(w.db, query, arg, w.timeOpts.utc())
+			if err != nil {
+				return nil, err
+			}
+
+			// statement is killed if ctx is done before rows are returned
+			q, stop := w.withCancel(ctx, w.withServerTimeout(ctx, withTraceComment(ctx, q)))
+			defer stop()
+			return w.leaseQuery(ctx, func(qr queryer) (*sql.Rows, error) {
+				return qr.QueryContext(ctx, q, args...)
+			})
 		})
-		if r != nil {
-			res = r.(*sqlx.Rows)
+		if err == nil {
+			res = guardRows(&sqlx.Rows{Rows: r.(*sql.Rows), Mapper: w.db.Mapper})
 		}
 
 		// check networking/wsrep error
-		if shouldFailure(w, target.isWsrep, err) {
-			target.failure(w)
+		if target.shouldFailure(w, err) && target.countFailure(w, err) {
 			continue
 		}
 
+		if err == nil {
+			target.leaks.trackRows(w, query, res.Rows)
+		} else {
+			res = nil
+		}
+
 		return
 	}
 }
@@ -617,25 +804,51 @@ func _namedExec(ctx context.Context, target *balancer, query string, arg interfa
 		r interface{}
 	)
 
+	defer target.misses.invalidate(query)
+
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	// read-after-write consistency
+	ctx, target = target.route(ctx, query)
+	markWrite(ctx)
+
+	queryInfoFromContext(ctx).start()
 	for {
-		if w, err = getDBFromBalancer(target); err != nil {
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
 			reportError(query, err)
 			return
 		}
 
+		// split-brain protection
+		if err = target.checkQuorum(ctx, w); err == errNodeFailed {
+			continue
+		} else if err != nil {
+			reportNodeError(w, query, err)
+			return
+		}
+
 		// executing
-		r, err = retryBackoff(query, func() (interface{}, error) {
-			return w.db.NamedExecContext(ctx, query, arg)
+		r, err = retryBackoff(ctx, w, query, func() (interface{}, error) {
+			q, args, err := bindNamed(w.db, query, arg, w.timeOpts.utc())
+			if err != nil {
+				return nil, err
+			}
+
+			q, stop := w.withCancel(ctx, w.withServerTimeout(ctx, withTraceComment(ctx, q)))
+			defer stop()
+			return w.db.ExecContext(ctx, q, args...)
 		})
 		if r != nil {
 			res = r.(sql.Result)
 		}
 
 		// check networking/wsrep error
-		if shouldFailure(w, target.isWsrep, err) {
-			target.failure(w)
+		if target.shouldFailure(w, err) && target.countFailure(w, err) {
 			continue
 		}
+		target.checkMisroute(w, query, err)
 
 		return
 	}
@@ -671,26 +884,44 @@ func _query(ctx context.Context, target *balancer, query string, args ...interfa
 		r interface{}
 	)
 
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	// read-after-write consistency
+	ctx, target = target.route(ctx, query)
+
+	startedAt := time.Now()
+	queryInfoFromContext(ctx).start()
 	for {
-		if w, err = getDBFromBalancer(target); err != nil {
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
 			reportError(query, err)
 			return
 		}
 
 		// executing
-		r, err = retryBackoff(query, func() (interface{}, error) {
-			return w.db.QueryContext(ctx, query, args...)
+		r, err = leaseBackoff(ctx, w, query, func() (interface{}, error) {
+			nargs, buf := w.acquireArgs(args)
+			defer putValues(buf)
+
+			return w.leaseQuery(ctx, func(q queryer) (*sql.Rows, error) {
+				return q.QueryContext(ctx, w.withServerTimeout(ctx, withTraceComment(ctx, w.rebind(query))), nargs...)
+			})
 		})
-		if r != nil {
+		if err == nil {
 			res = r.(*sql.Rows)
 		}
 
 		// check networking/wsrep error
-		if shouldFailure(w, target.isWsrep, err) {
-			target.failure(w)
+		if target.shouldFailure(w, err) && target.countFailure(w, err) {
 			continue
 		}
 
+		if err == nil {
+			target.leaks.trackRows(w, query, res)
+			target.replayShadow(w, query, args, time.Since(startedAt))
+		}
+
 		dbr = w
 		return
 	}
@@ -730,26 +961,44 @@ func _queryx(ctx context.Context, target *balancer, query string, args ...interf
 		r interface{}
 	)
 
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	// read-after-write consistency
+	ctx, target = target.route(ctx, query)
+
+	startedAt := time.Now()
+	queryInfoFromContext(ctx).start()
 	for {
-		if w, err = getDBFromBalancer(target); err != nil {
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
 			reportError(query, err)
 			return
 		}
 
 		// executing
-		r, err = retryBackoff(query, func() (interface{}, error) {
-			return w.db.QueryxContext(ctx, query, args...)
+		r, err = leaseBackoff(ctx, w, query, func() (interface{}, error) {
+			nargs, buf := w.acquireArgs(args)
+			defer putValues(buf)
+
+			return w.leaseQuery(ctx, func(q queryer) (*sql.Rows, error) {
+				return q.QueryContext(ctx, w.withServerTimeout(ctx, withTraceComment(ctx, w.rebind(query))), nargs...)
+			})
 		})
-		if r != nil {
-			res = r.(*sqlx.Rows)
+		if err == nil {
+			res = guardRows(&sqlx.Rows{Rows: r.(*sql.Rows), Mapper: w.db.Mapper})
 		}
 
 		// check networking/wsrep error
-		if shouldFailure(w, target.isWsrep, err) {
-			target.failure(w)
+		if target.shouldFailure(w, err) && target.countFailure(w, err) {
 			continue
 		}
 
+		if err == nil {
+			target.leaks.trackRows(w, query, res.Rows)
+			target.replayShadow(w, query, args, time.Since(startedAt))
+		}
+
 		dbr = w
 		return
 	}
@@ -786,13 +1035,34 @@ func (dbs *DBs) QueryxContextOnMaster(ctx context.Context, query string, args ..
 func _queryRow(ctx context.Context, target *balancer, query string, args ...interface{}) (dbr *wrapper, res *sql.Row, err error) {
 	var w *wrapper
 
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	// read-after-write consistency
+	ctx, target = target.route(ctx, query)
+
+	queryInfoFromContext(ctx).start()
 	for {
-		if w, err = getDBFromBalancer(target); err != nil {
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
 			reportError(query, err)
 			return
 		}
 
-		res, dbr = w.db.QueryRowContext(ctx, query, args...), w
+		if err = w.limiter.acquire(ctx, priorityFromContext(ctx)); err != nil {
+			return
+		}
+
+		info := queryInfoFromContext(ctx)
+		info.attempt()
+
+		startedAt := time.Now()
+		nargs, buf := w.acquireArgs(args)
+		res, dbr = w.leaseQueryRow(ctx, w.withServerTimeout(ctx, withTraceComment(ctx, w.rebind(query))), nargs...), w
+		putValues(buf)
+		w.stats.done(query, nil)
+		statsCollectorFromContext(ctx).record(w, query, time.Since(startedAt), nil)
+		info.served(w)
 		return
 	}
 }
@@ -832,13 +1102,35 @@ func (dbs *DBs) QueryRowContextOnMaster(ctx context.Context, query string, args
 func _queryRowx(ctx context.Context, target *balancer, query string, args ...interface{}) (dbr *wrapper, res *sqlx.Row, err error) {
 	var w *wrapper
 
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	// read-after-write consistency
+	ctx, target = target.route(ctx, query)
+
+	queryInfoFromContext(ctx).start()
 	for {
-		if w, err = getDBFromBalancer(target); err != nil {
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
 			reportError(query, err)
 			return
 		}
 
-		res, dbr = w.db.QueryRowxContext(ctx, query, args...), w
+		if err = w.limiter.acquire(ctx, priorityFromContext(ctx)); err != nil {
+			return
+		}
+
+		info := queryInfoFromContext(ctx)
+		info.attempt()
+
+		startedAt := time.Now()
+		nargs, buf := w.acquireArgs(args)
+		res, dbr = guardRow(w.db.QueryRowxContext(ctx, w.withServerTimeout(ctx, withTraceComment(ctx, w.rebind(query))), nargs...)), w
+		putValues(buf)
+		w.limiter.release()
+		w.stats.done(query, nil)
+		statsCollectorFromContext(ctx).record(w, query, time.Since(startedAt), nil)
+		info.served(w)
 		return
 	}
 }
@@ -876,26 +1168,67 @@ func (dbs *DBs) QueryRowxContextOnMaster(ctx context.Context, query string, args
 	return
 }
 
-func _select(ctx context.Context, target *balancer, dest interface{}, query string, args ...interface{}) (dbr *wrapper, err error) {
+func _select(ctx context.Context, target *balancer, dest interface{}, query string, args ...interface{}) (*wrapper, error) {
+	if target.coalesces(query) {
+		return target.coalesce(ctx, "select", dest, query, args, func(ctx context.Context, dest interface{}) (*wrapper, error) {
+			return selectNodes(ctx, target, dest, query, args...)
+		})
+	}
+	return selectNodes(ctx, target, dest, query, args...)
+}
+
+func selectNodes(ctx context.Context, target *balancer, dest interface{}, query string, args ...interface{}) (dbr *wrapper, err error) {
 	var w *wrapper
 
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	// read-after-write consistency
+	ctx, target = target.route(ctx, query)
+
+	n, retries, limit := destLen(dest), 0, target.maxRowsFor(ctx)
+	startedAt := time.Now()
+	queryInfoFromContext(ctx).start()
 	for {
-		if w, err = getDBFromBalancer(target); err != nil {
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
 			reportError(query, err)
 			return
 		}
 
 		// executing
-		_, err = retryBackoff(query, func() (interface{}, error) {
-			return nil, w.db.SelectContext(ctx, dest, query, args...)
+		_, err = retryBackoff(ctx, w, query, func() (interface{}, error) {
+			truncateDest(dest, n) // discard partial results of previous attempt
+			q, stop := w.withCancel(ctx, w.withServerTimeout(ctx, withTraceComment(ctx, w.withOptimizerHints(ctx, w.rebind(query)))))
+			defer stop()
+
+			nargs, buf := w.acquireArgs(args)
+			defer putValues(buf)
+
+			return nil, w.localize(dest, w.hinted(ctx, func(e sqlx.ExtContext) error {
+				return selectContext(ctx, w, e, limit, dest, q, nargs...)
+			}))
 		})
 
 		// check networking/wsrep error
-		if shouldFailure(w, target.isWsrep, err) {
-			target.failure(w)
+		if target.shouldFailure(w, err) && target.countFailure(w, err) {
 			continue
 		}
 
+		// retry on another node
+		if isNetworkError(err) && retries < target.getReadRetries() {
+			if rctx, ok := target.retryRead(ctx, w); ok {
+				retries, ctx = retries+1, rctx
+				reportNodeError(w, query, err)
+				continue
+			}
+		}
+
+		if err == nil {
+			logBigSelect(w, query, dest, n)
+			target.replayShadow(w, query, args, time.Since(startedAt))
+		}
+
 		dbr = w
 		return
 	}
@@ -929,26 +1262,67 @@ func (dbs *DBs) SelectContextOnMaster(ctx context.Context, dest interface{}, que
 	return
 }
 
-func _get(ctx context.Context, target *balancer, dest interface{}, query string, args ...interface{}) (dbr *wrapper, err error) {
+func _get(ctx context.Context, target *balancer, dest interface{}, query string, args ...interface{}) (*wrapper, error) {
+	return target.getMissCached(ctx, query, args, func() (*wrapper, error) {
+		if target.coalesces(query) {
+			return target.coalesce(ctx, "get", dest, query, args, func(ctx context.Context, dest interface{}) (*wrapper, error) {
+				return getNodes(ctx, target, dest, query, args...)
+			})
+		}
+		return getNodes(ctx, target, dest, query, args...)
+	})
+}
+
+func getNodes(ctx context.Context, target *balancer, dest interface{}, query string, args ...interface{}) (dbr *wrapper, err error) {
 	var w *wrapper
 
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	// read-after-write consistency
+	ctx, target = target.route(ctx, query)
+
+	retries := 0
+	startedAt := time.Now()
+	queryInfoFromContext(ctx).start()
 	for {
-		if w, err = getDBFromBalancer(target); err != nil {
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
 			reportError(query, err)
 			return
 		}
 
 		// executing
-		_, err = retryBackoff(query, func() (interface{}, error) {
-			return nil, w.db.GetContext(ctx, dest, query, args...)
+		_, err = retryBackoff(ctx, w, query, func() (interface{}, error) {
+			q, stop := w.withCancel(ctx, w.withServerTimeout(ctx, withTraceComment(ctx, w.withOptimizerHints(ctx, w.rebind(query)))))
+			defer stop()
+
+			nargs, buf := w.acquireArgs(args)
+			defer putValues(buf)
+
+			return nil, w.localize(dest, w.hinted(ctx, func(e sqlx.ExtContext) error {
+				return getContext(ctx, w, e, dest, q, nargs...)
+			}))
 		})
 
 		// check networking/wsrep error
-		if shouldFailure(w, target.isWsrep, err) {
-			target.failure(w)
+		if target.shouldFailure(w, err) && target.countFailure(w, err) {
 			continue
 		}
 
+		// retry on another node
+		if isNetworkError(err) && retries < target.getReadRetries() {
+			if rctx, ok := target.retryRead(ctx, w); ok {
+				retries, ctx = retries+1, rctx
+				reportNodeError(w, query, err)
+				continue
+			}
+		}
+
+		if err == nil {
+			target.replayShadow(w, query, args, time.Since(startedAt))
+		}
+
 		dbr = w
 		return
 	}
@@ -992,25 +1366,55 @@ func _exec(ctx context.Context, target *balancer, query string, args ...interfac
 		r interface{}
 	)
 
+	defer target.misses.invalidate(query)
+
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	// read-after-write consistency
+	ctx, target = target.route(ctx, query)
+	markWrite(ctx)
+
+	queryInfoFromContext(ctx).start()
 	for {
-		if w, err = getDBFromBalancer(target); err != nil {
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
 			reportError(query, err)
 			return
 		}
 
+		// split-brain protection
+		if err = target.checkQuorum(ctx, w); err == errNodeFailed {
+			continue
+		} else if err != nil {
+			reportNodeError(w, query, err)
+			return
+		}
+
 		// executing
-		r, err = retryBackoff(query, func() (interface{}, error) {
-			return w.db.ExecContext(ctx, query, args...)
+		r, err = retryBackoff(ctx, w, query, func() (interface{}, error) {
+			q, stop := w.withCancel(ctx, withTraceComment(ctx, w.withOptimizerHints(ctx, w.rebind(query))))
+			defer stop()
+
+			nargs, buf := w.acquireArgs(args)
+			defer putValues(buf)
+
+			var res sql.Result
+			err := w.hinted(ctx, func(e sqlx.ExtContext) (err error) {
+				res, err = e.ExecContext(ctx, q, nargs...)
+				return
+			})
+			return res, err
 		})
 		if r != nil {
 			res = r.(sql.Result)
 		}
 
 		// check networking/wsrep error
-		if shouldFailure(w, target.isWsrep, err) {
-			target.failure(w)
+		if target.shouldFailure(w, err) && target.countFailure(w, err) {
 			continue
 		}
+		target.checkMisroute(w, query, err)
 
 		return
 	}
@@ -1042,23 +1446,37 @@ func _prepareContext(ctx context.Context, target *balancer, query string) (dbx *
 		r interface{}
 	)
 
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	// read-after-write consistency
+	ctx, target = target.route(ctx, query)
+
+	queryInfoFromContext(ctx).start()
 	for {
-		if w, err = getDBFromBalancer(target); err != nil {
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
 			reportError(query, err)
 			return
 		}
 
+		// server-side prepared statements do not survive poolers
+		if w.pooler {
+			err = ErrPoolerPrepare
+			reportNodeError(w, query, err)
+			return
+		}
+
 		// executing
-		r, err = retryBackoff(query, func() (interface{}, error) {
-			return w.db.PrepareContext(ctx, query)
+		r, err = retryBackoff(ctx, w, query, func() (interface{}, error) {
+			return w.db.PrepareContext(ctx, w.rebind(query))
 		})
 		if r != nil {
 			stmt = r.(*sql.Stmt)
 		}
 
 		// check networking/wsrep error
-		if shouldFailure(w, target.isWsrep, err) {
-			target.failure(w)
+		if target.shouldFailure(w, err) && target.countFailure(w, err) {
 			continue
 		}
 
@@ -1109,23 +1527,37 @@ func _preparexContext(ctx context.Context, target *balancer, query string) (dbx
 		r interface{}
 	)
 
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	// read-after-write consistency
+	ctx, target = target.route(ctx, query)
+
+	queryInfoFromContext(ctx).start()
 	for {
-		if w, err = getDBFromBalancer(target); err != nil {
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
 			reportError(query, err)
 			return
 		}
 
+		// server-side prepared statements do not survive poolers
+		if w.pooler {
+			err = ErrPoolerPrepare
+			reportNodeError(w, query, err)
+			return
+		}
+
 		// executing
-		r, err = retryBackoff(query, func() (interface{}, error) {
-			return w.db.PreparexContext(ctx, query)
+		r, err = retryBackoff(ctx, w, query, func() (interface{}, error) {
+			return w.db.PreparexContext(ctx, w.rebind(query))
 		})
 		if r != nil {
 			stmt = r.(*sqlx.Stmt)
 		}
 
 		// check networking/wsrep error
-		if shouldFailure(w, target.isWsrep, err) {
-			target.failure(w)
+		if target.shouldFailure(w, err) && target.countFailure(w, err) {
 			continue
 		}
 
@@ -1180,14 +1612,29 @@ func _prepareNamedContext(ctx context.Context, target *balancer, query string) (
 		r interface{}
 	)
 
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	// read-after-write consistency
+	ctx, target = target.route(ctx, query)
+
+	queryInfoFromContext(ctx).start()
 	for {
-		if w, err = getDBFromBalancer(target); err != nil {
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
 			reportError(query, err)
 			return
 		}
 
+		// server-side prepared statements do not survive poolers
+		if w.pooler {
+			err = ErrPoolerPrepare
+			reportNodeError(w, query, err)
+			return
+		}
+
 		// executing
-		r, err = retryBackoff(query, func() (interface{}, error) {
+		r, err = retryBackoff(ctx, w, query, func() (interface{}, error) {
 			return w.db.PrepareNamedContext(ctx, query)
 		})
 		if r != nil {
@@ -1195,8 +1642,7 @@ func _prepareNamedContext(ctx context.Context, target *balancer, query string) (
 		}
 
 		// check networking/wsrep error
-		if shouldFailure(w, target.isWsrep, err) {
-			target.failure(w)
+		if target.shouldFailure(w, err) && target.countFailure(w, err) {
 			continue
 		}
 
@@ -1225,36 +1671,12 @@ func (dbs *DBs) PrepareNamedContextOnSlave(ctx context.Context, query string) (d
 	return _prepareNamedContext(ctx, dbs.slaves, query)
 }
 
-func _mustExec(ctx context.Context, target *balancer, query string, args ...interface{}) (res sql.Result) {
-	var (
-		w   *wrapper
-		err error
-		r   interface{}
-	)
-
-	for {
-		if w, err = getDBFromBalancer(target); err != nil {
-			panic(err)
-		}
-
-		r, err = retryBackoff(query, func() (interface{}, error) {
-			return w.db.ExecContext(ctx, query, args...)
-		})
-		if r != nil {
-			res = r.(sql.Result)
-		}
-
-		// check networking/wsrep error
-		if shouldFailure(w, target.isWsrep, err) {
-			target.failure(w)
-			continue
-		}
-
-		if err != nil {
-			panic(err)
-		}
-		return
+func _mustExec(ctx context.Context, target *balancer, query string, args ...interface{}) sql.Result {
+	res, err := _exec(ctx, target, query, args...)
+	if err != nil {
+		panic(err)
 	}
+	return res
 }
 
 // MustExec do exec on masters and panic on error
@@ -1335,62 +1757,80 @@ func (dbs *DBs) Begin() (*sql.Tx, error) {
 // an error will be returned.
 //
 // Transaction is bound to one of master connections.
-func (dbs *DBs) BeginTx(ctx context.Context, opts *sql.TxOptions) (res *sql.Tx, err error) {
-	var (
-		w *wrapper
-		r interface{}
-	)
+func (dbs *DBs) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	tx, err := dbs.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return tx.Tx, nil
+}
 
-	for {
-		if w, err = getDBFromBalancer(dbs.masters); err != nil {
-			reportError("BeginTx", err)
-			return nil, err
-		}
+// Beginx begins a transaction and returns an *sqlx.Tx instead of an *sql.Tx.
+//
+// Transaction is bound to one of master connections.
+func (dbs *DBs) Beginx() (*sqlx.Tx, error) {
+	return dbs.BeginTxx(context.Background(), nil)
+}
 
-		// executing
-		r, err = retryBackoff("START TRANSACTION", func() (interface{}, error) {
-			return w.db.BeginTx(ctx, opts)
-		})
-		if r != nil {
-			res = r.(*sql.Tx)
-		}
+// BeginTxx begins a transaction and returns an *sqlx.Tx instead of an
+// *sql.Tx.
+//
+// The provided context is used until the transaction is committed or rolled
+// back. If the context is canceled, the sql package will roll back the
+// transaction. Tx.Commit will return an error if the context provided to
+// BeginxContext is canceled.
+//
+// Transaction is bound to one of master connections.
+func (dbs *DBs) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
-		// check networking/wsrep error
-		if shouldFailure(w, dbs.masters.isWsrep, err) {
-			dbs.masters.failure(w)
-			continue
-		}
+	ctx, cancel := dbs.limitTx(ctx)
 
-		return
+	w, tx, err := dbs.beginTxx(ctx, opts)
+	if err != nil {
+		cancel()
+		return nil, err
 	}
+
+	// database/sql does not notify finished transactions, concurrency slot is only held while beginning
+	w.limiter.release()
+	return tx, nil
 }
 
-// Beginx begins a transaction and returns an *sqlx.Tx instead of an *sql.Tx.
-//
-// Transaction is bound to one of master connections.
-func (dbs *DBs) Beginx() (res *sqlx.Tx, err error) {
-	var (
-		w *wrapper
-		r interface{}
-	)
+// beginTxx starts a transaction on one of masters, which is returned too. Concurrency slot of master is held
+// until caller releases it once transaction is finished.
+func (dbs *DBs) beginTxx(ctx context.Context, opts *sql.TxOptions) (w *wrapper, res *sqlx.Tx, err error) {
+	var r interface{}
 
+	markWrite(ctx)
+
+	queryInfoFromContext(ctx).start()
 	for {
-		if w, err = getDBFromBalancer(dbs.masters); err != nil {
-			reportError("Beginx", err)
-			return nil, err
+		if w, err = getDBFromBalancer(ctx, dbs.masters); err != nil {
+			reportError("BeginTxx", err)
+			return nil, nil, err
+		}
+
+		// split-brain protection
+		if err = dbs.masters.checkQuorum(ctx, w); err == errNodeFailed {
+			continue
+		} else if err != nil {
+			reportNodeError(w, "BeginTxx", err)
+			return nil, nil, err
 		}
 
 		// executing
-		r, err = retryBackoff("START TRANSACTION", func() (interface{}, error) {
-			return w.db.Beginx()
+		r, err = leaseBackoff(ctx, w, "START TRANSACTION", func() (interface{}, error) {
+			return w.db.BeginTxx(ctx, opts)
 		})
 		if r != nil {
 			res = r.(*sqlx.Tx)
 		}
 
 		// check networking/wsrep error
-		if shouldFailure(w, dbs.masters.isWsrep, err) {
-			dbs.masters.failure(w)
+		if dbs.masters.shouldFailure(w, err) && dbs.masters.countFailure(w, err) {
 			continue
 		}
 
@@ -1398,50 +1838,69 @@ func (dbs *DBs) Beginx() (res *sqlx.Tx, err error) {
 	}
 }
 
-// BeginTxx begins a transaction and returns an *sqlx.Tx instead of an
-// *sql.Tx.
-//
-// The provided context is used until the transaction is committed or rolled
-// back. If the context is canceled, the sql package will roll back the
-// transaction. Tx.Commit will return an error if the context provided to
-// BeginxContext is canceled.
-//
-// Transaction is bound to one of master connections.
-func (dbs *DBs) BeginTxx(ctx context.Context, opts *sql.TxOptions) (res *sqlx.Tx, err error) {
+// _conn checks out a connection of one of healthy nodes of target. Concurrency slot of node is held
+// until caller releases it once connection is closed.
+func _conn(ctx context.Context, target *balancer) (dbr *wrapper, res *sql.Conn, err error) {
 	var (
 		w *wrapper
 		r interface{}
 	)
 
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	queryInfoFromContext(ctx).start()
 	for {
-		if w, err = getDBFromBalancer(dbs.masters); err != nil {
-			reportError("BeginTxx", err)
-			return nil, err
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
+			reportError("Conn", err)
+			return
 		}
 
 		// executing
-		r, err = retryBackoff("START TRANSACTION", func() (interface{}, error) {
-			return w.db.BeginTxx(ctx, opts)
+		r, err = leaseBackoff(ctx, w, "Conn", func() (interface{}, error) {
+			return w.db.Conn(ctx)
 		})
 		if r != nil {
-			res = r.(*sqlx.Tx)
+			res = r.(*sql.Conn)
 		}
 
 		// check networking/wsrep error
-		if shouldFailure(w, dbs.masters.isWsrep, err) {
-			dbs.masters.failure(w)
+		if target.shouldFailure(w, err) && target.countFailure(w, err) {
 			continue
 		}
 
+		dbr = w
 		return
 	}
 }
 
+// connectNode opens node of dsn with options of ConnectMasterSlaves
+func (dbs *DBs) connectNode(dsn string, role Role, ind int) (*wrapper, error) {
+	w := newWrapper(nil, dsn, role, ind)
+	dbConn, err := openDB(dbs.driverName, dsn, w.getRole, &dbs.opts)
+	w.db = dbConn
+
+	w.pooler = dbs.opts.isPooler(dsn)
+	w.timeOpts = dbs.opts.timeOpts
+	w.rebound = dbs.opts.rebind
+	w.flavorOverride = dbs.opts.flavor
+	w.openProbe = func() (*sqlx.DB, error) {
+		return openDB(dbs.driverName, dsn, w.getRole, &dbs.opts)
+	}
+
+	return w, err
+}
+
 // ConnectMasterSlaves to master-slave databases, healthchecks will ensure they are working
 // driverName: mysql, postgres, etc.
 // masterDSNs: data source names of Masters.
 // slaveDSNs: data source names of Slaves.
-// args: args[0] = true to indicates galera/wsrep cluster.
+// args: true to indicates galera/wsrep cluster, DriverWrapper/ConnectorWrapper to wrap driver of every node,
+// PreferredPrimary to designate a master as primary, QuorumCheck to verify quorum before writes,
+// AuthProvider to supply credentials at connecting time, CloudSQLDialer to connect to Cloud SQL instances,
+// SetDialer to override how connections are established, SlaveCredentials to connect slaves as another user, ApplicationName to tag connections with service name and role,
+// ServerFlavor to override detected flavor of mysql compatible nodes.
 func ConnectMasterSlaves(driverName string, masterDSNs []string, slaveDSNs []string, args ...interface{}) (*DBs, []error) {
 	// Validate slave address
 	if slaveDSNs == nil {
@@ -1452,13 +1911,8 @@ func ConnectMasterSlaves(driverName string, masterDSNs []string, slaveDSNs []str
 		masterDSNs = []string{}
 	}
 
-	isWsrep := false
-	if len(args) > 0 {
-		switch args[0].(type) {
-		case bool:
-			isWsrep = args[0].(bool)
-		}
-	}
+	opts := parseConnectArgs(args)
+	isWsrep := opts.isWsrep
 
 	nMaster := len(masterDSNs)
 	nSlave := len(slaveDSNs)
@@ -1467,16 +1921,23 @@ func ConnectMasterSlaves(driverName string, masterDSNs []string, slaveDSNs []str
 	errResult := make([]error, nAll)
 	dbs := &DBs{
 		driverName: driverName,
+		opts:       opts,
 
-		masters:  newBalancer(nil, nMaster>>2, nMaster, isWsrep),
-		_masters: make([]*wrapper, nMaster),
+		masters: newBalancer(nil, nMaster>>2, isWsrep),
+		slaves:  newBalancer(nil, nSlave>>2, isWsrep),
+		all:     newBalancer(nil, nAll>>2, isWsrep),
+	}
+	masters, slaves, all := make([]*wrapper, nMaster), make([]*wrapper, nSlave), make([]*wrapper, nAll)
 
-		slaves:  newBalancer(nil, nSlave>>2, nSlave, isWsrep),
-		_slaves: make([]*wrapper, nSlave),
+	// failed nodes of all balancers are checked by one scheduler
+	health := newHealthScheduler(nAll >> 1)
+	dbs.masters.health, dbs.slaves.health, dbs.all.health = health, health, health
 
-		all:  newBalancer(nil, nAll>>2, nAll, isWsrep),
-		_all: make([]*wrapper, nAll),
-	}
+	dbs.events = newEventLog(DefaultEventHistorySize)
+	dbs.masters.events, dbs.slaves.events = dbs.events, dbs.events
+
+	dbs.leaks = newLeakTracker()
+	dbs.masters.leaks, dbs.slaves.leaks = dbs.leaks, dbs.leaks
 
 	// channel to sync routines
 	c := make(chan byte, len(errResult))
@@ -1485,12 +1946,11 @@ func ConnectMasterSlaves(driverName string, masterDSNs []string, slaveDSNs []str
 	n := 0
 	for i := range masterDSNs {
 		go func(mId, eId int) {
-			dbConn, err := sqlx.Open(driverName, masterDSNs[mId])
-			dbs._masters[mId], errResult[eId] = &wrapper{db: dbConn, dsn: masterDSNs[mId]}, err
-			dbs.masters.add(dbs._masters[mId])
+			masters[mId], errResult[eId] = dbs.connectNode(masterDSNs[mId], RoleMaster, mId)
+			dbs.masters.add(masters[mId])
 
-			dbs._all[eId] = dbs._masters[mId]
-			dbs.all.add(dbs._masters[mId])
+			all[eId] = masters[mId]
+			dbs.all.add(masters[mId])
 
 			c <- 0
 		}(i, n)
@@ -1500,12 +1960,11 @@ func ConnectMasterSlaves(driverName string, masterDSNs []string, slaveDSNs []str
 	// Concurrency connect to slaves
 	for i := range slaveDSNs {
 		go func(sId, eId int) {
-			dbConn, err := sqlx.Open(driverName, slaveDSNs[sId])
-			dbs._slaves[sId], errResult[eId] = &wrapper{db: dbConn, dsn: slaveDSNs[sId]}, err
-			dbs.slaves.add(dbs._slaves[sId])
+			slaves[sId], errResult[eId] = dbs.connectNode(slaveDSNs[sId], RoleSlave, sId)
+			dbs.slaves.add(slaves[sId])
 
-			dbs._all[eId] = dbs._slaves[sId]
-			dbs.all.add(dbs._slaves[sId])
+			all[eId] = slaves[sId]
+			dbs.all.add(slaves[sId])
 
 			c <- 0
 		}(i, n)
@@ -1515,6 +1974,31 @@ func ConnectMasterSlaves(driverName string, masterDSNs []string, slaveDSNs []str
 	for i := 0; i < len(errResult); i++ {
 		<-c
 	}
+	dbs.setNodes(masters, slaves, all)
+
+	dbs.slaves.master = dbs.masters
+	dbs.slaves.routeChains = &dbs.routeChains
+	dbs.slaves.coalesced = &dbs.coalesced
+	dbs.masters.coalesced = &dbs.coalesced
+	dbs.slaves.misses = &dbs.misses
+	dbs.masters.misses = &dbs.misses
+	dbs.slaves.routing = &routingCounters{}
+	dbs.masters.routing = dbs.slaves.routing
+
+	dbs.masters.setMembers(masters)
+	dbs.slaves.setMembers(slaves)
+
+	if opts.quorumCheck != nil {
+		dbs.masters.quorum = newQuorumChecker(opts.quorumCheck)
+	}
+
+	if p := opts.preferredPrimary; p != nil && p.Index >= 0 && p.Index < nMaster {
+		dbs.masters.setPreferred(masters[p.Index], p)
+	}
+
+	if opts.slaveCreds != nil && nSlave > 0 {
+		dbs.verifySlaveCredentials(nMaster, errResult)
+	}
 
 	return dbs, errResult
 }
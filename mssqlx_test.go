@@ -281,13 +281,13 @@ func TestParseError(t *testing.T) {
 	db, _ := sqlx.Open("postgres", "user=test1 dbname=test2 sslmode=disable")
 
 	errT = fmt.Errorf("abc")
-	if err = parseError(&wrapper{db: db, dsn: "user=test1 dbname=test2 sslmode=disable"}, errT); err != ErrNetwork {
+	if err = parseError(newWrapper(db, "user=test1 dbname=test2 sslmode=disable", RoleMaster, 0), errT); err != ErrNetwork {
 		t.Fatal(err)
 	}
 }
 
 func TestDbBalancer(t *testing.T) {
-	dbB := newBalancer(nil, 0, 4, true)
+	dbB := newBalancer(nil, 0, true)
 
 	if dbB.numberOfHealthChecker != 2 {
 		t.Fatal("DbBalancer init fail")
@@ -308,10 +308,10 @@ func TestDbBalancer(t *testing.T) {
 	db4, _ := sqlx.Open("postgres", dsn)
 
 	dbB.add(nil)
-	dbB.add(&wrapper{db: db1, dsn: dsn})
-	dbB.add(&wrapper{db: db2, dsn: dsn})
-	dbB.add(&wrapper{db: db3, dsn: dsn})
-	dbB.add(&wrapper{db: db4, dsn: dsn})
+	dbB.add(newWrapper(db1, dsn, RoleMaster, 0))
+	dbB.add(newWrapper(db2, dsn, RoleMaster, 0))
+	dbB.add(newWrapper(db3, dsn, RoleMaster, 0))
+	dbB.add(newWrapper(db4, dsn, RoleMaster, 0))
 
 	if dbB.size() != 4 {
 		t.Fatal("DbBalancer: add fail")
@@ -333,12 +333,12 @@ func TestDbBalancer(t *testing.T) {
 		t.Fatal("DbBalancer: get fail")
 	} else {
 		dbB.failure(x)
-		if dbB.size() != 3 || (&wrapper{db: db3, dsn: dsn}).checkWsrepReady() {
+		if dbB.size() != 3 || newWrapper(db3, dsn, RoleMaster, 0).checkWsrepReady() {
 			t.Fatal("DbBalancer: failure fail")
 		}
 
 		dbB.failure(nil)
-		if dbB.size() != 3 || (&wrapper{db: db3, dsn: dsn}).checkWsrepReady() {
+		if dbB.size() != 3 || newWrapper(db3, dsn, RoleMaster, 0).checkWsrepReady() {
 			t.Fatal("DbBalancer: failure fail")
 		}
 	}
@@ -372,14 +372,14 @@ func TestConnectMasterSlave(t *testing.T) {
 	}
 
 	// ensure no nil dbs
-	for _, v := range db._all {
+	for _, v := range db.allNodes() {
 		if v.db == nil {
 			t.Fatal("Nil DB in list")
 		}
 	}
 
 	// test another ping
-	for _, v := range db._all {
+	for _, v := range db.allNodes() {
 		if e := ping(v); e != nil && e.Error() != "pq: role \"test1\" does not exist" {
 			t.Fatal(e)
 		}
@@ -478,40 +478,40 @@ func TestGlobalFunc(t *testing.T) {
 	if dbs.Rebind("SELECT * FROM test") != "" {
 		t.Fatal("Test rebind fail")
 	}
-	dbs._all = nil
+	dbs.setNodes(nil, nil, nil)
 	if rb := dbs.Rebind("SELECT * FROM test"); rb != "" {
 		t.Fatal("Test rebind fail", rb)
 	}
-	dbs._all = []*wrapper{nil}
+	dbs.setNodes(nil, nil, []*wrapper{nil})
 	if rb := dbs.Rebind("SELECT * FROM test"); rb != "" {
 		t.Fatal("Test rebind fail", rb)
 	}
 
 	// bindname
-	dbs._all = nil
+	dbs.setNodes(nil, nil, nil)
 	if _, _, e := dbs.BindNamed("DELETE FROM person WHERE first_name=:first_name", "John"); e != ErrNoConnection {
 		t.Fatal("Test BindNamed failed")
 	}
-	dbs._all = []*wrapper{}
+	dbs.setNodes(nil, nil, []*wrapper{})
 	if _, _, e := dbs.BindNamed("DELETE FROM person WHERE first_name=:first_name", "John"); e != ErrNoConnection {
 		t.Fatal("Test BindNamed failed")
 	}
-	dbs._all = []*wrapper{nil}
+	dbs.setNodes(nil, nil, []*wrapper{nil})
 	if _, _, e := dbs.BindNamed("DELETE FROM person WHERE first_name=:first_name", "John"); e != ErrNoConnection {
 		t.Fatal("Test BindNamed failed")
 	}
 
 	dsn := "user=test1 dbname=test2 sslmode=disable"
 	_db1, _ := sqlx.Open("postgres", dsn)
-	db1 := &wrapper{db: _db1, dsn: dsn}
+	db1 := newWrapper(_db1, dsn, RoleMaster, 0)
 	_db2, _ := sqlx.Open("postgres", dsn)
-	db2 := &wrapper{db: _db2, dsn: dsn}
+	db2 := newWrapper(_db2, dsn, RoleMaster, 0)
 	_db3, _ := sqlx.Open("postgres", dsn)
-	db3 := &wrapper{db: _db3, dsn: dsn}
+	db3 := newWrapper(_db3, dsn, RoleMaster, 0)
 	_db4, _ := sqlx.Open("postgres", dsn)
-	db4 := &wrapper{db: _db4, dsn: dsn}
+	db4 := newWrapper(_db4, dsn, RoleMaster, 0)
 
-	dbB := newBalancer(nil, -1, 2, true)
+	dbB := newBalancer(nil, -1, true)
 	dbB.add(db1)
 	dbB.add(db2)
 	if _, _, err := _query(context.Background(), dbB, "SELECT 1"); err != ErrNoConnectionOrWsrep {
@@ -519,7 +519,7 @@ func TestGlobalFunc(t *testing.T) {
 	}
 	dbB.destroy()
 
-	dbB = newBalancer(nil, -1, 2, true)
+	dbB = newBalancer(nil, -1, true)
 	dbB.add(db1)
 	dbB.add(db2)
 	tmp := 1
@@ -1700,7 +1700,7 @@ func TestUsages(t *testing.T) {
 		}
 		tx1.Commit()
 		isSlave := false
-		for _, v := range db._slaves {
+		for _, v := range db.slaveNodes() {
 			if v.db == dbx1 {
 				isSlave = true
 				break
@@ -1727,7 +1727,7 @@ func TestUsages(t *testing.T) {
 		}
 		tx1.Commit()
 		isSlave = false
-		for _, v := range db._slaves {
+		for _, v := range db.slaveNodes() {
 			if v.db == dbx1 {
 				isSlave = true
 				break
@@ -1823,7 +1823,7 @@ func TestStressQueries(t *testing.T) {
 
 			for range ch {
 				if _, err := db.Exec(db.Rebind("INSERT INTO stress VALUES (?, ?)"), "a", 12); err != nil {
-					t.Fatal(err)
+					t.Error(err)
 				}
 
 				time.Sleep(time.Millisecond)
@@ -1899,3 +1899,40 @@ func TestStressQueries(t *testing.T) {
 		wg.Wait()
 	})
 }
+
+func TestContextCancelStopsRetry(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := dbs.ExecContext(ctx, "SELECT 1"); err != context.Canceled {
+		t.Fatal("ContextCancel: exec should return context.Canceled", err)
+	}
+
+	var n int
+	if err := dbs.GetContext(ctx, &n, "SELECT 1"); err != context.Canceled {
+		t.Fatal("ContextCancel: get should return context.Canceled", err)
+	}
+
+	// no node available: waiting for nodes stops at deadline
+	b := newBalancer(nil, 0, false)
+	defer b.destroy()
+	b.setHealthCheckPeriod(1000)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	startedAt := time.Now()
+	if _, err := getDBFromBalancer(ctx, b); err != context.DeadlineExceeded || time.Since(startedAt) > 500*time.Millisecond {
+		t.Fatal("ContextCancel: waiting for node should stop at deadline", err)
+	}
+
+	if err := sleepContext(ctx, time.Second); err != context.DeadlineExceeded {
+		t.Fatal("ContextCancel: sleep should stop at deadline", err)
+	}
+	if err := sleepContext(context.Background(), time.Millisecond); err != nil {
+		t.Fatal("ContextCancel: sleep fail", err)
+	}
+}
@@ -0,0 +1,280 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DriverWrapper wraps database driver of every node at connecting time,
+// i.e instrumentation wrappers like ocsql.Wrap.
+//
+// Pass it as an arg of ConnectMasterSlaves.
+type DriverWrapper func(driver.Driver) driver.Driver
+
+// ConnectorWrapper wraps connector of every node at connecting time,
+// i.e instrumentation wrappers like otelsql.WrapConnector.
+//
+// Pass it as an arg of ConnectMasterSlaves.
+type ConnectorWrapper func(driver.Connector) driver.Connector
+
+// RebindOption makes every query of DBs functions rebound from QUESTION (?) bindvars
+// into bindvar type of node's driver, i.e $1 for postgres, so that the same query is portable across drivers,
+// including queries of transactions started by BeginNestedTx and of Conn.
+//
+// Pass RebindAlways as an arg of ConnectMasterSlaves.
+type RebindOption bool
+
+// RebindAlways rebinds every query automatically
+const RebindAlways RebindOption = true
+
+// options parsed from args of ConnectMasterSlaves
+type connectOptions struct {
+	isWsrep          bool
+	driverWrapper    DriverWrapper
+	connectorWrapper ConnectorWrapper
+	preferredPrimary *PreferredPrimary
+	quorumCheck      QuorumCheck
+	poolerMode       PoolerMode
+	timeOpts         *TimeOptions
+	rebind           bool
+	authProvider     AuthProvider
+	dial             dialFunc
+	nodeDials        map[string]dialFunc
+	appName          ApplicationName
+	slaveCreds       *SlaveCredentials
+	flavor           ServerFlavor
+}
+
+func parseConnectArgs(args []interface{}) (opts connectOptions) {
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case bool:
+			opts.isWsrep = v
+
+		case DriverWrapper:
+			opts.driverWrapper = v
+
+		case ConnectorWrapper:
+			opts.connectorWrapper = v
+
+		case PreferredPrimary:
+			opts.preferredPrimary = &v
+
+		case *PreferredPrimary:
+			opts.preferredPrimary = v
+
+		case QuorumCheck:
+			opts.quorumCheck = v
+
+		case PoolerMode:
+			opts.poolerMode = v
+
+		case TimeOptions:
+			opts.timeOpts = &v
+
+		case *TimeOptions:
+			opts.timeOpts = v
+
+		case RebindOption:
+			opts.rebind = bool(v)
+
+		case AuthProvider:
+			opts.authProvider = v
+
+		case SlaveCredentials:
+			opts.slaveCreds = &v
+
+		case *SlaveCredentials:
+			opts.slaveCreds = v
+
+		case ServerFlavor:
+			opts.flavor = v
+
+		case ApplicationName:
+			opts.appName = v
+
+		case CloudSQLDialer:
+			opts.dial = v.dial
+
+		case DialerOption:
+			if len(v.dsns) == 0 {
+				opts.dial = dialFunc(v.dial)
+				break
+			}
+
+			if opts.nodeDials == nil {
+				opts.nodeDials = make(map[string]dialFunc)
+			}
+			for _, dsn := range v.dsns {
+				opts.nodeDials[dsn] = dialFunc(v.dial)
+			}
+		}
+	}
+	return
+}
+
+// connector for drivers which do not implement driver.DriverContext
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// openDB opens pool of node, connecting with dsn of its current role
+func openDB(driverName, dsn string, role func() Role, opts *connectOptions) (*sqlx.DB, error) {
+	if opts.roleDSN() {
+		c := &roleConnector{role: role, open: func(role Role) (driver.Connector, error) {
+			return nodeConnector(driverName, dsn, role, opts)
+		}}
+		connector, err := c.connector()
+		if err != nil {
+			return nil, err
+		}
+		c.driver = connector.Driver()
+		return sqlx.NewDb(sql.OpenDB(c), driverName), nil
+	}
+
+	if opts.driverWrapper == nil && opts.connectorWrapper == nil && opts.authProvider == nil && opts.dialerOf(dsn) == nil {
+		dsn, err := opts.nodeDSN(driverName, dsn, role())
+		if err != nil {
+			return nil, err
+		}
+		return sqlx.Open(driverName, dsn)
+	}
+
+	connector, err := nodeConnector(driverName, dsn, role(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return sqlx.NewDb(sql.OpenDB(connector), driverName), nil
+}
+
+// nodeConnector returns connector of node as role
+func nodeConnector(driverName, dsn string, role Role, opts *connectOptions) (driver.Connector, error) {
+	dial := opts.dialerOf(dsn)
+
+	dsn, err := opts.nodeDSN(driverName, dsn, role)
+	if err != nil {
+		return nil, err
+	}
+
+	// lookup registered driver, no connection is made
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	_ = db.Close()
+
+	if opts.driverWrapper != nil {
+		d = opts.driverWrapper(d)
+	}
+
+	open := func(dsn string) (driver.Connector, error) {
+		return newConnector(d, dsn)
+	}
+	if dial != nil {
+		if open, err = dialOpener(driverName, d, dial); err != nil {
+			return nil, err
+		}
+	}
+
+	var connector driver.Connector
+	if opts.authProvider != nil {
+		connector = &authConnector{dsn: dsn, driver: d, provider: opts.authProvider, open: open}
+	} else if connector, err = open(dsn); err != nil {
+		return nil, err
+	}
+
+	if opts.connectorWrapper != nil {
+		connector = opts.connectorWrapper(connector)
+	}
+	return connector, nil
+}
+
+// roleDSN reports whether nodes connect with dsn depending on their role
+func (opts *connectOptions) roleDSN() bool {
+	return opts.slaveCreds != nil || opts.appName != ""
+}
+
+// roleConnector connects with connector of current role of node, so that connections opened
+// after ApplyTopology changes role authenticate and are tagged as new role
+type roleConnector struct {
+	role   func() Role
+	open   func(role Role) (driver.Connector, error)
+	driver driver.Driver
+
+	mu         sync.Mutex
+	connectors map[Role]driver.Connector
+}
+
+func (c *roleConnector) connector() (driver.Connector, error) {
+	role := c.role()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if connector, ok := c.connectors[role]; ok {
+		return connector, nil
+	}
+
+	connector, err := c.open(role)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.connectors == nil {
+		c.connectors = make(map[Role]driver.Connector)
+	}
+	c.connectors[role] = connector
+	return connector, nil
+}
+
+func (c *roleConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	connector, err := c.connector()
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+func (c *roleConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// nodeDSN returns dsn which node of role actually connects with, adjusted for pooler, slave credentials and application name
+func (opts *connectOptions) nodeDSN(driverName, dsn string, role Role) (_ string, err error) {
+	if opts.isPooler(dsn) {
+		dsn = poolerDSN(driverName, dsn)
+	}
+
+	if role == RoleSlave && opts.slaveCreds != nil {
+		if dsn, err = withCredentials(driverName, dsn, opts.slaveCreds); err != nil {
+			return
+		}
+	}
+
+	if opts.appName != "" {
+		dsn, err = tagDSN(driverName, dsn, opts.appName, role)
+	}
+	return dsn, err
+}
+
+// rebind transforms query into bindvar type of node's driver if RebindAlways is set
+func (w *wrapper) rebind(query string) string {
+	if w.rebound {
+		return w.db.Rebind(query)
+	}
+	return query
+}
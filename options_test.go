@@ -0,0 +1,102 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type countingDriver struct {
+	driver.Driver
+	opened *int32
+}
+
+func (d countingDriver) Open(dsn string) (driver.Conn, error) {
+	atomic.AddInt32(d.opened, 1)
+	return d.Driver.Open(dsn)
+}
+
+func TestParseConnectArgs(t *testing.T) {
+	if opts := parseConnectArgs(nil); opts.isWsrep || opts.driverWrapper != nil || opts.connectorWrapper != nil {
+		t.Fatal("ParseConnectArgs: default options fail")
+	}
+
+	opts := parseConnectArgs([]interface{}{true, DriverWrapper(func(d driver.Driver) driver.Driver { return d }), 1})
+	if !opts.isWsrep || opts.driverWrapper == nil {
+		t.Fatal("ParseConnectArgs: parse fail")
+	}
+}
+
+func TestDriverWrapper(t *testing.T) {
+	var opened, wrapped int32
+
+	dbs, errs := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"},
+		DriverWrapper(func(d driver.Driver) driver.Driver {
+			return countingDriver{Driver: d, opened: &opened}
+		}),
+		ConnectorWrapper(func(c driver.Connector) driver.Connector {
+			atomic.AddInt32(&wrapped, 1)
+			return c
+		}),
+	)
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer dbs.Destroy()
+
+	if atomic.LoadInt32(&wrapped) != 2 {
+		t.Fatal("DriverWrapper: connector of every node should be wrapped")
+	}
+
+	if _, err := dbs.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.ExecOnSlave("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if atomic.LoadInt32(&opened) < 2 {
+		t.Fatal("DriverWrapper: wrapped driver should be used")
+	}
+}
+
+func TestRebindAlways(t *testing.T) {
+	if opts := parseConnectArgs([]interface{}{RebindAlways}); !opts.rebind {
+		t.Fatal("RebindAlways: parse fail")
+	}
+
+	w := newWrapper(sqlx.NewDb(nil, "postgres"), "", RoleMaster, 0)
+	if q := w.rebind("SELECT * FROM t WHERE a = ? AND b = ?"); q != "SELECT * FROM t WHERE a = ? AND b = ?" {
+		t.Fatal("RebindAlways: disabled fail", q)
+	}
+
+	w.rebound = true
+	if q := w.rebind("SELECT * FROM t WHERE a = ? AND b = ?"); q != "SELECT * FROM t WHERE a = $1 AND b = $2" {
+		t.Fatal("RebindAlways: rebind fail", q)
+	}
+	if q := (&Tx{state: &txState{node: w}}).rebind("SELECT * FROM t WHERE a = ?"); q != "SELECT * FROM t WHERE a = $1" {
+		t.Fatal("RebindAlways: transaction rebind fail", q)
+	}
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"}, RebindAlways)
+	defer dbs.Destroy()
+
+	var v int
+	if err := dbs.Get(&v, "SELECT ?", 3); err != nil || v != 3 {
+		t.Fatal("RebindAlways: query fail", err)
+	}
+
+	tx, err := dbs.BeginNestedTx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err = tx.Get(&v, "SELECT ?", 4); err != nil || v != 4 {
+		t.Fatal("RebindAlways: transaction query fail", err)
+	}
+}
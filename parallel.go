@@ -0,0 +1,177 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultSelectParallelLimit default maximum number of queries SelectParallel runs concurrently
+	DefaultSelectParallelLimit = 4
+)
+
+var (
+	// ErrInvalidDestination destination must be a non-nil pointer to slice
+	ErrInvalidDestination = errors.New("Destination must be a non-nil pointer to slice")
+)
+
+// QuerySpec is a query and its placeholder parameters.
+type QuerySpec struct {
+	Query string
+	Args  []interface{}
+}
+
+func (dbs *DBs) getSelectParallelLimit() int {
+	if v := atomic.LoadInt32(&dbs.selectParallelLimit); v > 0 {
+		return int(v)
+	}
+	return DefaultSelectParallelLimit
+}
+
+// SetSelectParallelLimit sets the maximum number of queries SelectParallel runs concurrently.
+//
+// If n <= 0, DefaultSelectParallelLimit is used.
+func (dbs *DBs) SetSelectParallelLimit(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt32(&dbs.selectParallelLimit, int32(n))
+}
+
+// SelectParallel runs independent selects concurrently on slaves and appends all
+// results into dest, which must be a pointer to slice. Results are appended in the
+// same order as queries, regardless of completion order.
+//
+// Useful for querying partitioned tables (i.e per-month tables) at once.
+// The first error encountered is returned and dest is left untouched.
+func (dbs *DBs) SelectParallel(ctx context.Context, dest interface{}, queries []QuerySpec) error {
+	return _selectParallel(ctx, dbs.slaves, dbs.getSelectParallelLimit(), dest, queries)
+}
+
+// SelectParallelOnMaster runs independent selects concurrently on masters and appends all
+// results into dest, which must be a pointer to slice. Results are appended in the
+// same order as queries, regardless of completion order.
+func (dbs *DBs) SelectParallelOnMaster(ctx context.Context, dest interface{}, queries []QuerySpec) error {
+	return _selectParallel(ctx, dbs.masters, dbs.getSelectParallelLimit(), dest, queries)
+}
+
+func _selectParallel(ctx context.Context, target *balancer, limit int, dest interface{}, queries []QuerySpec) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return ErrInvalidDestination
+	}
+
+	n := len(queries)
+	if n == 0 {
+		return nil
+	}
+
+	if limit > n {
+		limit = n
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sliceType := v.Elem().Type()
+	parts := make([]reflect.Value, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+
+	for i := range queries {
+		parts[i] = reflect.New(sliceType)
+
+		wg.Add(1)
+		go func(ind int) {
+			defer wg.Done()
+
+			select {
+			case <-ctx.Done():
+				errs[ind] = ctx.Err()
+				return
+
+			case sem <- struct{}{}:
+			}
+
+			_, errs[ind] = _select(ctx, target, parts[ind].Interface(), queries[ind].Query, queries[ind].Args...)
+			if errs[ind] != nil {
+				cancel() // no need to continue others
+			}
+
+			<-sem
+		}(i)
+	}
+	wg.Wait()
+
+	// report the root cause instead of cancellation caused by it
+	var err error
+	for i := range errs {
+		if errs[i] != nil && (err == nil || err == context.Canceled) {
+			err = errs[i]
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	result := v.Elem()
+	for i := range parts {
+		result = reflect.AppendSlice(result, parts[i].Elem())
+	}
+	v.Elem().Set(result)
+
+	return nil
+}
+
+// SelectWithTotal runs the page query into dest and the count query concurrently on slaves,
+// returning total counted rows. Both queries share the same args.
+//
+// Typical usage is for list endpoints which need both a page and total number of records.
+func (dbs *DBs) SelectWithTotal(ctx context.Context, dest interface{}, query, countQuery string, args ...interface{}) (int64, error) {
+	return _selectWithTotal(ctx, dbs.slaves, dest, query, countQuery, args...)
+}
+
+// SelectWithTotalOnMaster runs the page query into dest and the count query concurrently on masters,
+// returning total counted rows. Both queries share the same args.
+func (dbs *DBs) SelectWithTotalOnMaster(ctx context.Context, dest interface{}, query, countQuery string, args ...interface{}) (int64, error) {
+	return _selectWithTotal(ctx, dbs.masters, dest, query, countQuery, args...)
+}
+
+func _selectWithTotal(ctx context.Context, target *balancer, dest interface{}, query, countQuery string, args ...interface{}) (total int64, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var countErr error
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		if _, countErr = _get(ctx, target, &total, countQuery, args...); countErr != nil {
+			cancel() // no need to continue page query
+		}
+		wg.Done()
+	}()
+
+	if _, err = _select(ctx, target, dest, query, args...); err != nil {
+		cancel() // no need to continue count query
+	}
+	wg.Wait()
+
+	// report the root cause instead of cancellation caused by it
+	if err == nil || (err == context.Canceled && countErr != nil) {
+		err = countErr
+	}
+
+	return
+}
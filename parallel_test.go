@@ -0,0 +1,72 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSelectParallel(t *testing.T) {
+	dbs := &DBs{}
+	if dbs.getSelectParallelLimit() != DefaultSelectParallelLimit {
+		t.Fatal("SelectParallel: default limit fail")
+	}
+	if dbs.SetSelectParallelLimit(2); dbs.getSelectParallelLimit() != 2 {
+		t.Fatal("SelectParallel: set limit fail")
+	}
+
+	var places []Place
+	if err := dbs.SelectParallel(context.Background(), places, nil); err != ErrInvalidDestination {
+		t.Fatal("SelectParallel: invalid destination check fail")
+	}
+
+	_RunWithSchema(defaultSchema, t, func(db *DBs, t *testing.T) {
+		_loadDefaultFixture(db, t)
+		db.SetSelectParallelLimit(1)
+
+		var people []Person
+		err := db.SelectParallel(context.Background(), &people, []QuerySpec{
+			{Query: db.Rebind("SELECT * FROM person WHERE first_name = ?"), Args: []interface{}{"John"}},
+			{Query: db.Rebind("SELECT * FROM person WHERE first_name = ?"), Args: []interface{}{"Jason"}},
+			{Query: db.Rebind("SELECT * FROM person WHERE first_name = ?"), Args: []interface{}{"Nobody"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(people) != 2 || people[0].FirstName != "John" || people[1].FirstName != "Jason" {
+			t.Fatal("SelectParallel: unexpected result", people)
+		}
+
+		if err = db.SelectParallelOnMaster(context.Background(), &people, []QuerySpec{
+			{Query: "SELECT * FROM person"},
+			{Query: "SELECT * FROM not_existed_table"},
+		}); err == nil || len(people) != 2 {
+			t.Fatal("SelectParallel: error should be returned and destination untouched")
+		}
+	})
+}
+
+func TestSelectWithTotal(t *testing.T) {
+	_RunWithSchema(defaultSchema, t, func(db *DBs, t *testing.T) {
+		_loadDefaultFixture(db, t)
+
+		var people []Person
+		total, err := db.SelectWithTotal(context.Background(), &people,
+			db.Rebind("SELECT * FROM person WHERE first_name <> ? ORDER BY first_name LIMIT 1"),
+			db.Rebind("SELECT COUNT(*) FROM person WHERE first_name <> ?"), "Nobody")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 2 || len(people) != 1 || people[0].FirstName != "Jason" {
+			t.Fatal("SelectWithTotal: unexpected result", total, people)
+		}
+
+		if _, err = db.SelectWithTotalOnMaster(context.Background(), &people,
+			"SELECT * FROM person", "SELECT COUNT(*) FROM not_existed_table"); err == nil {
+			t.Fatal("SelectWithTotal: count error should be returned")
+		}
+		if _, err = db.SelectWithTotalOnMaster(context.Background(), &people,
+			"SELECT * FROM not_existed_table", "SELECT COUNT(*) FROM person"); err == nil || err == context.Canceled {
+			t.Fatal("SelectWithTotal: page error should be returned", err)
+		}
+	})
+}
@@ -0,0 +1,286 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrPartitionNotSupported partition maintenance is not supported by driver
+	ErrPartitionNotSupported = errors.New("Partition maintenance is only supported by mysql and postgres drivers")
+
+	// ErrInvalidPartitionInterval partition interval is unknown
+	ErrInvalidPartitionInterval = errors.New("Invalid partition interval")
+)
+
+const (
+	// DefaultPartitionPremake default number of future partitions created ahead
+	DefaultPartitionPremake = 3
+
+	// DefaultPartitionMaintenancePeriod default period of partition maintenance
+	DefaultPartitionMaintenancePeriod = time.Hour
+)
+
+// PartitionInterval is time range covered by each partition.
+type PartitionInterval int
+
+const (
+	// PartitionDaily one partition per day
+	PartitionDaily PartitionInterval = iota
+
+	// PartitionWeekly one partition per week, starting on Monday
+	PartitionWeekly
+
+	// PartitionMonthly one partition per month
+	PartitionMonthly
+)
+
+// PartitionSpec declares time-based partitions of a table. Boundaries are in UTC.
+//
+// On postgres, table is declaratively partitioned by RANGE on a date/timestamp column, partitions are
+// named <table>_p<yyyymmdd> of their start. On mysql, table is partitioned by RANGE COLUMNS on a
+// date/datetime column without MAXVALUE partition, partitions are named p<yyyymmdd>.
+type PartitionSpec struct {
+	Table    string
+	Interval PartitionInterval
+
+	// Premake number of future partitions, besides current one, kept created. Default is DefaultPartitionPremake.
+	Premake int
+
+	// Retention number of past partitions kept, older ones are dropped. Zero keeps all.
+	Retention int
+}
+
+// PartitionChanges partitions created and dropped for a table.
+type PartitionChanges struct {
+	Table   string
+	Created []string
+	Dropped []string
+}
+
+// start returns start of partition containing t
+func (i PartitionInterval) start(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	switch i {
+	case PartitionWeekly:
+		day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+
+	case PartitionMonthly:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// add returns start of n-th partition following the one starting at start
+func (i PartitionInterval) add(start time.Time, n int) time.Time {
+	switch i {
+	case PartitionWeekly:
+		return start.AddDate(0, 0, 7*n)
+
+	case PartitionMonthly:
+		return start.AddDate(0, n, 0)
+	}
+	return start.AddDate(0, 0, n)
+}
+
+func (i PartitionInterval) valid() bool {
+	return i >= PartitionDaily && i <= PartitionMonthly
+}
+
+// partitionDialect generates partition DDL of a driver
+type partitionDialect struct {
+	name   func(table string, start time.Time) string // unqualified name of partition
+	list   func(table string) (query string, args []interface{})
+	create func(table, name string, from, to time.Time) string
+	drop   func(table string, names []string) string
+}
+
+// splitTable splits qualified table name into schema (empty if unqualified) and name
+func splitTable(table string) (schema, name string) {
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		return table[:i], table[i+1:]
+	}
+	return "", table
+}
+
+func getPartitionDialect(driverName string) *partitionDialect {
+	switch driverName {
+	case "postgres", "pgx":
+		return &partitionDialect{
+			name: func(table string, start time.Time) string {
+				_, name := splitTable(table)
+				return name + "_p" + start.Format("20060102")
+			},
+			list: func(table string) (string, []interface{}) {
+				return "SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid WHERE i.inhparent = $1::regclass", []interface{}{table}
+			},
+			create: func(table, name string, from, to time.Time) string {
+				if schema, _ := splitTable(table); schema != "" {
+					name = schema + "." + name
+				}
+				return "CREATE TABLE IF NOT EXISTS " + name + " PARTITION OF " + table +
+					" FOR VALUES FROM ('" + from.Format("2006-01-02") + "') TO ('" + to.Format("2006-01-02") + "')"
+			},
+			drop: func(table string, names []string) string {
+				qualified := names
+				if schema, _ := splitTable(table); schema != "" {
+					qualified = make([]string, len(names))
+					for i, name := range names {
+						qualified[i] = schema + "." + name
+					}
+				}
+				return "DROP TABLE IF EXISTS " + strings.Join(qualified, ", ")
+			},
+		}
+
+	case "mysql":
+		return &partitionDialect{
+			name: func(_ string, start time.Time) string {
+				return "p" + start.Format("20060102")
+			},
+			list: func(table string) (string, []interface{}) {
+				schema, name := splitTable(table)
+				return "SELECT PARTITION_NAME FROM information_schema.PARTITIONS WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) " +
+					"AND TABLE_NAME = ? AND PARTITION_NAME IS NOT NULL", []interface{}{schema, name}
+			},
+			create: func(table, name string, _, to time.Time) string {
+				return "ALTER TABLE " + table + " ADD PARTITION (PARTITION " + name + " VALUES LESS THAN ('" + to.Format("2006-01-02") + "'))"
+			},
+			drop: func(table string, names []string) string {
+				return "ALTER TABLE " + table + " DROP PARTITION " + strings.Join(names, ", ")
+			},
+		}
+	}
+	return nil
+}
+
+// plan returns partitions to create and drop, given existing ones
+func (d *partitionDialect) plan(spec PartitionSpec, now time.Time, existing map[string]bool) (create []time.Time, drop []string) {
+	current := spec.Interval.start(now)
+
+	for n := 0; n <= spec.Premake; n++ {
+		if start := spec.Interval.add(current, n); !existing[d.name(spec.Table, start)] {
+			create = append(create, start)
+		}
+	}
+
+	if spec.Retention > 0 {
+		oldest := d.name(spec.Table, spec.Interval.add(current, -spec.Retention))
+		prefix := d.name(spec.Table, time.Time{})
+		prefix = prefix[:len(prefix)-len("00010101")]
+
+		for name := range existing {
+			// names of managed partitions sort by their start
+			if strings.HasPrefix(name, prefix) && len(name) == len(oldest) && name < oldest {
+				drop = append(drop, name)
+			}
+		}
+		sort.Strings(drop)
+	}
+	return
+}
+
+// MaintainPartitions creates future partitions and drops expired ones of tables on master, as declared by specs.
+func (dbs *DBs) MaintainPartitions(ctx context.Context, specs []PartitionSpec) ([]PartitionChanges, error) {
+	d := getPartitionDialect(dbs.driverName)
+	if d == nil {
+		return nil, ErrPartitionNotSupported
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	changes := make([]PartitionChanges, 0, len(specs))
+	for _, spec := range specs {
+		c, err := dbs.maintainPartitions(ctx, d, spec, time.Now())
+		if len(c.Created) > 0 || len(c.Dropped) > 0 {
+			changes = append(changes, c)
+		}
+		if err != nil {
+			return changes, err
+		}
+	}
+	return changes, nil
+}
+
+func (dbs *DBs) maintainPartitions(ctx context.Context, d *partitionDialect, spec PartitionSpec, now time.Time) (c PartitionChanges, err error) {
+	c.Table = spec.Table
+
+	if !spec.Interval.valid() {
+		return c, ErrInvalidPartitionInterval
+	}
+	if spec.Premake <= 0 {
+		spec.Premake = DefaultPartitionPremake
+	}
+
+	var names []string
+	query, args := d.list(spec.Table)
+	if err = dbs.SelectContextOnMaster(ctx, &names, query, args...); err != nil {
+		return
+	}
+
+	existing := make(map[string]bool, len(names))
+	for _, name := range names {
+		existing[name] = true
+	}
+
+	create, drop := d.plan(spec, now, existing)
+	for _, start := range create {
+		name := d.name(spec.Table, start)
+		if _, err = dbs.ExecContext(ctx, d.create(spec.Table, name, start, spec.Interval.add(start, 1))); err != nil {
+			return
+		}
+		c.Created = append(c.Created, name)
+		logEntry(LogLevelInfo, "partition is created", LogField{Key: "partition", Value: name})
+	}
+
+	if len(drop) > 0 {
+		if _, err = dbs.ExecContext(ctx, d.drop(spec.Table, drop)); err != nil {
+			return
+		}
+		c.Dropped = drop
+		logEntry(LogLevelInfo, "partitions are dropped", LogField{Key: "partitions", Value: drop})
+	}
+	return
+}
+
+// SchedulePartitionMaintenance runs MaintainPartitions right away then every period until ctx is done.
+// If period <= 0, DefaultPartitionMaintenancePeriod is used. Errors are logged.
+func (dbs *DBs) SchedulePartitionMaintenance(ctx context.Context, specs []PartitionSpec, period time.Duration) error {
+	if getPartitionDialect(dbs.driverName) == nil {
+		return ErrPartitionNotSupported
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if period <= 0 {
+		period = DefaultPartitionMaintenancePeriod
+	}
+
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		for {
+			if _, err := dbs.MaintainPartitions(ctx, specs); err != nil && ctx.Err() == nil {
+				reportError("maintain partitions", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package mssqlx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPartitionMaintenance(t *testing.T) {
+	now := time.Date(2024, 2, 29, 15, 4, 5, 0, time.UTC) // Thursday
+
+	if s := PartitionDaily.start(now); !s.Equal(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("PartitionMaintenance: daily start fail", s)
+	}
+	if s := PartitionWeekly.start(now); !s.Equal(time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("PartitionMaintenance: weekly start fail", s)
+	}
+	if s := PartitionMonthly.start(now); !s.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("PartitionMaintenance: monthly start fail", s)
+	}
+	if s := PartitionWeekly.start(time.Date(2024, 3, 3, 23, 0, 0, 0, time.UTC)); !s.Equal(time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("PartitionMaintenance: weekly start on Sunday fail", s)
+	}
+	if s := PartitionMonthly.add(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 2); !s.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("PartitionMaintenance: monthly add fail", s)
+	}
+	if PartitionInterval(7).valid() {
+		t.Fatal("PartitionMaintenance: unknown interval should be invalid")
+	}
+
+	pg := getPartitionDialect("postgres")
+	spec := PartitionSpec{Table: "public.events", Interval: PartitionDaily, Premake: 2, Retention: 2}
+	existing := map[string]bool{"events_p20240226": true, "events_p20240227": true, "events_p20240229": true, "events_archive": true}
+
+	create, drop := pg.plan(spec, now, existing)
+	if len(create) != 2 || !create[0].Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) || !create[1].Equal(time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("PartitionMaintenance: planning creation fail", create)
+	}
+	if !reflect.DeepEqual(drop, []string{"events_p20240226"}) {
+		t.Fatal("PartitionMaintenance: planning drop fail", drop)
+	}
+
+	if q := pg.create(spec.Table, pg.name(spec.Table, create[0]), create[0], create[1]); q != "CREATE TABLE IF NOT EXISTS public.events_p20240301 PARTITION OF public.events FOR VALUES FROM ('2024-03-01') TO ('2024-03-02')" {
+		t.Fatal("PartitionMaintenance: postgres creation fail", q)
+	}
+	if q := pg.drop(spec.Table, drop); q != "DROP TABLE IF EXISTS public.events_p20240226" {
+		t.Fatal("PartitionMaintenance: postgres drop fail", q)
+	}
+
+	my := getPartitionDialect("mysql")
+	spec = PartitionSpec{Table: "events", Interval: PartitionMonthly, Premake: 1}
+	create, drop = my.plan(spec, now, map[string]bool{"p20240101": true, "p20240201": true})
+	if len(create) != 1 || len(drop) != 0 {
+		t.Fatal("PartitionMaintenance: planning without retention fail", create, drop)
+	}
+	if q := my.create(spec.Table, my.name(spec.Table, create[0]), create[0], PartitionMonthly.add(create[0], 1)); q != "ALTER TABLE events ADD PARTITION (PARTITION p20240301 VALUES LESS THAN ('2024-04-01'))" {
+		t.Fatal("PartitionMaintenance: mysql creation fail", q)
+	}
+	if q, args := my.list("app.events"); len(args) != 2 || args[0] != "app" || args[1] != "events" || q == "" {
+		t.Fatal("PartitionMaintenance: mysql listing fail", args)
+	}
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+	if _, err := dbs.MaintainPartitions(context.Background(), []PartitionSpec{spec}); err != ErrPartitionNotSupported {
+		t.Fatal("PartitionMaintenance: unsupported driver should fail", err)
+	}
+	if err := dbs.SchedulePartitionMaintenance(context.Background(), []PartitionSpec{spec}, 0); err != ErrPartitionNotSupported {
+		t.Fatal("PartitionMaintenance: unsupported driver should fail", err)
+	}
+}
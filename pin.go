@@ -0,0 +1,55 @@
+package mssqlx
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type pinKey struct{}
+
+// node pinned to a context
+type pinnedNode struct {
+	target *balancer
+	node   atomic.Value // *wrapper
+}
+
+func (p *pinnedNode) load() (w *wrapper) {
+	w, _ = p.node.Load().(*wrapper)
+	return
+}
+
+func (p *pinnedNode) store(w *wrapper) {
+	p.node.Store(w)
+}
+
+func pinnedNodeFromContext(ctx context.Context, target *balancer) *pinnedNode {
+	if ctx == nil {
+		return nil
+	}
+	if p, ok := ctx.Value(pinKey{}).(*pinnedNode); ok && p.target == target {
+		return p
+	}
+	return nil
+}
+
+// PinSlave selects one healthy slave and returns a context which routes all reads made with it
+// to that slave, so multi-query read flows see a consistent replica instead of bouncing
+// between replicas with different lag.
+//
+// When pinned slave fails, reads fall back to another healthy slave, which becomes the new pinned one.
+func (dbs *DBs) PinSlave(ctx context.Context) (context.Context, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	queryInfoFromContext(ctx).start()
+	w, err := getDBFromBalancer(ctx, dbs.slaves)
+	if err != nil {
+		return ctx, err
+	}
+
+	p := &pinnedNode{target: dbs.slaves}
+	p.store(w)
+
+	return context.WithValue(ctx, pinKey{}, p), nil
+}
@@ -0,0 +1,43 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestPinSlave(t *testing.T) {
+	dsn := "user=test1 dbname=test1 sslmode=disable"
+	db1, _ := sqlx.Open("postgres", dsn)
+	db2, _ := sqlx.Open("postgres", dsn)
+
+	slaves := newBalancer(nil, 0, false)
+	defer slaves.destroy()
+
+	w1, w2 := newWrapper(db1, dsn, RoleMaster, 0), newWrapper(db2, dsn, RoleMaster, 0)
+	slaves.add(w1)
+	slaves.add(w2)
+
+	dbs := &DBs{slaves: slaves}
+	ctx, err := dbs.PinSlave(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pinned := slaves.pick(ctx)
+	for i := 0; i < 4; i++ {
+		if slaves.pick(ctx) != pinned {
+			t.Fatal("PinSlave: reads should be routed to pinned slave")
+		}
+	}
+
+	if slaves.pick(context.Background()) == slaves.pick(context.Background()) {
+		t.Fatal("PinSlave: unpinned reads should be balanced")
+	}
+
+	slaves.failure(pinned)
+	if fallback := slaves.pick(ctx); fallback == pinned || slaves.pick(ctx) != fallback {
+		t.Fatal("PinSlave: should fallback and re-pin on failure")
+	}
+}
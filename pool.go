@@ -0,0 +1,42 @@
+package mssqlx
+
+import "sync"
+
+const (
+	// larger slices are left to GC instead of being pooled
+	maxPooledValues = 1024
+)
+
+var valuesPool = sync.Pool{
+	New: func() interface{} {
+		s := make([]interface{}, 0, 16)
+		return &s
+	},
+}
+
+// getValues returns zeroed []interface{} of length n from pool. Put it back with putValues when done.
+func getValues(n int) *[]interface{} {
+	p := valuesPool.Get().(*[]interface{})
+	if cap(*p) < n {
+		s := make([]interface{}, n)
+		*p = s
+	} else {
+		*p = (*p)[:n]
+	}
+	return p
+}
+
+// putValues returns slice to pool. Nil is ignored.
+func putValues(p *[]interface{}) {
+	if p == nil || cap(*p) > maxPooledValues {
+		return
+	}
+
+	s := *p
+	for i := range s {
+		s[i] = nil // do not retain scanned values/args
+	}
+	*p = s[:0]
+
+	valuesPool.Put(p)
+}
@@ -0,0 +1,64 @@
+package mssqlx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValuesPool(t *testing.T) {
+	p := getValues(3)
+	if len(*p) != 3 {
+		t.Fatal("ValuesPool: length fail", len(*p))
+	}
+	(*p)[0] = "x"
+	putValues(p)
+
+	if p = getValues(2); len(*p) != 2 || (*p)[0] != nil {
+		t.Fatal("ValuesPool: pooled slice should be zeroed", *p)
+	}
+	putValues(p)
+	putValues(nil)
+
+	if p = getValues(maxPooledValues + 1); len(*p) != maxPooledValues+1 {
+		t.Fatal("ValuesPool: large slice fail", len(*p))
+	}
+	putValues(p)
+
+	w := &wrapper{}
+	args := []interface{}{time.Now()}
+	if normalized, buf := w.acquireArgs(args); buf != nil || &normalized[0] != &args[0] {
+		t.Fatal("ValuesPool: args should be untouched without UTC normalization")
+	}
+
+	loc := time.FixedZone("X", 3600)
+	w.timeOpts = &TimeOptions{UTC: true}
+	normalized, buf := w.acquireArgs([]interface{}{time.Date(2020, 1, 1, 1, 0, 0, 0, loc), 1})
+	if buf == nil || normalized[0].(time.Time).Location() != time.UTC || normalized[1] != 1 {
+		t.Fatal("ValuesPool: UTC normalization fail", normalized)
+	}
+	putValues(buf)
+}
+
+func BenchmarkAcquireArgs(b *testing.B) {
+	w := &wrapper{timeOpts: &TimeOptions{UTC: true}}
+	args := []interface{}{1, "a", time.Now(), 2.5}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, buf := w.acquireArgs(args)
+		putValues(buf)
+	}
+}
+
+func BenchmarkGetStruct(b *testing.B) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var row reflectCacheRow
+		if err := dbs.Get(&row, "SELECT 1 AS id, 'a' AS name"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrPoolerPrepare server-side prepared statements do not survive transaction/statement pooling
+	ErrPoolerPrepare = errors.New("Prepared statements are not supported on pooler node")
+
+	// ErrPoolerSessionState session-scoped state leaks to other clients of a pooler
+	ErrPoolerSessionState = errors.New("Session variables are not supported on pooler node")
+)
+
+// PoolerMode tells whether node with given dsn is actually a connection pooler
+// (i.e PgBouncer in transaction pooling mode, ProxySQL) rather than a database server.
+//
+// Pass it as an arg of ConnectMasterSlaves. On pooler nodes:
+//
+//   - prepared statements are refused with ErrPoolerPrepare.
+//   - query args are sent with simple protocol/client-side interpolation, when driver supports it.
+//   - WithSessionVars uses transaction-scoped SET LOCAL on postgres, and is refused with ErrPoolerSessionState otherwise.
+type PoolerMode func(dsn string) bool
+
+// AllPoolers marks every node as pooler.
+var AllPoolers PoolerMode = func(string) bool { return true }
+
+func (opts *connectOptions) isPooler(dsn string) bool {
+	return opts.poolerMode != nil && opts.poolerMode(dsn)
+}
+
+func addDSNParam(dsn, key, value string) string {
+	if strings.Contains(dsn, key+"=") {
+		return dsn // respect user's setting
+	}
+
+	if strings.Contains(dsn, "?") {
+		return dsn + "&" + key + "=" + value
+	}
+	return dsn + "?" + key + "=" + value
+}
+
+// poolerDSN adjusts dsn for simple-protocol-friendly behavior.
+func poolerDSN(driverName, dsn string) string {
+	switch driverName {
+	case "mysql":
+		// interpolate args client-side instead of server-side prepared statements
+		return addDSNParam(dsn, "interpolateParams", "true")
+
+	case "pgx":
+		if strings.Contains(dsn, "://") {
+			return addDSNParam(dsn, "default_query_exec_mode", "simple_protocol")
+		}
+		if strings.Contains(dsn, "default_query_exec_mode=") {
+			return dsn
+		}
+		return dsn + " default_query_exec_mode=simple_protocol"
+	}
+
+	// lib/pq uses unnamed statements, which are fine with poolers
+	return dsn
+}
+
+func isPostgres(driverName string) bool {
+	return driverName == "postgres" || driverName == "pgx"
+}
+
+// withLocalVars applies vars with SET LOCAL inside a transaction wrapping fn,
+// so that pooler could not leak them to other clients.
+func (dbs *DBs) withLocalVars(ctx context.Context, conn *Conn, vars map[string]string, fn func(*Conn) error) (err error) {
+	if !isPostgres(dbs.driverName) {
+		return ErrPoolerSessionState
+	}
+
+	if _, err = conn.ExecContext(ctx, "BEGIN"); err != nil {
+		return
+	}
+
+	defer func() {
+		query := "COMMIT"
+		if err != nil {
+			query = "ROLLBACK"
+		}
+
+		// finish with background context, ctx might be done already
+		if _, e := conn.ExecContext(context.Background(), query); e != nil {
+			reportError(query, e)
+			if err == nil {
+				err = e
+			}
+		}
+	}()
+
+	for name, value := range vars {
+		if _, err = conn.ExecContext(ctx, "SET LOCAL "+name+" = "+sessionVarValue(dbs.driverName, value)); err != nil {
+			return
+		}
+	}
+
+	return fn(conn)
+}
@@ -0,0 +1,52 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPoolerDSN(t *testing.T) {
+	cases := []struct {
+		driverName, dsn, expected string
+	}{
+		{"mysql", "root:123@tcp(127.0.0.1:3306)/test", "root:123@tcp(127.0.0.1:3306)/test?interpolateParams=true"},
+		{"mysql", "root:123@tcp(127.0.0.1:3306)/test?parseTime=true", "root:123@tcp(127.0.0.1:3306)/test?parseTime=true&interpolateParams=true"},
+		{"mysql", "root@/test?interpolateParams=false", "root@/test?interpolateParams=false"},
+		{"pgx", "postgres://u@h/db", "postgres://u@h/db?default_query_exec_mode=simple_protocol"},
+		{"pgx", "host=h dbname=db", "host=h dbname=db default_query_exec_mode=simple_protocol"},
+		{"postgres", "host=h dbname=db", "host=h dbname=db"},
+	}
+
+	for _, c := range cases {
+		if got := poolerDSN(c.driverName, c.dsn); got != c.expected {
+			t.Fatal("PoolerDSN: unexpected dsn", c.driverName, got)
+		}
+	}
+}
+
+func TestPoolerMode(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{"file::memory:"}, PoolerMode(func(dsn string) bool {
+		return dsn == ":memory:"
+	}))
+	defer dbs.Destroy()
+
+	if !dbs.masterNodes()[0].pooler || dbs.slaveNodes()[0].pooler {
+		t.Fatal("PoolerMode: nodes are not marked properly")
+	}
+
+	if _, _, err := dbs.Preparex("SELECT 1"); err != ErrPoolerPrepare {
+		t.Fatal("PoolerMode: prepared statement should be refused on pooler", err)
+	}
+	if _, _, err := dbs.PreparexOnSlave("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dbs.Exec("SELECT ?", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	fn := func(*Conn) error { return nil }
+	if err := dbs.WithSessionVars(context.Background(), map[string]string{"a": "1"}, fn); err != ErrPoolerSessionState {
+		t.Fatal("PoolerMode: session vars should be refused on pooler", err)
+	}
+}
@@ -0,0 +1,236 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrPreflightNotSupported preflight checks are not supported by driver
+	ErrPreflightNotSupported = errors.New("Preflight checks are only supported by mysql, postgres and sqlite3 drivers")
+)
+
+// Settings checked by Preflight.
+const (
+	// SettingVersion server version. Nodes are compatible if their major versions are the same
+	SettingVersion = "version"
+
+	// SettingTimeZone session time zone
+	SettingTimeZone = "time_zone"
+
+	// SettingSQLMode sql_mode of mysql
+	SettingSQLMode = "sql_mode"
+
+	// SettingStandardConformingStrings standard_conforming_strings of postgres
+	SettingStandardConformingStrings = "standard_conforming_strings"
+
+	// SettingWritePrivileges whether user of master has write privileges
+	SettingWritePrivileges = "write_privileges"
+)
+
+// PreflightReport is result of Preflight.
+type PreflightReport struct {
+	Nodes []PreflightNode `json:"nodes"`
+
+	// Diffs settings whose values are not the same across nodes
+	Diffs []PreflightDiff `json:"diffs,omitempty"`
+
+	// Issues nodes which could not be checked, or lack required privileges
+	Issues []PreflightIssue `json:"issues,omitempty"`
+}
+
+// OK returns true if there is neither diff nor issue.
+func (r *PreflightReport) OK() bool {
+	return len(r.Diffs) == 0 && len(r.Issues) == 0
+}
+
+// PreflightNode settings of a node.
+type PreflightNode struct {
+	Name     string            `json:"name"`
+	Role     Role              `json:"role"`
+	Settings map[string]string `json:"settings"`
+}
+
+// PreflightDiff a setting whose values are not the same across nodes.
+type PreflightDiff struct {
+	Setting string `json:"setting"`
+
+	// Values node name => value
+	Values map[string]string `json:"values"`
+}
+
+// PreflightIssue a problem of node found by Preflight.
+type PreflightIssue struct {
+	Node    string `json:"node"`
+	Message string `json:"message"`
+}
+
+// settingsQuery returns query of settings, whose columns are named after settings
+func settingsQuery(driverName string) string {
+	switch driverName {
+	case "mysql":
+		return "SELECT VERSION() AS version, IF(@@session.time_zone = 'SYSTEM', @@system_time_zone, @@session.time_zone) AS time_zone, " +
+			"@@session.sql_mode AS sql_mode"
+
+	case "postgres", "pgx":
+		return "SELECT current_setting('server_version') AS version, current_setting('TimeZone') AS time_zone, " +
+			"current_setting('standard_conforming_strings') AS standard_conforming_strings"
+
+	case "sqlite3":
+		return "SELECT sqlite_version() AS version"
+	}
+	return ""
+}
+
+// Preflight verifies every node before traffic hits it: server versions, time zones and sql_mode/standard_conforming_strings
+// should be the same across nodes, users of masters should have write privileges. Differences across nodes and
+// problems are reported, so that misconfigured replicas are caught at startup.
+func (dbs *DBs) Preflight(ctx context.Context) (report PreflightReport, err error) {
+	query := settingsQuery(dbs.driverName)
+	if query == "" {
+		err = ErrPreflightNotSupported
+		return
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	nodes := make([]*wrapper, 0, len(dbs.allNodes()))
+	for _, w := range dbs.allNodes() {
+		if w != nil {
+			nodes = append(nodes, w)
+		}
+	}
+
+	report.Nodes = make([]PreflightNode, len(nodes))
+	issues := make([]string, len(nodes))
+
+	var wg sync.WaitGroup
+	for i, w := range nodes {
+		wg.Add(1)
+		go func(i int, w *wrapper) {
+			defer wg.Done()
+			report.Nodes[i], issues[i] = dbs.preflightNode(ctx, w, query)
+		}(i, w)
+	}
+	wg.Wait()
+
+	for i, msg := range issues {
+		if msg != "" {
+			report.Issues = append(report.Issues, PreflightIssue{Node: report.Nodes[i].Name, Message: msg})
+		}
+	}
+	report.Diffs = diffSettings(dbs.driverName, report.Nodes)
+
+	return
+}
+
+// preflightNode reads settings of w, returns issue of node if any
+func (dbs *DBs) preflightNode(ctx context.Context, w *wrapper, query string) (node PreflightNode, issue string) {
+	node = PreflightNode{Name: w.getName(), Role: w.getRole(), Settings: make(map[string]string)}
+	if w.db == nil {
+		return node, "node is not connected"
+	}
+
+	rows, err := w.db.QueryContext(ctx, query)
+	if err != nil {
+		return node, err.Error()
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return node, err.Error()
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	if !rows.Next() {
+		if err = rows.Err(); err == nil {
+			err = sql.ErrNoRows
+		}
+		return node, err.Error()
+	}
+	if err = rows.Scan(dest...); err != nil {
+		return node, err.Error()
+	}
+	for i, column := range columns {
+		node.Settings[column] = values[i].String
+	}
+
+	if privileges := writePrivilegesQuery(dbs.driverName); privileges != "" && node.Role == RoleMaster {
+		var n int64
+		if err = w.db.GetContext(ctx, &n, privileges); err != nil {
+			return node, err.Error()
+		}
+
+		node.Settings[SettingWritePrivileges] = strconv.FormatBool(n > 0)
+		if n == 0 {
+			return node, "master user has no write privileges"
+		}
+	}
+
+	return
+}
+
+// diffSettings returns settings whose values differ across nodes, sorted by setting
+func diffSettings(driverName string, nodes []PreflightNode) (diffs []PreflightDiff) {
+	settings := make(map[string]struct{})
+	for _, n := range nodes {
+		for s := range n.Settings {
+			settings[s] = struct{}{}
+		}
+	}
+	delete(settings, SettingWritePrivileges) // differs by role
+
+	names := make([]string, 0, len(settings))
+	for s := range settings {
+		names = append(names, s)
+	}
+	sort.Strings(names)
+
+	for _, s := range names {
+		values, distinct := make(map[string]string, len(nodes)), make(map[string]struct{})
+		for _, n := range nodes {
+			if v, ok := n.Settings[s]; ok {
+				values[n.Name] = v
+				if s == SettingVersion {
+					v = majorVersion(driverName, v)
+				}
+				distinct[v] = struct{}{}
+			}
+		}
+
+		if len(distinct) > 1 {
+			diffs = append(diffs, PreflightDiff{Setting: s, Values: values})
+		}
+	}
+	return
+}
+
+// majorVersion returns major version of server, i.e 8.0 of mysql 8.0.35, 15 of postgres 15.4
+func majorVersion(driverName, version string) string {
+	parts := strings.FieldsFunc(version, func(r rune) bool { return r == '.' || r == '-' || r == ' ' })
+
+	n := 2
+	if isPostgres(driverName) && len(parts) > 0 {
+		if major, err := strconv.Atoi(parts[0]); err == nil && major >= 10 {
+			n = 1
+		}
+	}
+
+	if len(parts) < n {
+		return version
+	}
+	return strings.Join(parts[:n], ".")
+}
@@ -0,0 +1,46 @@
+package mssqlx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestPreflight(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:", ":memory:"})
+	defer dbs.Destroy()
+
+	report, err := dbs.Preflight(context.Background())
+	if err != nil || !report.OK() || len(report.Nodes) != 3 || report.Nodes[0].Settings[SettingVersion] == "" {
+		t.Fatal("Preflight: same nodes should pass", err, report)
+	}
+
+	dbs.slaveNodes()[1].db.Close()
+	if report, _ = dbs.Preflight(context.Background()); report.OK() || len(report.Issues) != 1 || report.Issues[0].Node != "slave-1" {
+		t.Fatal("Preflight: failing node should be reported", report)
+	}
+
+	nodes := []PreflightNode{
+		{Name: "master-0", Settings: map[string]string{SettingVersion: "8.0.35", SettingSQLMode: "STRICT_TRANS_TABLES", SettingWritePrivileges: "true"}},
+		{Name: "slave-0", Settings: map[string]string{SettingVersion: "8.0.28", SettingSQLMode: "STRICT_TRANS_TABLES"}},
+		{Name: "slave-1", Settings: map[string]string{SettingVersion: "5.7.44-log", SettingSQLMode: ""}},
+	}
+	expected := []PreflightDiff{
+		{Setting: SettingSQLMode, Values: map[string]string{"master-0": "STRICT_TRANS_TABLES", "slave-0": "STRICT_TRANS_TABLES", "slave-1": ""}},
+		{Setting: SettingVersion, Values: map[string]string{"master-0": "8.0.35", "slave-0": "8.0.28", "slave-1": "5.7.44-log"}},
+	}
+	if diffs := diffSettings("mysql", nodes); !reflect.DeepEqual(diffs, expected) {
+		t.Fatal("Preflight: diff fail", diffs)
+	}
+
+	if diffs := diffSettings("mysql", nodes[:2]); len(diffs) != 0 {
+		t.Fatal("Preflight: same major versions should be compatible", diffs)
+	}
+
+	if v := majorVersion("postgres", "15.4 (Debian 15.4-1)"); v != "15" {
+		t.Fatal("Preflight: postgres major version fail", v)
+	}
+	if v := majorVersion("postgres", "9.6.24"); v != "9.6" {
+		t.Fatal("Preflight: legacy postgres major version fail", v)
+	}
+}
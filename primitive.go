@@ -0,0 +1,130 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// scanPrimitives scans single-column rows into ptr, calling add after each row.
+func scanPrimitives(rows *sql.Rows, limit int, ptr interface{}, add func()) error {
+	for n := 0; rows.Next(); n++ {
+		if limit > 0 && n >= limit {
+			return ErrTooManyRows
+		}
+
+		if err := rows.Scan(ptr); err != nil {
+			return err
+		}
+		add()
+	}
+	return rows.Err()
+}
+
+// selectPrimitives is a fast path of Select for slices of primitives and time.Time, avoiding
+// reflection for each row. Returns false if dest is not supported.
+func selectPrimitives(ctx context.Context, q sqlx.QueryerContext, limit int, dest interface{}, query string, args ...interface{}) (bool, error) {
+	switch dest.(type) {
+	case *[]int, *[]int32, *[]int64, *[]uint, *[]uint32, *[]uint64,
+		*[]float32, *[]float64, *[]string, *[]bool, *[][]byte, *[]time.Time:
+	default:
+		return false, nil
+	}
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return true, err
+	}
+	defer rows.Close()
+
+	// results are appended to dest only if all rows are scanned
+	switch d := dest.(type) {
+	case *[]int:
+		var v int
+		r := *d
+		if err = scanPrimitives(rows, limit, &v, func() { r = append(r, v) }); err == nil {
+			*d = r
+		}
+
+	case *[]int32:
+		var v int32
+		r := *d
+		if err = scanPrimitives(rows, limit, &v, func() { r = append(r, v) }); err == nil {
+			*d = r
+		}
+
+	case *[]int64:
+		var v int64
+		r := *d
+		if err = scanPrimitives(rows, limit, &v, func() { r = append(r, v) }); err == nil {
+			*d = r
+		}
+
+	case *[]uint:
+		var v uint
+		r := *d
+		if err = scanPrimitives(rows, limit, &v, func() { r = append(r, v) }); err == nil {
+			*d = r
+		}
+
+	case *[]uint32:
+		var v uint32
+		r := *d
+		if err = scanPrimitives(rows, limit, &v, func() { r = append(r, v) }); err == nil {
+			*d = r
+		}
+
+	case *[]uint64:
+		var v uint64
+		r := *d
+		if err = scanPrimitives(rows, limit, &v, func() { r = append(r, v) }); err == nil {
+			*d = r
+		}
+
+	case *[]float32:
+		var v float32
+		r := *d
+		if err = scanPrimitives(rows, limit, &v, func() { r = append(r, v) }); err == nil {
+			*d = r
+		}
+
+	case *[]float64:
+		var v float64
+		r := *d
+		if err = scanPrimitives(rows, limit, &v, func() { r = append(r, v) }); err == nil {
+			*d = r
+		}
+
+	case *[]string:
+		var v string
+		r := *d
+		if err = scanPrimitives(rows, limit, &v, func() { r = append(r, v) }); err == nil {
+			*d = r
+		}
+
+	case *[]bool:
+		var v bool
+		r := *d
+		if err = scanPrimitives(rows, limit, &v, func() { r = append(r, v) }); err == nil {
+			*d = r
+		}
+
+	case *[][]byte:
+		var v []byte
+		r := *d
+		if err = scanPrimitives(rows, limit, &v, func() { r, v = append(r, v), nil }); err == nil {
+			*d = r
+		}
+
+	case *[]time.Time:
+		var v time.Time
+		r := *d
+		if err = scanPrimitives(rows, limit, &v, func() { r = append(r, v) }); err == nil {
+			*d = r
+		}
+	}
+
+	return true, err
+}
@@ -0,0 +1,73 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+const primitiveRowsQuery = "WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < 1000) SELECT n FROM seq"
+
+func TestSelectPrimitives(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	ints := []int64{0}
+	if err := dbs.Select(&ints, "SELECT 1 UNION ALL SELECT 2"); err != nil || len(ints) != 3 || ints[2] != 2 {
+		t.Fatal("SelectPrimitives: int64 fail", err, ints)
+	}
+
+	var strs []string
+	if err := dbs.Select(&strs, "SELECT 'a' UNION ALL SELECT 'b'"); err != nil || len(strs) != 2 || strs[1] != "b" {
+		t.Fatal("SelectPrimitives: string fail", err, strs)
+	}
+
+	var bs [][]byte
+	if err := dbs.Select(&bs, "SELECT x'0102' UNION ALL SELECT x'03'"); err != nil || len(bs) != 2 || len(bs[0]) != 2 || bs[1][0] != 3 {
+		t.Fatal("SelectPrimitives: bytes fail", err, bs)
+	}
+
+	dbs.MustExec("CREATE TABLE event (at DATETIME)")
+	dbs.MustExec("INSERT INTO event VALUES (?)", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	var ts []time.Time
+	if err := dbs.SelectOnMaster(&ts, "SELECT at FROM event"); err != nil || len(ts) != 1 || ts[0].Year() != 2020 {
+		t.Fatal("SelectPrimitives: time fail", err, ts)
+	}
+
+	var fs []float64
+	if err := dbs.SelectContext(WithMaxRows(context.Background(), 1), &fs, "SELECT 1.5 UNION ALL SELECT 2.5"); err != ErrTooManyRows || len(fs) != 0 {
+		t.Fatal("SelectPrimitives: max rows fail", err, fs)
+	}
+
+	if err := dbs.Select(&ints, "SELECT 1, 2"); err == nil {
+		t.Fatal("SelectPrimitives: multiple columns should fail")
+	}
+}
+
+func BenchmarkSelectPrimitives(b *testing.B) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	w := dbs.slaveNodes()[0]
+
+	b.Run("fast", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var ids []int64
+			if _, err := selectPrimitives(context.Background(), w.db, 0, &ids, primitiveRowsQuery); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("reflect", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var ids []int64
+			if err := w.db.Select(&ids, primitiveRowsQuery); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
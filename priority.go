@@ -0,0 +1,240 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// Priority class of queries, used when concurrency limit of a node is saturated.
+type Priority int
+
+const (
+	// PriorityLow for background/batch jobs
+	PriorityLow Priority = iota
+
+	// PriorityNormal default priority
+	PriorityNormal
+
+	// PriorityHigh for user-facing traffic
+	PriorityHigh
+
+	numPriorities = 3
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose queries are queued with priority p when
+// concurrency limit of a node is saturated. Waiting queries of higher priority always go first,
+// so background jobs can't starve interactive traffic.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priorityFromContext(ctx context.Context) Priority {
+	if ctx != nil {
+		if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+			if p < PriorityLow {
+				return PriorityLow
+			}
+			if p > PriorityHigh {
+				return PriorityHigh
+			}
+			return p
+		}
+	}
+	return PriorityNormal
+}
+
+// concurrency limiter of a node with priority queues
+type limiter struct {
+	mu      sync.Mutex
+	limit   int
+	inUse   int
+	waiters [numPriorities][]chan struct{}
+	idleCh  chan struct{} // closed once no query is in flight, see idle
+}
+
+func (l *limiter) setLimit(n int) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	l.limit = n
+	for l.limit <= 0 || l.inUse < l.limit {
+		if !l.grant() {
+			break
+		}
+		l.inUse++
+	}
+	l.mu.Unlock()
+}
+
+// grant slot to the first waiter of highest priority. Must be called with lock held.
+func (l *limiter) grant() bool {
+	for p := numPriorities - 1; p >= 0; p-- {
+		if len(l.waiters[p]) > 0 {
+			ch := l.waiters[p][0]
+			l.waiters[p] = l.waiters[p][1:]
+			close(ch)
+			return true
+		}
+	}
+	return false
+}
+
+func (l *limiter) acquire(ctx context.Context, p Priority) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	if l.limit <= 0 || l.inUse < l.limit {
+		l.inUse++
+		l.mu.Unlock()
+		return nil
+	}
+
+	ch := make(chan struct{})
+	l.waiters[p] = append(l.waiters[p], ch)
+	l.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+
+	case <-ctx.Done():
+		l.mu.Lock()
+		for i, c := range l.waiters[p] {
+			if c == ch {
+				l.waiters[p] = append(l.waiters[p][:i], l.waiters[p][i+1:]...)
+				l.mu.Unlock()
+				return ctx.Err()
+			}
+		}
+		l.mu.Unlock()
+
+		// slot was granted meanwhile, give it back
+		l.release()
+		return ctx.Err()
+	}
+}
+
+func (l *limiter) release() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	if (l.limit > 0 && l.inUse > l.limit) || !l.grant() {
+		l.inUse--
+	}
+	if l.inUse == 0 && l.idleCh != nil {
+		close(l.idleCh)
+		l.idleCh = nil
+	}
+	l.mu.Unlock()
+}
+
+// limited reports whether concurrency of node is limited
+func (l *limiter) limited() bool {
+	if l == nil {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit > 0
+}
+
+// closedChan is returned by idle when no query is in flight
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// idle returns channel closed once no query is in flight
+func (l *limiter) idle() <-chan struct{} {
+	if l == nil {
+		return closedChan
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inUse == 0 {
+		return closedChan
+	}
+	if l.idleCh == nil {
+		l.idleCh = make(chan struct{})
+	}
+	return l.idleCh
+}
+
+// number of in-flight queries
+func (l *limiter) inFlight() (n int) {
+	if l != nil {
+		l.mu.Lock()
+		n = l.inUse
+		l.mu.Unlock()
+	}
+	return
+}
+
+// queryer is implemented by pool and connections of node
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// leaseQuery runs query holding concurrency slot of node until its rows are closed. If concurrency of node
+// is limited, query runs on a connection of its own, released with the slot once rows are closed. Otherwise
+// slot is released right away. On error, slot is left to leaseBackoff.
+func (w *wrapper) leaseQuery(ctx context.Context, query func(q queryer) (*sql.Rows, error)) (*sql.Rows, error) {
+	if !w.limiter.limited() {
+		rows, err := query(w.db)
+		if err == nil {
+			w.limiter.release()
+		}
+		return rows, err
+	}
+
+	conn, err := w.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	go w.releaseConn(conn)
+	return rows, nil
+}
+
+// leaseQueryRow is leaseQuery for a single row, slot is held until row is scanned
+func (w *wrapper) leaseQueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if w.limiter.limited() {
+		if conn, err := w.db.Conn(ctx); err == nil {
+			row := conn.QueryRowContext(ctx, query, args...)
+			go w.releaseConn(conn)
+			return row
+		}
+	}
+
+	defer w.limiter.release()
+	return w.db.QueryRowContext(ctx, query, args...)
+}
+
+// releaseConn closes conn, which waits for rows read from it to be closed, then releases concurrency slot
+func (w *wrapper) releaseConn(conn *sql.Conn) {
+	_ = conn.Close()
+	w.limiter.release()
+}
@@ -0,0 +1,152 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPriority(t *testing.T) {
+	if priorityFromContext(context.Background()) != PriorityNormal ||
+		priorityFromContext(WithPriority(context.Background(), Priority(10))) != PriorityHigh ||
+		priorityFromContext(WithPriority(context.Background(), Priority(-1))) != PriorityLow {
+		t.Fatal("Priority: priority from context fail")
+	}
+
+	var nilLimiter *limiter
+	if nilLimiter.acquire(context.Background(), PriorityLow) != nil {
+		t.Fatal("Priority: nil limiter should be unlimited")
+	}
+	nilLimiter.release()
+	nilLimiter.setLimit(1)
+
+	l := &limiter{}
+	l.setLimit(1)
+	if err := l.acquire(context.Background(), PriorityNormal); err != nil {
+		t.Fatal(err)
+	}
+
+	// canceled waiter
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(ctx, PriorityHigh); err != context.DeadlineExceeded {
+		t.Fatal("Priority: waiting should respect context", err)
+	}
+
+	order := make(chan Priority, 2)
+	waiter := func(p Priority) {
+		if err := l.acquire(context.Background(), p); err == nil {
+			l.release()
+			order <- p
+		}
+	}
+
+	go waiter(PriorityLow)
+	time.Sleep(10 * time.Millisecond)
+	go waiter(PriorityHigh)
+	time.Sleep(10 * time.Millisecond)
+
+	l.release()
+	if <-order != PriorityHigh || <-order != PriorityLow {
+		t.Fatal("Priority: high priority should be granted first")
+	}
+
+	l.mu.Lock()
+	if l.inUse != 0 {
+		t.Fatal("Priority: slots leaked", l.inUse)
+	}
+	l.mu.Unlock()
+
+	// raising limit grants waiters
+	l.setLimit(1)
+	_ = l.acquire(context.Background(), PriorityNormal)
+	go waiter(PriorityLow)
+	time.Sleep(10 * time.Millisecond)
+	l.setLimit(0)
+	if <-order != PriorityLow {
+		t.Fatal("Priority: raising limit should grant waiters")
+	}
+}
+
+func TestConcurrencyLimitLeases(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{"file:leases?mode=memory&cache=shared"}, nil)
+	defer dbs.Destroy()
+
+	w := dbs.masterNodes()[0]
+	dbs.SetMaxConcurrentQueries(1)
+
+	inUse := func() int {
+		return w.limiter.inFlight()
+	}
+
+	// streaming rows hold the slot until closed
+	rows, err := dbs.QueryxOnMaster("SELECT 1 UNION ALL SELECT 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inUse() != 1 {
+		t.Fatal("ConcurrencyLimit: open rows should hold slot")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = dbs.ExecContext(ctx, "SELECT 1"); err != context.DeadlineExceeded {
+		t.Fatal("ConcurrencyLimit: query should wait for open rows", err)
+	}
+
+	var n int
+	for rows.Next() {
+		n++
+	}
+	if n != 2 || rows.Err() != nil || !released(w) {
+		t.Fatal("ConcurrencyLimit: exhausted rows should release slot", n, rows.Err(), inUse())
+	}
+
+	// row holds the slot until scanned
+	row, err := dbs.QueryRowOnMaster("SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inUse() != 1 {
+		t.Fatal("ConcurrencyLimit: unscanned row should hold slot")
+	}
+	if err = row.Scan(&n); err != nil || !released(w) {
+		t.Fatal("ConcurrencyLimit: scanned row should release slot", err)
+	}
+
+	// transaction started by BeginNestedTx holds the slot until finished
+	tx, err := dbs.BeginNestedTx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inUse() != 1 {
+		t.Fatal("ConcurrencyLimit: open transaction should hold slot")
+	}
+	if err = tx.Rollback(); err != nil || inUse() != 0 {
+		t.Fatal("ConcurrencyLimit: finished transaction should release slot", err)
+	}
+
+	// conn holds the slot until closed
+	conn, err := dbs.Conn(context.Background(), RoleMaster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inUse() != 1 {
+		t.Fatal("ConcurrencyLimit: checked out conn should hold slot")
+	}
+	_ = conn.Close()
+	_ = conn.Close()
+	if inUse() != 0 {
+		t.Fatal("ConcurrencyLimit: closed conn should release slot once", inUse())
+	}
+}
+
+// released waits for concurrency slots of w, released once connection of rows is closed
+func released(w *wrapper) bool {
+	select {
+	case <-w.limiter.idle():
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
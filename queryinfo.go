@@ -0,0 +1,75 @@
+package mssqlx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type queryInfoKey struct{}
+
+// QueryInfo holds metadata of a call: which node served it, how many attempts
+// were made and total latency, including time spent on failover.
+//
+// Attach it to context with WithQueryInfo before calling, then read it after the call returns.
+// If the context is reused, info describes the last call.
+type QueryInfo struct {
+	// Node name which served the call, i.e: master-0, slave-1
+	Node string
+
+	// Role of node which served the call
+	Role Role
+
+	// Attempts number of executions made, including retries on bad connection/deadlock and failover
+	Attempts int
+
+	// Latency total latency of the call
+	Latency time.Duration
+
+	mu        sync.Mutex
+	startedAt time.Time
+}
+
+// WithQueryInfo returns a context which collects metadata of calls made with it into info.
+func WithQueryInfo(ctx context.Context, info *QueryInfo) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, queryInfoKey{}, info)
+}
+
+func queryInfoFromContext(ctx context.Context) *QueryInfo {
+	if ctx == nil {
+		return nil
+	}
+	info, _ := ctx.Value(queryInfoKey{}).(*QueryInfo)
+	return info
+}
+
+// start resets info at the start of a call, so that info reused by following calls describes the last one
+func (info *QueryInfo) start() {
+	if info != nil {
+		info.mu.Lock()
+		info.Attempts, info.Latency, info.startedAt = 0, 0, time.Now()
+		info.mu.Unlock()
+	}
+}
+
+func (info *QueryInfo) attempt() {
+	if info != nil {
+		info.mu.Lock()
+		info.Attempts++
+		info.mu.Unlock()
+	}
+}
+
+func (info *QueryInfo) served(w *wrapper) {
+	if info != nil && w != nil {
+		info.mu.Lock()
+		info.Node, info.Role = w.getName(), w.getRole()
+		if !info.startedAt.IsZero() {
+			info.Latency = time.Since(info.startedAt)
+		}
+		info.mu.Unlock()
+	}
+}
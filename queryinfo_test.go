@@ -0,0 +1,59 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueryInfo(t *testing.T) {
+	if queryInfoFromContext(nil) != nil || queryInfoFromContext(context.Background()) != nil {
+		t.Fatal("QueryInfo: should be nil")
+	}
+
+	var info *QueryInfo
+	info.start()
+	info.attempt()
+	info.served(nil)
+
+	_RunWithSchema(defaultSchema, t, func(db *DBs, t *testing.T) {
+		_loadDefaultFixture(db, t)
+
+		var info QueryInfo
+		var people []Person
+		if err := db.SelectContext(WithQueryInfo(context.Background(), &info), &people, "SELECT * FROM person"); err != nil {
+			t.Fatal(err)
+		}
+		if info.Role != RoleSlave || info.Node == "" || info.Attempts != 1 || info.Latency <= 0 {
+			t.Fatal("QueryInfo: unexpected info", info.Node, info.Role, info.Attempts, info.Latency)
+		}
+
+		info = QueryInfo{}
+		if _, err := db.ExecContext(WithQueryInfo(context.Background(), &info), "DELETE FROM person"); err != nil {
+			t.Fatal(err)
+		}
+		if info.Role != RoleMaster || info.Attempts != 1 {
+			t.Fatal("QueryInfo: unexpected info", info.Node, info.Role, info.Attempts)
+		}
+	})
+}
+
+func TestQueryInfoReused(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+
+	var info QueryInfo
+	ctx := WithQueryInfo(context.Background(), &info)
+
+	if _, err := dbs.ExecContext(ctx, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, err := dbs.ExecContext(ctx, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Attempts != 1 || info.Latency <= 0 || info.Latency >= 50*time.Millisecond {
+		t.Fatal("QueryInfo: reused info should describe the last call", info.Attempts, info.Latency)
+	}
+}
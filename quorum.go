@@ -0,0 +1,94 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrNoPrimaryQuorum master node is partitioned from primary component of cluster, writes are refused
+	ErrNoPrimaryQuorum = errors.New("Master node has no primary quorum, writes are refused")
+
+	// node failed while checking, caller should pick another one
+	errNodeFailed = errors.New("Node failed")
+)
+
+const (
+	// DefaultQuorumCheckTTL default duration a quorum check result of a node is cached
+	DefaultQuorumCheckTTL = time.Second
+)
+
+// QuorumCheck reports whether a master node belongs to the primary component of cluster.
+//
+// Pass it as an arg of ConnectMasterSlaves to verify quorum before sending writes to masters,
+// preventing divergent writes during network splits. Results are cached per node for DefaultQuorumCheckTTL.
+type QuorumCheck func(ctx context.Context, db *sqlx.DB) (bool, error)
+
+// GaleraQuorumCheck checks wsrep_cluster_status of Galera/Wsrep node is Primary.
+func GaleraQuorumCheck(ctx context.Context, db *sqlx.DB) (bool, error) {
+	var v struct {
+		VariableName string `db:"Variable_name"`
+		Value        string `db:"Value"`
+	}
+
+	if err := db.GetContext(ctx, &v, "SHOW STATUS LIKE 'wsrep_cluster_status'"); err != nil {
+		return false, err
+	}
+
+	return v.Value == "Primary", nil
+}
+
+type quorumResult struct {
+	ok bool
+	at time.Time
+}
+
+type quorumChecker struct {
+	check QuorumCheck
+	ttl   time.Duration
+
+	mu      sync.Mutex
+	results map[*wrapper]quorumResult
+}
+
+func newQuorumChecker(check QuorumCheck) *quorumChecker {
+	return &quorumChecker{check: check, ttl: DefaultQuorumCheckTTL, results: make(map[*wrapper]quorumResult)}
+}
+
+// checkQuorum returns ErrNoPrimaryQuorum if node w is partitioned, errNodeFailed if
+// w is failed while checking.
+func (c *balancer) checkQuorum(ctx context.Context, w *wrapper) error {
+	q := c.quorum
+	if q == nil {
+		return nil
+	}
+
+	q.mu.Lock()
+	r, ok := q.results[w]
+	q.mu.Unlock()
+
+	if !ok || time.Since(r.at) > q.ttl {
+		isPrimary, err := q.check(ctx, w.db)
+		if err != nil {
+			if c.shouldFailure(w, err) && c.countFailure(w, err) {
+				return errNodeFailed
+			}
+			return err // not cached
+		}
+
+		r = quorumResult{ok: isPrimary, at: time.Now()}
+
+		q.mu.Lock()
+		q.results[w] = r
+		q.mu.Unlock()
+	}
+
+	if !r.ok {
+		return ErrNoPrimaryQuorum
+	}
+	return nil
+}
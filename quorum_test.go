@@ -0,0 +1,49 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestQuorumCheck(t *testing.T) {
+	var isPrimary, checked int32 = 1, 0
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"}, QuorumCheck(func(ctx context.Context, db *sqlx.DB) (bool, error) {
+		atomic.AddInt32(&checked, 1)
+		return atomic.LoadInt32(&isPrimary) == 1, nil
+	}))
+	defer dbs.Destroy()
+
+	if _, err := dbs.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.Exec("SELECT 1"); err != nil || atomic.LoadInt32(&checked) != 1 {
+		t.Fatal("QuorumCheck: result should be cached", err)
+	}
+
+	// partitioned
+	atomic.StoreInt32(&isPrimary, 0)
+	dbs.masters.quorum.ttl = 0
+
+	if _, err := dbs.Exec("SELECT 1"); err != ErrNoPrimaryQuorum {
+		t.Fatal("QuorumCheck: writes should be refused", err)
+	}
+	if _, err := dbs.Beginx(); err != ErrNoPrimaryQuorum {
+		t.Fatal("QuorumCheck: transactions should be refused", err)
+	}
+	if _, err := dbs.ExecOnSlave("SELECT 1"); err != nil {
+		t.Fatal("QuorumCheck: slaves should not be checked", err)
+	}
+
+	// check error is returned without caching
+	dbs.masters.quorum.check = func(ctx context.Context, db *sqlx.DB) (bool, error) {
+		return false, errors.New("abc")
+	}
+	if _, err := dbs.Exec("SELECT 1"); err == nil || err.Error() != "abc" {
+		t.Fatal("QuorumCheck: check error should be returned", err)
+	}
+}
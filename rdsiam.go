@@ -0,0 +1,291 @@
+package mssqlx
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	// RDSIAMTokenTTL lifetime of RDS IAM auth tokens
+	RDSIAMTokenTTL = 15 * time.Minute
+
+	// tokens are re-generated this long before they expire
+	rdsIAMTokenRefreshMargin = time.Minute
+)
+
+var (
+	// ErrAWSCredentialsNotFound AWS credentials are not found in environment
+	ErrAWSCredentialsNotFound = errors.New("AWS credentials are not found in environment")
+
+	// ErrRDSIAMNotSupported RDS IAM authentication is only supported by mysql and postgres drivers
+	ErrRDSIAMNotSupported = errors.New("RDS IAM authentication is only supported by mysql and postgres drivers")
+)
+
+// AWSCredentials credentials to sign RDS IAM auth tokens.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// AWSCredentialsProvider returns current AWS credentials, i.e from instance role or assumed role.
+type AWSCredentialsProvider func(ctx context.Context) (AWSCredentials, error)
+
+// EnvAWSCredentials reads credentials from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+func EnvAWSCredentials(context.Context) (AWSCredentials, error) {
+	c := AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return c, ErrAWSCredentialsNotFound
+	}
+	return c, nil
+}
+
+type rdsToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// rdsIAMAuth generates RDS IAM auth tokens and caches them until shortly before expiry
+type rdsIAMAuth struct {
+	driverName  string
+	region      string
+	credentials AWSCredentialsProvider
+	now         func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]rdsToken // endpoint + user => token
+}
+
+// RDSIAMAuth returns AuthProvider authenticating to RDS/Aurora nodes with IAM auth tokens, used as password
+// of DSN. Tokens are generated before connecting and re-generated before RDSIAMTokenTTL expiry.
+// Host, port and user are taken from DSN of node, which should enable TLS.
+//
+// driverName is mysql or postgres. If credentials is nil, EnvAWSCredentials is used.
+func RDSIAMAuth(driverName, region string, credentials AWSCredentialsProvider) AuthProvider {
+	if credentials == nil {
+		credentials = EnvAWSCredentials
+	}
+
+	a := &rdsIAMAuth{
+		driverName:  driverName,
+		region:      region,
+		credentials: credentials,
+		now:         time.Now,
+		tokens:      make(map[string]rdsToken),
+	}
+	return a.provide
+}
+
+func (a *rdsIAMAuth) provide(ctx context.Context, dsn string) (string, error) {
+	switch a.driverName {
+	case "mysql":
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return "", err
+		}
+
+		if cfg.Passwd, err = a.token(ctx, cfg.Addr, cfg.User); err != nil {
+			return "", err
+		}
+		cfg.AllowCleartextPasswords = true // required by IAM auth plugin
+
+		return cfg.FormatDSN(), nil
+
+	case "postgres", "pgx":
+		return a.providePostgres(ctx, dsn)
+	}
+
+	return "", ErrRDSIAMNotSupported
+}
+
+func (a *rdsIAMAuth) providePostgres(ctx context.Context, dsn string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "5432")
+		}
+
+		token, err := a.token(ctx, host, u.User.Username())
+		if err != nil {
+			return "", err
+		}
+
+		u.User = url.UserPassword(u.User.Username(), token)
+		return u.String(), nil
+	}
+
+	params := parsePostgresKV(dsn)
+
+	port := params["port"]
+	if port == "" {
+		port = "5432"
+	}
+
+	token, err := a.token(ctx, net.JoinHostPort(params["host"], port), params["user"])
+	if err != nil {
+		return "", err
+	}
+
+	return dsn + " password='" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(token) + "'", nil
+}
+
+// parsePostgresKV parses key=value DSN of postgres
+func parsePostgresKV(dsn string) map[string]string {
+	params := make(map[string]string)
+
+	for s := strings.TrimSpace(dsn); s != ""; s = strings.TrimSpace(s) {
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " ")
+
+		var value strings.Builder
+		if strings.HasPrefix(s, "'") {
+			i := 1
+			for ; i < len(s) && s[i] != '\''; i++ {
+				if s[i] == '\\' && i+1 < len(s) { // escaped char
+					i++
+				}
+				value.WriteByte(s[i])
+			}
+			if i < len(s) { // closing quote
+				i++
+			}
+			s = s[i:]
+		} else {
+			end := strings.IndexByte(s, ' ')
+			if end < 0 {
+				end = len(s)
+			}
+			value.WriteString(s[:end])
+			s = s[end:]
+		}
+
+		params[key] = value.String()
+	}
+
+	return params
+}
+
+// token returns cached token of endpoint (host:port) and user, generating a new one if it is about to expire
+func (a *rdsIAMAuth) token(ctx context.Context, endpoint, user string) (string, error) {
+	key := endpoint + "/" + user
+	now := a.now()
+
+	a.mu.Lock()
+	t, ok := a.tokens[key]
+	a.mu.Unlock()
+
+	if ok && now.Add(rdsIAMTokenRefreshMargin).Before(t.expiresAt) {
+		return t.token, nil
+	}
+
+	creds, err := a.credentials(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	t = rdsToken{token: buildRDSAuthToken(endpoint, a.region, user, creds, now), expiresAt: now.Add(RDSIAMTokenTTL)}
+
+	a.mu.Lock()
+	a.tokens[key] = t
+	a.mu.Unlock()
+
+	return t.token, nil
+}
+
+// buildRDSAuthToken presigns rds-db:connect request with AWS Signature Version 4
+func buildRDSAuthToken(endpoint, region, user string, creds AWSCredentials, now time.Time) string {
+	now = now.UTC()
+	amzDate, date := now.Format("20060102T150405Z"), now.Format("20060102")
+	scope := date + "/" + region + "/rds-db/aws4_request"
+
+	params := map[string]string{
+		"Action":              "connect",
+		"DBUser":              user,
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    creds.AccessKeyID + "/" + scope,
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       "900",
+		"X-Amz-SignedHeaders": "host",
+	}
+	if creds.SessionToken != "" {
+		params["X-Amz-Security-Token"] = creds.SessionToken
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	query := make([]string, len(keys))
+	for i, k := range keys {
+		query[i] = sigV4Escape(k) + "=" + sigV4Escape(params[k])
+	}
+	canonicalQuery := strings.Join(query, "&")
+
+	emptyHash := sha256.Sum256(nil)
+	canonicalRequest := "GET\n/\n" + canonicalQuery + "\nhost:" + endpoint + "\n\nhost\n" + hex.EncodeToString(emptyHash[:])
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := sigV4SigningKey(creds.SecretAccessKey, date, region, "rds-db")
+	return endpoint + "/?" + canonicalQuery + "&X-Amz-Signature=" + hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func sigV4SigningKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	for _, s := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	return key
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sigV4Escape percent-encodes everything but unreserved characters
+func sigV4Escape(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&15])
+		}
+	}
+	return b.String()
+}
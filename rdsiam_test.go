@@ -0,0 +1,80 @@
+package mssqlx
+
+import (
+	"context"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigV4SigningKey(t *testing.T) {
+	// example of AWS Signature Version 4 documentation
+	key := sigV4SigningKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	if s := hex.EncodeToString(key); s != "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d" {
+		t.Fatal("SigV4SigningKey: fail", s)
+	}
+
+	if s := sigV4Escape("a-b_c.d~e/f+g h"); s != "a-b_c.d~e%2Ff%2Bg%20h" {
+		t.Fatal("SigV4Escape: fail", s)
+	}
+}
+
+func TestRDSIAMAuth(t *testing.T) {
+	var calls int
+	creds := func(context.Context) (AWSCredentials, error) {
+		calls++
+		return AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET", SessionToken: "SESSION"}, nil
+	}
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := RDSIAMAuth("mysql", "us-east-1", creds)
+
+	dsn, err := a(context.Background(), "iamuser@tcp(db.us-east-1.rds.amazonaws.com:3306)/test?tls=true")
+	if err != nil {
+		t.Fatal("RDSIAMAuth: mysql fail", err)
+	}
+	if !strings.HasPrefix(dsn, "iamuser:db.us-east-1.rds.amazonaws.com:3306/?Action=connect&DBUser=iamuser&X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=AKID%2F") ||
+		!strings.Contains(dsn, "X-Amz-Security-Token=SESSION") || !strings.Contains(dsn, "allowCleartextPasswords=true") {
+		t.Fatal("RDSIAMAuth: mysql dsn fail", dsn)
+	}
+
+	// tokens are cached until shortly before expiry
+	r := &rdsIAMAuth{driverName: "postgres", region: "eu-west-1", credentials: creds, now: func() time.Time { return now }, tokens: map[string]rdsToken{}}
+	calls = 0
+
+	dsn, err = r.provide(context.Background(), "host=db.example port=5433 user='iam user' sslmode=require")
+	if err != nil || !strings.HasPrefix(dsn, "host=db.example port=5433 user='iam user' sslmode=require password='db.example:5433/?Action=connect&DBUser=iam%20user&") {
+		t.Fatal("RDSIAMAuth: postgres kv dsn fail", err, dsn)
+	}
+	if !strings.Contains(dsn, "X-Amz-Date=20200102T030405Z") || !strings.Contains(dsn, "X-Amz-Expires=900") {
+		t.Fatal("RDSIAMAuth: postgres token fail", dsn)
+	}
+
+	if _, err = r.provide(context.Background(), "host=db.example port=5433 user='iam user'"); err != nil || calls != 1 {
+		t.Fatal("RDSIAMAuth: token should be cached", err, calls)
+	}
+
+	now = now.Add(RDSIAMTokenTTL - rdsIAMTokenRefreshMargin)
+	if _, err = r.provide(context.Background(), "host=db.example port=5433 user='iam user'"); err != nil || calls != 2 {
+		t.Fatal("RDSIAMAuth: token should be refreshed before expiry", err, calls)
+	}
+
+	dsn, err = r.provide(context.Background(), "postgres://iam@db.example/test?sslmode=require")
+	if err != nil {
+		t.Fatal("RDSIAMAuth: postgres url fail", err)
+	}
+	u, _ := url.Parse(dsn)
+	if p, _ := u.User.Password(); !strings.HasPrefix(p, "db.example:5432/?Action=connect&DBUser=iam&") {
+		t.Fatal("RDSIAMAuth: postgres url dsn fail", dsn)
+	}
+
+	if _, err = RDSIAMAuth("sqlite3", "us-east-1", creds)(context.Background(), ""); err != ErrRDSIAMNotSupported {
+		t.Fatal("RDSIAMAuth: unsupported driver should fail", err)
+	}
+
+	if params := parsePostgresKV(`a=1  b='x \' y' c=`); params["a"] != "1" || params["b"] != "x ' y" || params["c"] != "" {
+		t.Fatal("RDSIAMAuth: parse postgres dsn fail", params)
+	}
+}
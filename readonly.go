@@ -0,0 +1,136 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	// ErrCredentialsNotSupported rewriting credentials of DSN is not supported by driver
+	ErrCredentialsNotSupported = errors.New("Slave credentials are only supported by mysql and postgres drivers")
+
+	// ErrReadOnlyCheckNotSupported verifying privileges of slave user is not supported by driver
+	ErrReadOnlyCheckNotSupported = errors.New("Verifying read-only slaves is only supported by mysql and postgres drivers")
+
+	// ErrSlaveUserCanWrite user of slave has write privileges
+	ErrSlaveUserCanWrite = errors.New("Slave user has write privileges")
+)
+
+// SlaveCredentials replaces credentials of every slave DSN, i.e with a read-only database user,
+// so that master and slave pools could share DSNs while authenticating as different users.
+// ConnectMasterSlaves verifies that slave user could not write, reporting ErrSlaveUserCanWrite as error of slave
+// (see VerifySlavesReadOnly). Connections of slaves promoted by ApplyTopology are recycled, new ones authenticate
+// with master credentials.
+//
+// Pass it as an arg of ConnectMasterSlaves.
+type SlaveCredentials struct {
+	User     string
+	Password string
+}
+
+// withCredentials replaces user and password of dsn
+func withCredentials(driverName, dsn string, creds *SlaveCredentials) (string, error) {
+	switch driverName {
+	case "mysql":
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return "", err
+		}
+
+		cfg.User, cfg.Passwd = creds.User, creds.Password
+		return cfg.FormatDSN(), nil
+
+	case "postgres", "pgx":
+		if strings.Contains(dsn, "://") {
+			u, err := url.Parse(dsn)
+			if err != nil {
+				return "", err
+			}
+
+			u.User = url.UserPassword(creds.User, creds.Password)
+			return u.String(), nil
+		}
+
+		// later settings take precedence
+		quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+		return dsn + " user='" + quote.Replace(creds.User) + "' password='" + quote.Replace(creds.Password) + "'", nil
+	}
+
+	return "", ErrCredentialsNotSupported
+}
+
+// writePrivilegesQuery returns query counting write privileges of current user
+func writePrivilegesQuery(driverName string) string {
+	switch driverName {
+	case "mysql":
+		return "SELECT (SELECT COUNT(*) FROM information_schema.USER_PRIVILEGES WHERE PRIVILEGE_TYPE IN " + mysqlWritePrivileges + ") + " +
+			"(SELECT COUNT(*) FROM information_schema.SCHEMA_PRIVILEGES WHERE PRIVILEGE_TYPE IN " + mysqlWritePrivileges + ") + " +
+			"(SELECT COUNT(*) FROM information_schema.TABLE_PRIVILEGES WHERE PRIVILEGE_TYPE IN " + mysqlWritePrivileges + ")"
+
+	case "postgres", "pgx":
+		return "SELECT COUNT(*) FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace " +
+			"WHERE c.relkind IN ('r', 'p') AND n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg_toast%' " +
+			"AND has_table_privilege(c.oid, 'INSERT, UPDATE, DELETE, TRUNCATE')"
+	}
+	return ""
+}
+
+// information_schema of mysql lists privileges granted to current user only
+const mysqlWritePrivileges = "('INSERT', 'UPDATE', 'DELETE', 'CREATE', 'DROP', 'ALTER', 'SUPER')"
+
+// VerifySlavesReadOnly checks that user of every slave has no write privileges. It is run by ConnectMasterSlaves
+// with SlaveCredentials. Returned errors are indexed by slaves: ErrSlaveUserCanWrite if user could write, or error of checking.
+func (dbs *DBs) VerifySlavesReadOnly(ctx context.Context) []error {
+	errs := make([]error, len(dbs.slaveNodes()))
+
+	query := writePrivilegesQuery(dbs.driverName)
+	if query == "" {
+		for i := range errs {
+			errs[i] = ErrReadOnlyCheckNotSupported
+		}
+		return errs
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var wg sync.WaitGroup
+	for i, w := range dbs.slaveNodes() {
+		if w == nil || w.db == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, w *wrapper) {
+			defer wg.Done()
+
+			var n int64
+			if errs[i] = w.db.GetContext(ctx, &n, query); errs[i] == nil && n > 0 {
+				errs[i] = ErrSlaveUserCanWrite
+				reportNodeError(w, "VerifySlavesReadOnly", errs[i])
+			}
+		}(i, w)
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// verifySlaveCredentials reports slaves whose user could write as errors of ConnectMasterSlaves,
+// indexed by all nodes. Slaves which could not be checked are left to VerifySlavesReadOnly.
+func (dbs *DBs) verifySlaveCredentials(nMaster int, errs []error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultHealthCheckTimeout)
+	defer cancel()
+
+	for i, err := range dbs.VerifySlavesReadOnly(ctx) {
+		if err == ErrSlaveUserCanWrite && errs[nMaster+i] == nil {
+			errs[nMaster+i] = err
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package mssqlx
+
+import (
+	"context"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+// isNetworkError reports whether err is likely caused by a broken connection, i.e node died mid-scan.
+func isNetworkError(err error) bool {
+	if err == nil || err == context.Canceled || err == context.DeadlineExceeded {
+		return false
+	}
+
+	if isErrBadConn(err) || err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+
+	s := err.Error()
+	return strings.Contains(s, "connection reset") || strings.Contains(s, "broken pipe") || strings.Contains(s, "unexpected EOF")
+}
+
+type triedNodesKey struct{}
+
+// isTried reports whether w is already tried by read retries of ctx
+func isTried(ctx context.Context, w *wrapper) bool {
+	tried, _ := ctx.Value(triedNodesKey{}).([]*wrapper)
+	return containsNode(tried, w)
+}
+
+// untried picks a node not tried by read retries of ctx and matching fn, if fn is not nil
+func (c *balancer) untried(ctx context.Context, fn func(*wrapper) bool) *wrapper {
+	return c.dbs.nextMatching(func(w *wrapper) bool {
+		return !isTried(ctx, w) && (fn == nil || fn(w))
+	})
+}
+
+// retryRead returns ctx excluding w, besides nodes already tried, from picking of retried read.
+// Read is not retried if every node is tried.
+func (c *balancer) retryRead(ctx context.Context, w *wrapper) (context.Context, bool) {
+	tried, _ := ctx.Value(triedNodesKey{}).([]*wrapper)
+	ctx = context.WithValue(ctx, triedNodesKey{}, append(tried[:len(tried):len(tried)], w))
+	return ctx, c.untried(ctx, nil) != nil
+}
+
+func (c *balancer) getReadRetries() int {
+	return int(atomic.LoadInt32(&c.readRetries))
+}
+
+func (c *balancer) setReadRetries(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt32(&c.readRetries, int32(n))
+}
+
+// SetReadRetries sets maximum number of times Select/Get are retried on another node after
+// a network failure (i.e node died mid-scan), even if failing node is still reachable.
+// Results scanned partially are discarded before retrying. Nodes already tried are skipped,
+// even by pinned, keyed or preferred primary routing.
+//
+// If n <= 0, reads are retried only when failing node is unreachable. The default is 0.
+func (dbs *DBs) SetReadRetries(n int) {
+	dbs.masters.setReadRetries(n)
+	dbs.slaves.setReadRetries(n)
+}
+
+// destLen returns length of slice destination, -1 for others
+func destLen(dest interface{}) int {
+	if v := reflect.ValueOf(dest); v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Slice {
+		return v.Elem().Len()
+	}
+	return -1
+}
+
+// truncateDest discards rows appended to slice destination by a failed attempt
+func truncateDest(dest interface{}, n int) {
+	if n >= 0 {
+		if v := reflect.ValueOf(dest).Elem(); v.Len() > n {
+			v.SetLen(n)
+		}
+	}
+}
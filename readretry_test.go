@@ -0,0 +1,59 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestReadRetry(t *testing.T) {
+	if !isNetworkError(io.ErrUnexpectedEOF) || !isNetworkError(driver.ErrBadConn) || !isNetworkError(errors.New("read: connection reset by peer")) {
+		t.Fatal("ReadRetry: network error detection fail")
+	}
+	if isNetworkError(nil) || isNetworkError(context.Canceled) || isNetworkError(errors.New("syntax error")) {
+		t.Fatal("ReadRetry: non-network error detection fail")
+	}
+
+	dest := []int{1, 2}
+	n := destLen(&dest)
+	dest = append(dest, 3, 4)
+	if truncateDest(&dest, n); len(dest) != 2 {
+		t.Fatal("ReadRetry: truncate dest fail", dest)
+	}
+	if destLen(&n) != -1 {
+		t.Fatal("ReadRetry: non-slice dest fail")
+	}
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:", ":memory:"})
+	defer dbs.Destroy()
+
+	if dbs.SetReadRetries(-1); dbs.slaves.getReadRetries() != 0 {
+		t.Fatal("ReadRetry: negative retries fail")
+	}
+	if dbs.SetReadRetries(2); dbs.slaves.getReadRetries() != 2 || dbs.masters.getReadRetries() != 2 {
+		t.Fatal("ReadRetry: set retries fail")
+	}
+
+	var out []int
+	if err := dbs.Select(&out, "SELECT 1 UNION ALL SELECT 2"); err != nil || len(out) != 2 {
+		t.Fatal("ReadRetry: select fail", err, out)
+	}
+
+	// keyed reads are retried on another node
+	ctx := WithRoutingKey(context.Background(), "tenant-1")
+	w := dbs.slaves.pick(ctx)
+
+	ctx, ok := dbs.slaves.retryRead(ctx, w)
+	if !ok {
+		t.Fatal("ReadRetry: another node should be left")
+	}
+	if other := dbs.slaves.pick(ctx); other == nil || other == w {
+		t.Fatal("ReadRetry: tried node should be excluded")
+	}
+
+	if _, ok = dbs.slaves.retryRead(ctx, dbs.slaves.pick(ctx)); ok {
+		t.Fatal("ReadRetry: read should not be retried once every node is tried")
+	}
+}
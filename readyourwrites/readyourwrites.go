@@ -0,0 +1,20 @@
+// Package readyourwrites provides net/http middleware for read-after-write consistency.
+package readyourwrites
+
+import (
+	"net/http"
+
+	"github.com/linxGnu/mssqlx"
+)
+
+// Middleware tracks writes made through mssqlx.DBs with request context. Once request
+// performed a write, its following reads are routed to masters for the remainder of request.
+//
+//	http.Handle("/", readyourwrites.Middleware(handler))
+//
+// Handlers must pass r.Context() (or derived ones) to context-aware functions of mssqlx.DBs.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(mssqlx.WithReadYourWrites(r.Context())))
+	})
+}
@@ -0,0 +1,41 @@
+package readyourwrites
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/linxGnu/mssqlx"
+)
+
+func TestMiddleware(t *testing.T) {
+	dbs, _ := mssqlx.ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	var roles []mssqlx.Role
+	query := func(r *http.Request) {
+		var info mssqlx.QueryInfo
+		var v int
+		if err := dbs.GetContext(mssqlx.WithQueryInfo(r.Context(), &info), &v, "SELECT 1"); err != nil {
+			t.Fatal(err)
+		}
+		roles = append(roles, info.Role)
+	}
+
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query(r)
+
+		if _, err := dbs.ExecContext(r.Context(), "SELECT 1"); err != nil {
+			t.Fatal(err)
+		}
+
+		query(r)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if len(roles) != 2 || roles[0] != mssqlx.RoleSlave || roles[1] != mssqlx.RoleMaster {
+		t.Fatal("Middleware: reads after write should go to master", roles)
+	}
+}
@@ -0,0 +1,75 @@
+package mssqlx
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultRecycleTimeout default time after which pooling of recycled node is restored,
+	// even if some of its old connections are still in use
+	DefaultRecycleTimeout = 30 * time.Second
+
+	// same as default of database/sql
+	defaultMaxIdleConns = 2
+)
+
+// setMaxIdleConns sets max idle connections of node, applied after recycling if it is in progress
+func (w *wrapper) setMaxIdleConns(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt32(&w.maxIdle, int32(n)+1)
+
+	if atomic.LoadInt32(&w.recycling) == 0 {
+		w.db.SetMaxIdleConns(n)
+	}
+}
+
+func (w *wrapper) getMaxIdleConns() int {
+	if v := atomic.LoadInt32(&w.maxIdle); v > 0 {
+		return int(v - 1)
+	}
+	return defaultMaxIdleConns
+}
+
+// recycle closes idle connections of node and makes in-use ones closed once released.
+// Pooling is restored when node has no connection left or timeout elapses.
+func (w *wrapper) recycle(timeout time.Duration) {
+	if w.db == nil {
+		return
+	}
+
+	w.db.SetMaxIdleConns(0)
+	if !atomic.CompareAndSwapInt32(&w.recycling, 0, 1) { // already recycling
+		return
+	}
+
+	go func() {
+		deadline := time.Now().Add(timeout)
+		for w.db.Stats().InUse > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		atomic.StoreInt32(&w.recycling, 0)
+		w.db.SetMaxIdleConns(w.getMaxIdleConns())
+		logEntry(LogLevelInfo, "connections are recycled", nodeFields(w)...)
+	}()
+}
+
+// RecycleConnections closes pooled connections of nodes of role, i.e after a promotion behind a VIP,
+// so that traffic moves to the new primary promptly. Idle connections are closed immediately,
+// in-use ones are closed once released. New connections are not pooled until every old connection
+// of node is closed, or DefaultRecycleTimeout elapses.
+func (dbs *DBs) RecycleConnections(role Role) error {
+	if _, err := dbs.getBalancer(role); err != nil {
+		return err
+	}
+
+	for _, w := range dbs.allNodes() {
+		if w != nil && w.getRole() == role {
+			w.recycle(DefaultRecycleTimeout)
+		}
+	}
+	return nil
+}
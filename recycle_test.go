@@ -0,0 +1,68 @@
+package mssqlx
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRecycleConnections(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	if err := dbs.RecycleConnections(Role("unknown")); err != ErrInvalidRole {
+		t.Fatal("RecycleConnections: role check fail", err)
+	}
+
+	dbs.SetMasterMaxIdleConns(4)
+	w := dbs.masterNodes()[0]
+
+	// idle and in-use connections
+	conn, err := w.db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dbs.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = dbs.slaveNodes()[0].db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if st := w.db.Stats(); st.Idle != 1 || st.InUse != 1 {
+		t.Fatal("RecycleConnections: setup fail", st)
+	}
+
+	if err = dbs.RecycleConnections(RoleMaster); err != nil {
+		t.Fatal(err)
+	}
+	if st := w.db.Stats(); st.Idle != 0 || st.InUse != 1 {
+		t.Fatal("RecycleConnections: idle connections should be closed", st)
+	}
+	if dbs.slaveNodes()[0].db.Stats().OpenConnections == 0 {
+		t.Fatal("RecycleConnections: slaves should not be recycled")
+	}
+
+	// setting applied after recycling
+	dbs.SetMasterMaxIdleConns(3)
+
+	_ = conn.Close()
+	for i := 0; i < 100 && isRecycling(w); i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if st := w.db.Stats(); st.OpenConnections != 0 || isRecycling(w) || w.getMaxIdleConns() != 3 {
+		t.Fatal("RecycleConnections: released connection should be closed", st)
+	}
+
+	// pooling is restored
+	if _, err = dbs.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if st := w.db.Stats(); st.Idle != 1 {
+		t.Fatal("RecycleConnections: pooling should be restored", st)
+	}
+}
+
+func isRecycling(w *wrapper) bool {
+	return atomic.LoadInt32(&w.recycling) == 1
+}
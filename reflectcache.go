@@ -0,0 +1,192 @@
+package mssqlx
+
+import (
+	"container/list"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+const (
+	// DefaultReflectCacheSize default number of field index plans kept by reflection cache
+	DefaultReflectCacheSize = 1024
+)
+
+// ReflectCacheStats statistics of reflection metadata cache.
+type ReflectCacheStats struct {
+	Size     int
+	Capacity int
+	Hits     uint64
+	Misses   uint64
+}
+
+// HitRate returns ratio of lookups served from cache.
+func (s ReflectCacheStats) HitRate() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
+
+type planKind uint8
+
+const (
+	planScan planKind = iota
+	planBind
+)
+
+type planKey struct {
+	kind     planKind
+	mapper   *reflectx.Mapper
+	t        reflect.Type
+	postgres bool
+	utc      bool
+	columns  string
+}
+
+// scanPlan is field index plan for scanning a column set into struct type
+type scanPlan struct {
+	traversals [][]int
+	bindings   []bindingKind
+}
+
+// bindField is a field of named query arg struct
+type bindField struct {
+	name    string
+	index   []int
+	binding bindingKind
+}
+
+type planEntry struct {
+	key  planKey
+	plan interface{}
+}
+
+// planCache is LRU of field index plans
+type planCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[planKey]*list.Element
+	hits     uint64
+	misses   uint64
+}
+
+func newPlanCache(capacity int) *planCache {
+	return &planCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[planKey]*list.Element),
+	}
+}
+
+func (c *planCache) get(key planKey) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.hits++
+		c.ll.MoveToFront(e)
+		return e.Value.(*planEntry).plan, true
+	}
+
+	c.misses++
+	return nil, false
+}
+
+func (c *planCache) put(key planKey, plan interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.capacity <= 0 {
+		return
+	}
+
+	if e, ok := c.items[key]; ok {
+		e.Value.(*planEntry).plan = plan
+		c.ll.MoveToFront(e)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&planEntry{key: key, plan: plan})
+	c.evict()
+}
+
+func (c *planCache) evict() {
+	for c.ll.Len() > c.capacity {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*planEntry).key)
+	}
+}
+
+func (c *planCache) resize(capacity int) {
+	c.mu.Lock()
+	c.capacity = capacity
+	if capacity < 0 {
+		c.capacity = 0
+	}
+	c.evict()
+	c.mu.Unlock()
+}
+
+func (c *planCache) stats() ReflectCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return ReflectCacheStats{Size: c.ll.Len(), Capacity: c.capacity, Hits: c.hits, Misses: c.misses}
+}
+
+var reflectCache = newPlanCache(DefaultReflectCacheSize)
+
+// SetReflectCacheSize sets number of field index plans (per struct type and column set) cached
+// for scanning and binding structs. Least recently used plans are evicted. Zero disables caching.
+// Default is DefaultReflectCacheSize.
+func SetReflectCacheSize(n int) {
+	reflectCache.resize(n)
+}
+
+// GetReflectCacheStats returns statistics of reflection metadata cache.
+func GetReflectCacheStats() ReflectCacheStats {
+	return reflectCache.stats()
+}
+
+// getScanPlan returns field index plan for scanning columns into struct type t
+func getScanPlan(m *reflectx.Mapper, t reflect.Type, postgres bool, columns []string) *scanPlan {
+	key := planKey{kind: planScan, mapper: m, t: t, postgres: postgres, columns: strings.Join(columns, "\x00")}
+	if p, ok := reflectCache.get(key); ok {
+		return p.(*scanPlan)
+	}
+
+	tm := m.TypeMap(t)
+	p := &scanPlan{
+		traversals: m.TraversalsByName(t, columns),
+		bindings:   make([]bindingKind, len(columns)),
+	}
+	for i, traversal := range p.traversals {
+		if len(traversal) > 0 {
+			p.bindings[i] = fieldBinding(tm.GetByTraversal(traversal), postgres, false)
+		}
+	}
+
+	reflectCache.put(key, p)
+	return p
+}
+
+// getBindPlan returns fields of named query arg struct type t
+func getBindPlan(m *reflectx.Mapper, t reflect.Type, postgres, utc bool) []bindField {
+	key := planKey{kind: planBind, mapper: m, t: t, postgres: postgres, utc: utc}
+	if p, ok := reflectCache.get(key); ok {
+		return p.([]bindField)
+	}
+
+	tm := m.TypeMap(t)
+	fields := make([]bindField, 0, len(tm.Names))
+	for name, fi := range tm.Names {
+		fields = append(fields, bindField{name: name, index: fi.Index, binding: fieldBinding(fi, postgres, utc)})
+	}
+
+	reflectCache.put(key, fields)
+	return fields
+}
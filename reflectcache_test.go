@@ -0,0 +1,111 @@
+package mssqlx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+type reflectCacheRow struct {
+	ID   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+func TestReflectCache(t *testing.T) {
+	c := newPlanCache(2)
+	k1, k2, k3 := planKey{columns: "a"}, planKey{columns: "b"}, planKey{columns: "c"}
+
+	c.put(k1, 1)
+	c.put(k2, 2)
+	if _, ok := c.get(k1); !ok {
+		t.Fatal("ReflectCache: get fail")
+	}
+
+	c.put(k3, 3) // k2 is least recently used
+	if _, ok := c.get(k2); ok {
+		t.Fatal("ReflectCache: LRU eviction fail")
+	}
+	if v, ok := c.get(k3); !ok || v.(int) != 3 {
+		t.Fatal("ReflectCache: get fail", v)
+	}
+
+	if s := c.stats(); s.Size != 2 || s.Capacity != 2 || s.Hits != 2 || s.Misses != 1 || s.HitRate() < 0.66 {
+		t.Fatal("ReflectCache: stats fail", s)
+	}
+
+	c.resize(1)
+	if s := c.stats(); s.Size != 1 {
+		t.Fatal("ReflectCache: resize fail", s)
+	}
+	if _, ok := c.get(k3); !ok {
+		t.Fatal("ReflectCache: resize should keep most recently used")
+	}
+
+	c.resize(0)
+	c.put(k1, 1)
+	if s := c.stats(); s.Size != 0 {
+		t.Fatal("ReflectCache: disabled cache should not store", s)
+	}
+
+	if (ReflectCacheStats{}).HitRate() != 0 {
+		t.Fatal("ReflectCache: empty hit rate fail")
+	}
+}
+
+func TestScanPlanCached(t *testing.T) {
+	m := reflectx.NewMapperFunc("db", func(s string) string { return s })
+	typ := reflect.TypeOf(reflectCacheRow{})
+
+	before := GetReflectCacheStats()
+	p1 := getScanPlan(m, typ, false, []string{"name", "id", "unknown"})
+	p2 := getScanPlan(m, typ, false, []string{"name", "id", "unknown"})
+	if p1 != p2 {
+		t.Fatal("ScanPlan: plan should be cached")
+	}
+	if len(p1.traversals) != 3 || len(p1.traversals[0]) != 1 || p1.traversals[0][0] != 1 || len(p1.traversals[2]) != 0 {
+		t.Fatal("ScanPlan: traversals fail", p1.traversals)
+	}
+	if s := GetReflectCacheStats(); s.Hits-before.Hits < 1 || s.Misses-before.Misses < 1 {
+		t.Fatal("ScanPlan: stats fail", s)
+	}
+
+	if p3 := getScanPlan(m, typ, false, []string{"id"}); p3 == p1 {
+		t.Fatal("ScanPlan: different column set should have different plan")
+	}
+
+	if fields := getBindPlan(m, typ, false, false); len(fields) != 2 {
+		t.Fatal("BindPlan: fields fail", fields)
+	}
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	var rows []reflectCacheRow
+	if err := dbs.Select(&rows, "SELECT 1 AS id, 'a' AS name UNION ALL SELECT 2, 'b'"); err != nil || len(rows) != 2 || rows[1].Name != "b" {
+		t.Fatal("ScanPlan: select fail", err, rows)
+	}
+
+	var row reflectCacheRow
+	if err := dbs.Get(&row, "SELECT 3 AS id, 'c' AS name"); err != nil || row.ID != 3 || row.Name != "c" {
+		t.Fatal("ScanPlan: get fail", err, row)
+	}
+
+	if err := dbs.SelectContext(WithMaxRows(context.Background(), 1), &rows, "SELECT 1 AS id, 'a' AS name UNION ALL SELECT 2, 'b'"); err != ErrTooManyRows {
+		t.Fatal("ScanPlan: max rows fail", err)
+	}
+}
+
+func BenchmarkSelectStruct(b *testing.B) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var rows []reflectCacheRow
+		if err := dbs.Select(&rows, "SELECT 1 AS id, 'a' AS name"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
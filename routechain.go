@@ -0,0 +1,134 @@
+package mssqlx
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// RouteStep is a step of routing chain, see SetRouteChain.
+type RouteStep int
+
+const (
+	// NearestSlave routes to one of slaves designated nearest by SetNearestSlaves
+	NearestSlave RouteStep = iota
+
+	// AnySlave routes to any healthy slave
+	AnySlave
+
+	// Master routes to masters
+	Master
+)
+
+type routeChainKey struct{}
+
+// WithRouteChain returns a context whose slave-balanced reads follow chain: they are served by the
+// first step having a healthy node, i.e [NearestSlave, AnySlave, Master] degrades to any slave when nearest
+// ones are down, then to masters when all slaves are down. It overrides chain registered by SetRouteChain.
+func WithRouteChain(ctx context.Context, chain ...RouteStep) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, routeChainKey{}, chain)
+}
+
+func routeChainFromContext(ctx context.Context) (chain []RouteStep, ok bool) {
+	if ctx != nil {
+		chain, ok = ctx.Value(routeChainKey{}).([]RouteStep)
+	}
+	return
+}
+
+// SetRouteChain registers routing chain of query, applied when query is run by slave-balanced
+// functions (i.e Select, Get, Queryx). Query must be exactly the same text. Empty chain unregisters query.
+func (dbs *DBs) SetRouteChain(query string, chain ...RouteStep) {
+	if len(chain) == 0 {
+		dbs.routeChains.Delete(query)
+	} else {
+		dbs.routeChains.Store(query, chain)
+	}
+}
+
+// SetNearestSlaves designates slaves (by name or DSN) which NearestSlave step routes to, i.e ones in the same zone.
+func (dbs *DBs) SetNearestSlaves(nodes ...string) error {
+	nearest := make(map[*wrapper]bool, len(nodes))
+	for _, name := range nodes {
+		w := dbs.findNode(name)
+		if w == nil {
+			return ErrNodeNotFound
+		}
+		nearest[w] = true
+	}
+
+	for _, w := range dbs.allNodes() {
+		if w != nil {
+			var v int32
+			if nearest[w] {
+				v = 1
+			}
+			atomic.StoreInt32(&w.nearest, v)
+		}
+	}
+	return nil
+}
+
+func (w *wrapper) isNearest() bool {
+	return atomic.LoadInt32(&w.nearest) == 1
+}
+
+func hasStep(chain []RouteStep, step RouteStep) bool {
+	for _, s := range chain {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// routeChain resolves chain of query made with ctx into balancer serving it. Returned context carries chain for picking nodes.
+func (c *balancer) routeChain(ctx context.Context, query string) (context.Context, *balancer) {
+	chain, ok := routeChainFromContext(ctx)
+	if !ok && c.routeChains != nil {
+		if v, found := c.routeChains.Load(query); found {
+			chain = v.([]RouteStep)
+			ctx = context.WithValue(ctx, routeChainKey{}, chain)
+		}
+	}
+
+	for _, step := range chain {
+		switch step {
+		case NearestSlave:
+			if c.dbs.nextMatching((*wrapper).isNearest) != nil {
+				return ctx, c
+			}
+
+		case AnySlave:
+			if c.dbs.size() > 0 {
+				return ctx, c
+			}
+
+		case Master:
+			if isRoutingDebug() {
+				debugMasters(ctx, RoutingChainFallback)
+			}
+			return ctx, c.fallback()
+		}
+	}
+
+	return ctx, c
+}
+
+// pickByChain picks a node following routing chain of ctx, ok is false if ctx has no chain
+func (c *balancer) pickByChain(ctx context.Context) (w *wrapper, ok bool) {
+	chain, ok := routeChainFromContext(ctx)
+	if !ok || c.master == nil {
+		return nil, false
+	}
+
+	if hasStep(chain, NearestSlave) {
+		if w = c.dbs.nextMatching((*wrapper).isNearest); w != nil || !hasStep(chain, AnySlave) {
+			return w, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,61 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRouteChain(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:", ":memory:"})
+	defer dbs.Destroy()
+
+	if err := dbs.SetNearestSlaves("slave-9"); err != ErrNodeNotFound {
+		t.Fatal("RouteChain: unknown node fail", err)
+	}
+	if err := dbs.SetNearestSlaves("slave-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	slave1 := dbs.slaveNodes()[1]
+
+	query := "SELECT 1"
+	dbs.SetRouteChain(query, NearestSlave, AnySlave, Master)
+
+	ctx, target := dbs.slaves.route(context.Background(), query)
+	if target != dbs.slaves {
+		t.Fatal("RouteChain: route nearest fail")
+	}
+	for i := 0; i < 4; i++ {
+		if w := target.pick(ctx); w != slave1 {
+			t.Fatal("RouteChain: pick nearest fail", w.getName())
+		}
+	}
+
+	// nearest is down, degrade to any slave
+	dbs.slaves.dbs.remove(slave1)
+	if ctx, target = dbs.slaves.route(context.Background(), query); target != dbs.slaves || target.pick(ctx) != dbs.slaveNodes()[0] {
+		t.Fatal("RouteChain: degrade to any slave fail")
+	}
+
+	// all slaves are down, degrade to master
+	dbs.slaves.dbs.remove(dbs.slaveNodes()[0])
+	if _, target = dbs.slaves.route(context.Background(), query); target != dbs.masters {
+		t.Fatal("RouteChain: degrade to master fail")
+	}
+
+	var v int
+	if err := dbs.Get(&v, query); err != nil || v != 1 {
+		t.Fatal("RouteChain: query fail", err)
+	}
+
+	// per-call chain overrides registered one
+	if _, target = dbs.slaves.route(WithRouteChain(context.Background(), NearestSlave), query); target != dbs.slaves {
+		t.Fatal("RouteChain: per-call chain fail")
+	}
+
+	// unregistered
+	dbs.SetRouteChain(query)
+	if _, target = dbs.slaves.route(context.Background(), query); target != dbs.slaves {
+		t.Fatal("RouteChain: unregister fail")
+	}
+}
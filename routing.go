@@ -0,0 +1,60 @@
+package mssqlx
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+type routingKey struct{}
+
+// WithRoutingKey returns a context whose queries are routed by consistent hashing of key
+// (i.e user id) among healthy nodes. Queries of the same key keep hitting the same node,
+// improving per-node buffer pool locality for hot entities.
+//
+// When a node fails, only keys assigned to it are remapped.
+func WithRoutingKey(ctx context.Context, key string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, routingKey{}, key)
+}
+
+func routingKeyFromContext(ctx context.Context) (key string, ok bool) {
+	if ctx != nil {
+		key, ok = ctx.Value(routingKey{}).(string)
+	}
+	return
+}
+
+// rendezvous (highest random weight) hashing of key among nodes, drained ones are skipped unless all nodes are drained
+func (b *dbList) hashed(key string) (w *wrapper) {
+	list, stored := b.list.Load().([]*wrapper)
+	if !stored {
+		return
+	}
+
+	if w = hashedNode(list, key, true); w == nil {
+		w = hashedNode(list, key, false)
+	}
+	return
+}
+
+func hashedNode(list []*wrapper, key string, skipDrained bool) (w *wrapper) {
+	var max uint64
+	for _, node := range list {
+		if skipDrained && node.getWeight() == 0 {
+			continue
+		}
+
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(node.id()))
+
+		if score := h.Sum64(); w == nil || score > max {
+			w, max = node, score
+		}
+	}
+
+	return
+}
@@ -0,0 +1,43 @@
+package mssqlx
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestRoutingKey(t *testing.T) {
+	if _, ok := routingKeyFromContext(context.Background()); ok {
+		t.Fatal("RoutingKey: should not be found")
+	}
+
+	dsn := "user=test1 dbname=test1 sslmode=disable"
+	slaves := newBalancer(nil, 0, false)
+	defer slaves.destroy()
+
+	for i := 0; i < 3; i++ {
+		db, _ := sqlx.Open("postgres", dsn)
+		slaves.add(newWrapper(db, dsn, RoleSlave, i))
+	}
+
+	assigned := make(map[string]*wrapper)
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		ctx := WithRoutingKey(context.Background(), key)
+
+		if assigned[key] = slaves.pick(ctx); assigned[key] != slaves.pick(ctx) {
+			t.Fatal("RoutingKey: same key should be routed to same node")
+		}
+	}
+
+	failed := slaves.pick(WithRoutingKey(context.Background(), "0"))
+	slaves.failure(failed)
+
+	for key, w := range assigned {
+		if now := slaves.pick(WithRoutingKey(context.Background(), key)); now == failed || (w != failed && now != w) {
+			t.Fatal("RoutingKey: only keys of failed node should be remapped")
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package mssqlx
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// RoutingReason tells why a query is routed to a node or balancer, or why a node is skipped.
+type RoutingReason string
+
+const (
+	// RoutingBalanced node is picked by balancing
+	RoutingBalanced RoutingReason = "balanced"
+
+	// RoutingPinned node is pinned to context
+	RoutingPinned RoutingReason = "pinned"
+
+	// RoutingKeyed node is picked by routing key
+	RoutingKeyed RoutingReason = "routing_key"
+
+	// RoutingFresh node is picked within max staleness of context
+	RoutingFresh RoutingReason = "fresh"
+
+	// RoutingNearest node is picked by routing chain
+	RoutingNearest RoutingReason = "route_chain"
+
+	// RoutingForceMaster read goes to masters because of ForceMaster/ReadYourWrites directive
+	RoutingForceMaster RoutingReason = "force_master"
+
+	// RoutingLagging read goes to masters because no slave is within max staleness, or slave is skipped for its lag
+	RoutingLagging RoutingReason = "lagging"
+
+	// RoutingChainFallback read goes to masters following routing chain
+	RoutingChainFallback RoutingReason = "route_chain_fallback"
+
+	// RoutingUnhealthy node failed and query is retried on another node, or no healthy node is available
+	RoutingUnhealthy RoutingReason = "unhealthy"
+
+	// RoutingSaturated concurrency limit of node is reached, query waits for a slot
+	RoutingSaturated RoutingReason = "saturated"
+)
+
+// Keys of structured fields in routing debug entries.
+const (
+	// LogFieldReason RoutingReason of entry
+	LogFieldReason = "reason"
+
+	// LogFieldAttempt number of node picks made for the query, starting from 1. Greater than 1 means retry
+	LogFieldAttempt = "attempt"
+)
+
+var routingDebug int32
+
+// SetRoutingDebug enables debug log entries for every routing decision: node picked and why,
+// nodes skipped (unhealthy, lagging, saturated), fallbacks to masters and retry attempts,
+// so that "why did this query go to the master?" could be answered. Entries are logged
+// at LogLevelDebug with LogFieldReason and LogFieldAttempt fields. Disabled by default.
+func SetRoutingDebug(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&routingDebug, v)
+}
+
+func isRoutingDebug() bool {
+	return atomic.LoadInt32(&routingDebug) == 1
+}
+
+type routingTraceKey struct{}
+
+// routingTrace of a query, carried by context while routing debug is enabled
+type routingTrace struct {
+	query string
+	picks int32
+}
+
+// withRoutingTrace starts tracing routing of query
+func withRoutingTrace(ctx context.Context, query string) context.Context {
+	if isRoutingDebug() && routingTraceFromContext(ctx) == nil {
+		ctx = context.WithValue(ctx, routingTraceKey{}, &routingTrace{query: query})
+	}
+	return ctx
+}
+
+func routingTraceFromContext(ctx context.Context) *routingTrace {
+	if ctx != nil {
+		if t, ok := ctx.Value(routingTraceKey{}).(*routingTrace); ok {
+			return t
+		}
+	}
+	return nil
+}
+
+func routingFields(ctx context.Context, reason RoutingReason, attempt int32) []LogField {
+	fields := []LogField{{Key: LogFieldReason, Value: string(reason)}}
+	if t := routingTraceFromContext(ctx); t != nil {
+		fields = append(fields, LogField{Key: LogFieldQuery, Value: t.query})
+		if attempt == 0 {
+			attempt = atomic.LoadInt32(&t.picks)
+		}
+	}
+	if attempt > 0 {
+		fields = append(fields, LogField{Key: LogFieldAttempt, Value: int(attempt)})
+	}
+	return fields
+}
+
+// debugPicked logs node picked for query
+func debugPicked(ctx context.Context, w *wrapper, reason RoutingReason) {
+	var attempt int32
+	if t := routingTraceFromContext(ctx); t != nil {
+		attempt = atomic.AddInt32(&t.picks, 1)
+	}
+	logEntry(LogLevelDebug, "node is picked", nodeFields(w, routingFields(ctx, reason, attempt)...)...)
+}
+
+// debugSkipped logs node skipped for query
+func debugSkipped(ctx context.Context, w *wrapper, reason RoutingReason) {
+	logEntry(LogLevelDebug, "node is skipped", nodeFields(w, routingFields(ctx, reason, 0)...)...)
+}
+
+// debugMasters logs read routed to masters
+func debugMasters(ctx context.Context, reason RoutingReason) {
+	logEntry(LogLevelDebug, "read is routed to masters", routingFields(ctx, reason, 0)...)
+}
+
+// debugSkippedLagging logs slaves skipped for exceeding max staleness
+func (b *dbList) debugSkippedLagging(ctx context.Context, d int64) {
+	list, _ := b.list.Load().([]*wrapper)
+	for _, w := range list {
+		if lag, ok := w.getLag(); !ok || int64(lag) > d {
+			debugSkipped(ctx, w, RoutingLagging)
+		}
+	}
+}
+
+// saturated reports whether concurrency limit is reached
+func (l *limiter) saturated() bool {
+	if l == nil {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit > 0 && l.inUse >= l.limit
+}
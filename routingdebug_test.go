@@ -0,0 +1,102 @@
+package mssqlx
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingLogger struct {
+	level   LogLevel // level of recorded entries
+	mu      sync.Mutex
+	entries []map[string]interface{}
+}
+
+func (l *recordingLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	if level != l.level {
+		return
+	}
+
+	entry := map[string]interface{}{"msg": msg}
+	for _, f := range fields {
+		entry[f.Key] = f.Value
+	}
+
+	l.mu.Lock()
+	l.entries = append(l.entries, entry)
+	l.mu.Unlock()
+}
+
+func (l *recordingLogger) find(reason RoutingReason) map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, e := range l.entries {
+		if e[LogFieldReason] == string(reason) {
+			return e
+		}
+	}
+	return nil
+}
+
+// findMsg returns latest entry of msg
+func (l *recordingLogger) findMsg(msg string) map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		if l.entries[i]["msg"] == msg {
+			return l.entries[i]
+		}
+	}
+	return nil
+}
+
+func TestRoutingDebug(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:", ":memory:"})
+	defer dbs.Destroy()
+
+	l := &recordingLogger{}
+	SetLogger(l)
+	defer SetLogger(stderrLogger{})
+
+	// disabled by default
+	var n int
+	_ = dbs.Get(&n, "SELECT 1")
+	if len(l.entries) != 0 {
+		t.Fatal("RoutingDebug: should be disabled by default", l.entries)
+	}
+
+	SetRoutingDebug(true)
+	defer SetRoutingDebug(false)
+
+	if err := dbs.Get(&n, "SELECT 2"); err != nil {
+		t.Fatal(err)
+	}
+	if e := l.find(RoutingBalanced); e == nil || e[LogFieldQuery] != "SELECT 2" || e[LogFieldAttempt] != 1 || e[LogFieldRole] != "slave" {
+		t.Fatal("RoutingDebug: picked node should be logged", e)
+	}
+
+	_ = dbs.GetContext(WithForceMaster(context.Background()), &n, "SELECT 3")
+	if e := l.find(RoutingForceMaster); e == nil || e[LogFieldQuery] != "SELECT 3" {
+		t.Fatal("RoutingDebug: force master should be logged", e)
+	}
+
+	// slaves have unknown lag
+	_ = dbs.GetContext(WithMaxStaleness(context.Background(), time.Second), &n, "SELECT 4")
+	if e := l.find(RoutingLagging); e == nil || e[LogFieldQuery] != "SELECT 4" {
+		t.Fatal("RoutingDebug: lagging slaves should be logged", e)
+	}
+
+	dbs.SetMasterMaxConcurrentQueries(1)
+	w := dbs.masterNodes()[0]
+	_ = w.limiter.acquire(context.Background(), PriorityNormal)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _ = dbs.ExecContext(ctx, "SELECT 5")
+	w.limiter.release()
+	if e := l.find(RoutingSaturated); e == nil || e[LogFieldNode] != "master-0" {
+		t.Fatal("RoutingDebug: saturated node should be logged", e)
+	}
+}
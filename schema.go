@@ -0,0 +1,136 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+var (
+	// ErrSchemaCheckNotSupported schema consistency check is not supported by driver
+	ErrSchemaCheckNotSupported = errors.New("Schema consistency check is not supported by driver")
+)
+
+// SchemaDiff is schema difference of a table between a node and the reference master.
+type SchemaDiff struct {
+	// Node name
+	Node string
+
+	// Table name
+	Table string
+
+	// Missing columns of master which node does not have
+	Missing []string
+
+	// Extra columns of node which master does not have
+	Extra []string
+
+	// Changed columns whose type or nullability differ from master
+	Changed []string
+
+	// Err fetching schema from node
+	Err error
+}
+
+// column definitions query of a table, returning name, type and nullability
+func columnsQuery(driverName string) string {
+	switch driverName {
+	case "mysql":
+		return "SELECT column_name, column_type, is_nullable FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position"
+
+	case "postgres", "pgx":
+		return "SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position"
+
+	case "sqlite3":
+		return "SELECT name, type, CASE WHEN \"notnull\" = 0 THEN 'YES' ELSE 'NO' END FROM pragma_table_info(?)"
+	}
+	return ""
+}
+
+// fetch column definitions of table: name -> type and nullability
+func fetchColumns(ctx context.Context, w *wrapper, query, table string) (map[string]string, error) {
+	rows, err := w.db.QueryContext(ctx, query, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]string)
+	for rows.Next() {
+		var name, typ, nullable string
+		if err = rows.Scan(&name, &typ, &nullable); err != nil {
+			return nil, err
+		}
+		columns[name] = typ + " " + nullable
+	}
+
+	return columns, rows.Err()
+}
+
+func diffColumns(master, node map[string]string) (missing, extra, changed []string) {
+	for name, def := range master {
+		if v, ok := node[name]; !ok {
+			missing = append(missing, name)
+		} else if v != def {
+			changed = append(changed, name)
+		}
+	}
+
+	for name := range node {
+		if _, ok := master[name]; !ok {
+			extra = append(extra, name)
+		}
+	}
+
+	sort.Strings(missing)
+	sort.Strings(extra)
+	sort.Strings(changed)
+	return
+}
+
+// VerifySchemaConsistency fetches column definitions of tables from every node, including failing ones,
+// and reports nodes whose schema differs from the first master, i.e replicas missing a migration.
+//
+// Nodes which could not be fetched are reported with Err. An empty result means schemas are consistent.
+func (dbs *DBs) VerifySchemaConsistency(ctx context.Context, tables ...string) ([]SchemaDiff, error) {
+	query := columnsQuery(dbs.driverName)
+	if query == "" {
+		return nil, ErrSchemaCheckNotSupported
+	}
+
+	if len(dbs.masterNodes()) == 0 || dbs.masterNodes()[0] == nil {
+		return nil, ErrNoConnection
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	reference := dbs.masterNodes()[0]
+
+	var diffs []SchemaDiff
+	for _, table := range tables {
+		master, err := fetchColumns(ctx, reference, query, table)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, w := range dbs.allNodes() {
+			if w == nil || w == reference {
+				continue
+			}
+
+			node, err := fetchColumns(ctx, w, query, table)
+			if err != nil {
+				diffs = append(diffs, SchemaDiff{Node: w.getName(), Table: table, Err: err})
+				continue
+			}
+
+			if missing, extra, changed := diffColumns(master, node); len(missing)+len(extra)+len(changed) > 0 {
+				diffs = append(diffs, SchemaDiff{Node: w.getName(), Table: table, Missing: missing, Extra: extra, Changed: changed})
+			}
+		}
+	}
+
+	return diffs, nil
+}
@@ -0,0 +1,56 @@
+package mssqlx
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifySchemaConsistency(t *testing.T) {
+	if _, err := (&DBs{driverName: "unknown"}).VerifySchemaConsistency(context.Background(), "t"); err != ErrSchemaCheckNotSupported {
+		t.Fatal("VerifySchemaConsistency: unsupported driver check fail")
+	}
+
+	dir, err := ioutil.TempDir("", "mssqlx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	master, slave := filepath.Join(dir, "master.db"), filepath.Join(dir, "slave.db")
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{master}, []string{slave})
+	defer dbs.Destroy()
+
+	if _, err = dbs.masterNodes()[0].db.Exec("CREATE TABLE person (id INTEGER NOT NULL, name TEXT, email TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dbs.slaveNodes()[0].db.Exec("CREATE TABLE person (id INTEGER NOT NULL, name INTEGER, age INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+
+	diffs, err := dbs.VerifySchemaConsistency(context.Background(), "person")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diffs) != 1 {
+		t.Fatal("VerifySchemaConsistency: unexpected diffs", diffs)
+	}
+	if d := diffs[0]; d.Node != "slave-0" || d.Table != "person" || len(d.Missing) != 1 || d.Missing[0] != "email" ||
+		len(d.Extra) != 1 || d.Extra[0] != "age" || len(d.Changed) != 1 || d.Changed[0] != "name" {
+		t.Fatal("VerifySchemaConsistency: unexpected diff", d)
+	}
+
+	if _, err = dbs.slaveNodes()[0].db.Exec("DROP TABLE person"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dbs.slaveNodes()[0].db.Exec("CREATE TABLE person (id INTEGER NOT NULL, name TEXT, email TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	if diffs, err = dbs.VerifySchemaConsistency(context.Background(), "person"); err != nil || len(diffs) != 0 {
+		t.Fatal("VerifySchemaConsistency: schemas should be consistent", diffs, err)
+	}
+}
@@ -0,0 +1,114 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidSessionVar session variable name contains invalid characters
+	ErrInvalidSessionVar = errors.New("Invalid session variable name")
+)
+
+func isValidSessionVar(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// sessionVarValue quotes value as string literal of driver. Backslashes are escaped too,
+// since mysql and postgres escape string literals treat them as escape characters.
+func sessionVarValue(driverName, value string) string {
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+
+	quoted := strings.Replace(value, "'", "''", -1)
+	switch {
+	case driverName == "mysql":
+		return "'" + strings.Replace(quoted, `\`, `\\`, -1) + "'"
+	case isPostgres(driverName):
+		return "E'" + strings.Replace(quoted, `\`, `\\`, -1) + "'"
+	}
+	return "'" + quoted + "'"
+}
+
+func setSessionVarQuery(driverName, name, value string) string {
+	if driverName == "mysql" {
+		return "SET SESSION " + name + " = " + value
+	}
+	return "SET " + name + " = " + value
+}
+
+// WithSessionVars checks out a dedicated master connection, applies vars by SET statements,
+// runs fn with the connection then resets vars to their defaults before returning the connection to pool.
+// If vars could not be reset, the connection is closed instead.
+//
+// It is a safe way to use session variables (i.e statement timeout, sql_mode) through the balancer.
+// On pooler nodes, see PoolerMode.
+func (dbs *DBs) WithSessionVars(ctx context.Context, vars map[string]string, fn func(*Conn) error) error {
+	return dbs.withSessionVars(ctx, RoleMaster, vars, fn)
+}
+
+// WithSessionVarsOnSlave checks out a dedicated slave connection, applies vars by SET statements,
+// runs fn with the connection then resets vars to their defaults before returning the connection to pool.
+func (dbs *DBs) WithSessionVarsOnSlave(ctx context.Context, vars map[string]string, fn func(*Conn) error) error {
+	return dbs.withSessionVars(ctx, RoleSlave, vars, fn)
+}
+
+func (dbs *DBs) withSessionVars(ctx context.Context, role Role, vars map[string]string, fn func(*Conn) error) (err error) {
+	for name := range vars {
+		if !isValidSessionVar(name) {
+			return ErrInvalidSessionVar
+		}
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	conn, err := dbs.Conn(ctx, role)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	if conn.w.pooler {
+		return dbs.withLocalVars(ctx, conn, vars, fn)
+	}
+
+	applied := make([]string, 0, len(vars))
+	defer func() {
+		// reset with background context, ctx might be done already
+		for _, name := range applied {
+			query := setSessionVarQuery(dbs.driverName, name, "DEFAULT")
+			if _, e := conn.ExecContext(context.Background(), query); e != nil {
+				reportError(query, e)
+				if err == nil {
+					err = e
+				}
+
+				// connection still carries vars, do not return it to pool
+				conn.discard()
+				return
+			}
+		}
+	}()
+
+	for name, value := range vars {
+		if _, err = conn.ExecContext(ctx, setSessionVarQuery(dbs.driverName, name, sessionVarValue(dbs.driverName, value))); err != nil {
+			return
+		}
+		applied = append(applied, name)
+	}
+
+	return fn(conn)
+}
@@ -0,0 +1,70 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSessionVars(t *testing.T) {
+	if !isValidSessionVar("sql_mode") || !isValidSessionVar("search_path") || isValidSessionVar("") || isValidSessionVar("a;DROP TABLE b") {
+		t.Fatal("SessionVars: variable name validation fail")
+	}
+
+	if v := sessionVarValue("mysql", "1000"); v != "1000" {
+		t.Fatal("SessionVars: numeric value should not be quoted", v)
+	}
+	if v := sessionVarValue("sqlite3", "it's"); v != "'it''s'" {
+		t.Fatal("SessionVars: string value should be quoted", v)
+	}
+	if v := sessionVarValue("mysql", `a\'; DROP TABLE b; -- `); v != `'a\\''; DROP TABLE b; -- '` {
+		t.Fatal("SessionVars: backslash should be escaped", v)
+	}
+	if v := sessionVarValue("postgres", `a\'b`); v != `E'a\\''b'` {
+		t.Fatal("SessionVars: backslash should be escaped", v)
+	}
+
+	if q := setSessionVarQuery("mysql", "a", "1"); q != "SET SESSION a = 1" {
+		t.Fatal("SessionVars: unexpected query", q)
+	}
+	if q := setSessionVarQuery("postgres", "a", "DEFAULT"); q != "SET a = DEFAULT" {
+		t.Fatal("SessionVars: unexpected query", q)
+	}
+
+	dbs := &DBs{}
+	if err := dbs.WithSessionVars(context.Background(), map[string]string{"a b": "c"}, nil); err != ErrInvalidSessionVar {
+		t.Fatal("SessionVars: invalid variable check fail")
+	}
+
+	if TestWPostgres {
+		err := pgDBs.WithSessionVars(context.Background(), map[string]string{"search_path": "mssqlx"}, func(conn *Conn) error {
+			var path string
+			if err := conn.QueryRowContext(context.Background(), "SHOW search_path").Scan(&path); err != nil {
+				return err
+			}
+			if path != "mssqlx" {
+				t.Fatal("SessionVars: variable is not applied", path)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSessionVarsDiscard(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+
+	conn, err := dbs.Conn(context.Background(), RoleMaster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn.discard()
+	_ = conn.Close()
+
+	if n := conn.w.db.Stats().OpenConnections; n != 0 {
+		t.Fatal("SessionVars: discarded connection should not be returned to pool", n)
+	}
+}
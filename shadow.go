@@ -0,0 +1,242 @@
+package mssqlx
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"hash"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// DefaultShadowTimeout default timeout of each replay on shadow node
+	DefaultShadowTimeout = 5 * time.Second
+
+	// DefaultShadowMaxInFlight default max number of concurrent replays
+	DefaultShadowMaxInFlight = 16
+)
+
+// ShadowOptions configures shadow traffic, see DBs.SetShadow.
+type ShadowOptions struct {
+	// Percent of read queries on slaves replayed to shadow node, in (0, 100]
+	Percent float64
+
+	// CompareResults compares digest of shadow result with digest of result re-read from the node
+	// which served the query. It costs one more read on that node per replay.
+	CompareResults bool
+
+	// Timeout of each replay. Default is DefaultShadowTimeout
+	Timeout time.Duration
+
+	// MaxInFlight bounds concurrent replays, sampled queries beyond it are dropped.
+	// Default is DefaultShadowMaxInFlight
+	MaxInFlight int
+
+	// OnResult receives result of each replay, called from replaying goroutine
+	OnResult func(ShadowResult)
+}
+
+// ShadowResult is result of a query replayed to shadow node.
+type ShadowResult struct {
+	Query string
+
+	// Node which served the query
+	Node string
+
+	// Latency of serving node and shadow node
+	Latency       time.Duration
+	ShadowLatency time.Duration
+
+	// Err of shadow node, if any
+	Err error
+
+	// Compared is true if digests of results are compared, Match tells whether they are equal
+	Compared bool
+	Match    bool
+}
+
+// ShadowStats counters of shadow traffic.
+type ShadowStats struct {
+	Replayed   uint64 `json:"replayed"`
+	Dropped    uint64 `json:"dropped"`
+	Failed     uint64 `json:"failed"`
+	Mismatched uint64 `json:"mismatched"`
+}
+
+type shadowing struct {
+	db       *sqlx.DB
+	opts     ShadowOptions
+	inFlight int32
+
+	replayed   uint64
+	dropped    uint64
+	failed     uint64
+	mismatched uint64
+}
+
+// SetShadow asynchronously replays a sampled percentage of read queries on slaves to shadow node
+// (i.e a new replica under evaluation), comparing latency and optionally result digests.
+// Responses to callers are always served by balanced nodes, never wait for shadow node.
+// Queries inside transactions are not replayed.
+//
+// Shadow node is not owned by DBs. Passing nil db stops shadowing.
+func (dbs *DBs) SetShadow(db *sqlx.DB, opts ShadowOptions) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultShadowTimeout
+	}
+	if opts.MaxInFlight <= 0 {
+		opts.MaxInFlight = DefaultShadowMaxInFlight
+	}
+
+	var s *shadowing
+	if db != nil && opts.Percent > 0 {
+		s = &shadowing{db: db, opts: opts}
+	}
+	dbs.slaves.shadow.Store(s)
+}
+
+// ShadowStats returns counters of current shadowing, zero if there is none.
+func (dbs *DBs) ShadowStats() ShadowStats {
+	s := dbs.slaves.getShadow()
+	if s == nil {
+		return ShadowStats{}
+	}
+
+	return ShadowStats{
+		Replayed:   atomic.LoadUint64(&s.replayed),
+		Dropped:    atomic.LoadUint64(&s.dropped),
+		Failed:     atomic.LoadUint64(&s.failed),
+		Mismatched: atomic.LoadUint64(&s.mismatched),
+	}
+}
+
+func (c *balancer) getShadow() *shadowing {
+	s, _ := c.shadow.Load().(*shadowing)
+	return s
+}
+
+// replayShadow replays query served by w to shadow node if sampled
+func (c *balancer) replayShadow(w *wrapper, query string, args []interface{}, latency time.Duration) {
+	s := c.getShadow()
+	if s == nil || !s.sampled() {
+		return
+	}
+
+	if atomic.AddInt32(&s.inFlight, 1) > int32(s.opts.MaxInFlight) {
+		atomic.AddInt32(&s.inFlight, -1)
+		atomic.AddUint64(&s.dropped, 1)
+		return
+	}
+
+	// args are owned by caller, copy them before going async
+	nargs, buf := w.acquireArgs(args)
+	copied := make([]interface{}, len(nargs))
+	for i, arg := range nargs {
+		if b, ok := arg.([]byte); ok {
+			arg = append([]byte(nil), b...)
+		}
+		copied[i] = arg
+	}
+	putValues(buf)
+
+	go func() {
+		defer atomic.AddInt32(&s.inFlight, -1)
+		s.replay(w, query, copied, latency)
+	}()
+}
+
+func (s *shadowing) sampled() bool {
+	if s.opts.Percent >= 100 {
+		return true
+	}
+
+	jitterRandLock.Lock()
+	v := jitterRand.Float64() * 100
+	jitterRandLock.Unlock()
+
+	return v < s.opts.Percent
+}
+
+func (s *shadowing) replay(w *wrapper, query string, args []interface{}, latency time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+
+	shadowQuery := query
+	if w.rebound {
+		shadowQuery = s.db.Rebind(query)
+	}
+
+	startedAt := time.Now()
+	digest, err := resultDigest(ctx, s.db.DB, shadowQuery, args)
+
+	res := ShadowResult{Query: query, Node: w.id(), Latency: latency, ShadowLatency: time.Since(startedAt), Err: err}
+	atomic.AddUint64(&s.replayed, 1)
+
+	if err != nil {
+		atomic.AddUint64(&s.failed, 1)
+		logEntry(LogLevelDebug, "shadow query failed: "+err.Error(), LogField{Key: LogFieldQuery, Value: query})
+	} else if s.opts.CompareResults {
+		if reference, err := resultDigest(ctx, w.db.DB, w.rebind(query), args); err == nil {
+			res.Compared, res.Match = true, reference == digest
+			if !res.Match {
+				atomic.AddUint64(&s.mismatched, 1)
+				logEntry(LogLevelInfo, "shadow result mismatched", nodeFields(w, LogField{Key: LogFieldQuery, Value: query})...)
+			}
+		}
+	}
+
+	if s.opts.OnResult != nil {
+		s.opts.OnResult(res)
+	}
+}
+
+// resultDigest runs query and hashes all returned rows
+func resultDigest(ctx context.Context, db *sql.DB, query string, args []interface{}) (string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	h := sha256.New()
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return "", err
+		}
+		for _, v := range values {
+			writeDigestValue(h, v)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	return string(h.Sum(nil)), nil
+}
+
+// writeDigestValue writes length-prefixed value, NULL is distinguished from empty value
+func writeDigestValue(h hash.Hash, v sql.RawBytes) {
+	var size [8]byte
+	if v == nil {
+		binary.BigEndian.PutUint64(size[:], ^uint64(0))
+	} else {
+		binary.BigEndian.PutUint64(size[:], uint64(len(v)))
+	}
+	_, _ = h.Write(size[:])
+	_, _ = h.Write(v)
+}
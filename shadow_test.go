@@ -0,0 +1,73 @@
+package mssqlx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestShadow(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	shadow, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shadow.Close()
+	shadow.SetMaxOpenConns(1)
+
+	results := make(chan ShadowResult, 4)
+	dbs.SetShadow(shadow, ShadowOptions{Percent: 100, CompareResults: true, OnResult: func(r ShadowResult) {
+		results <- r
+	}})
+
+	var n int
+	if err = dbs.Get(&n, "SELECT ?", 1); err != nil || n != 1 {
+		t.Fatal("Shadow: query fail", err)
+	}
+
+	select {
+	case r := <-results:
+		if r.Err != nil || !r.Compared || !r.Match || r.Node != "slave-0" || r.Query != "SELECT ?" {
+			t.Fatal("Shadow: result fail", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shadow: query should be replayed")
+	}
+
+	// shadow node diverges
+	if _, err = shadow.Exec("CREATE TABLE t (v INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = shadow.Exec("INSERT INTO t VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	if err = dbs.Get(&n, "SELECT COUNT(*) FROM sqlite_master"); err != nil {
+		t.Fatal("Shadow: query fail", err)
+	}
+	if r := <-results; r.Err != nil || !r.Compared || r.Match {
+		t.Fatal("Shadow: mismatch should be detected", r)
+	}
+
+	rows, err := dbs.Query("SELECT * FROM t") // fails on slave, not replayed
+	if err == nil {
+		rows.Close()
+		t.Fatal("Shadow: query should fail")
+	}
+
+	if stats := dbs.ShadowStats(); stats.Replayed != 2 || stats.Mismatched != 1 || stats.Failed != 0 {
+		t.Fatal("Shadow: stats fail", stats)
+	}
+
+	dbs.SetShadow(nil, ShadowOptions{})
+	if err = dbs.Get(&n, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case r := <-results:
+		t.Fatal("Shadow: stopped shadowing should not replay", r)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
@@ -0,0 +1,39 @@
+package mssqlx
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrSimulatedFailure cause of node failure made by SimulateNodeFailure
+	ErrSimulatedFailure = errors.New("Simulated node failure")
+)
+
+// SimulateNodeFailure marks node (i.e master-0, slave-1) failed for d without touching the database,
+// so that game-day exercises could validate failover behavior of application in staging.
+// Node is taken out of rotation like on real failure and put back by health checker after d.
+// Calling it again with d <= 0 ends simulation early.
+func (dbs *DBs) SimulateNodeFailure(name string, d time.Duration) error {
+	w := dbs.findNode(name)
+	if w == nil {
+		return ErrNodeNotFound
+	}
+
+	target, err := dbs.getBalancer(w.getRole())
+	if err != nil {
+		return err
+	}
+
+	atomic.StoreInt64(&w.simulatedUntil, time.Now().Add(d).UnixNano())
+	if d > 0 {
+		target.failureWithCause(w, ErrSimulatedFailure)
+	}
+
+	return nil
+}
+
+func (w *wrapper) isFailureSimulated() bool {
+	return time.Now().UnixNano() < atomic.LoadInt64(&w.simulatedUntil)
+}
@@ -0,0 +1,56 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSimulateNodeFailure(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:", ":memory:"}, nil)
+	defer dbs.Destroy()
+	dbs.SetMasterHealthCheckPeriod(5)
+
+	if err := dbs.SimulateNodeFailure("master-9", time.Second); err != ErrNodeNotFound {
+		t.Fatal("SimulateNodeFailure: not found check fail", err)
+	}
+
+	if err := dbs.SimulateNodeFailure("master-0", 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		var info QueryInfo
+		if _, err := dbs.ExecContext(WithQueryInfo(context.Background(), &info), "SELECT 1"); err != nil || info.Node != "master-1" {
+			t.Fatal("SimulateNodeFailure: traffic should not go to failed node", info.Node, err)
+		}
+	}
+
+	if events := dbs.Events(); len(events) != 1 || events[0].To != NodeStateDown || events[0].Cause != ErrSimulatedFailure.Error() {
+		t.Fatal("SimulateNodeFailure: should be recorded", events)
+	}
+
+	w := dbs.findNode("master-0")
+	time.Sleep(20 * time.Millisecond)
+	if dbs.masters.dbs.contains(w) {
+		t.Fatal("SimulateNodeFailure: node should stay failed for duration")
+	}
+
+	for i := 0; i < 100 && !dbs.masters.dbs.contains(w); i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !dbs.masters.dbs.contains(w) {
+		t.Fatal("SimulateNodeFailure: node should be back after duration")
+	}
+
+	// ended early
+	_ = dbs.SimulateNodeFailure("master-1", time.Hour)
+	_ = dbs.SimulateNodeFailure("master-1", 0)
+	w = dbs.findNode("master-1")
+	for i := 0; i < 100 && !dbs.masters.dbs.contains(w); i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !dbs.masters.dbs.contains(w) {
+		t.Fatal("SimulateNodeFailure: node should be back when simulation ends")
+	}
+}
@@ -0,0 +1,21 @@
+package mssqlx
+
+import "sync/atomic"
+
+// Single-node mode: a balancer without master to fall back to (i.e masters), configured with exactly one node,
+// has nothing to route between. Queries go straight to that node without consulting routing directives of context,
+// and failing node is never evicted since there is no other node to retry on: errors are returned to caller
+// instead of waiting for node to come back.
+
+func (c *balancer) isSingle() bool {
+	return atomic.LoadInt32(&c.single) == 1
+}
+
+// updateSingle switches single-node mode on topology change
+func (c *balancer) updateSingle(members int) {
+	var single int32
+	if members == 1 && c.master == nil {
+		single = 1
+	}
+	atomic.StoreInt32(&c.single, single)
+}
@@ -0,0 +1,59 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestSingleNode(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+
+	if !dbs.masters.isSingle() || dbs.slaves.isSingle() {
+		t.Fatal("SingleNode: detection fail")
+	}
+
+	w := dbs.masterNodes()[0]
+	if dbs.masters.pick(WithRoutingKey(context.Background(), "k")) != w {
+		t.Fatal("SingleNode: pick fail")
+	}
+
+	// only node is kept, error is returned to caller
+	if dbs.masters.countFailure(w, driver.ErrBadConn) || dbs.masters.size() != 1 {
+		t.Fatal("SingleNode: only node should not be evicted")
+	}
+
+	if _, err := dbs.Exec("SELECT 1"); err != nil {
+		t.Fatal("SingleNode: exec fail", err)
+	}
+
+	multi, _ := ConnectMasterSlaves("sqlite3", []string{":memory:", ":memory:"}, []string{":memory:"})
+	defer multi.Destroy()
+
+	if multi.masters.isSingle() || multi.slaves.isSingle() {
+		t.Fatal("SingleNode: multi nodes detection fail")
+	}
+}
+
+func BenchmarkPickSingleNode(b *testing.B) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+
+	ctx := WithRoutingKey(WithPriority(context.Background(), PriorityLow), "k")
+
+	b.Run("single", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			dbs.masters.pick(ctx)
+		}
+	})
+
+	b.Run("balanced", func(b *testing.B) {
+		dbs.masters.updateSingle(2)
+		defer dbs.masters.updateSingle(1)
+
+		for i := 0; i < b.N; i++ {
+			dbs.masters.pick(ctx)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package mssqlx
+
+import (
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// Keys of structured fields in slow log entries.
+const (
+	// LogFieldElapsed duration of statement or transaction
+	LogFieldElapsed = "elapsed"
+
+	// LogFieldRows number of rows returned by statement
+	LogFieldRows = "rows"
+
+	// LogFieldBytes number of bytes returned by statement
+	LogFieldBytes = "bytes"
+)
+
+// SlowLogOptions configures SetSlowLog. Zero thresholds are unchecked.
+type SlowLogOptions struct {
+	// Statement statements executing longer than it are logged
+	Statement time.Duration
+
+	// Transaction transactions started by BeginNestedTx lasting longer than it, from begin to commit or rollback, are logged
+	Transaction time.Duration
+
+	// Rows statements returning more rows than it are logged. Rows are counted by Select and BufferedQueryx
+	Rows int
+
+	// Bytes statements returning more bytes than it are logged. Bytes are counted by Select and BufferedQueryx,
+	// size of Select result is estimated from its destination
+	Bytes int64
+}
+
+type slowLogHolder struct {
+	opts *SlowLogOptions
+}
+
+var slowLog atomic.Value // slowLogHolder
+
+func init() {
+	slowLog.Store(slowLogHolder{})
+}
+
+// SetSlowLog logs statements and transactions exceeding thresholds of opts, and statements returning
+// too many rows or bytes, at LogLevelWarn with fingerprint of query (see LogFieldQuery) and node, so that
+// workloads knocking replicas over could be found. Passing zero options disables it, the default.
+func SetSlowLog(opts SlowLogOptions) {
+	var h slowLogHolder
+	if opts != (SlowLogOptions{}) {
+		h.opts = &opts
+	}
+	slowLog.Store(h)
+}
+
+func getSlowLog() *SlowLogOptions {
+	return slowLog.Load().(slowLogHolder).opts
+}
+
+// logSlowStatement logs statement executed by w if it is slow
+func logSlowStatement(w *wrapper, query string, elapsed time.Duration) {
+	if opts := getSlowLog(); opts != nil && opts.Statement > 0 && elapsed > opts.Statement {
+		logEntry(LogLevelWarn, "slow statement", nodeFields(w,
+			LogField{Key: LogFieldQuery, Value: fingerprint(query)},
+			LogField{Key: LogFieldElapsed, Value: elapsed.String()})...)
+	}
+}
+
+// logBigResult logs statement executed by w if it returned too many rows or bytes
+func logBigResult(w *wrapper, query string, rows int, bytes int64) {
+	opts := getSlowLog()
+	if opts == nil || !(opts.Rows > 0 && rows > opts.Rows || opts.Bytes > 0 && bytes > opts.Bytes) {
+		return
+	}
+
+	logEntry(LogLevelWarn, "big result", nodeFields(w,
+		LogField{Key: LogFieldQuery, Value: fingerprint(query)},
+		LogField{Key: LogFieldRows, Value: rows},
+		LogField{Key: LogFieldBytes, Value: bytes})...)
+}
+
+// logBigSelect logs Select made by w if it appended too many rows or bytes to dest, which had n rows before
+func logBigSelect(w *wrapper, query string, dest interface{}, n int) {
+	opts := getSlowLog()
+	if opts == nil || opts.Rows <= 0 && opts.Bytes <= 0 {
+		return
+	}
+
+	if n < 0 {
+		return // not a slice
+	}
+
+	v := reflect.ValueOf(dest).Elem()
+
+	var bytes int64
+	if opts.Bytes > 0 {
+		for i := n; i < v.Len(); i++ {
+			bytes += valueBytes(v.Index(i), 0)
+		}
+	}
+
+	logBigResult(w, query, v.Len()-n, bytes)
+}
+
+// valueBytes estimates size of scanned value. Pointers are followed up to a few levels deep.
+func valueBytes(v reflect.Value, depth int) (n int64) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() || depth >= 3 {
+			return
+		}
+		return valueBytes(v.Elem(), depth+1)
+
+	case reflect.String:
+		return int64(v.Len())
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return int64(v.Len())
+		}
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			n += valueBytes(v.Field(i), depth)
+		}
+		return
+	}
+
+	return int64(v.Type().Size())
+}
+
+// logSlowTx logs transaction if it lasted too long
+func (s *txState) logSlowTx() {
+	opts := getSlowLog()
+	if opts == nil || opts.Transaction <= 0 || !atomic.CompareAndSwapInt32(&s.logged, 0, 1) {
+		return
+	}
+
+	elapsed := time.Since(s.begunAt)
+	if elapsed <= opts.Transaction {
+		return
+	}
+
+	first, _ := s.first.Load().(string)
+	logEntry(LogLevelWarn, "slow transaction", nodeFields(s.node,
+		LogField{Key: LogFieldQuery, Value: fingerprint(first)},
+		LogField{Key: LogFieldElapsed, Value: elapsed.String()},
+		LogField{Key: "statements", Value: int(atomic.LoadInt32(&s.statements))})...)
+}
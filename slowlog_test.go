@@ -0,0 +1,75 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSlowLog(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{"file:slowlog?mode=memory&cache=shared"}, nil)
+	defer dbs.Destroy()
+
+	if _, err := dbs.Exec("CREATE TABLE users (id INTEGER, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := dbs.Exec("INSERT INTO users VALUES (?, 'abcdefghij')", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l := &recordingLogger{level: LogLevelWarn}
+	SetLogger(l)
+	defer SetLogger(stderrLogger{})
+
+	// disabled by default
+	var users []struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+	if err := dbs.SelectOnMaster(&users, "SELECT * FROM users"); err != nil || len(users) != 5 || len(l.entries) != 0 {
+		t.Fatal("SlowLog: should be disabled by default", err, l.entries)
+	}
+
+	SetSlowLog(SlowLogOptions{Rows: 4, Bytes: 1 << 20, Transaction: 10 * time.Millisecond})
+	defer SetSlowLog(SlowLogOptions{})
+
+	users = users[:0]
+	if err := dbs.SelectOnMaster(&users, "SELECT * FROM users WHERE id < 100"); err != nil {
+		t.Fatal(err)
+	}
+	if e := l.findMsg("big result"); e == nil || e[LogFieldQuery] != "SELECT * FROM users WHERE id < ?" || e[LogFieldRows] != 5 || e[LogFieldNode] == "" {
+		t.Fatal("SlowLog: big select should be logged", e)
+	}
+
+	SetSlowLog(SlowLogOptions{Bytes: 40})
+	rows, err := dbs.BufferedQueryxOnMaster("SELECT name FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rows.Close()
+	if e := l.findMsg("big result"); e == nil || e[LogFieldQuery] != "SELECT name FROM users" || e[LogFieldBytes].(int64) <= 40 {
+		t.Fatal("SlowLog: big buffered result should be logged", l.entries)
+	}
+
+	SetSlowLog(SlowLogOptions{Statement: time.Nanosecond, Transaction: 10 * time.Millisecond})
+	tx, err := dbs.BeginNestedTx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Exec("UPDATE users SET name = 'x' WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if e := l.findMsg("slow transaction"); e == nil || e[LogFieldQuery] != "UPDATE users SET name = ? WHERE id = ?" || e["statements"] != 1 || e[LogFieldRole] != "master" {
+		t.Fatal("SlowLog: slow transaction should be logged", e)
+	}
+	if e := l.findMsg("slow statement"); e == nil || e[LogFieldElapsed] == "" {
+		t.Fatal("SlowLog: slow statement should be logged", e)
+	}
+}
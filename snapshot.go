@@ -0,0 +1,98 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Queryer runs read queries, i.e inside a snapshot of ReadSnapshot.
+type Queryer interface {
+	sqlx.QueryerContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+var _ Queryer = (*sqlx.Tx)(nil)
+
+var snapshotTxOptions = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+
+// _beginTx starts a transaction on one of healthy nodes of target. Concurrency slot of node is held
+// until caller releases it once transaction is finished.
+func _beginTx(ctx context.Context, target *balancer, opts *sql.TxOptions) (w *wrapper, tx *sqlx.Tx, err error) {
+	var r interface{}
+
+	queryInfoFromContext(ctx).start()
+	for {
+		if w, err = getDBFromBalancer(ctx, target); err != nil {
+			reportError("START TRANSACTION", err)
+			return
+		}
+
+		// executing
+		r, err = leaseBackoff(ctx, w, "START TRANSACTION", func() (interface{}, error) {
+			return w.db.BeginTxx(ctx, opts)
+		})
+		if r != nil {
+			tx = r.(*sqlx.Tx)
+		}
+
+		// check networking/wsrep error
+		if target.shouldFailure(w, err) && target.countFailure(w, err) {
+			continue
+		}
+
+		return
+	}
+}
+
+// ReadSnapshot opens a read-only REPEATABLE READ transaction on one of slaves and runs fn with it,
+// so that multiple SELECTs see a consistent snapshot. Transaction is finished when fn returns.
+func (dbs *DBs) ReadSnapshot(ctx context.Context, fn func(q Queryer) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, target := dbs.slaves.route(ctx, "")
+	return _readSnapshot(ctx, target, fn)
+}
+
+// ReadSnapshotOnMaster opens a read-only REPEATABLE READ transaction on one of masters and runs fn with it.
+func (dbs *DBs) ReadSnapshotOnMaster(ctx context.Context, fn func(q Queryer) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return _readSnapshot(ctx, dbs.masters, fn)
+}
+
+func _readSnapshot(ctx context.Context, target *balancer, fn func(q Queryer) error) (err error) {
+	if err = consumeQueryBudget(ctx); err != nil {
+		return
+	}
+
+	w, tx, err := _beginTx(ctx, target, snapshotTxOptions)
+	if err != nil {
+		return
+	}
+	defer w.limiter.release()
+
+	defer func() {
+		if e := recover(); e != nil {
+			_ = tx.Rollback()
+			panic(e)
+		}
+
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+
+		// check networking/wsrep error
+		if target.shouldFailure(w, err) {
+			target.countFailure(w, err)
+		}
+	}()
+
+	return fn(tx)
+}
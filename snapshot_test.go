@@ -0,0 +1,28 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestReadSnapshot(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	var a, b int
+	err := dbs.ReadSnapshot(context.Background(), func(q Queryer) error {
+		if err := q.GetContext(context.Background(), &a, "SELECT 1"); err != nil {
+			return err
+		}
+		return q.GetContext(context.Background(), &b, "SELECT 2")
+	})
+	if err != nil || a != 1 || b != 2 {
+		t.Fatal("ReadSnapshot: fail", err, a, b)
+	}
+
+	e := errors.New("abort")
+	if err = dbs.ReadSnapshotOnMaster(context.Background(), func(q Queryer) error { return e }); err != e {
+		t.Fatal("ReadSnapshot: error propagation fail", err)
+	}
+}
@@ -0,0 +1,144 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrLagNotSupported measuring replication lag is not supported by driver
+	ErrLagNotSupported = errors.New("Measuring replication lag is only supported by mysql and postgres drivers")
+)
+
+const (
+	// DefaultLagCheckPeriod default period of measuring replication lag
+	DefaultLagCheckPeriod = time.Second
+)
+
+type maxStalenessKey struct{}
+
+// WithMaxStaleness returns a context whose slave-balanced reads are served by slaves with measured
+// replication lag not exceeding d, or by masters if there is no such slave. Lag is measured by MonitorLag;
+// slaves with unknown lag are never picked.
+func WithMaxStaleness(ctx context.Context, d time.Duration) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if d < 0 {
+		d = 0
+	}
+	return context.WithValue(ctx, maxStalenessKey{}, d)
+}
+
+func maxStalenessFromContext(ctx context.Context) (d time.Duration, ok bool) {
+	if ctx != nil {
+		d, ok = ctx.Value(maxStalenessKey{}).(time.Duration)
+	}
+	return
+}
+
+func (w *wrapper) getLag() (time.Duration, bool) {
+	v := atomic.LoadInt64(&w.lag)
+	return time.Duration(v), v >= 0
+}
+
+func (w *wrapper) setLag(d time.Duration, known bool) {
+	if !known {
+		d = -1
+	}
+	atomic.StoreInt64(&w.lag, int64(d))
+}
+
+// fresh returns next node, in round-robin manner, whose lag is known and not exceeding d
+func (b *dbList) fresh(d time.Duration) *wrapper {
+	return b.nextMatching(freshWithin(d))
+}
+
+func freshWithin(d time.Duration) func(*wrapper) bool {
+	return func(w *wrapper) bool {
+		lag, ok := w.getLag()
+		return ok && lag <= d
+	}
+}
+
+func measureLag(ctx context.Context, w *wrapper) (time.Duration, bool, error) {
+	switch w.db.DriverName() {
+	case "postgres", "pgx":
+		var seconds float64
+		if err := w.db.GetContext(ctx, &seconds, "SELECT COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)"); err != nil {
+			return 0, false, err
+		}
+		return time.Duration(seconds * float64(time.Second)), true, nil
+
+	case "mysql":
+		return measureMySQLLag(ctx, w)
+	}
+
+	return 0, false, ErrLagNotSupported
+}
+
+func (dbs *DBs) refreshLag(ctx context.Context) {
+	for _, w := range dbs.allNodes() {
+		if w == nil || w.getRole() != RoleSlave {
+			continue
+		}
+
+		lag, known, err := measureLag(ctx, w)
+		if err != nil {
+			reportNodeError(w, "measure replication lag", err)
+		}
+		w.setLag(lag, known)
+	}
+}
+
+// MonitorLag measures replication lag of slaves every period until ctx is done.
+// Measured lags are used by WithMaxStaleness. If period <= 0, DefaultLagCheckPeriod is used.
+func (dbs *DBs) MonitorLag(ctx context.Context, period time.Duration) error {
+	switch dbs.driverName {
+	case "postgres", "pgx", "mysql":
+	default:
+		return ErrLagNotSupported
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if period <= 0 {
+		period = DefaultLagCheckPeriod
+	}
+
+	dbs.refreshLag(ctx)
+
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-ticker.C:
+				dbs.refreshLag(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// ReplicationLags returns measured replication lag of slaves by node name. Slaves with unknown lag are omitted.
+func (dbs *DBs) ReplicationLags() map[string]time.Duration {
+	lags := make(map[string]time.Duration)
+	for _, w := range dbs.allNodes() {
+		if w != nil && w.getRole() == RoleSlave {
+			if lag, ok := w.getLag(); ok {
+				lags[w.getName()] = lag
+			}
+		}
+	}
+	return lags
+}
@@ -0,0 +1,53 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMaxStaleness(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:", ":memory:"})
+	defer dbs.Destroy()
+
+	if err := dbs.MonitorLag(context.Background(), time.Second); err != ErrLagNotSupported {
+		t.Fatal("MaxStaleness: unsupported driver fail", err)
+	}
+
+	ctx := WithMaxStaleness(context.Background(), time.Second)
+
+	// lags are unknown, reads go to masters
+	if _, target := dbs.slaves.route(ctx, ""); target != dbs.masters {
+		t.Fatal("MaxStaleness: route to masters fail")
+	}
+
+	slave0, slave1 := dbs.slaveNodes()[0], dbs.slaveNodes()[1]
+	slave0.setLag(2*time.Second, true)
+	slave1.setLag(500*time.Millisecond, true)
+
+	if _, target := dbs.slaves.route(ctx, ""); target != dbs.slaves {
+		t.Fatal("MaxStaleness: route to slaves fail")
+	}
+	for i := 0; i < 4; i++ {
+		if w := dbs.slaves.pick(ctx); w != slave1 {
+			t.Fatal("MaxStaleness: pick fresh slave fail", w.getName())
+		}
+	}
+
+	var v int
+	if err := dbs.GetContext(ctx, &v, "SELECT 1"); err != nil || v != 1 {
+		t.Fatal("MaxStaleness: query fail", err)
+	}
+
+	if lags := dbs.ReplicationLags(); len(lags) != 2 || lags[slave1.getName()] != 500*time.Millisecond {
+		t.Fatal("MaxStaleness: lags fail", lags)
+	}
+
+	slave1.setLag(0, false)
+	if _, ok := dbs.ReplicationLags()[slave1.getName()]; ok {
+		t.Fatal("MaxStaleness: unknown lag fail")
+	}
+	if _, target := dbs.slaves.route(ctx, ""); target != dbs.masters {
+		t.Fatal("MaxStaleness: unknown lag fail")
+	}
+}
@@ -0,0 +1,142 @@
+package mssqlx
+
+import (
+	"strings"
+)
+
+// StatementType is type of a statement, judged by its leading keyword.
+type StatementType string
+
+const (
+	// StatementSelect SELECT, including WITH ... SELECT
+	StatementSelect StatementType = "SELECT"
+
+	// StatementInsert INSERT, REPLACE or UPSERT
+	StatementInsert StatementType = "INSERT"
+
+	// StatementUpdate UPDATE or MERGE
+	StatementUpdate StatementType = "UPDATE"
+
+	// StatementDelete DELETE
+	StatementDelete StatementType = "DELETE"
+
+	// StatementDDL CREATE, ALTER, DROP, TRUNCATE or RENAME
+	StatementDDL StatementType = "DDL"
+
+	// StatementOther any other statement, i.e SET, SHOW, CALL
+	StatementOther StatementType = "OTHER"
+)
+
+// statementTypes indexed by kind
+var statementTypes = [...]StatementType{StatementSelect, StatementInsert, StatementUpdate, StatementDelete, StatementDDL, StatementOther}
+
+const (
+	kindSelect = iota
+	kindInsert
+	kindUpdate
+	kindDelete
+	kindDDL
+	kindOther
+)
+
+// ClassifyStatement returns type of query, judged by its leading keyword after comments.
+// Data-modifying statement of a WITH query decides its type.
+func ClassifyStatement(query string) StatementType {
+	return statementTypes[statementKind(query)]
+}
+
+func statementKind(query string) int {
+	keyword, rest := nextKeyword(query)
+	switch keyword {
+	case "SELECT", "VALUES", "TABLE":
+		return kindSelect
+
+	case "INSERT", "REPLACE", "UPSERT":
+		return kindInsert
+
+	case "UPDATE", "MERGE":
+		return kindUpdate
+
+	case "DELETE":
+		return kindDelete
+
+	case "CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME":
+		return kindDDL
+
+	case "WITH":
+		return withStatementKind(rest)
+	}
+	return kindOther
+}
+
+// withStatementKind returns kind of main statement following common table expressions
+func withStatementKind(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '(':
+			depth++
+
+		case c == ')':
+			depth--
+
+		case c == '\'':
+			if end := strings.IndexByte(s[i+1:], '\''); end >= 0 {
+				i += end + 1
+			}
+
+		case depth == 0 && isKeywordByte(c) && (i == 0 || !isKeywordByte(s[i-1])):
+			end := i
+			for end < len(s) && isKeywordByte(s[end]) {
+				end++
+			}
+
+			switch strings.ToUpper(s[i:end]) {
+			case "SELECT":
+				return kindSelect
+			case "INSERT":
+				return kindInsert
+			case "UPDATE":
+				return kindUpdate
+			case "DELETE":
+				return kindDelete
+			}
+			i = end - 1
+		}
+	}
+	return kindOther
+}
+
+// nextKeyword returns upper-cased leading keyword of s after whitespaces, comments and parentheses, and the rest of s
+func nextKeyword(s string) (string, string) {
+	for {
+		s = strings.TrimLeft(s, " \t\r\n(")
+
+		switch {
+		case strings.HasPrefix(s, "/*"):
+			end := strings.Index(s, "*/")
+			if end < 0 {
+				return "", ""
+			}
+			s = s[end+2:]
+
+		case strings.HasPrefix(s, "--"), strings.HasPrefix(s, "#"):
+			end := strings.IndexByte(s, '\n')
+			if end < 0 {
+				return "", ""
+			}
+			s = s[end+1:]
+
+		default:
+			end := 0
+			for end < len(s) && isKeywordByte(s[end]) {
+				end++
+			}
+			return strings.ToUpper(s[:end]), s[end:]
+		}
+	}
+}
+
+func isKeywordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
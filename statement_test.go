@@ -0,0 +1,26 @@
+package mssqlx
+
+import "testing"
+
+func TestClassifyStatement(t *testing.T) {
+	for query, expected := range map[string]StatementType{
+		"SELECT 1": StatementSelect,
+		"  (select a FROM t) UNION (SELECT b FROM u)": StatementSelect,
+		"/* trace */ insert INTO t VALUES (1)":        StatementInsert,
+		"-- comment\nREPLACE INTO t VALUES (1)":       StatementInsert,
+		"UPDATE t SET a = 1":                          StatementUpdate,
+		"DELETE FROM t":                               StatementDelete,
+		"CREATE TABLE t (id INT)":                     StatementDDL,
+		"TRUNCATE t":                                  StatementDDL,
+		"SET TIME ZONE 'UTC'":                         StatementOther,
+		"":                                            StatementOther,
+		"/* unterminated":                             StatementOther,
+		"WITH x AS (SELECT 1) SELECT * FROM x":        StatementSelect,
+		"WITH x AS (DELETE FROM t RETURNING id) INSERT INTO u SELECT id FROM x": StatementInsert,
+		"WITH x AS (SELECT ')') UPDATE t SET a = 1":                             StatementUpdate,
+	} {
+		if actual := ClassifyStatement(query); actual != expected {
+			t.Fatal("ClassifyStatement: unexpected type", query, actual)
+		}
+	}
+}
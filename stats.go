@@ -0,0 +1,191 @@
+package mssqlx
+
+import (
+	"database/sql"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// ErrorRateWindow is rolling window of error rate of nodes
+	ErrorRateWindow = time.Minute
+
+	errorRateBuckets = 6
+)
+
+// counters of a node
+type nodeStats struct {
+	queries uint64
+	errors  uint64
+
+	// queries by statement kind
+	statements [len(statementTypes)]uint64
+
+	// rolling counters of last ErrorRateWindow, bucket of slot i counts slot numbers i, i+errorRateBuckets, ...
+	recent [errorRateBuckets]rateBucket
+}
+
+type rateBucket struct {
+	slot     int64
+	queries  uint64
+	errors   uint64
+	timeouts uint64
+}
+
+func (s *nodeStats) done(query string, err error) {
+	s.doneAt(time.Now(), query, err)
+}
+
+func (s *nodeStats) doneAt(now time.Time, query string, err error) {
+	if s != nil {
+		failed := err != nil && err != sql.ErrNoRows
+
+		atomic.AddUint64(&s.queries, 1)
+		atomic.AddUint64(&s.statements[statementKind(query)], 1)
+		if failed {
+			atomic.AddUint64(&s.errors, 1)
+		}
+
+		slot := rateSlot(now)
+		b := &s.recent[slot%errorRateBuckets]
+		if old := atomic.LoadInt64(&b.slot); old != slot && atomic.CompareAndSwapInt64(&b.slot, old, slot) {
+			atomic.StoreUint64(&b.queries, 0)
+			atomic.StoreUint64(&b.errors, 0)
+			atomic.StoreUint64(&b.timeouts, 0)
+		}
+
+		atomic.AddUint64(&b.queries, 1)
+		if failed {
+			atomic.AddUint64(&b.errors, 1)
+			if isTimeoutError(err) {
+				atomic.AddUint64(&b.timeouts, 1)
+			}
+		}
+	}
+}
+
+func (s *nodeStats) load() (queries, errors uint64) {
+	if s != nil {
+		queries, errors = atomic.LoadUint64(&s.queries), atomic.LoadUint64(&s.errors)
+	}
+	return
+}
+
+// statementCounts returns number of queries by statement type, nil if none
+func (s *nodeStats) statementCounts() (counts map[StatementType]uint64) {
+	if s == nil {
+		return
+	}
+
+	for kind := range s.statements {
+		if n := atomic.LoadUint64(&s.statements[kind]); n > 0 {
+			if counts == nil {
+				counts = make(map[StatementType]uint64, len(statementTypes))
+			}
+			counts[statementTypes[kind]] = n
+		}
+	}
+	return
+}
+
+// errorRate returns ratio of failed queries within ErrorRateWindow before now
+func (s *nodeStats) errorRate(now time.Time) float64 {
+	queries, errors, _ := s.recentCounts(now)
+	return ratio(errors, queries)
+}
+
+// timeoutRate returns ratio of timed out queries within ErrorRateWindow before now and number of queries
+func (s *nodeStats) timeoutRate(now time.Time) (float64, uint64) {
+	queries, _, timeouts := s.recentCounts(now)
+	return ratio(timeouts, queries), queries
+}
+
+// recentCounts sums rolling counters within ErrorRateWindow before now
+func (s *nodeStats) recentCounts(now time.Time) (queries, errors, timeouts uint64) {
+	if s == nil {
+		return
+	}
+
+	current := rateSlot(now)
+	for i := range s.recent {
+		b := &s.recent[i]
+		if slot := atomic.LoadInt64(&b.slot); slot > current-errorRateBuckets && slot <= current {
+			queries += atomic.LoadUint64(&b.queries)
+			errors += atomic.LoadUint64(&b.errors)
+			timeouts += atomic.LoadUint64(&b.timeouts)
+		}
+	}
+	return
+}
+
+func ratio(n, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+func rateSlot(t time.Time) int64 {
+	return t.UnixNano() / int64(ErrorRateWindow/errorRateBuckets)
+}
+
+// NodeStatus is status of a node.
+type NodeStatus struct {
+	Name    string `json:"name"`
+	Role    Role   `json:"role"`
+	Healthy bool   `json:"healthy"`
+	Queries uint64 `json:"queries"`
+	Errors  uint64 `json:"errors"`
+	Weight  int    `json:"weight"`
+
+	// ErrorRate ratio of failed queries within ErrorRateWindow, regardless of whether node is in rotation
+	ErrorRate float64 `json:"error_rate"`
+
+	// Statements number of queries by statement type, i.e read vs write mix
+	Statements map[StatementType]uint64 `json:"statements,omitempty"`
+
+	// TimeoutRate ratio of queries timed out within ErrorRateWindow, see SetTimeoutEjection
+	TimeoutRate float64 `json:"timeout_rate"`
+
+	// ClockSkew is node clock minus client clock measured by MonitorClockSkew, ClockSkewAlert tells
+	// whether it exceeds threshold set by SetClockSkewThreshold
+	ClockSkew      time.Duration `json:"clock_skew,omitempty"`
+	ClockSkewAlert bool          `json:"clock_skew_alert,omitempty"`
+
+	// Flavor of mysql compatible node, once detected
+	Flavor ServerFlavor `json:"flavor,omitempty"`
+
+	// Maintenance tells whether node is in maintenance window set by MarkNodeMaintenance
+	Maintenance bool `json:"maintenance,omitempty"`
+}
+
+// ClusterStatus is status of all nodes.
+type ClusterStatus struct {
+	DriverName string       `json:"driver_name"`
+	Nodes      []NodeStatus `json:"nodes"`
+	Routing    RoutingStats `json:"routing"`
+}
+
+// Status returns status of all master-slave nodes: health, query counters and rolling error rates.
+func (dbs *DBs) Status() ClusterStatus {
+	nodes := make([]NodeStatus, 0, len(dbs.allNodes()))
+	threshold, now := dbs.getClockSkewThreshold(), time.Now()
+	for _, w := range dbs.allNodes() {
+		if w != nil {
+			role := w.getRole()
+			target, _ := dbs.getBalancer(role)
+
+			st := NodeStatus{Name: w.getName(), Role: role, Healthy: target != nil && target.dbs.contains(w), Weight: w.getWeight()}
+			st.Queries, st.Errors = w.stats.load()
+			st.Statements = w.stats.statementCounts()
+			st.ErrorRate = w.stats.errorRate(now)
+			st.TimeoutRate, _ = w.stats.timeoutRate(now)
+			st.ClockSkew, _ = w.getClockSkew()
+			st.ClockSkewAlert = w.isClockSkewed(threshold)
+			st.Flavor = w.detectedFlavor()
+			st.Maintenance = w.inMaintenance()
+			nodes = append(nodes, st)
+		}
+	}
+	return ClusterStatus{DriverName: dbs.driverName, Nodes: nodes, Routing: dbs.RoutingStats()}
+}
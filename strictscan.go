@@ -0,0 +1,112 @@
+package mssqlx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+// StrictScanMode tells how Select/Get react when destination struct has db-tagged
+// fields which result set never provided, i.e typos in column aliases.
+type StrictScanMode int32
+
+const (
+	// StrictScanOff does not check unused fields. This is default.
+	StrictScanOff StrictScanMode = iota
+
+	// StrictScanLog logs a warning with unused fields.
+	StrictScanLog
+
+	// StrictScanError returns UnusedFieldsError.
+	StrictScanError
+)
+
+// UnusedFieldsError is returned by Select/Get in StrictScanError mode when destination
+// struct has db-tagged fields which result set never provided.
+type UnusedFieldsError struct {
+	// Node name which served the query
+	Node string
+
+	// Query executed
+	Query string
+
+	// Dest type of destination
+	Dest string
+
+	// Fields not provided by result set
+	Fields []string
+}
+
+func (e *UnusedFieldsError) Error() string {
+	return "fields " + strings.Join(e.Fields, ", ") + " of " + e.Dest + " are not provided by result set (node: " + e.Node + ")"
+}
+
+func _setStrictScan(target []*wrapper, mode StrictScanMode) {
+	for _, db := range target {
+		if db != nil {
+			atomic.StoreInt32(&db.strictScan, int32(mode))
+		}
+	}
+}
+
+// SetStrictScan sets strict scan mode for all master-slave databases. Recommended for development.
+func (dbs *DBs) SetStrictScan(mode StrictScanMode) {
+	_setStrictScan(dbs.allNodes(), mode)
+}
+
+// unusedFields returns db-tagged leaf fields of t not provided by columns
+func unusedFields(w *wrapper, t reflect.Type, columns []string) (unused []string) {
+	tm := w.db.Mapper.TypeMap(t)
+
+	provided := make(map[string]bool, len(columns))
+	for _, traversal := range getScanPlan(w.db.Mapper, t, isPostgres(w.db.DriverName()), columns).traversals {
+		for fi := tm.GetByTraversal(traversal); fi != nil; fi = fi.Parent {
+			provided[fi.Path] = true
+		}
+	}
+
+	for _, fi := range tm.Index {
+		if fi.Embedded || provided[fi.Path] {
+			continue
+		}
+
+		if tag := fi.Field.Tag.Get("db"); tag == "" || tag == "-" {
+			continue
+		}
+
+		// nested struct is provided through its fields
+		if ft := fi.Field.Type; len(fi.Children) > 0 && ft != timeType && !reflect.PtrTo(ft).Implements(scannerType) {
+			continue
+		}
+
+		unused = append(unused, fi.Path)
+	}
+
+	return
+}
+
+// checkScan diagnoses scan error, or checks unused fields of destination in strict scan mode
+func (w *wrapper) checkScan(err error, query string, dest interface{}, t reflect.Type, columns []string) error {
+	if err != nil {
+		return w.diagnoseScan(err, query, dest, t, columns)
+	}
+
+	mode := StrictScanMode(atomic.LoadInt32(&w.strictScan))
+	if mode == StrictScanOff {
+		return nil
+	}
+
+	unused := unusedFields(w, t, columns)
+	if len(unused) == 0 {
+		return nil
+	}
+
+	e := &UnusedFieldsError{Node: w.getName(), Query: query, Dest: fmt.Sprintf("%T", dest), Fields: unused}
+	if mode == StrictScanError {
+		return e
+	}
+
+	logEntry(LogLevelWarn, e.Error(), nodeFields(w, LogField{Key: LogFieldQuery, Value: query})...)
+	return nil
+}
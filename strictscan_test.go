@@ -0,0 +1,58 @@
+package mssqlx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStrictScan(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+
+	type person struct {
+		FirstName string `db:"first_name"`
+		Email     string `db:"email"`
+		Untagged  string
+	}
+
+	if _, err := dbs.Exec("CREATE TABLE person (first_name TEXT, email TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.Exec("INSERT INTO person VALUES ('a', 'b')"); err != nil {
+		t.Fatal(err)
+	}
+
+	var people []person
+	if err := dbs.SelectOnMaster(&people, "SELECT first_name FROM person"); err != nil {
+		t.Fatal("StrictScan: should be off by default", err)
+	}
+
+	dbs.SetStrictScan(StrictScanError)
+
+	err := dbs.SelectOnMaster(&people, "SELECT first_name, email AS emial_typo FROM person")
+	if _, ok := err.(*ColumnMismatchError); !ok {
+		t.Fatal("StrictScan: column mismatch should be reported first", err)
+	}
+
+	var p person
+	err = dbs.GetOnMaster(&p, "SELECT first_name FROM person")
+	if e, ok := err.(*UnusedFieldsError); !ok || len(e.Fields) != 1 || e.Fields[0] != "email" {
+		t.Fatal("StrictScan: unused field should be reported", err)
+	}
+
+	if err = dbs.GetOnMaster(&p, "SELECT * FROM person"); err != nil {
+		t.Fatal(err)
+	}
+
+	defer SetLogger(stderrLogger{})
+	c := &captureLogger{}
+	SetLogger(c)
+
+	dbs.SetStrictScan(StrictScanLog)
+	if err = dbs.SelectOnMaster(&people, "SELECT email FROM person"); err != nil {
+		t.Fatal("StrictScan: unused fields should be logged only", err)
+	}
+	if len(c.entries) != 1 || !strings.Contains(c.entries[0], "fields first_name of") {
+		t.Fatal("StrictScan: unexpected logs", c.entries)
+	}
+}
@@ -0,0 +1,275 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// db tag options of generated statements.
+const (
+	// skip field when it holds zero value, i.e `db:"name,omitempty"`
+	omitemptyTagOption = "omitempty"
+
+	// never write field, i.e `db:"created_at,readonly"`
+	readonlyTagOption = "readonly"
+
+	// omit field on insert so that database default applies, i.e `db:"id,default"`
+	defaultTagOption = "default"
+)
+
+var (
+	// ErrInvalidStruct arg must be a struct or a non-nil pointer to struct
+	ErrInvalidStruct = errors.New("Arg must be a struct or a non-nil pointer to struct")
+
+	// ErrNoColumns there is no column to write
+	ErrNoColumns = errors.New("No column to write")
+
+	// ErrNoKeyColumns key columns are required to match rows
+	ErrNoKeyColumns = errors.New("Key columns are required")
+
+	// ErrNoVersionColumn version column is required for optimistic locking
+	ErrNoVersionColumn = errors.New("Version column is required")
+
+	// ErrStaleVersion row was modified concurrently, no row with expected version is updated
+	ErrStaleVersion = errors.New("Stale version: row was modified concurrently")
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (dbs *DBs) mapper() *reflectx.Mapper {
+	for _, w := range dbs.allNodes() {
+		if w != nil && w.db != nil {
+			return w.db.Mapper
+		}
+	}
+	return reflectx.NewMapperFunc("db", sqlx.NameMapper)
+}
+
+func hasTagOption(fi *reflectx.FieldInfo, option string) bool {
+	_, ok := fi.Options[option]
+	return ok
+}
+
+// structColumn is a writable column of struct arg
+type structColumn struct {
+	name  string
+	field *reflectx.FieldInfo
+}
+
+// structColumns returns top-level columns of struct arg, respecting readonly/omitempty options.
+func structColumns(m *reflectx.Mapper, arg interface{}) ([]structColumn, error) {
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, ErrInvalidStruct
+	}
+
+	fields := writableColumns(m, v.Type())
+
+	columns := fields[:0]
+	for _, c := range fields {
+		f := reflectx.FieldByIndexesReadOnly(v, c.field.Index)
+		if hasTagOption(c.field, omitemptyTagOption) && (!f.IsValid() || f.IsZero()) {
+			continue
+		}
+		columns = append(columns, c)
+	}
+
+	return columns, nil
+}
+
+// writableColumns returns top-level columns of struct type t, except readonly ones.
+func writableColumns(m *reflectx.Mapper, t reflect.Type) []structColumn {
+	columns := typeColumns(m, t)
+
+	writable := columns[:0]
+	for _, c := range columns {
+		if !hasTagOption(c.field, readonlyTagOption) {
+			writable = append(writable, c)
+		}
+	}
+	return writable
+}
+
+// typeColumns returns all top-level columns of struct type t.
+func typeColumns(m *reflectx.Mapper, t reflect.Type) []structColumn {
+	tm := m.TypeMap(t)
+
+	columns := make([]structColumn, 0, len(tm.Index))
+	for _, fi := range tm.Index {
+		if fi.Embedded || fi.Name == "" || strings.Contains(fi.Path, ".") {
+			continue
+		}
+
+		t := fi.Field.Type
+		if len(fi.Children) > 0 && t != timeType && !t.Implements(valuerType) && !reflect.PtrTo(t).Implements(valuerType) {
+			continue // nested struct, not a column
+		}
+
+		columns = append(columns, structColumn{name: fi.Path, field: fi})
+	}
+
+	return columns
+}
+
+// insertStructQuery generates named insert statement of struct arg.
+func insertStructQuery(m *reflectx.Mapper, table string, arg interface{}) (string, error) {
+	columns, err := structColumns(m, arg)
+	if err != nil {
+		return "", err
+	}
+
+	names, params := make([]string, 0, len(columns)), make([]string, 0, len(columns))
+	for _, c := range columns {
+		if !hasTagOption(c.field, defaultTagOption) {
+			names = append(names, c.name)
+			params = append(params, ":"+c.name)
+		}
+	}
+
+	if len(names) == 0 {
+		return "", ErrNoColumns
+	}
+
+	return "INSERT INTO " + table + " (" + strings.Join(names, ", ") + ") VALUES (" + strings.Join(params, ", ") + ")", nil
+}
+
+// InsertStruct inserts struct arg into table on masters. Columns are generated from db tags
+// of arg's fields, with options:
+//
+//	`db:"name,omitempty"` skips field holding zero value.
+//	`db:"name,readonly"` never writes field.
+//	`db:"name,default"` omits field so that database default applies.
+func (dbs *DBs) InsertStruct(ctx context.Context, table string, arg interface{}) (sql.Result, error) {
+	query, err := insertStructQuery(dbs.mapper(), table, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return _namedExec(ctx, dbs.masters, query, arg)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// updateStructQuery generates named update statement of struct arg, matching rows by keys.
+// If versionColumn is not empty, version is checked and incremented.
+func updateStructQuery(m *reflectx.Mapper, table string, arg interface{}, versionColumn string, keys []string) (string, error) {
+	if len(keys) == 0 {
+		return "", ErrNoKeyColumns
+	}
+
+	columns, err := structColumns(m, arg)
+	if err != nil {
+		return "", err
+	}
+
+	sets := make([]string, 0, len(columns)+1)
+	for _, c := range columns {
+		if c.name != versionColumn && !containsString(keys, c.name) {
+			sets = append(sets, c.name+" = :"+c.name)
+		}
+	}
+
+	if versionColumn != "" {
+		sets = append(sets, versionColumn+" = "+versionColumn+" + 1")
+	}
+
+	if len(sets) == 0 {
+		return "", ErrNoColumns
+	}
+
+	conds := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		conds = append(conds, key+" = :"+key)
+	}
+	if versionColumn != "" {
+		conds = append(conds, versionColumn+" = :"+versionColumn)
+	}
+
+	return "UPDATE " + table + " SET " + strings.Join(sets, ", ") + " WHERE " + strings.Join(conds, " AND "), nil
+}
+
+// UpdateStruct updates rows of table matching key columns with struct arg on masters.
+// Columns are generated from db tags of arg's fields, respecting omitempty and readonly options.
+func (dbs *DBs) UpdateStruct(ctx context.Context, table string, arg interface{}, keys ...string) (sql.Result, error) {
+	query, err := updateStructQuery(dbs.mapper(), table, arg, "", keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return _namedExec(ctx, dbs.masters, query, arg)
+}
+
+// UpdateStructVersioned is UpdateStruct with optimistic locking: row is updated only if its
+// versionColumn still equals arg's version, which is then incremented. ErrStaleVersion is
+// returned when no row is affected, meaning row was modified (or deleted) concurrently.
+//
+// If arg is a pointer, its version field is incremented on success.
+func (dbs *DBs) UpdateStructVersioned(ctx context.Context, table string, arg interface{}, versionColumn string, keys ...string) (sql.Result, error) {
+	if versionColumn == "" {
+		return nil, ErrNoVersionColumn
+	}
+
+	m := dbs.mapper()
+
+	query, err := updateStructQuery(m, table, arg, versionColumn, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	res, err := _namedExec(ctx, dbs.masters, query, arg)
+	if err != nil {
+		return res, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return res, err
+	}
+	if affected == 0 {
+		return res, ErrStaleVersion
+	}
+
+	// keep arg in sync with row
+	if v := reflect.ValueOf(arg); v.Kind() == reflect.Ptr {
+		if f := m.FieldByName(v, versionColumn); f.IsValid() && f.CanSet() {
+			switch f.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				f.SetInt(f.Int() + 1)
+
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				f.SetUint(f.Uint() + 1)
+			}
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,121 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+type structStmtBase struct {
+	CreatedAt time.Time `db:"created_at,readonly"`
+}
+
+type structStmtItem struct {
+	structStmtBase
+	ID    int64  `db:"id,default"`
+	Name  string `db:"name"`
+	Note  string `db:"note,omitempty"`
+	Other struct {
+		A int `db:"a"`
+	} `db:"other"`
+	Ignored string `db:"-"`
+}
+
+func TestInsertStructQuery(t *testing.T) {
+	m := reflectx.NewMapperFunc("db", sqlx.NameMapper)
+
+	if _, err := insertStructQuery(m, "item", 1); err != ErrInvalidStruct {
+		t.Fatal("InsertStruct: arg check fail")
+	}
+
+	q, err := insertStructQuery(m, "item", &structStmtItem{Name: "a"})
+	if err != nil || q != "INSERT INTO item (name) VALUES (:name)" {
+		t.Fatal("InsertStruct: unexpected query", q, err)
+	}
+
+	if q, _ = insertStructQuery(m, "item", structStmtItem{Note: "b"}); q != "INSERT INTO item (name, note) VALUES (:name, :note)" {
+		t.Fatal("InsertStruct: unexpected query", q)
+	}
+
+	type readonly struct {
+		ID int64 `db:"id,readonly"`
+	}
+	if _, err = insertStructQuery(m, "item", readonly{}); err != ErrNoColumns {
+		t.Fatal("InsertStruct: no column check fail")
+	}
+}
+
+func TestInsertStruct(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+
+	if _, err := dbs.Exec("CREATE TABLE item (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, note TEXT DEFAULT 'none', created_at DATETIME DEFAULT CURRENT_TIMESTAMP)"); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := dbs.InsertStruct(context.Background(), "item", &structStmtItem{ID: 100, Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := res.LastInsertId(); id != 1 {
+		t.Fatal("InsertStruct: id should be generated by database", id)
+	}
+
+	var note string
+	if err = dbs.GetOnMaster(&note, "SELECT note FROM item WHERE id = 1"); err != nil || note != "none" {
+		t.Fatal("InsertStruct: omitted field should get default", note, err)
+	}
+}
+
+func TestUpdateStructVersioned(t *testing.T) {
+	m := reflectx.NewMapperFunc("db", sqlx.NameMapper)
+
+	type account struct {
+		ID      int64  `db:"id"`
+		Name    string `db:"name"`
+		Version int    `db:"version"`
+	}
+
+	if _, err := updateStructQuery(m, "account", account{}, "", nil); err != ErrNoKeyColumns {
+		t.Fatal("UpdateStruct: key check fail")
+	}
+	if q, _ := updateStructQuery(m, "account", account{}, "version", []string{"id"}); q != "UPDATE account SET name = :name, version = version + 1 WHERE id = :id AND version = :version" {
+		t.Fatal("UpdateStruct: unexpected query", q)
+	}
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+
+	if _, err := dbs.Exec("CREATE TABLE account (id INTEGER PRIMARY KEY, name TEXT, version INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.Exec("INSERT INTO account VALUES (1, 'a', 0)"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := dbs.UpdateStructVersioned(ctx, "account", &account{}, ""); err != ErrNoVersionColumn {
+		t.Fatal("UpdateStructVersioned: version column check fail")
+	}
+
+	first, second := &account{ID: 1, Name: "b"}, account{ID: 1, Name: "c"}
+	if _, err := dbs.UpdateStructVersioned(ctx, "account", first, "version", "id"); err != nil || first.Version != 1 {
+		t.Fatal("UpdateStructVersioned: update should succeed", first.Version, err)
+	}
+	if _, err := dbs.UpdateStructVersioned(ctx, "account", second, "version", "id"); err != ErrStaleVersion {
+		t.Fatal("UpdateStructVersioned: stale version should be detected", err)
+	}
+
+	var got account
+	if err := dbs.GetOnMaster(&got, "SELECT * FROM account WHERE id = 1"); err != nil || got.Name != "b" || got.Version != 1 {
+		t.Fatal("UpdateStructVersioned: unexpected row", got, err)
+	}
+
+	first.Name = "d"
+	if _, err := dbs.UpdateStruct(ctx, "account", first, "id"); err != nil {
+		t.Fatal(err)
+	}
+}
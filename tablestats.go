@@ -0,0 +1,233 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrTableStatsNotSupported table statistics advisory is not supported by driver
+	ErrTableStatsNotSupported = errors.New("Table statistics advisory is only supported by mysql and postgres drivers")
+)
+
+const (
+	// DefaultTableStatsCheckPeriod default period of sampling table statistics
+	DefaultTableStatsCheckPeriod = time.Hour
+
+	// DefaultDeadRatio default ratio of dead tuples (postgres) or free space (mysql) above which table is reported
+	DefaultDeadRatio = 0.2
+
+	// DefaultTableStatsMinRows default number of rows below which table is not reported
+	DefaultTableStatsMinRows = 1000
+
+	// LogFieldTable log field of table name
+	LogFieldTable = "table"
+)
+
+// TableAdviceReason is why a table is reported.
+type TableAdviceReason string
+
+const (
+	// AdviceDeadTuples table has high ratio of dead tuples (postgres) or free space (mysql)
+	AdviceDeadTuples TableAdviceReason = "dead_tuples"
+
+	// AdviceMissingStats table has never been analyzed
+	AdviceMissingStats TableAdviceReason = "missing_stats"
+)
+
+// TableAdvice reports a table which should be maintained.
+type TableAdvice struct {
+	Table  string            `json:"table"`
+	Reason TableAdviceReason `json:"reason"`
+
+	// Rows estimated number of live rows
+	Rows int64 `json:"rows"`
+
+	// DeadRatio dead tuples over all tuples on postgres, free space over allocated space on mysql
+	DeadRatio float64 `json:"dead_ratio"`
+
+	// Suggestion statement to run, i.e VACUUM, ANALYZE, OPTIMIZE TABLE
+	Suggestion string `json:"suggestion"`
+}
+
+// TableStatsOptions configures table statistics advisory.
+type TableStatsOptions struct {
+	// Role of node which statistics are sampled from. Default is RoleSlave.
+	//
+	// Postgres collects table statistics per server, standbys do not see dead tuples of primary:
+	// use RoleMaster for postgres.
+	Role Role
+
+	// Period of sampling by MonitorTableStats. Default is DefaultTableStatsCheckPeriod.
+	Period time.Duration
+
+	// DeadRatio above which table is reported. Default is DefaultDeadRatio.
+	DeadRatio float64
+
+	// MinRows below which table is not reported. Default is DefaultTableStatsMinRows.
+	MinRows int64
+
+	// OnAdvice is called with advices of every sampling by MonitorTableStats, besides logging them.
+	OnAdvice func([]TableAdvice)
+}
+
+// tableStats is sampled statistics of a table
+type tableStats struct {
+	Table    string `db:"table_name"`
+	Rows     int64  `db:"live_rows"`
+	Dead     int64  `db:"dead"`  // dead tuples on postgres, free bytes on mysql
+	Total    int64  `db:"total"` // all tuples on postgres, allocated bytes on mysql
+	Analyzed bool   `db:"analyzed"`
+}
+
+// tableStatsQuery returns query sampling tableStats of user tables
+func tableStatsQuery(driverName string) string {
+	switch driverName {
+	case "postgres", "pgx":
+		return "SELECT schemaname || '.' || relname AS table_name, n_live_tup AS live_rows, n_dead_tup AS dead, " +
+			"n_live_tup + n_dead_tup AS total, COALESCE(last_analyze, last_autoanalyze) IS NOT NULL AS analyzed " +
+			"FROM pg_stat_user_tables"
+
+	case "mysql":
+		return "SELECT CONCAT(t.TABLE_SCHEMA, '.', t.TABLE_NAME) AS table_name, COALESCE(t.TABLE_ROWS, 0) AS live_rows, " +
+			"COALESCE(t.DATA_FREE, 0) AS dead, COALESCE(t.DATA_LENGTH + t.INDEX_LENGTH + t.DATA_FREE, 0) AS total, " +
+			"s.table_name IS NOT NULL AS analyzed FROM information_schema.TABLES t " +
+			"LEFT JOIN mysql.innodb_table_stats s ON s.database_name = t.TABLE_SCHEMA AND s.table_name = t.TABLE_NAME " +
+			"WHERE t.TABLE_TYPE = 'BASE TABLE' AND t.ENGINE = 'InnoDB' " +
+			"AND t.TABLE_SCHEMA NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys')"
+	}
+	return ""
+}
+
+func (opts *TableStatsOptions) normalize() {
+	if opts.Role == "" {
+		opts.Role = RoleSlave
+	}
+	if opts.Period <= 0 {
+		opts.Period = DefaultTableStatsCheckPeriod
+	}
+	if opts.DeadRatio <= 0 {
+		opts.DeadRatio = DefaultDeadRatio
+	}
+	if opts.MinRows <= 0 {
+		opts.MinRows = DefaultTableStatsMinRows
+	}
+}
+
+// adviseTables returns advices of sampled tables
+func adviseTables(driverName string, stats []tableStats, opts TableStatsOptions) (advices []TableAdvice) {
+	vacuum, analyze := "VACUUM", "ANALYZE"
+	if driverName == "mysql" {
+		vacuum, analyze = "OPTIMIZE TABLE", "ANALYZE TABLE"
+	}
+
+	for _, s := range stats {
+		if s.Rows < opts.MinRows {
+			continue
+		}
+
+		advice := TableAdvice{Table: s.Table, Rows: s.Rows}
+		if s.Total > 0 {
+			advice.DeadRatio = float64(s.Dead) / float64(s.Total)
+		}
+
+		switch {
+		case !s.Analyzed:
+			advice.Reason, advice.Suggestion = AdviceMissingStats, analyze
+
+		case advice.DeadRatio > opts.DeadRatio:
+			advice.Reason, advice.Suggestion = AdviceDeadTuples, vacuum
+
+		default:
+			continue
+		}
+
+		advices = append(advices, advice)
+	}
+	return
+}
+
+// TableAdvices samples table statistics from a healthy node of opts.Role, returns tables with high
+// dead tuple ratio or missing statistics.
+func (dbs *DBs) TableAdvices(ctx context.Context, opts TableStatsOptions) ([]TableAdvice, error) {
+	query := tableStatsQuery(dbs.driverName)
+	if query == "" {
+		return nil, ErrTableStatsNotSupported
+	}
+	opts.normalize()
+
+	target, err := dbs.getBalancer(opts.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	queryInfoFromContext(ctx).start()
+	w, err := getDBFromBalancer(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []tableStats
+	if err = w.db.SelectContext(ctx, &stats, query); err != nil {
+		return nil, err
+	}
+
+	return adviseTables(dbs.driverName, stats, opts), nil
+}
+
+// MonitorTableStats samples table statistics every opts.Period until ctx is done. Tables with high dead tuple
+// ratio or missing statistics are logged as warnings and passed to opts.OnAdvice, giving early warning
+// of tables which should be vacuumed, optimized or analyzed.
+func (dbs *DBs) MonitorTableStats(ctx context.Context, opts TableStatsOptions) error {
+	if tableStatsQuery(dbs.driverName) == "" {
+		return ErrTableStatsNotSupported
+	}
+	opts.normalize()
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	go func() {
+		ticker := time.NewTicker(opts.Period)
+		defer ticker.Stop()
+
+		for {
+			dbs.reportTableAdvices(ctx, opts)
+
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (dbs *DBs) reportTableAdvices(ctx context.Context, opts TableStatsOptions) {
+	advices, err := dbs.TableAdvices(ctx, opts)
+	if err != nil {
+		if ctx.Err() == nil {
+			reportError("sample table statistics", err)
+		}
+		return
+	}
+
+	for _, a := range advices {
+		logEntry(LogLevelWarn, "table needs "+a.Suggestion, LogField{Key: LogFieldTable, Value: a.Table},
+			LogField{Key: LogFieldReason, Value: a.Reason}, LogField{Key: LogFieldRows, Value: a.Rows},
+			LogField{Key: "dead_ratio", Value: a.DeadRatio})
+	}
+
+	if opts.OnAdvice != nil {
+		opts.OnAdvice(advices)
+	}
+}
@@ -0,0 +1,51 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTableAdvices(t *testing.T) {
+	opts := TableStatsOptions{}
+	opts.normalize()
+	if opts.Role != RoleSlave || opts.DeadRatio != DefaultDeadRatio || opts.MinRows != DefaultTableStatsMinRows {
+		t.Fatal("TableAdvices: defaults fail", opts)
+	}
+
+	stats := []tableStats{
+		{Table: "public.small", Rows: 10, Dead: 100, Total: 110},
+		{Table: "public.bloated", Rows: 5000, Dead: 5000, Total: 10000, Analyzed: true},
+		{Table: "public.fresh", Rows: 5000, Dead: 10, Total: 5010, Analyzed: true},
+		{Table: "public.new", Rows: 2000, Total: 2000},
+	}
+
+	advices := adviseTables("postgres", stats, opts)
+	if len(advices) != 2 {
+		t.Fatal("TableAdvices: postgres advices fail", advices)
+	}
+	if a := advices[0]; a.Table != "public.bloated" || a.Reason != AdviceDeadTuples || a.Suggestion != "VACUUM" || a.DeadRatio != 0.5 {
+		t.Fatal("TableAdvices: dead tuples advice fail", a)
+	}
+	if a := advices[1]; a.Table != "public.new" || a.Reason != AdviceMissingStats || a.Suggestion != "ANALYZE" {
+		t.Fatal("TableAdvices: missing stats advice fail", a)
+	}
+
+	if advices = adviseTables("mysql", stats, opts); advices[0].Suggestion != "OPTIMIZE TABLE" || advices[1].Suggestion != "ANALYZE TABLE" {
+		t.Fatal("TableAdvices: mysql advices fail", advices)
+	}
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil)
+	defer dbs.Destroy()
+	if _, err := dbs.TableAdvices(context.Background(), opts); err != ErrTableStatsNotSupported {
+		t.Fatal("TableAdvices: unsupported driver should fail", err)
+	}
+	if err := dbs.MonitorTableStats(context.Background(), opts); err != ErrTableStatsNotSupported {
+		t.Fatal("TableAdvices: unsupported driver should fail", err)
+	}
+
+	if TestWMysql {
+		if _, err := myDBs.TableAdvices(context.Background(), TableStatsOptions{Role: RoleMaster}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
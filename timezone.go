@@ -0,0 +1,91 @@
+package mssqlx
+
+import (
+	"reflect"
+	"time"
+)
+
+var timePtrType = reflect.TypeOf(&time.Time{})
+
+// TimeOptions normalizes time values, eliminating parseTime/timezone mismatches between nodes
+// (i.e MySQL and Postgres ones).
+//
+// Pass it as an arg of ConnectMasterSlaves.
+type TimeOptions struct {
+	// UTC converts time.Time args (including fields of named struct args) to UTC before binding.
+	UTC bool
+
+	// Location, if set, time.Time values scanned by Select/Get are converted into.
+	Location *time.Location
+}
+
+func (opts *TimeOptions) utc() bool {
+	return opts != nil && opts.UTC
+}
+
+func toUTC(arg interface{}) interface{} {
+	switch v := arg.(type) {
+	case time.Time:
+		return v.UTC()
+
+	case *time.Time:
+		if v != nil {
+			return v.UTC()
+		}
+	}
+	return arg
+}
+
+// acquireArgs converts time args to UTC if configured. Converted args are taken from pool,
+// returned buffer (might be nil) must be released with putValues once query was sent to driver.
+func (w *wrapper) acquireArgs(args []interface{}) ([]interface{}, *[]interface{}) {
+	if !w.timeOpts.utc() || len(args) == 0 {
+		return args, nil
+	}
+
+	p := getValues(len(args))
+	normalized := *p
+	for i := range args {
+		normalized[i] = toUTC(args[i])
+	}
+	return normalized, p
+}
+
+// localize converts scanned times of dest into configured location, if scanning succeeded
+func (w *wrapper) localize(dest interface{}, err error) error {
+	if err == nil && w.timeOpts != nil && w.timeOpts.Location != nil {
+		localizeTimes(reflect.ValueOf(dest), w.timeOpts.Location)
+	}
+	return err
+}
+
+func localizeTimes(v reflect.Value, loc *time.Location) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			localizeTimes(v.Elem(), loc)
+		}
+
+	case reflect.Struct:
+		if v.Type() == timeType {
+			if t := v.Interface().(time.Time); v.CanSet() && !t.IsZero() {
+				v.Set(reflect.ValueOf(t.In(loc)))
+			}
+			return
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" { // exported
+				localizeTimes(v.Field(i), loc)
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		switch v.Type().Elem().Kind() {
+		case reflect.Ptr, reflect.Struct, reflect.Slice, reflect.Array:
+			for i := 0; i < v.Len(); i++ {
+				localizeTimes(v.Index(i), loc)
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package mssqlx
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeOptions(t *testing.T) {
+	loc := time.FixedZone("ICT", 7*3600)
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, nil, TimeOptions{UTC: true, Location: loc})
+	defer dbs.Destroy()
+
+	if _, err := dbs.Exec("CREATE TABLE event (id INTEGER, at DATETIME)"); err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2020, 1, 1, 7, 0, 0, 0, loc)
+	if _, err := dbs.Exec("INSERT INTO event VALUES (1, ?)", at); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.NamedExec("INSERT INTO event VALUES (:id, :at)", map[string]interface{}{"id": 2, "at": &at}); err != nil {
+		t.Fatal(err)
+	}
+
+	var raw []string
+	if err := dbs.SelectOnMaster(&raw, "SELECT CAST(at AS TEXT) FROM event ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range raw {
+		if !strings.HasPrefix(s, "2020-01-01 00:00:00") || !strings.HasSuffix(s, "+00:00") {
+			t.Fatal("TimeOptions: args should be bound in UTC", raw)
+		}
+	}
+
+	type event struct {
+		ID int       `db:"id"`
+		At time.Time `db:"at"`
+	}
+
+	var events []event
+	if err := dbs.SelectOnMaster(&events, "SELECT * FROM event ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].At.Location() != loc || !events[0].At.Equal(at) {
+		t.Fatal("TimeOptions: scanned times should be in configured location", events)
+	}
+
+	var e event
+	if err := dbs.GetOnMaster(&e, "SELECT * FROM event WHERE id = 2"); err != nil || e.At.Location() != loc {
+		t.Fatal("TimeOptions: scanned time should be in configured location", e, err)
+	}
+}
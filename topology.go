@@ -0,0 +1,210 @@
+package mssqlx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrInvalidTopology a node is given both master and slave roles
+	ErrInvalidTopology = errors.New("Node could not be both master and slave")
+)
+
+// Topology is the set of master and slave nodes, identified by name (i.e master-0, slave-1) or dsn.
+type Topology struct {
+	Masters []string `json:"masters"`
+	Slaves  []string `json:"slaves"`
+}
+
+func (c *balancer) setMembers(nodes []*wrapper) {
+	members := make(map[*wrapper]struct{}, len(nodes))
+	for _, w := range nodes {
+		if w != nil {
+			members[w] = struct{}{}
+		}
+	}
+	c.members.Store(members)
+	c.updateSingle(len(members))
+}
+
+// repoint balancer to nodes. Healthy ones serve traffic immediately, others are health checked.
+func (c *balancer) repoint(nodes []*wrapper, healthy map[*wrapper]bool) {
+	var checks []*wrapper
+
+	list := make([]*wrapper, 0, len(nodes))
+	for _, w := range nodes {
+		switch {
+		case w.isFenced():
+
+		case healthy[w]:
+			list = append(list, w)
+
+		case !c.isMember(w): // failing node which is not tracked by this balancer yet
+			checks = append(checks, w)
+		}
+	}
+
+	c.setMembers(nodes)
+	c.dbs.set(list)
+
+	for _, w := range checks {
+		go c.sendFailure(w)
+	}
+}
+
+// Topology returns current topology, with nodes named as in Status.
+func (dbs *DBs) Topology() (t Topology) {
+	t.Masters, t.Slaves = []string{}, []string{}
+	for _, w := range dbs.allNodes() {
+		if w != nil {
+			switch w.getRole() {
+			case RoleMaster:
+				t.Masters = append(t.Masters, w.getName())
+
+			case RoleSlave:
+				t.Slaves = append(t.Slaves, w.getName())
+			}
+		}
+	}
+	return
+}
+
+// renameNodes names nodes which changed role after their new role, keeping index if it is not taken
+func (dbs *DBs) renameNodes(changed map[*wrapper]bool) {
+	taken := make(map[string]bool, len(dbs.allNodes()))
+	for _, w := range dbs.allNodes() {
+		if w != nil && !changed[w] {
+			taken[w.getName()] = true
+		}
+	}
+
+	for _, w := range dbs.allNodes() {
+		if !changed[w] {
+			continue
+		}
+
+		name := w.getName()
+		if i := strings.LastIndexByte(name, '-'); i >= 0 {
+			name = string(w.getRole()) + name[i:]
+		}
+		for i := 0; name == "" || taken[name]; i++ {
+			name = nodeName(w.getRole(), i)
+		}
+
+		taken[name] = true
+		w.setName(name)
+	}
+}
+
+func (dbs *DBs) resolveNodes(ids []string, role Role, roles map[*wrapper]Role) ([]*wrapper, error) {
+	nodes := make([]*wrapper, 0, len(ids))
+	for _, id := range ids {
+		w := dbs.findNode(id)
+		if w == nil {
+			return nil, ErrNodeNotFound
+		}
+
+		if _, ok := roles[w]; ok {
+			return nil, ErrInvalidTopology
+		}
+		roles[w] = role
+
+		nodes = append(nodes, w)
+	}
+	return nodes, nil
+}
+
+// ApplyTopology atomically re-points master and slave balancers to given nodes, identified
+// by name (i.e master-0, slave-1) or dsn, without waiting for health checkers to notice
+// a failover. Nodes changing role are renamed after their new role (i.e master-0 demoted to slave becomes
+// slave-0, or slave-N if it is taken), so dsn is the stable identifier; nodes which are not listed are detached from traffic.
+//
+// Only nodes passed to ConnectMasterSlaves are accepted. With SlaveCredentials or ApplicationName, connections
+// of nodes changing role are recycled, so that new ones authenticate and are tagged as their new role. Intended to be called from
+// topology-change callbacks of Patroni, Orchestrator and the like. See TopologyHandler.
+func (dbs *DBs) ApplyTopology(masters, slaves []string) error {
+	roles := make(map[*wrapper]Role, len(dbs.allNodes()))
+
+	mNodes, err := dbs.resolveNodes(masters, RoleMaster, roles)
+	if err != nil {
+		return err
+	}
+
+	sNodes, err := dbs.resolveNodes(slaves, RoleSlave, roles)
+	if err != nil {
+		return err
+	}
+
+	dbs.topologyLock.Lock()
+	defer dbs.topologyLock.Unlock()
+
+	healthy := make(map[*wrapper]bool, len(dbs.allNodes()))
+	for _, w := range dbs.allNodes() {
+		if w != nil {
+			healthy[w] = dbs.masters.dbs.contains(w) || dbs.slaves.dbs.contains(w)
+		}
+	}
+
+	changed := make(map[*wrapper]bool)
+	for _, w := range dbs.allNodes() {
+		if w != nil && w.getRole() != roles[w] {
+			w.setRole(roles[w])
+			changed[w] = roles[w] != "" // detached nodes keep their names
+		}
+	}
+	dbs.renameNodes(changed)
+
+	for w := range changed {
+		logEntry(LogLevelInfo, "node role is changed", nodeFields(w)...)
+
+		// new connections authenticate as user of role
+		if dbs.opts.roleDSN() {
+			w.recycle(DefaultRecycleTimeout)
+		}
+	}
+
+	dbs.masters.repoint(mNodes, healthy)
+	dbs.slaves.repoint(sNodes, healthy)
+
+	return nil
+}
+
+type topologyHandler struct {
+	dbs *DBs
+}
+
+// TopologyHandler returns an http.Handler receiving topology-change webhooks.
+//
+// GET responds current Topology as JSON. POST/PUT with a Topology JSON body applies it by
+// ApplyTopology, responding 204 on success or 400 on invalid topology.
+func (dbs *DBs) TopologyHandler() http.Handler {
+	return &topologyHandler{dbs: dbs}
+}
+
+func (h *topologyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(h.dbs.Topology())
+
+	case http.MethodPost, http.MethodPut:
+		var t Topology
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := h.dbs.ApplyTopology(t.Masters, t.Slaves); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.Header().Set("Allow", "GET, POST, PUT")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,109 @@
+package mssqlx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestApplyTopology(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	if err := dbs.ApplyTopology([]string{"master-9"}, nil); err != ErrNodeNotFound {
+		t.Fatal("ApplyTopology: not found check fail")
+	}
+	if err := dbs.ApplyTopology([]string{"master-0"}, []string{"master-0"}); err != ErrInvalidTopology {
+		t.Fatal("ApplyTopology: duplicated node check fail")
+	}
+
+	// failover: slave-0 is promoted, nodes are renamed after their new roles
+	if err := dbs.ApplyTopology([]string{"slave-0"}, []string{"master-0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var info QueryInfo
+	if _, err := dbs.ExecContext(WithQueryInfo(context.Background(), &info), "SELECT 1"); err != nil || info.Node != "master-0" || info.Role != RoleMaster {
+		t.Fatal("ApplyTopology: writes should go to promoted node", info.Node, err)
+	}
+
+	status := dbs.Status()
+	if m, s := status.Nodes[0], status.Nodes[1]; m.Name != "slave-0" || m.Role != RoleSlave || !m.Healthy || s.Name != "master-0" || s.Role != RoleMaster || !s.Healthy {
+		t.Fatal("ApplyTopology: unexpected status", status)
+	}
+
+	// detach demoted node
+	if err := dbs.ApplyTopology([]string{"master-0"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if st := dbs.Status().Nodes[0]; st.Role != "" || st.Healthy {
+		t.Fatal("ApplyTopology: node should be detached", st)
+	}
+	if dbs.slaves.size() != 0 {
+		t.Fatal("ApplyTopology: slaves should be empty")
+	}
+}
+
+func TestApplyTopologyGrowRole(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:", ":memory:"})
+	defer dbs.Destroy()
+
+	// slave-1 is promoted, keeping its index
+	if err := dbs.ApplyTopology([]string{"master-0", "slave-1"}, []string{"slave-0"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := dbs.Topology(); len(got.Masters) != 2 || got.Masters[0] != "master-0" || got.Masters[1] != "master-1" {
+		t.Fatal("ApplyTopology: unexpected names", got)
+	}
+
+	// writes are balanced between masters
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		var info QueryInfo
+		if _, err := dbs.ExecContext(WithQueryInfo(context.Background(), &info), "SELECT 1"); err != nil {
+			t.Fatal(err)
+		}
+		seen[info.Node] = true
+	}
+	if len(seen) != 2 {
+		t.Fatal("ApplyTopology: writes should be balanced between masters", seen)
+	}
+}
+
+func TestTopologyHandler(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	h := dbs.TopologyHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"masters":["slave-0"],"slaves":["master-0"]}`)))
+	if rec.Code != http.StatusNoContent {
+		t.Fatal("TopologyHandler: unexpected response", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var got Topology
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got.Masters) != 1 || got.Masters[0] != "master-0" || len(got.Slaves) != 1 || got.Slaves[0] != "slave-0" {
+		t.Fatal("TopologyHandler: unexpected topology", rec.Body.String())
+	}
+
+	for _, body := range []string{`{`, `{"masters":["unknown"]}`} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatal("TopologyHandler: invalid body should be rejected", body, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatal("TopologyHandler: unexpected method should be rejected", rec.Code)
+	}
+}
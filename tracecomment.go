@@ -0,0 +1,127 @@
+package mssqlx
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+type traceCommentKey struct{}
+
+// TraceExtractor returns W3C traceparent of ctx, i.e of OpenTelemetry span, empty if there is none.
+type TraceExtractor func(ctx context.Context) string
+
+type traceExtractorHolder struct {
+	extract TraceExtractor
+}
+
+var traceExtractor atomic.Value // traceExtractorHolder
+
+// SetTraceExtractor sets extractor of W3C traceparent from context of statements, so that it is appended as SQL comment
+// (sqlcommenter format, i.e /*traceparent='00-...-01'*/) to every statement made through DBs functions.
+// Slow-query logs of servers could then be correlated back to application traces.
+// Passing nil removes extractor.
+//
+// Statements carrying comments are distinct for server-side caches of statement text.
+func SetTraceExtractor(extract TraceExtractor) {
+	traceExtractor.Store(traceExtractorHolder{extract: extract})
+}
+
+func getTraceExtractor() TraceExtractor {
+	h, _ := traceExtractor.Load().(traceExtractorHolder)
+	return h.extract
+}
+
+// trace tags appended as SQL comment
+type traceTags map[string]string
+
+// WithTraceParent returns a context whose statements carry W3C traceparent
+// (i.e 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01) as SQL comment. Invalid traceparent is ignored.
+func WithTraceParent(ctx context.Context, traceparent string) context.Context {
+	return withTraceTag(ctx, "traceparent", traceparent)
+}
+
+// WithRequestID returns a context whose statements carry request id as SQL comment.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return withTraceTag(ctx, "request_id", id)
+}
+
+func withTraceTag(ctx context.Context, key, value string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	tags := make(traceTags)
+	if parent, ok := ctx.Value(traceCommentKey{}).(traceTags); ok {
+		for k, v := range parent {
+			tags[k] = v
+		}
+	}
+	tags[key] = value
+
+	return context.WithValue(ctx, traceCommentKey{}, tags)
+}
+
+// isTraceParent validates W3C traceparent: version-traceid-parentid-flags in lowercase hex
+func isTraceParent(s string) bool {
+	if len(s) != 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c != '-' && !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// withTraceComment appends trace tags of ctx to query as SQL comment
+func withTraceComment(ctx context.Context, query string) string {
+	if ctx == nil {
+		return query
+	}
+
+	tags, _ := ctx.Value(traceCommentKey{}).(traceTags)
+	if extract := getTraceExtractor(); extract != nil {
+		if tp := extract(ctx); tp != "" {
+			if _, ok := tags["traceparent"]; !ok {
+				tags = traceTags{"traceparent": tp, "request_id": tags["request_id"]}
+			}
+		}
+	}
+	if len(tags) == 0 {
+		return query
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k, v := range tags {
+		if v != "" && (k != "traceparent" || isTraceParent(v)) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return query
+	}
+	sort.Strings(keys)
+
+	var comment strings.Builder
+	comment.WriteString("/*")
+	for i, k := range keys {
+		if i > 0 {
+			comment.WriteByte(',')
+		}
+		// escaped value contains neither quote nor comment terminator
+		comment.WriteString(k + "='" + url.QueryEscape(tags[k]) + "'")
+	}
+	comment.WriteString("*/")
+
+	// comment goes before terminating semicolon
+	trimmed := strings.TrimRight(query, " \t\r\n")
+	if strings.HasSuffix(trimmed, ";") {
+		return trimmed[:len(trimmed)-1] + " " + comment.String() + ";"
+	}
+	return trimmed + " " + comment.String()
+}
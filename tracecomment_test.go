@@ -0,0 +1,62 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestWithTraceComment(t *testing.T) {
+	if q := withTraceComment(context.Background(), "SELECT 1"); q != "SELECT 1" {
+		t.Fatal("TraceComment: query without trace should be kept", q)
+	}
+
+	ctx := WithRequestID(WithTraceParent(context.Background(), testTraceParent), "req/1*/")
+	for query, expected := range map[string]string{
+		"SELECT 1":      "SELECT 1 /*request_id='req%2F1%2A%2F',traceparent='" + testTraceParent + "'*/",
+		"SELECT 1; \n":  "SELECT 1 /*request_id='req%2F1%2A%2F',traceparent='" + testTraceParent + "'*/;",
+		"UPDATE t SET ": "UPDATE t SET /*request_id='req%2F1%2A%2F',traceparent='" + testTraceParent + "'*/",
+	} {
+		if q := withTraceComment(ctx, query); q != expected {
+			t.Fatal("TraceComment: comment fail", q)
+		}
+	}
+
+	if q := withTraceComment(WithTraceParent(context.Background(), "00-zz"), "SELECT 1"); q != "SELECT 1" {
+		t.Fatal("TraceComment: invalid traceparent should be ignored", q)
+	}
+
+	SetTraceExtractor(func(context.Context) string { return testTraceParent })
+	defer SetTraceExtractor(nil)
+	if q := withTraceComment(context.Background(), "SELECT 1"); q != "SELECT 1 /*traceparent='"+testTraceParent+"'*/" {
+		t.Fatal("TraceComment: extractor fail", q)
+	}
+}
+
+func TestTraceCommentStatements(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	ctx := WithTraceParent(context.Background(), testTraceParent)
+
+	var n int
+	if err := dbs.GetContext(ctx, &n, "SELECT ?;", 3); err != nil || n != 3 {
+		t.Fatal("TraceComment: query with comment fail", err)
+	}
+	if _, err := dbs.ExecContext(ctx, "CREATE TABLE t (v INTEGER)"); err != nil {
+		t.Fatal("TraceComment: exec with comment fail", err)
+	}
+	if _, err := dbs.NamedExecContext(ctx, "INSERT INTO t VALUES (:v)", map[string]interface{}{"v": 1}); err != nil {
+		t.Fatal("TraceComment: named exec with comment fail", err)
+	}
+
+	rows, err := dbs.NamedQueryContextOnMaster(WithRequestID(ctx, "req:v"), "SELECT v FROM t WHERE v = :v", map[string]interface{}{"v": 1})
+	if err != nil {
+		t.Fatal("TraceComment: named query with comment fail", err)
+	}
+	if !rows.Next() {
+		t.Fatal("TraceComment: named query with comment should return row")
+	}
+	_ = rows.Close()
+}
@@ -0,0 +1,115 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Tx is an sqlx.Tx supporting nested transactions which are emulated by savepoints.
+//
+// Root Tx commits/rollbacks the underlying transaction. Nested Tx releases/rollbacks
+// to its savepoint instead.
+type Tx struct {
+	*sqlx.Tx
+	savepoint string
+	state     *txState // shared by root and nested transactions
+	done      int32
+}
+
+// NewTx wraps an existing transaction to support nested transactions.
+func NewTx(tx *sqlx.Tx) *Tx {
+	return newTx(tx, nil, nil)
+}
+
+// BeginNestedTx starts a transaction which supports nested transactions via savepoints.
+//
+// Transaction is bound to one of master connections.
+func (dbs *DBs) BeginNestedTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	return dbs.beginTx(ctx, opts)
+}
+
+// beginTx starts a transaction on one of masters, tracked by idle transaction watchdog
+// and aborted once max transaction duration elapses
+func (dbs *DBs) beginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// cancelled by idle transaction watchdog or when transaction is finished
+	ctx, cancel := context.WithCancel(ctx)
+
+	w, tx, err := dbs.beginTxx(ctx, opts)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	root := newTx(tx, cancel, &dbs.txs)
+	root.state.node, root.state.leased = w, 1
+	root.state.misses = &dbs.misses
+	root.state.limit(dbs.getMaxTxDuration())
+	return root, nil
+}
+
+// IsNested reports whether tx is a nested transaction.
+func (tx *Tx) IsNested() bool {
+	return tx.savepoint != ""
+}
+
+// BeginNested starts a nested transaction by creating a savepoint inside tx.
+//
+// Layered code could call BeginNested without tracking whether a transaction is already opened.
+func (tx *Tx) BeginNested() (*Tx, error) {
+	return tx.BeginNestedContext(context.Background())
+}
+
+// BeginNestedContext starts a nested transaction by creating a savepoint inside tx.
+func (tx *Tx) BeginNestedContext(ctx context.Context) (*Tx, error) {
+	if atomic.LoadInt32(&tx.done) != 0 {
+		return nil, sql.ErrTxDone
+	}
+
+	savepoint := "mssqlx_sp_" + strconv.FormatUint(uint64(atomic.AddUint32(&tx.state.seq, 1)), 10)
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return nil, err
+	}
+
+	return &Tx{Tx: tx.Tx, savepoint: savepoint, state: tx.state}, nil
+}
+
+// Commit commits the transaction. For nested transaction, its savepoint is released.
+func (tx *Tx) Commit() (err error) {
+	if !atomic.CompareAndSwapInt32(&tx.done, 0, 1) {
+		return sql.ErrTxDone
+	}
+
+	if tx.savepoint == "" {
+		defer tx.state.finish()
+		return tx.state.err(tx.Tx.Commit())
+	}
+
+	_, err = tx.Exec("RELEASE SAVEPOINT " + tx.savepoint)
+	return
+}
+
+// Rollback aborts the transaction. For nested transaction, only changes made after
+// its savepoint are rolled back, then the savepoint is released.
+func (tx *Tx) Rollback() (err error) {
+	if !atomic.CompareAndSwapInt32(&tx.done, 0, 1) {
+		return sql.ErrTxDone
+	}
+
+	if tx.savepoint == "" {
+		defer tx.state.finish()
+		return tx.state.err(tx.Tx.Rollback())
+	}
+
+	if _, err = tx.Exec("ROLLBACK TO SAVEPOINT " + tx.savepoint); err == nil {
+		_, err = tx.Exec("RELEASE SAVEPOINT " + tx.savepoint)
+	}
+	return
+}
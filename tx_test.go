@@ -0,0 +1,65 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestNestedTx(t *testing.T) {
+	_RunWithSchema(defaultSchema, t, func(db *DBs, t *testing.T) {
+		tx, err := db.BeginNestedTx(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tx.IsNested() {
+			t.Fatal("NestedTx: root should not be nested")
+		}
+		tx.MustExec(tx.Rebind("INSERT INTO place (country, telcode) VALUES (?, ?)"), "Vietnam", 84)
+
+		nested, err := tx.BeginNested()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !nested.IsNested() {
+			t.Fatal("NestedTx: child should be nested")
+		}
+		nested.MustExec(nested.Rebind("INSERT INTO place (country, telcode) VALUES (?, ?)"), "Japan", 81)
+		if err = nested.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+		if err = nested.Commit(); err != sql.ErrTxDone {
+			t.Fatal("NestedTx: commit after rollback should fail")
+		}
+		if db.driverName == "sqlite3" {
+			// failed statement would abort the whole transaction on postgres
+			if _, err = tx.Exec("RELEASE SAVEPOINT " + nested.savepoint); err == nil {
+				t.Fatal("NestedTx: savepoint should be released after rollback")
+			}
+		}
+
+		nested, err = tx.BeginNested()
+		if err != nil {
+			t.Fatal(err)
+		}
+		nested.MustExec(nested.Rebind("INSERT INTO place (country, telcode) VALUES (?, ?)"), "Korea", 82)
+		if err = nested.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = tx.BeginNested(); err != sql.ErrTxDone {
+			t.Fatal("NestedTx: begin nested on finished tx should fail")
+		}
+
+		var countries []string
+		if err = db.SelectOnMaster(&countries, "SELECT country FROM place ORDER BY telcode"); err != nil {
+			t.Fatal(err)
+		}
+		if len(countries) != 2 || countries[0] != "Korea" || countries[1] != "Vietnam" {
+			t.Fatal("NestedTx: unexpected result", countries)
+		}
+	})
+}
@@ -0,0 +1,405 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// IdleTxAction is what idle transaction watchdog does with transactions idle beyond threshold.
+type IdleTxAction int
+
+const (
+	// IdleTxLog only logs a warning
+	IdleTxLog IdleTxAction = iota
+
+	// IdleTxCancel cancels context of transaction, aborting it with its in-flight statements
+	IdleTxCancel
+
+	// IdleTxRollback rollbacks transaction
+	IdleTxRollback
+)
+
+// IdleTxOptions configures WatchIdleTransactions.
+type IdleTxOptions struct {
+	// IdleTimeout transactions without statements for longer than it are idle. Default is DefaultMaxTxAge.
+	IdleTimeout time.Duration
+
+	// Action on idle transactions. Default is IdleTxLog.
+	Action IdleTxAction
+}
+
+// state shared by root and nested transactions
+type txState struct {
+	lastActive int64 // unix nano, first field, 64-bit aligned for atomic access
+	active     int32 // number of in-flight statements
+	reported   int32
+	seq        uint32
+	statements int32
+	logged     int32
+	timedOut   int32
+	leased     int32 // concurrency slot of node is held until transaction is finished
+	finished   int32
+
+	root     *Tx
+	node     *wrapper // master running transaction, if known
+	begunAt  time.Time
+	first    atomic.Value // string, first statement
+	cancel   context.CancelFunc
+	registry *sync.Map
+	timer    *time.Timer // aborts transaction after max duration
+
+	misses *missCache // invalidated by writes of transaction
+	mu     sync.Mutex
+	writes []string
+
+	streams []txStream // guarded by mu
+}
+
+func newTx(tx *sqlx.Tx, cancel context.CancelFunc, registry *sync.Map) *Tx {
+	now := time.Now()
+	root := &Tx{Tx: tx, state: &txState{lastActive: now.UnixNano(), begunAt: now, cancel: cancel, registry: registry}}
+	root.state.root = root
+
+	if registry != nil {
+		registry.Store(root, struct{}{})
+	}
+	return root
+}
+
+// begin marks start of a statement, returned func marks its end
+func (s *txState) begin(query string) func() {
+	if atomic.AddInt32(&s.statements, 1) == 1 {
+		s.first.Store(query)
+	}
+	atomic.AddInt32(&s.active, 1)
+	return func() {
+		atomic.StoreInt64(&s.lastActive, time.Now().UnixNano())
+		atomic.AddInt32(&s.active, -1)
+		s.written(query)
+	}
+}
+
+// txStream is a statement whose rows are being read
+type txStream struct {
+	rows *sql.Rows
+	end  func()
+}
+
+// stream keeps statement in flight until rows are closed, so that transaction iterating them is not idle.
+// Only transactions tracked by idle transaction watchdog keep their streams, ended once found closed by idle.
+func (s *txState) stream(rows *sql.Rows, end func()) {
+	if s.registry == nil {
+		end()
+		return
+	}
+
+	s.mu.Lock()
+	s.streams = append(s.streams, txStream{rows: rows, end: end})
+	s.mu.Unlock()
+}
+
+// endStreams ends statements whose rows are closed, or every statement if all
+func (s *txState) endStreams(all bool) {
+	var ended []txStream
+
+	s.mu.Lock()
+	open := s.streams[:0]
+	for _, st := range s.streams {
+		if _, err := st.rows.Columns(); all || err != nil { // fails once rows are closed
+			ended = append(ended, st)
+		} else {
+			open = append(open, st)
+		}
+	}
+	s.streams = open
+	s.mu.Unlock()
+
+	for _, st := range ended {
+		st.end()
+	}
+}
+
+func (s *txState) idle(now time.Time) time.Duration {
+	s.endStreams(false)
+	if atomic.LoadInt32(&s.active) > 0 {
+		return 0
+	}
+	return now.Sub(time.Unix(0, atomic.LoadInt64(&s.lastActive)))
+}
+
+func (s *txState) finish() {
+	if !atomic.CompareAndSwapInt32(&s.finished, 0, 1) {
+		return
+	}
+
+	s.endStreams(true)
+	s.logSlowTx()
+	s.invalidateWrites()
+
+	if s.registry != nil {
+		s.registry.Delete(s.root)
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	if atomic.LoadInt32(&s.leased) == 1 {
+		s.node.limiter.release()
+	}
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// checkIdleTransactions applies action to transactions idle beyond timeout, returns number of idle ones
+func (dbs *DBs) checkIdleTransactions(now time.Time, opts IdleTxOptions) (n int) {
+	dbs.txs.Range(func(k, _ interface{}) bool {
+		tx := k.(*Tx)
+		idle := tx.state.idle(now)
+		if idle <= opts.IdleTimeout {
+			return true
+		}
+		n++
+
+		if atomic.CompareAndSwapInt32(&tx.state.reported, 0, 1) {
+			first, _ := tx.state.first.Load().(string)
+			logEntry(LogLevelWarn, "idle transaction", nodeFields(tx.state.node,
+				LogField{Key: LogFieldQuery, Value: fingerprint(first)},
+				LogField{Key: "idle", Value: idle.String()})...)
+		}
+
+		switch opts.Action {
+		case IdleTxCancel:
+			tx.abort()
+
+		case IdleTxRollback:
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				reportError("ROLLBACK", err)
+			}
+		}
+		return true
+	})
+	return
+}
+
+// abort cancels context of root transaction, so that database/sql rolls it back.
+// Commit and Rollback return sql.ErrTxDone then.
+func (tx *Tx) abort() {
+	if atomic.CompareAndSwapInt32(&tx.done, 0, 1) {
+		tx.state.finish()
+	}
+}
+
+// WatchIdleTransactions checks transactions started by BeginNestedTx until ctx is done, applying opts.Action
+// to ones idle (no statements) beyond opts.IdleTimeout, so that abandoned transactions do not block vacuums and replication.
+func (dbs *DBs) WatchIdleTransactions(ctx context.Context, opts IdleTxOptions) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = DefaultMaxTxAge
+	}
+
+	go func() {
+		ticker := time.NewTicker(opts.IdleTimeout / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case now := <-ticker.C:
+				dbs.checkIdleTransactions(now, opts)
+			}
+		}
+	}()
+}
+
+// Exec executes a query without returning any rows.
+func (tx *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	defer tx.state.begin(query)()
+	res, err := tx.Tx.Exec(tx.rebind(query), args...)
+	return res, tx.state.err(err)
+}
+
+// ExecContext executes a query without returning any rows.
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer tx.state.begin(query)()
+	res, err := tx.Tx.ExecContext(ctx, tx.rebind(query), args...)
+	return res, tx.state.err(err)
+}
+
+// query runs query of statement, which is in flight until returned rows are closed
+func (tx *Tx) query(ctx context.Context, statement, query string, args ...interface{}) (*sql.Rows, error) {
+	end := tx.state.begin(statement)
+
+	res, err := tx.Tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		end()
+		return nil, tx.state.err(err)
+	}
+
+	tx.state.stream(res, end)
+	return res, nil
+}
+
+func (tx *Tx) queryx(ctx context.Context, statement, query string, args ...interface{}) (*sqlx.Rows, error) {
+	res, err := tx.query(ctx, statement, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return guardRows(&sqlx.Rows{Rows: res, Mapper: tx.Tx.Mapper}), nil
+}
+
+// Query executes a query that returns rows, typically a SELECT.
+func (tx *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.query(context.Background(), query, tx.rebind(query), args...)
+}
+
+// QueryContext executes a query that returns rows, typically a SELECT.
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.query(ctx, query, tx.rebind(query), args...)
+}
+
+// QueryRow executes a query that is expected to return at most one row.
+func (tx *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
+	defer tx.state.begin(query)()
+	return tx.Tx.QueryRow(tx.rebind(query), args...)
+}
+
+// QueryRowContext executes a query that is expected to return at most one row.
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer tx.state.begin(query)()
+	return tx.Tx.QueryRowContext(ctx, tx.rebind(query), args...)
+}
+
+// Queryx executes a query that returns rows, typically a SELECT.
+func (tx *Tx) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	return tx.queryx(context.Background(), query, tx.rebind(query), args...)
+}
+
+// QueryxContext executes a query that returns rows, typically a SELECT.
+func (tx *Tx) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return tx.queryx(ctx, query, tx.rebind(query), args...)
+}
+
+// QueryRowx executes a query that is expected to return at most one row.
+func (tx *Tx) QueryRowx(query string, args ...interface{}) *sqlx.Row {
+	defer tx.state.begin(query)()
+	return guardRow(tx.Tx.QueryRowx(tx.rebind(query), args...))
+}
+
+// QueryRowxContext executes a query that is expected to return at most one row.
+func (tx *Tx) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	defer tx.state.begin(query)()
+	return guardRow(tx.Tx.QueryRowxContext(ctx, tx.rebind(query), args...))
+}
+
+// rebind transforms query into bindvar type of driver if master running transaction has RebindAlways set
+func (tx *Tx) rebind(query string) string {
+	if w := tx.state.node; w != nil {
+		return w.rebind(query)
+	}
+	return query
+}
+
+// bindingNode returns master running transaction, or a node of driver and mapper of transaction if it is
+// wrapped by NewTx, respecting binding of struct args and destinations either way
+func (tx *Tx) bindingNode() *wrapper {
+	if w := tx.state.node; w != nil {
+		return w
+	}
+
+	db := sqlx.NewDb(nil, tx.Tx.DriverName())
+	db.Mapper = tx.Tx.Mapper
+	return newWrapper(db, "", RoleMaster, 0)
+}
+
+// get scans row into dest respecting binding of struct destination
+func (tx *Tx) get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return getContext(ctx, tx.bindingNode(), tx.Tx, dest, tx.rebind(query), args...)
+}
+
+// sel scans rows into dest respecting binding of struct destination
+func (tx *Tx) sel(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return selectContext(ctx, tx.bindingNode(), tx.Tx, 0, dest, tx.rebind(query), args...)
+}
+
+// bindNamed binds named statement with arg respecting binding of struct args
+func (tx *Tx) bindNamed(query string, arg interface{}) (string, []interface{}, error) {
+	w := tx.bindingNode()
+	return bindNamed(w.db, query, arg, w.timeOpts.utc())
+}
+
+// Get does a QueryRow and scans the resulting row into dest.
+func (tx *Tx) Get(dest interface{}, query string, args ...interface{}) error {
+	defer tx.state.begin(query)()
+	return tx.state.err(tx.get(context.Background(), dest, query, args...))
+}
+
+// GetContext does a QueryRow and scans the resulting row into dest.
+func (tx *Tx) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	defer tx.state.begin(query)()
+	return tx.state.err(tx.get(ctx, dest, query, args...))
+}
+
+// Select does a Query and scans all resulting rows into dest.
+func (tx *Tx) Select(dest interface{}, query string, args ...interface{}) error {
+	defer tx.state.begin(query)()
+	return tx.state.err(tx.sel(context.Background(), dest, query, args...))
+}
+
+// SelectContext does a Query and scans all resulting rows into dest.
+func (tx *Tx) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	defer tx.state.begin(query)()
+	return tx.state.err(tx.sel(ctx, dest, query, args...))
+}
+
+// NamedExec executes a named query, with fields of arg as named parameters.
+func (tx *Tx) NamedExec(query string, arg interface{}) (sql.Result, error) {
+	defer tx.state.begin(query)()
+	q, args, err := tx.bindNamed(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	res, err := tx.Tx.Exec(q, args...)
+	return res, tx.state.err(err)
+}
+
+// NamedExecContext executes a named query, with fields of arg as named parameters.
+func (tx *Tx) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	defer tx.state.begin(query)()
+	q, args, err := tx.bindNamed(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	res, err := tx.Tx.ExecContext(ctx, q, args...)
+	return res, tx.state.err(err)
+}
+
+// NamedQuery executes a named query that returns rows, with fields of arg as named parameters.
+func (tx *Tx) NamedQuery(query string, arg interface{}) (*sqlx.Rows, error) {
+	q, args, err := tx.bindNamed(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return tx.queryx(context.Background(), query, q, args...)
+}
+
+// MustExec executes a query without returning any rows and panics on error.
+func (tx *Tx) MustExec(query string, args ...interface{}) sql.Result {
+	defer tx.state.begin(query)()
+	return tx.Tx.MustExec(tx.rebind(query), args...)
+}
+
+// MustExecContext executes a query without returning any rows and panics on error.
+func (tx *Tx) MustExecContext(ctx context.Context, query string, args ...interface{}) sql.Result {
+	defer tx.state.begin(query)()
+	return tx.Tx.MustExecContext(ctx, tx.rebind(query), args...)
+}
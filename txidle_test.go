@@ -0,0 +1,98 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestIdleTransactions(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	tx, err := dbs.BeginNestedTx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := IdleTxOptions{IdleTimeout: time.Minute, Action: IdleTxLog}
+	if n := dbs.checkIdleTransactions(time.Now(), opts); n != 0 {
+		t.Fatal("IdleTransactions: active transaction reported", n)
+	}
+
+	later := time.Now().Add(2 * time.Minute)
+	if n := dbs.checkIdleTransactions(later, opts); n != 1 {
+		t.Fatal("IdleTransactions: idle transaction not reported", n)
+	}
+
+	// statement resets idle time
+	var v int
+	if err = tx.Get(&v, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := dbs.checkIdleTransactions(time.Now().Add(30*time.Second), opts); n != 0 {
+		t.Fatal("IdleTransactions: activity not tracked", n)
+	}
+
+	opts.Action = IdleTxRollback
+	if n := dbs.checkIdleTransactions(later.Add(time.Minute), opts); n != 1 {
+		t.Fatal("IdleTransactions: rollback fail", n)
+	}
+	if err = tx.Commit(); err != sql.ErrTxDone {
+		t.Fatal("IdleTransactions: transaction should be rolled back", err)
+	}
+	if n := dbs.checkIdleTransactions(later.Add(time.Hour), opts); n != 0 {
+		t.Fatal("IdleTransactions: finished transaction should be unregistered", n)
+	}
+
+	// cancel
+	if tx, err = dbs.BeginNestedTx(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	opts.Action = IdleTxCancel
+	if n := dbs.checkIdleTransactions(later, opts); n != 1 {
+		t.Fatal("IdleTransactions: cancel fail", n)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, err = tx.Exec("SELECT 1"); err == nil {
+		t.Fatal("IdleTransactions: cancelled transaction should fail")
+	}
+	if err = tx.Commit(); err != sql.ErrTxDone {
+		t.Fatal("IdleTransactions: cancelled transaction should be done", err)
+	}
+
+	// transaction iterating rows is not idle
+	if tx, err = dbs.BeginNestedTx(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := tx.Queryx("SELECT 1 UNION ALL SELECT 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Action = IdleTxLog
+	if n := dbs.checkIdleTransactions(later.Add(time.Hour), opts); n != 0 {
+		t.Fatal("IdleTransactions: transaction with open rows reported", n)
+	}
+
+	var sum int
+	for rows.Next() {
+		if err = rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		sum += v
+	}
+	if err = rows.Close(); err != nil || sum != 3 {
+		t.Fatal("IdleTransactions: streamed rows fail", sum, err)
+	}
+	if n := dbs.checkIdleTransactions(later.Add(time.Hour), opts); n != 1 {
+		t.Fatal("IdleTransactions: transaction with closed rows not reported", n)
+	}
+	if err = tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dbs.WatchIdleTransactions(ctx, IdleTxOptions{})
+	cancel()
+}
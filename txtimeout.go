@@ -0,0 +1,65 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrTxTimeout transaction is rolled back since it exceeds max duration set by SetMaxTxDuration
+	ErrTxTimeout = errors.New("Transaction exceeds max duration and is rolled back")
+)
+
+// SetMaxTxDuration sets max duration of transactions, protecting master from forgotten long transactions
+// in request handlers. Transactions exceeding it are aborted by cancelling their context: database/sql rolls
+// them back, in-flight statements are cancelled and their connections are discarded unless the driver could reset them.
+//
+// Statements, Commit and Rollback of transactions started by BeginNestedTx fail with ErrTxTimeout then,
+// those of other transactions with context or sql.ErrTxDone errors.
+//
+// If d <= 0, transactions last without limit, which is default.
+func (dbs *DBs) SetMaxTxDuration(d time.Duration) {
+	atomic.StoreInt64(&dbs.maxTxDuration, int64(d))
+}
+
+func (dbs *DBs) getMaxTxDuration() time.Duration {
+	return time.Duration(atomic.LoadInt64(&dbs.maxTxDuration))
+}
+
+// limitTx returns context of a transaction begun by BeginTx/BeginTxx, done once max duration elapses so that
+// database/sql rolls it back. database/sql does not notify finished transactions, so the timer lasts until then,
+// returned cancel releases it if transaction could not be begun.
+func (dbs *DBs) limitTx(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d := dbs.getMaxTxDuration(); d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return ctx, func() {}
+}
+
+// limit aborts transaction once d elapses, if d > 0
+func (s *txState) limit(d time.Duration) {
+	if d <= 0 || s.cancel == nil {
+		return
+	}
+
+	s.timer = time.AfterFunc(d, func() {
+		atomic.StoreInt32(&s.timedOut, 1)
+
+		first, _ := s.first.Load().(string)
+		logEntry(LogLevelWarn, "transaction exceeds max duration, rolled back", nodeFields(s.node,
+			LogField{Key: LogFieldQuery, Value: fingerprint(first)},
+			LogField{Key: LogFieldElapsed, Value: time.Since(s.begunAt).String()})...)
+
+		s.cancel()
+	})
+}
+
+// err returns ErrTxTimeout instead of err if transaction is aborted for exceeding max duration
+func (s *txState) err(err error) error {
+	if err != nil && atomic.LoadInt32(&s.timedOut) == 1 {
+		return ErrTxTimeout
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestMaxTxDuration(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{"file:maxtxduration?mode=memory&cache=shared"}, nil)
+	defer dbs.Destroy()
+
+	// connections of aborted transactions are discarded, keep in-memory database alive
+	keep, _ := dbs.masterNodes()[0].db.Conn(context.Background())
+	defer keep.Close()
+
+	if _, err := dbs.Exec("CREATE TABLE tx_duration (id INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	dbs.SetMaxTxDuration(50 * time.Millisecond)
+
+	// finished in time
+	tx, err := dbs.BeginNestedTx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Exec("INSERT INTO tx_duration VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// forgotten
+	if tx, err = dbs.BeginNestedTx(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Exec("INSERT INTO tx_duration VALUES (2)"); err != nil {
+		t.Fatal(err)
+	}
+
+	waitTxDone(t, tx.Tx)
+	if _, err = tx.Exec("INSERT INTO tx_duration VALUES (3)"); err != ErrTxTimeout {
+		t.Fatal("MaxTxDuration: statement should fail", err)
+	}
+	if err = tx.Commit(); err != ErrTxTimeout {
+		t.Fatal("MaxTxDuration: commit should fail", err)
+	}
+
+	var n int
+	if err = dbs.GetOnMaster(&n, "SELECT COUNT(*) FROM tx_duration"); err != nil || n != 1 {
+		t.Fatal("MaxTxDuration: transaction should be rolled back", n, err)
+	}
+
+	// plain transaction
+	ptx, err := dbs.BeginTxx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitTxDone(t, ptx)
+	if err = ptx.Commit(); err != sql.ErrTxDone && err != context.DeadlineExceeded {
+		t.Fatal("MaxTxDuration: plain transaction should be aborted", err)
+	}
+
+	// no limit
+	dbs.SetMaxTxDuration(0)
+	if ptx, err = dbs.BeginTxx(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if err = ptx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitTxDone waits until database/sql aborts tx
+func waitTxDone(t *testing.T, tx *sqlx.Tx) {
+	for i := 0; i < 400; i++ {
+		if _, err := tx.Exec("SELECT 1"); err != nil {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("MaxTxDuration: transaction should be aborted")
+}
@@ -0,0 +1,183 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrTxWatchNotSupported watching transactions is not supported by driver
+	ErrTxWatchNotSupported = errors.New("Watching transactions is only supported by mysql and postgres drivers")
+)
+
+const (
+	// DefaultTxWatchPeriod default period of polling transactions
+	DefaultTxWatchPeriod = 10 * time.Second
+
+	// DefaultMaxTxAge default age after which a transaction is reported
+	DefaultMaxTxAge = time.Minute
+)
+
+// TxWatchOptions configures WatchTransactions.
+type TxWatchOptions struct {
+	// Period of polling. Default is DefaultTxWatchPeriod.
+	Period time.Duration
+
+	// MaxAge transactions older than it are reported. Default is DefaultMaxTxAge.
+	MaxAge time.Duration
+
+	// MaxRowLocks transactions holding more row locks than it are reported. Zero means unchecked.
+	// On postgres, locks are counted from pg_locks, which lists only contended row locks.
+	MaxRowLocks int64
+
+	// OnLongTransaction is an optional hook called for every reported transaction, besides warning log.
+	OnLongTransaction func(LongTransaction)
+}
+
+// LongTransaction is a transaction on master outliving MaxAge or holding too many row locks.
+type LongTransaction struct {
+	// Node running transaction
+	Node string `json:"node"`
+
+	// ID of server process/thread running transaction
+	ID int64 `json:"id"`
+
+	// Age of transaction
+	Age time.Duration `json:"age"`
+
+	// RowLocks held by transaction
+	RowLocks int64 `json:"row_locks"`
+
+	// Query is fingerprint of current (or last) statement of transaction
+	Query string `json:"query"`
+}
+
+type longTxRow struct {
+	ID       int64   `db:"id"`
+	Age      float64 `db:"age"`
+	RowLocks int64   `db:"row_locks"`
+	Query    string  `db:"query"`
+}
+
+func longTxQuery(driverName string) string {
+	switch driverName {
+	case "mysql":
+		return "SELECT trx_mysql_thread_id AS id, TIMESTAMPDIFF(MICROSECOND, trx_started, NOW()) / 1000000 AS age, " +
+			"trx_rows_locked AS row_locks, COALESCE(trx_query, '') AS query FROM information_schema.innodb_trx " +
+			"WHERE trx_started < NOW() - INTERVAL ? SECOND OR (? > 0 AND trx_rows_locked > ?)"
+
+	case "postgres", "pgx":
+		return "SELECT a.pid AS id, EXTRACT(EPOCH FROM now() - a.xact_start) AS age, " +
+			"(SELECT COUNT(*) FROM pg_locks l WHERE l.pid = a.pid AND l.locktype IN ('tuple', 'transactionid')) AS row_locks, " +
+			"COALESCE(a.query, '') AS query FROM pg_stat_activity a " +
+			"WHERE a.xact_start IS NOT NULL AND a.pid <> pg_backend_pid() AND (a.xact_start < now() - make_interval(secs => $1) " +
+			"OR ($2 > 0 AND (SELECT COUNT(*) FROM pg_locks l WHERE l.pid = a.pid AND l.locktype IN ('tuple', 'transactionid')) > $3))"
+	}
+	return ""
+}
+
+var (
+	fingerprintString = regexp.MustCompile(`'(?:[^']|'')*'`)
+	fingerprintNumber = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	fingerprintSpace  = regexp.MustCompile(`\s+`)
+	fingerprintList   = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+)
+
+// fingerprint normalizes query by replacing literals with placeholders, so that same statements with different values look the same.
+func fingerprint(query string) string {
+	query = fingerprintString.ReplaceAllString(query, "?")
+	query = fingerprintNumber.ReplaceAllString(query, "?")
+	query = fingerprintList.ReplaceAllString(query, "(?+)")
+	return strings.TrimSpace(fingerprintSpace.ReplaceAllString(query, " "))
+}
+
+func (opts *TxWatchOptions) normalize() {
+	if opts.Period <= 0 {
+		opts.Period = DefaultTxWatchPeriod
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = DefaultMaxTxAge
+	}
+}
+
+// LongTransactions returns transactions on masters outliving opts.MaxAge or holding more than opts.MaxRowLocks row locks.
+func (dbs *DBs) LongTransactions(ctx context.Context, opts TxWatchOptions) (txs []LongTransaction, err error) {
+	query := longTxQuery(dbs.driverName)
+	if query == "" {
+		return nil, ErrTxWatchNotSupported
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	opts.normalize()
+
+	for _, w := range dbs.allNodes() {
+		if w == nil || w.getRole() != RoleMaster || !dbs.masters.dbs.contains(w) {
+			continue
+		}
+
+		var rows []longTxRow
+		if err = w.db.SelectContext(ctx, &rows, query, opts.MaxAge.Seconds(), opts.MaxRowLocks, opts.MaxRowLocks); err != nil {
+			reportNodeError(w, query, err)
+			return
+		}
+
+		for _, r := range rows {
+			txs = append(txs, LongTransaction{
+				Node:     w.getName(),
+				ID:       r.ID,
+				Age:      time.Duration(r.Age * float64(time.Second)),
+				RowLocks: r.RowLocks,
+				Query:    fingerprint(r.Query),
+			})
+		}
+	}
+
+	return
+}
+
+// WatchTransactions polls masters every opts.Period until ctx is done, emitting a warning log (and calling
+// opts.OnLongTransaction) for every transaction outliving opts.MaxAge or holding too many row locks.
+func (dbs *DBs) WatchTransactions(ctx context.Context, opts TxWatchOptions) error {
+	if longTxQuery(dbs.driverName) == "" {
+		return ErrTxWatchNotSupported
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	opts.normalize()
+
+	go func() {
+		ticker := time.NewTicker(opts.Period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-ticker.C:
+				txs, _ := dbs.LongTransactions(ctx, opts)
+				for _, tx := range txs {
+					logEntry(LogLevelWarn, "long transaction",
+						LogField{Key: LogFieldNode, Value: tx.Node},
+						LogField{Key: "id", Value: tx.ID},
+						LogField{Key: "age", Value: tx.Age.String()},
+						LogField{Key: "row_locks", Value: tx.RowLocks},
+						LogField{Key: LogFieldQuery, Value: tx.Query})
+
+					if opts.OnLongTransaction != nil {
+						opts.OnLongTransaction(tx)
+					}
+				}
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWatchTransactions(t *testing.T) {
+	if f := fingerprint("UPDATE  account SET balance = 10.5,\n name = 'O''Neil' WHERE id IN (1, 2, 3)"); f != "UPDATE account SET balance = ?, name = ? WHERE id IN (?+)" {
+		t.Fatal("WatchTransactions: fingerprint fail", f)
+	}
+	if f := fingerprint("SELECT * FROM t2 WHERE a = 1"); f != "SELECT * FROM t2 WHERE a = ?" {
+		t.Fatal("WatchTransactions: fingerprint identifier fail", f)
+	}
+
+	if longTxQuery("sqlite3") != "" || longTxQuery("mysql") == "" || longTxQuery("postgres") == "" {
+		t.Fatal("WatchTransactions: query fail")
+	}
+
+	opts := TxWatchOptions{}
+	if opts.normalize(); opts.Period != DefaultTxWatchPeriod || opts.MaxAge != DefaultMaxTxAge {
+		t.Fatal("WatchTransactions: default options fail")
+	}
+
+	dbs := &DBs{driverName: "sqlite3"}
+	if _, err := dbs.LongTransactions(context.Background(), opts); err != ErrTxWatchNotSupported {
+		t.Fatal("WatchTransactions: unsupported driver fail", err)
+	}
+	if err := dbs.WatchTransactions(context.Background(), opts); err != ErrTxWatchNotSupported {
+		t.Fatal("WatchTransactions: unsupported driver fail", err)
+	}
+}
@@ -2,14 +2,107 @@ package mssqlx
 
 import (
 	"runtime"
+	"strconv"
+	"sync"
 	"sync/atomic"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// Role of database node.
+type Role string
+
+const (
+	// RoleMaster master node
+	RoleMaster Role = "master"
+
+	// RoleSlave slave node
+	RoleSlave Role = "slave"
+)
+
 type wrapper struct {
-	db  *sqlx.DB
-	dsn string
+	lag      int64 // measured replication lag in nanoseconds, negative if unknown. First field, 64-bit aligned for atomic access
+	failedAt int64 // unix nano of last connection-level failure
+
+	simulatedUntil int64 // unix nano until which failure is simulated
+	clockSkew      int64 // node clock minus client clock in nanoseconds, valid if clockSkewKnown
+
+	maintenanceFrom int64 // unix nano of maintenance window start
+	maintenanceTo   int64 // unix nano of maintenance window end
+	ejectedUntil    int64 // unix nano until which node is ejected for too many timeouts
+
+	db       *sqlx.DB
+	dsn      string
+	name     atomic.Value // string, role prefixed, renamed with role by ApplyTopology
+	role     atomic.Value // Role, might be changed by ApplyTopology
+	limiter  *limiter
+	stats    *nodeStats
+	fenced   int32
+	failures int32 // consecutive connection-level failures
+	pooler   bool
+	rebound  bool // RebindAlways
+	nearest  int32
+	weight   int32 // traffic weight + 1, 0 means DefaultTrafficWeight
+
+	clockSkewKnown int32
+	clockSkewAlert int32
+
+	maxIdle   int32 // max idle connections + 1, 0 means default
+	recycling int32
+
+	timeOpts   *TimeOptions
+	strictScan int32
+
+	propagateDeadline int32
+	aggressiveCancel  int32
+
+	flavor         atomic.Value // flavorInfo, detected flavor of mysql compatible node
+	flavorOverride ServerFlavor
+	uuid           atomic.Value // string, @@server_uuid of mysql node
+
+	probeMu     sync.Mutex
+	probeDB     *sqlx.DB                 // single-connection pool of health probes, opened on first probe
+	openProbe   func() (*sqlx.DB, error) // nil if probes share pool of node
+	probeClosed bool
+}
+
+func newWrapper(db *sqlx.DB, dsn string, role Role, ind int) *wrapper {
+	w := &wrapper{lag: -1, db: db, dsn: dsn, limiter: &limiter{}, stats: &nodeStats{}}
+	w.setName(nodeName(role, ind))
+	w.setRole(role)
+	return w
+}
+
+func nodeName(role Role, ind int) string {
+	return string(role) + "-" + strconv.Itoa(ind)
+}
+
+// getName returns current name of node
+func (w *wrapper) getName() string {
+	name, _ := w.name.Load().(string)
+	return name
+}
+
+func (w *wrapper) setName(name string) {
+	w.name.Store(name)
+}
+
+// getRole returns current role of node
+func (w *wrapper) getRole() Role {
+	r, _ := w.role.Load().(Role)
+	return r
+}
+
+func (w *wrapper) setRole(r Role) {
+	w.role.Store(r)
+}
+
+// id identifies node, stable across failures
+func (w *wrapper) id() string {
+	if name := w.getName(); name != "" {
+		return name
+	}
+	return w.dsn
 }
 
 func (w *wrapper) checkWsrepReady() bool {
@@ -21,7 +114,7 @@ func (w *wrapper) checkWsrepReady() bool {
 	var v wsrepVariable
 
 	if err := w.db.Get(&v, "SHOW VARIABLES LIKE 'wsrep_on'"); err != nil {
-		reportError("SHOW VARIABLES LIKE 'wsrep_on'", err)
+		reportNodeError(w, "SHOW VARIABLES LIKE 'wsrep_on'", err)
 		return false
 	}
 
@@ -30,7 +123,7 @@ func (w *wrapper) checkWsrepReady() bool {
 	}
 
 	if err := w.db.Get(&v, "SHOW STATUS LIKE 'wsrep_ready'"); err != nil || v.Value != "ON" {
-		reportError("SHOW STATUS LIKE 'wsrep_ready'", err)
+		reportNodeError(w, "SHOW STATUS LIKE 'wsrep_ready'", err)
 		return false
 	}
 
@@ -76,11 +169,47 @@ func (b *dbList) next() (w *wrapper) {
 	return
 }
 
+// nextMatching returns next node, in round-robin manner, matching fn
+func (b *dbList) nextMatching(fn func(*wrapper) bool) *wrapper {
+	list, stored := b.list.Load().([]*wrapper)
+	if !stored || len(list) == 0 {
+		return nil
+	}
+
+	n := uint32(len(list))
+	start := atomic.AddUint32(&b.currentIndex, 1)
+	for i := uint32(0); i < n; i++ {
+		if w := list[(start+i)%n]; fn(w) {
+			return w
+		}
+	}
+	return nil
+}
+
+func (b *dbList) contains(w *wrapper) bool {
+	list, stored := b.list.Load().([]*wrapper)
+	return stored && containsNode(list, w)
+}
+
+func containsNode(list []*wrapper, w *wrapper) bool {
+	for i := range list {
+		if list[i] == w {
+			return true
+		}
+	}
+	return false
+}
+
 func (b *dbList) add(w *wrapper) {
 	if w != nil {
 		for {
 			if atomic.CompareAndSwapInt32(&b.state, 0, 1) { // lock first
 				list, stored := b.list.Load().([]*wrapper)
+				if stored && containsNode(list, w) { // already added
+					atomic.CompareAndSwapInt32(&b.state, 1, 0)
+					return
+				}
+
 				if !stored {
 					list = make([]*wrapper, 0, 8)
 				} else {
@@ -139,6 +268,18 @@ func (b *dbList) remove(w *wrapper) (removed bool) {
 	return
 }
 
+// set replaces whole list
+func (b *dbList) set(list []*wrapper) {
+	for {
+		if atomic.CompareAndSwapInt32(&b.state, 0, 1) { // lock first
+			b.list.Store(list)
+			atomic.CompareAndSwapInt32(&b.state, 1, 0)
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
 func (b *dbList) clear() {
 	atomic.StoreUint32(&b.currentIndex, 0)
 	b.list.Store(empty)
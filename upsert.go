@@ -0,0 +1,218 @@
+package mssqlx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+var (
+	// ErrUpsertNotSupported bulk upsert is not supported by driver
+	ErrUpsertNotSupported = errors.New("Bulk upsert is only supported by mysql, postgres and sqlite3 drivers")
+
+	// ErrInvalidRows rows must be a slice of structs
+	ErrInvalidRows = errors.New("Rows must be a slice of structs or pointers to struct")
+
+	// ErrNoConflictColumns conflict columns are neither given nor inferable from primary key
+	ErrNoConflictColumns = errors.New("Conflict columns are required, table has no primary key")
+)
+
+// primaryKeyQuery returns query selecting primary key columns of table
+func primaryKeyQuery(driverName string) string {
+	switch driverName {
+	case "postgres", "pgx":
+		return "SELECT a.attname FROM pg_index i JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey) " +
+			"WHERE i.indrelid = $1::regclass AND i.indisprimary ORDER BY array_position(i.indkey, a.attnum)"
+
+	case "mysql":
+		return "SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = DATABASE() " +
+			"AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY' ORDER BY ORDINAL_POSITION"
+
+	case "sqlite3":
+		return "SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk"
+	}
+	return ""
+}
+
+// upsertQuery generates bulk upsert of n rows of columns, bindvars are `?`
+func upsertQuery(driverName, table string, columns, conflictCols, updateCols []string, n int) string {
+	var b strings.Builder
+
+	b.WriteString("INSERT INTO ")
+	b.WriteString(table)
+	b.WriteString(" (")
+	b.WriteString(strings.Join(columns, ", "))
+	b.WriteString(") VALUES ")
+
+	row := "(" + strings.Repeat("?, ", len(columns)-1) + "?)"
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(row)
+	}
+
+	if driverName == "mysql" {
+		b.WriteString(" ON DUPLICATE KEY UPDATE ")
+		if len(updateCols) == 0 { // keep existing row
+			b.WriteString(conflictCols[0] + " = " + conflictCols[0])
+		}
+		for i, c := range updateCols {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(c + " = VALUES(" + c + ")")
+		}
+		return b.String()
+	}
+
+	b.WriteString(" ON CONFLICT (")
+	b.WriteString(strings.Join(conflictCols, ", "))
+	if len(updateCols) == 0 {
+		b.WriteString(") DO NOTHING")
+		return b.String()
+	}
+
+	b.WriteString(") DO UPDATE SET ")
+	for i, c := range updateCols {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(c + " = EXCLUDED." + c)
+	}
+	return b.String()
+}
+
+// upsertRows returns columns and values of rows, which must be a slice of structs or pointers to struct
+func upsertRows(m *reflectx.Mapper, rows interface{}) (columns []structColumn, values []reflect.Value, err error) {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		return nil, nil, ErrInvalidRows
+	}
+
+	t := v.Type().Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil, ErrInvalidRows
+	}
+
+	values = make([]reflect.Value, v.Len())
+	for i := range values {
+		e := v.Index(i)
+		for e.Kind() == reflect.Ptr {
+			if e.IsNil() {
+				return nil, nil, ErrInvalidRows
+			}
+			e = e.Elem()
+		}
+		values[i] = e
+	}
+
+	for _, c := range writableColumns(m, t) {
+		if !hasTagOption(c.field, defaultTagOption) {
+			columns = append(columns, c)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, nil, ErrNoColumns
+	}
+	return
+}
+
+// UpsertBatch inserts rows (a slice of structs or pointers to struct) into table on masters, updating updateCols
+// of rows conflicting on conflictCols: INSERT ... ON CONFLICT DO UPDATE on postgres and sqlite, INSERT ... ON
+// DUPLICATE KEY UPDATE on mysql (which matches any unique key). Returns total number of affected rows,
+// as reported by driver: mysql counts an updated row twice.
+//
+// Columns are generated from db tags of fields, readonly and default ones are skipped; omitempty is ignored so
+// that rows share columns. If conflictCols is empty, primary key of table is used. If updateCols is empty, all
+// columns except conflict ones are updated.
+//
+// Rows are chunked to fit placeholder limit of driver, chunks are executed one by one, not in a transaction.
+func (dbs *DBs) UpsertBatch(ctx context.Context, table string, rows interface{}, conflictCols, updateCols []string) (affected int64, err error) {
+	pkQuery := primaryKeyQuery(dbs.driverName)
+	if pkQuery == "" {
+		return 0, ErrUpsertNotSupported
+	}
+
+	columns, values, err := upsertRows(dbs.mapper(), rows)
+	if err != nil || len(values) == 0 {
+		return
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if len(conflictCols) == 0 {
+		if err = dbs.SelectContextOnMaster(ctx, &conflictCols, pkQuery, table); err != nil {
+			return
+		}
+		if len(conflictCols) == 0 {
+			return 0, ErrNoConflictColumns
+		}
+	}
+
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+
+	if len(updateCols) == 0 {
+		for _, name := range names {
+			if !containsString(conflictCols, name) {
+				updateCols = append(updateCols, name)
+			}
+		}
+	}
+
+	size := maxPlaceholders(dbs.driverName) / len(columns)
+	if size == 0 {
+		return 0, ErrNoColumns
+	}
+
+	bindType := sqlx.BindType(dbs.driverName)
+	for i := 0; i < len(values); i += size {
+		chunk := values[i:]
+		if len(chunk) > size {
+			chunk = chunk[:size]
+		}
+
+		args := make([]interface{}, 0, len(chunk)*len(columns))
+		for _, v := range chunk {
+			for _, c := range columns {
+				f := reflectx.FieldByIndexesReadOnly(v, c.field.Index)
+				if !hasTagOption(c.field, encryptedTagOption) {
+					args = append(args, f.Interface())
+					continue
+				}
+
+				arg, err := encryptValue(f)
+				if err != nil {
+					return affected, err
+				}
+				args = append(args, arg)
+			}
+		}
+
+		query := sqlx.Rebind(bindType, upsertQuery(dbs.driverName, table, names, conflictCols, updateCols, len(chunk)))
+		res, err := _exec(ctx, dbs.masters, query, args...)
+		if err != nil {
+			return affected, err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return affected, err
+		}
+		affected += n
+	}
+
+	return
+}
@@ -0,0 +1,73 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+type upsertPerson struct {
+	ID        int    `db:"id"`
+	Name      string `db:"name"`
+	Visits    int    `db:"visits"`
+	CreatedAt string `db:"created_at,readonly"`
+}
+
+func TestUpsertBatch(t *testing.T) {
+	q := upsertQuery("postgres", "person", []string{"id", "name", "visits"}, []string{"id"}, []string{"name"}, 2)
+	if q != "INSERT INTO person (id, name, visits) VALUES (?, ?, ?), (?, ?, ?) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name" {
+		t.Fatal("UpsertBatch: postgres query fail", q)
+	}
+	if q = upsertQuery("sqlite3", "person", []string{"id"}, []string{"id"}, nil, 1); q != "INSERT INTO person (id) VALUES (?) ON CONFLICT (id) DO NOTHING" {
+		t.Fatal("UpsertBatch: nothing to update fail", q)
+	}
+	if q = upsertQuery("mysql", "person", []string{"id", "name"}, []string{"id"}, []string{"name"}, 1); q != "INSERT INTO person (id, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name)" {
+		t.Fatal("UpsertBatch: mysql query fail", q)
+	}
+	if q = upsertQuery("mysql", "person", []string{"id"}, []string{"id"}, nil, 1); q != "INSERT INTO person (id) VALUES (?) ON DUPLICATE KEY UPDATE id = id" {
+		t.Fatal("UpsertBatch: mysql nothing to update fail", q)
+	}
+
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{"file:upsert?mode=memory&cache=shared"}, nil)
+	defer dbs.Destroy()
+
+	if _, err := dbs.Exec("CREATE TABLE person (id INTEGER PRIMARY KEY, name TEXT, visits INTEGER, created_at TEXT DEFAULT 'now')"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dbs.UpsertBatch(context.Background(), "person", []int{1}, nil, nil); err != ErrInvalidRows {
+		t.Fatal("UpsertBatch: invalid rows should fail", err)
+	}
+	if n, err := dbs.UpsertBatch(context.Background(), "person", []upsertPerson{}, nil, nil); n != 0 || err != nil {
+		t.Fatal("UpsertBatch: empty rows fail", n, err)
+	}
+
+	rows := make([]*upsertPerson, 1500) // more than one chunk
+	for i := range rows {
+		rows[i] = &upsertPerson{ID: i + 1, Name: "a", Visits: 1}
+	}
+	if n, err := dbs.UpsertBatch(context.Background(), "person", rows, nil, nil); err != nil || n != 1500 {
+		t.Fatal("UpsertBatch: insert fail", n, err)
+	}
+
+	// conflict inferred from primary key, only name is updated
+	if _, err := dbs.UpsertBatch(context.Background(), "person", []upsertPerson{{ID: 1, Name: "b", Visits: 5}, {ID: 2000, Name: "c"}}, nil, []string{"name"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var p upsertPerson
+	if err := dbs.GetOnMaster(&p, "SELECT * FROM person WHERE id = 1"); err != nil || p.Name != "b" || p.Visits != 1 || p.CreatedAt != "now" {
+		t.Fatal("UpsertBatch: update fail", p, err)
+	}
+
+	var count int
+	if err := dbs.GetOnMaster(&count, "SELECT COUNT(*) FROM person"); err != nil || count != 1501 {
+		t.Fatal("UpsertBatch: row count fail", count, err)
+	}
+
+	if _, err := dbs.Exec("CREATE TABLE person_nokey (id INTEGER, name TEXT, visits INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.UpsertBatch(context.Background(), "person_nokey", rows[:1], nil, nil); err != ErrNoConflictColumns {
+		t.Fatal("UpsertBatch: table without primary key should fail", err)
+	}
+}
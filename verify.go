@@ -0,0 +1,74 @@
+package mssqlx
+
+import (
+	"context"
+	"strings"
+)
+
+// QueryError is a statement rejected by VerifyQueries.
+type QueryError struct {
+	// Query rejected
+	Query string
+
+	// Node which rejected query
+	Node string
+
+	// Err returned by database
+	Err error
+}
+
+func (e *QueryError) Error() string {
+	return "invalid query " + e.Query + " (node: " + e.Node + "): " + e.Err.Error()
+}
+
+// isWriteQuery reports whether query is a DML statement, judged by its first keyword
+func isWriteQuery(query string) bool {
+	fields := strings.Fields(strings.TrimLeft(query, "("))
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch strings.ToUpper(fields[0]) {
+	case "INSERT", "UPDATE", "DELETE", "REPLACE", "MERGE", "UPSERT":
+		return true
+	}
+	return false
+}
+
+// verifyQuery prepares query inside a transaction which is rolled back, so that nothing is changed.
+func verifyQuery(ctx context.Context, target *balancer, query string) error {
+	w, tx, err := _beginTx(ctx, target, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, w.rebind(query))
+	if err != nil {
+		return &QueryError{Query: query, Node: w.getName(), Err: err}
+	}
+	return stmt.Close()
+}
+
+// VerifyQueries prepares each statement, on one of slaves for reads and on one of masters for DML,
+// to catch syntax/column errors at startup or in integration tests rather than at first use.
+// Statements are prepared inside transactions which are rolled back.
+//
+// Returned errors are in the same order as queries, nil for valid ones. Rejected statements are reported as *QueryError.
+func (dbs *DBs) VerifyQueries(ctx context.Context, queries []string) []error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	errs := make([]error, len(queries))
+	for i, query := range queries {
+		target := dbs.slaves
+		if isWriteQuery(query) {
+			target = dbs.masters
+		}
+		errs[i] = verifyQuery(ctx, target, query)
+	}
+	return errs
+}
@@ -0,0 +1,51 @@
+package mssqlx
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyQueries(t *testing.T) {
+	if !isWriteQuery("  insert INTO t VALUES (1)") || !isWriteQuery("UPDATE t SET a = 1") || isWriteQuery("SELECT 1") || isWriteQuery("") {
+		t.Fatal("VerifyQueries: write query detection fail")
+	}
+
+	dir, err := ioutil.TempDir("", "mssqlx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dsn := filepath.Join(dir, "verify.db")
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{dsn}, []string{dsn})
+	defer dbs.Destroy()
+
+	if _, err = dbs.Exec("CREATE TABLE person (id INTEGER, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := dbs.VerifyQueries(context.Background(), []string{
+		"SELECT id, name FROM person WHERE id = ?",
+		"INSERT INTO person (id, name) VALUES (?, ?)",
+		"SELECT unknown FROM person",
+		"DELETE FROM people",
+	})
+	if len(errs) != 4 || errs[0] != nil || errs[1] != nil {
+		t.Fatal("VerifyQueries: valid queries fail", errs)
+	}
+
+	if e, ok := errs[2].(*QueryError); !ok || e.Query != "SELECT unknown FROM person" || e.Node != "slave-0" {
+		t.Fatal("VerifyQueries: invalid read fail", errs[2])
+	}
+	if e, ok := errs[3].(*QueryError); !ok || e.Node != "master-0" {
+		t.Fatal("VerifyQueries: invalid write fail", errs[3])
+	}
+
+	var n int
+	if err = dbs.GetOnMaster(&n, "SELECT COUNT(*) FROM person"); err != nil || n != 0 {
+		t.Fatal("VerifyQueries: data changed", err, n)
+	}
+}
@@ -0,0 +1,380 @@
+package mssqlx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	// VirtualDriverName name of database/sql driver backed by clusters registered with RegisterCluster
+	VirtualDriverName = "mssqlx"
+)
+
+var (
+	// ErrClusterNotRegistered cluster referenced by data source name of virtual driver is not registered
+	ErrClusterNotRegistered = errors.New("Cluster is not registered")
+
+	// ErrInvalidClusterMode mode of data source name of virtual driver must be reader or writer
+	ErrInvalidClusterMode = errors.New("Invalid cluster mode, must be reader or writer")
+)
+
+var clusters sync.Map // name => *DBs
+
+func init() {
+	sql.Register(VirtualDriverName, virtualDriver{})
+}
+
+// RegisterCluster registers dbs by name, so that sql.Open("mssqlx", name) returns a handle backed by it.
+// Libraries which only accept driver name and data source name then benefit from failover and read/write splitting.
+//
+// Data source name is the name, optionally suffixed by "?mode=reader" for handle behaving like DBs.ReaderDB.
+// Default mode is writer, like DBs.WriterDB. Registering with existing name replaces previous cluster,
+// connections opened afterward use the new one.
+func RegisterCluster(name string, dbs *DBs) {
+	clusters.Store(name, dbs)
+}
+
+// UnregisterCluster removes cluster registered by name.
+func UnregisterCluster(name string) {
+	clusters.Delete(name)
+}
+
+// virtual database/sql handles, created on demand
+type virtualDBs struct {
+	mu     sync.Mutex
+	writer *sql.DB
+	reader *sql.DB
+}
+
+// WriterDB returns a *sql.DB handle backed by masters, for libraries (i.e GORM, ent, squirrel) which only accept *sql.DB.
+// Statements made through it are routed by balancer with failover, the same as DBs.Exec/DBs.QueryOnMaster.
+// Transactions are started on one of masters.
+//
+// Handle is shared and closed by DBs.Destroy.
+func (dbs *DBs) WriterDB() *sql.DB {
+	dbs.virtual.mu.Lock()
+	defer dbs.virtual.mu.Unlock()
+
+	if dbs.virtual.writer == nil {
+		dbs.virtual.writer = sql.OpenDB(&virtualConnector{dbs: dbs, reads: dbs.masters})
+	}
+	return dbs.virtual.writer
+}
+
+// ReaderDB returns a *sql.DB handle whose queries are balanced on slaves, the same as DBs.Query
+// (respecting read-your-writes/force master directives of context). Exec and transactions go to masters.
+//
+// Handle is shared and closed by DBs.Destroy.
+func (dbs *DBs) ReaderDB() *sql.DB {
+	dbs.virtual.mu.Lock()
+	defer dbs.virtual.mu.Unlock()
+
+	if dbs.virtual.reader == nil {
+		dbs.virtual.reader = sql.OpenDB(&virtualConnector{dbs: dbs, reads: dbs.slaves})
+	}
+	return dbs.virtual.reader
+}
+
+func (v *virtualDBs) close() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, db := range []*sql.DB{v.writer, v.reader} {
+		if db != nil {
+			_ = db.Close()
+		}
+	}
+	v.writer, v.reader = nil, nil
+}
+
+type virtualDriver struct{}
+
+func (d virtualDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(context.Background())
+}
+
+func (virtualDriver) OpenConnector(name string) (driver.Connector, error) {
+	reader := false
+	if i := strings.IndexByte(name, '?'); i >= 0 {
+		switch name[i+1:] {
+		case "mode=reader":
+			reader = true
+		case "mode=writer":
+		default:
+			return nil, ErrInvalidClusterMode
+		}
+		name = name[:i]
+	}
+	return &clusterConnector{name: name, reader: reader}, nil
+}
+
+// clusterConnector resolves registered cluster on each connect
+type clusterConnector struct {
+	name   string
+	reader bool
+}
+
+func (c *clusterConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	v, ok := clusters.Load(c.name)
+	if !ok {
+		return nil, ErrClusterNotRegistered
+	}
+
+	dbs := v.(*DBs)
+	if c.reader {
+		return &virtualConn{dbs: dbs, reads: dbs.slaves}, nil
+	}
+	return &virtualConn{dbs: dbs, reads: dbs.masters}, nil
+}
+
+func (c *clusterConnector) Driver() driver.Driver {
+	return virtualDriver{}
+}
+
+type virtualConnector struct {
+	dbs   *DBs
+	reads *balancer
+}
+
+func (c *virtualConnector) Connect(context.Context) (driver.Conn, error) {
+	return &virtualConn{dbs: c.dbs, reads: c.reads}, nil
+}
+
+func (c *virtualConnector) Driver() driver.Driver {
+	return virtualDriver{}
+}
+
+// virtualConn does not hold physical connection, statements are routed by balancer.
+// Inside transaction, they go to connection of the transaction.
+type virtualConn struct {
+	dbs   *DBs
+	reads *balancer
+	tx    *sql.Tx
+}
+
+// CheckNamedValue passes args as is, underlying driver converts them
+func (c *virtualConn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func (c *virtualConn) Prepare(query string) (driver.Stmt, error) {
+	return &virtualStmt{conn: c, query: query}, nil
+}
+
+func (c *virtualConn) Close() error {
+	if c.tx != nil {
+		_ = c.tx.Rollback()
+		c.tx = nil
+	}
+	return nil
+}
+
+func (c *virtualConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *virtualConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	tx, err := c.dbs.BeginTx(ctx, &sql.TxOptions{Isolation: sql.IsolationLevel(opts.Isolation), ReadOnly: opts.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+
+	c.tx = tx
+	return &virtualTx{conn: c}, nil
+}
+
+func (c *virtualConn) Ping(ctx context.Context) error {
+	queryInfoFromContext(ctx).start()
+	w, err := getDBFromBalancer(ctx, c.reads)
+	if err != nil {
+		return err
+	}
+	return w.db.PingContext(ctx)
+}
+
+func (c *virtualConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.tx != nil {
+		return c.tx.ExecContext(ctx, query, virtualArgs(args)...)
+	}
+	return _exec(ctx, c.dbs.masters, query, virtualArgs(args)...)
+}
+
+func (c *virtualConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	var (
+		rows *sql.Rows
+		err  error
+	)
+
+	if c.tx != nil {
+		rows, err = c.tx.QueryContext(ctx, query, virtualArgs(args)...)
+	} else {
+		_, rows, err = _query(ctx, c.reads, query, virtualArgs(args)...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newVirtualRows(rows)
+}
+
+func virtualArgs(args []driver.NamedValue) []interface{} {
+	r := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			r[i] = sql.Named(arg.Name, arg.Value)
+		} else {
+			r[i] = arg.Value
+		}
+	}
+	return r
+}
+
+type virtualTx struct {
+	conn *virtualConn
+}
+
+func (t *virtualTx) Commit() error {
+	tx := t.conn.tx
+	t.conn.tx = nil
+	return tx.Commit()
+}
+
+func (t *virtualTx) Rollback() error {
+	tx := t.conn.tx
+	t.conn.tx = nil
+	return tx.Rollback()
+}
+
+// virtualStmt is prepared lazily by node executing it
+type virtualStmt struct {
+	conn  *virtualConn
+	query string
+}
+
+func (s *virtualStmt) Close() error {
+	return nil
+}
+
+func (s *virtualStmt) NumInput() int {
+	return -1
+}
+
+func (s *virtualStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), valuesToNamed(args))
+}
+
+func (s *virtualStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), valuesToNamed(args))
+}
+
+func (s *virtualStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *virtualStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func (s *virtualStmt) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func valuesToNamed(args []driver.Value) []driver.NamedValue {
+	r := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		r[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return r
+}
+
+// virtualRows exposes rows of underlying node as driver rows
+type virtualRows struct {
+	rows    *sql.Rows
+	columns []string
+	types   []*sql.ColumnType
+	values  []interface{}
+	ptrs    []interface{}
+}
+
+func newVirtualRows(rows *sql.Rows) (*virtualRows, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+
+	r := &virtualRows{
+		rows:    rows,
+		columns: columns,
+		values:  make([]interface{}, len(columns)),
+		ptrs:    make([]interface{}, len(columns)),
+	}
+	for i := range r.values {
+		r.ptrs[i] = &r.values[i]
+	}
+
+	if types, err := rows.ColumnTypes(); err == nil {
+		r.types = types
+	}
+
+	return r, nil
+}
+
+func (r *virtualRows) Columns() []string {
+	return r.columns
+}
+
+func (r *virtualRows) Close() error {
+	return r.rows.Close()
+}
+
+func (r *virtualRows) Next(dest []driver.Value) error {
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+
+	if err := r.rows.Scan(r.ptrs...); err != nil {
+		return err
+	}
+
+	for i, v := range r.values {
+		dest[i] = v
+	}
+	return nil
+}
+
+func (r *virtualRows) ColumnTypeDatabaseTypeName(index int) string {
+	if index < len(r.types) {
+		return r.types[index].DatabaseTypeName()
+	}
+	return ""
+}
+
+func (r *virtualRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if index < len(r.types) {
+		return r.types[index].Nullable()
+	}
+	return
+}
+
+func (r *virtualRows) ColumnTypeScanType(index int) reflect.Type {
+	if index < len(r.types) {
+		if t := r.types[index].ScanType(); t != nil {
+			return t
+		}
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
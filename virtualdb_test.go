@@ -0,0 +1,136 @@
+package mssqlx
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVirtualDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mssqlx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dsn := filepath.Join(dir, "v.db")
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{dsn}, []string{dsn})
+
+	writer, reader := dbs.WriterDB(), dbs.ReaderDB()
+	if writer != dbs.WriterDB() || reader != dbs.ReaderDB() || writer == reader {
+		t.Fatal("VirtualDB: handles should be cached")
+	}
+
+	if err = writer.Ping(); err != nil {
+		t.Fatal("VirtualDB: ping fail", err)
+	}
+
+	if _, err = writer.Exec("CREATE TABLE person (id INTEGER PRIMARY KEY, name TEXT NOT NULL)"); err != nil {
+		t.Fatal("VirtualDB: exec fail", err)
+	}
+
+	res, err := reader.Exec("INSERT INTO person (name) VALUES (?)", "a") // exec goes to masters
+	if err != nil {
+		t.Fatal("VirtualDB: exec fail", err)
+	}
+	if id, _ := res.LastInsertId(); id != 1 {
+		t.Fatal("VirtualDB: last insert id fail", id)
+	}
+
+	// rolled back transaction
+	tx, err := writer.Begin()
+	if err != nil {
+		t.Fatal("VirtualDB: begin fail", err)
+	}
+	if _, err = tx.Exec("INSERT INTO person (name) VALUES (?)", "b"); err != nil {
+		t.Fatal("VirtualDB: exec in tx fail", err)
+	}
+	var n int
+	if err = tx.QueryRow("SELECT COUNT(*) FROM person").Scan(&n); err != nil || n != 2 {
+		t.Fatal("VirtualDB: query in tx fail", err, n)
+	}
+	if err = tx.Rollback(); err != nil {
+		t.Fatal("VirtualDB: rollback fail", err)
+	}
+
+	if err = reader.QueryRow("SELECT COUNT(*) FROM person").Scan(&n); err != nil || n != 1 {
+		t.Fatal("VirtualDB: rollback should discard changes", err, n)
+	}
+
+	stmt, err := reader.Prepare("SELECT id, name FROM person WHERE name = ?")
+	if err != nil {
+		t.Fatal("VirtualDB: prepare fail", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query("a")
+	if err != nil {
+		t.Fatal("VirtualDB: stmt query fail", err)
+	}
+
+	types, err := rows.ColumnTypes()
+	if err != nil || len(types) != 2 || types[1].DatabaseTypeName() != "TEXT" {
+		t.Fatal("VirtualDB: column types fail", err)
+	}
+
+	var (
+		id   int64
+		name string
+	)
+	if !rows.Next() {
+		t.Fatal("VirtualDB: stmt query should have row")
+	}
+	if err = rows.Scan(&id, &name); err != nil || id != 1 || name != "a" {
+		t.Fatal("VirtualDB: scan fail", err, id, name)
+	}
+	if rows.Next() || rows.Close() != nil {
+		t.Fatal("VirtualDB: stmt query should have one row")
+	}
+
+	dbs.Destroy()
+	if writer.Ping() == nil {
+		t.Fatal("VirtualDB: handles should be closed by Destroy")
+	}
+}
+
+func TestVirtualDriver(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:"})
+	defer dbs.Destroy()
+
+	if _, err := (virtualDriver{}).Open("vdriver"); err != ErrClusterNotRegistered {
+		t.Fatal("VirtualDriver: unregistered cluster should fail", err)
+	}
+	if _, err := (virtualDriver{}).Open("vdriver?mode=x"); err != ErrInvalidClusterMode {
+		t.Fatal("VirtualDriver: invalid mode should fail", err)
+	}
+
+	RegisterCluster("vdriver", dbs)
+	defer UnregisterCluster("vdriver")
+
+	for _, dsn := range []string{"vdriver", "vdriver?mode=reader", "vdriver?mode=writer"} {
+		db, err := sql.Open(VirtualDriverName, dsn)
+		if err != nil {
+			t.Fatal("VirtualDriver: open fail", err)
+		}
+
+		var n int
+		if err = db.QueryRow("SELECT ?", 7).Scan(&n); err != nil || n != 7 {
+			t.Fatal("VirtualDriver: query fail", dsn, err, n)
+		}
+		db.Close()
+	}
+
+	conn, err := (virtualDriver{}).Open("vdriver?mode=reader")
+	if err != nil || conn.(*virtualConn).reads != dbs.slaves {
+		t.Fatal("VirtualDriver: reader mode fail", err)
+	}
+
+	UnregisterCluster("vdriver")
+	db, _ := sql.Open(VirtualDriverName, "vdriver")
+	defer db.Close()
+	if err = db.Ping(); err != ErrClusterNotRegistered {
+		t.Fatal("VirtualDriver: unregistered cluster should fail", err)
+	}
+}
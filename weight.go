@@ -0,0 +1,90 @@
+package mssqlx
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+const (
+	// DefaultTrafficWeight default traffic weight of slaves
+	DefaultTrafficWeight = 100
+)
+
+var (
+	// ErrInvalidTrafficWeight traffic weight must be in [0, 100]
+	ErrInvalidTrafficWeight = errors.New("Traffic weight must be between 0 and 100")
+
+	// ErrNotSlave node is not a slave
+	ErrNotSlave = errors.New("Node is not a slave")
+)
+
+// SetSlaveTrafficWeight sets traffic weight (0-100) of slave (i.e slave-0), adjustable at runtime.
+// Balanced reads are spread among slaves in proportion to their weights, so that a newly added replica
+// could be canaried with weight 1 against others at DefaultTrafficWeight, then ramped up.
+//
+// Weight 0 drains slave: it receives no balanced reads, nor reads routed by key, unless every slave is drained.
+// Pinned and staleness-bounded reads are not weighted.
+func (dbs *DBs) SetSlaveTrafficWeight(node string, percent int) error {
+	if percent < 0 || percent > 100 {
+		return ErrInvalidTrafficWeight
+	}
+
+	w := dbs.findNode(node)
+	if w == nil {
+		return ErrNodeNotFound
+	}
+	if w.getRole() != RoleSlave {
+		return ErrNotSlave
+	}
+
+	atomic.StoreInt32(&w.weight, int32(percent)+1)
+
+	// weighted selection only when it matters
+	weighted := int32(0)
+	for _, s := range dbs.slaveNodes() {
+		if s != nil && s.getWeight() != DefaultTrafficWeight {
+			weighted = 1
+			break
+		}
+	}
+	atomic.StoreInt32(&dbs.slaves.weighted, weighted)
+
+	return nil
+}
+
+// getWeight returns traffic weight of node
+func (w *wrapper) getWeight() int {
+	if v := atomic.LoadInt32(&w.weight); v > 0 {
+		return int(v - 1)
+	}
+	return DefaultTrafficWeight
+}
+
+func (c *balancer) isWeighted() bool {
+	return atomic.LoadInt32(&c.weighted) == 1
+}
+
+// weighted returns node chosen in proportion to traffic weights, nil if all nodes are drained
+func (b *dbList) weighted() *wrapper {
+	list, stored := b.list.Load().([]*wrapper)
+	if !stored {
+		return nil
+	}
+
+	total := uint32(0)
+	for _, w := range list {
+		total += uint32(w.getWeight())
+	}
+	if total == 0 {
+		return nil
+	}
+
+	// scramble round-robin counter (Fibonacci hashing), so that picks of one node are not bursty
+	r := int64((atomic.AddUint32(&b.currentIndex, 1) * 2654435761) % total)
+	for _, w := range list {
+		if r -= int64(w.getWeight()); r < 0 {
+			return w
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package mssqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSlaveTrafficWeight(t *testing.T) {
+	dbs, _ := ConnectMasterSlaves("sqlite3", []string{":memory:"}, []string{":memory:", ":memory:", ":memory:"})
+	defer dbs.Destroy()
+
+	if err := dbs.SetSlaveTrafficWeight("slave-0", 101); err != ErrInvalidTrafficWeight {
+		t.Fatal("SlaveTrafficWeight: range check fail", err)
+	}
+	if err := dbs.SetSlaveTrafficWeight("slave-9", 1); err != ErrNodeNotFound {
+		t.Fatal("SlaveTrafficWeight: not found check fail", err)
+	}
+	if err := dbs.SetSlaveTrafficWeight("master-0", 1); err != ErrNotSlave {
+		t.Fatal("SlaveTrafficWeight: role check fail", err)
+	}
+
+	_ = dbs.SetSlaveTrafficWeight("slave-1", 10)
+	_ = dbs.SetSlaveTrafficWeight("slave-2", 0)
+
+	counts := make(map[string]int)
+	for i := 0; i < 11000; i++ {
+		counts[dbs.slaves.pick(context.Background()).getName()]++
+	}
+	if counts["slave-2"] != 0 || counts["slave-1"] < 800 || counts["slave-1"] > 1200 {
+		t.Fatal("SlaveTrafficWeight: weighted balancing fail", counts)
+	}
+
+	// drained node does not get keyed reads
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		if dbs.slaves.pick(WithRoutingKey(context.Background(), key)).getName() == "slave-2" {
+			t.Fatal("SlaveTrafficWeight: drained node should not get keyed reads")
+		}
+	}
+
+	for _, st := range dbs.Status().Nodes {
+		if st.Name == "slave-1" && st.Weight != 10 {
+			t.Fatal("SlaveTrafficWeight: status fail", st)
+		}
+	}
+
+	// every slave is drained, reads are still served
+	_ = dbs.SetSlaveTrafficWeight("slave-0", 0)
+	_ = dbs.SetSlaveTrafficWeight("slave-1", 0)
+	if dbs.slaves.pick(context.Background()) == nil || dbs.slaves.pick(WithRoutingKey(context.Background(), "a")) == nil {
+		t.Fatal("SlaveTrafficWeight: all drained slaves should still serve")
+	}
+
+	for _, node := range []string{"slave-0", "slave-1", "slave-2"} {
+		_ = dbs.SetSlaveTrafficWeight(node, DefaultTrafficWeight)
+	}
+	if dbs.slaves.isWeighted() {
+		t.Fatal("SlaveTrafficWeight: default weights should not be weighted")
+	}
+}